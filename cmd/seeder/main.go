@@ -8,6 +8,7 @@ import (
 
 	"kasir-api/config"
 	"kasir-api/helpers/logger"
+	model "kasir-api/models"
 	"kasir-api/repositories/postgres"
 )
 
@@ -139,6 +140,8 @@ func main() {
 
 func clearAllData(db *postgres.DB) error {
 	queries := []string{
+		"DELETE FROM stock_movements",
+		"DELETE FROM stock_transfers",
 		"DELETE FROM transaction_details",
 		"DELETE FROM transactions",
 		"DELETE FROM products",
@@ -194,6 +197,15 @@ func seedProducts(db *postgres.DB) ([]int, error) {
 			if err != nil {
 				return nil, fmt.Errorf("seed product %s: %w", p.Name, err)
 			}
+		} else if p.Stock > 0 {
+			// Seeded stock sits at the default outlet.
+			_, err = db.Exec(`
+				INSERT INTO outlet_stock (outlet_id, product_id, stock) VALUES ($1, $2, $3)
+				ON CONFLICT DO NOTHING
+			`, model.DefaultOutletID, id, p.Stock)
+			if err != nil {
+				return nil, fmt.Errorf("seed stock of %s: %w", p.Name, err)
+			}
 		}
 		ids = append(ids, id)
 	}
@@ -245,7 +257,9 @@ func seedSampleTransactions(db *postgres.DB, productIDs []int) (int, error) {
 			// Get product info
 			var productName string
 			var price int
-			err := db.QueryRow("SELECT name, price FROM products WHERE id = $1", productID).Scan(&productName, &price)
+			var categoryID *int
+			err := db.QueryRow("SELECT name, price, category_id FROM products WHERE id = $1", productID).
+				Scan(&productName, &price, &categoryID)
 			if err != nil {
 				continue
 			}
@@ -256,9 +270,9 @@ func seedSampleTransactions(db *postgres.DB, productIDs []int) (int, error) {
 
 			// Insert transaction detail
 			_, err = db.Exec(`
-				INSERT INTO transaction_details (transaction_id, product_id, product_name, quantity, price, subtotal)
-				VALUES ($1, $2, $3, $4, $5, $6)
-			`, transactionID, productID, productName, quantity, price, subtotal)
+				INSERT INTO transaction_details (transaction_id, product_id, product_name, quantity, price, subtotal, category_id)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`, transactionID, productID, productName, quantity, price, subtotal, categoryID)
 			if err != nil {
 				return 0, fmt.Errorf("create transaction detail: %w", err)
 			}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
+	_ "time/tzdata" // the store timezone must load on hosts without a zoneinfo database
+
+	model "kasir-api/models"
 
 	"github.com/spf13/viper"
 )
@@ -13,6 +17,41 @@ type Config struct {
 	DB        DatabaseConfig
 	Server    ServerConfig
 	RateLimit RateLimitConfig
+	Media     MediaConfig
+	Scale     ScaleConfig
+	Checkout  CheckoutConfig
+	Store     StoreConfig
+	Prices    PriceConfig
+}
+
+// StoreConfig holds settings of the store itself.
+type StoreConfig struct {
+	Location *time.Location // IANA timezone of the store, e.g. Asia/Jakarta; report days and hours follow it
+}
+
+// PriceConfig holds settings for scheduled price changes.
+type PriceConfig struct {
+	ScheduleInterval time.Duration // how often due scheduled prices are applied
+}
+
+// CheckoutConfig holds checkout policies.
+type CheckoutConfig struct {
+	CustomItems     bool     // allow custom items outside the catalog
+	CustomItemRoles []string // roles (X-Role header, unauthenticated and advisory only) allowed to sell custom items; empty allows all
+}
+
+// ScaleConfig holds settings for labels printed by weighing scales.
+type ScaleConfig struct {
+	// BarcodeRules lists the EAN-13 prefix ranges of scale labels as from-to:kind:plu_start:plu_length,
+	// comma-separated; kind is weight or price. Empty uses the built-in default.
+	BarcodeRules string
+}
+
+// MediaConfig holds settings for uploaded product images.
+type MediaConfig struct {
+	Dir            string // local directory for stored files
+	BaseURL        string // URL prefix the files are served under
+	MaxUploadBytes int64  // maximum size of one uploaded image
 }
 
 // RateLimitConfig holds rate limiting settings.
@@ -94,6 +133,33 @@ func Load() (*Config, error) {
 		rateBurst = 20
 	}
 
+	mediaDir := v.GetString("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = "uploads"
+	}
+	mediaBaseURL := strings.TrimSuffix(v.GetString("MEDIA_BASE_URL"), "/")
+	if mediaBaseURL == "" {
+		mediaBaseURL = "/media"
+	}
+	maxUploadMB := v.GetInt64("MEDIA_MAX_UPLOAD_MB")
+	if maxUploadMB <= 0 {
+		maxUploadMB = 5
+	}
+
+	scheduleInterval := v.GetDuration("PRICE_SCHEDULE_INTERVAL")
+	if scheduleInterval <= 0 {
+		scheduleInterval = time.Minute
+	}
+
+	timezone := strings.TrimSpace(v.GetString("STORE_TIMEZONE"))
+	if timezone == "" {
+		timezone = model.DefaultStoreTimezone
+	}
+	storeLocation, err := loadStoreLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("STORE_TIMEZONE: %w", err)
+	}
+
 	cfg := &Config{
 		RateLimit: RateLimitConfig{
 			Rate:  rateLimit,
@@ -113,7 +179,45 @@ func Load() (*Config, error) {
 		Server: ServerConfig{
 			Port: port,
 		},
+		Media: MediaConfig{
+			Dir:            mediaDir,
+			BaseURL:        mediaBaseURL,
+			MaxUploadBytes: maxUploadMB << 20,
+		},
+		Scale: ScaleConfig{
+			BarcodeRules: strings.TrimSpace(v.GetString("SCALE_BARCODE_RULES")),
+		},
+		Checkout: CheckoutConfig{
+			CustomItems:     v.GetBool("CHECKOUT_CUSTOM_ITEMS"),
+			CustomItemRoles: splitList(v.GetString("CHECKOUT_CUSTOM_ITEM_ROLES")),
+		},
+		Store: StoreConfig{
+			Location: storeLocation,
+		},
+		Prices: PriceConfig{
+			ScheduleInterval: scheduleInterval,
+		},
 	}
 
 	return cfg, nil
 }
+
+// loadStoreLocation loads the store timezone by its IANA name. "Local" is rejected: it is the server's
+// zone rather than the store's, and PostgreSQL cannot resolve it in AT TIME ZONE.
+func loadStoreLocation(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, fmt.Errorf("%q is not an IANA timezone, use a name such as %s", name, model.DefaultStoreTimezone)
+	}
+	return time.LoadLocation(name)
+}
+
+// splitList splits a comma-separated setting, dropping blanks.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
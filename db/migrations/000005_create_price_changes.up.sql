@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS price_changes (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    old_price INTEGER,
+    new_price INTEGER NOT NULL CHECK (new_price > 0),
+    changed_by VARCHAR(255) NOT NULL DEFAULT '',
+    effective_at TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_changes_product ON price_changes (product_id, effective_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_changes_pending ON price_changes (effective_at) WHERE applied_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE transaction_details DROP CONSTRAINT IF EXISTS fk_transaction_details_product;
+ALTER TABLE products DROP COLUMN IF EXISTS archived_at;
+ALTER TABLE categories DROP COLUMN IF EXISTS archived_at;
@@ -0,0 +1,8 @@
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+-- Products are archived instead of deleted, so history can reference them.
+-- NOT VALID skips checking rows written before soft delete existed.
+ALTER TABLE transaction_details
+    ADD CONSTRAINT fk_transaction_details_product
+    FOREIGN KEY (product_id) REFERENCES products(id) NOT VALID;
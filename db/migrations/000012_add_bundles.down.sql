@@ -0,0 +1,3 @@
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS bundle_product_id;
+
+DROP TABLE IF EXISTS bundle_components;
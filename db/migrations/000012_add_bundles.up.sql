@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS bundle_components (
+    bundle_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    component_id INTEGER NOT NULL REFERENCES products(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (bundle_id, component_id),
+    CHECK (bundle_id <> component_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bundle_components_component ON bundle_components (component_id);
+
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS bundle_product_id INTEGER;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS ingredient_movements;
+DROP TABLE IF EXISTS product_recipes;
+DROP TABLE IF EXISTS ingredients;
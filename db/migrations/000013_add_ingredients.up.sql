@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS ingredients (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    unit VARCHAR(16) NOT NULL,
+    stock NUMERIC(14,3) NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS product_recipes (
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    ingredient_id INTEGER NOT NULL REFERENCES ingredients(id),
+    quantity NUMERIC(14,3) NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (product_id, ingredient_id)
+);
+
+CREATE TABLE IF NOT EXISTS ingredient_movements (
+    id SERIAL PRIMARY KEY,
+    ingredient_id INTEGER NOT NULL REFERENCES ingredients(id),
+    type VARCHAR(16) NOT NULL CHECK (type IN ('sale', 'restock', 'waste', 'count')),
+    quantity NUMERIC(14,3) NOT NULL,
+    transaction_id INTEGER REFERENCES transactions(id),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ingredient_movements_created_at ON ingredient_movements (created_at);
+CREATE INDEX IF NOT EXISTS idx_ingredient_movements_ingredient ON ingredient_movements (ingredient_id, id);
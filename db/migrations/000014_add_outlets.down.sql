@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS stock_transfers;
+DROP INDEX IF EXISTS idx_transactions_outlet_created_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS outlet_id;
+DROP TABLE IF EXISTS outlet_stock;
+DROP TABLE IF EXISTS outlets;
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS outlets (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Existing stock and transactions belong to the default outlet.
+INSERT INTO outlets (id, name) VALUES (1, 'Outlet Utama') ON CONFLICT (id) DO NOTHING;
+SELECT setval(pg_get_serial_sequence('outlets', 'id'), (SELECT MAX(id) FROM outlets));
+
+-- products.stock stays the total over all outlets.
+CREATE TABLE IF NOT EXISTS outlet_stock (
+    outlet_id INTEGER NOT NULL REFERENCES outlets(id),
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    stock INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    PRIMARY KEY (outlet_id, product_id)
+);
+
+INSERT INTO outlet_stock (outlet_id, product_id, stock)
+SELECT 1, id, stock FROM products WHERE stock > 0
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS outlet_id INTEGER NOT NULL DEFAULT 1 REFERENCES outlets(id);
+CREATE INDEX IF NOT EXISTS idx_transactions_outlet_created_at ON transactions (outlet_id, created_at);
+
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    from_outlet_id INTEGER NOT NULL REFERENCES outlets(id),
+    to_outlet_id INTEGER NOT NULL REFERENCES outlets(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (from_outlet_id <> to_outlet_id)
+);
+
+-- Each transfer is booked as a pair of movements that net to zero.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id SERIAL PRIMARY KEY,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    outlet_id INTEGER NOT NULL REFERENCES outlets(id),
+    quantity INTEGER NOT NULL,
+    transfer_id INTEGER NOT NULL REFERENCES stock_transfers(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_transfer ON stock_movements (transfer_id);
@@ -0,0 +1,2 @@
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS unit;
+ALTER TABLE products DROP COLUMN IF EXISTS unit;
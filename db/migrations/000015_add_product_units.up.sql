@@ -0,0 +1,7 @@
+-- Products sold by weight or volume keep stock and quantities in grams or milliliters
+-- and are priced per kilogram or liter.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS unit VARCHAR(8) NOT NULL DEFAULT 'pcs'
+    CHECK (unit IN ('pcs', 'kg', 'l'));
+
+-- Snapshot of the unit at sale time, so quantity and price stay readable.
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS unit VARCHAR(8) NOT NULL DEFAULT 'pcs';
@@ -0,0 +1,3 @@
+-- PLU printed on scale labels, stored without leading zeros.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS plu VARCHAR(10);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_plu ON products (plu) WHERE plu IS NOT NULL;
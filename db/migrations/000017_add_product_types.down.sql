@@ -0,0 +1,9 @@
+UPDATE outlet_stock SET stock = 0 WHERE stock < 0;
+ALTER TABLE outlet_stock ADD CONSTRAINT outlet_stock_stock_check CHECK (stock >= 0);
+
+UPDATE products SET stock = 0 WHERE stock < 0;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_stock_check;
+ALTER TABLE products ADD CONSTRAINT products_stock_check CHECK (stock >= 0);
+
+ALTER TABLE products DROP COLUMN IF EXISTS allow_negative_stock;
+ALTER TABLE products DROP COLUMN IF EXISTS type;
@@ -0,0 +1,11 @@
+-- Non-stocked goods and services are sold without stock; stocked products may opt in to going
+-- below zero when they are sold before their delivery is booked.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS type VARCHAR(16) NOT NULL DEFAULT 'stocked'
+    CHECK (type IN ('stocked', 'non_stocked', 'service'));
+ALTER TABLE products ADD COLUMN IF NOT EXISTS allow_negative_stock BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_stock_check;
+ALTER TABLE products ADD CONSTRAINT products_stock_check CHECK (stock >= 0 OR allow_negative_stock);
+
+-- Outlet stock cannot see the product's policy; the repository checks it when booking.
+ALTER TABLE outlet_stock DROP CONSTRAINT IF EXISTS outlet_stock_stock_check;
@@ -0,0 +1,10 @@
+ALTER TABLE transaction_details DROP COLUMN IF EXISTS category_id;
+-- Custom items keep their name and amounts; 0 marks that they had no product. The product FK
+-- checks updated rows even though it is NOT VALID, so it is dropped around the update and
+-- re-added without validating the placeholder.
+ALTER TABLE transaction_details DROP CONSTRAINT IF EXISTS fk_transaction_details_product;
+UPDATE transaction_details SET product_id = 0 WHERE product_id IS NULL;
+ALTER TABLE transaction_details ALTER COLUMN product_id SET NOT NULL;
+ALTER TABLE transaction_details
+    ADD CONSTRAINT fk_transaction_details_product
+    FOREIGN KEY (product_id) REFERENCES products(id) NOT VALID;
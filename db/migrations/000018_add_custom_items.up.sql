@@ -0,0 +1,5 @@
+-- Custom items sold outside the catalog have no product; they may carry a category.
+ALTER TABLE transaction_details ALTER COLUMN product_id DROP NOT NULL;
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS category_id INTEGER REFERENCES categories(id);
+-- A rollback marks custom items with product 0; turn them back into custom items.
+UPDATE transaction_details SET product_id = NULL WHERE product_id = 0;
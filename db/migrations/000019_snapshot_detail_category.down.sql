@@ -0,0 +1,2 @@
+-- Only custom items carried a category before the snapshot.
+UPDATE transaction_details SET category_id = NULL WHERE product_id IS NOT NULL;
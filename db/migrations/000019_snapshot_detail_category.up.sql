@@ -0,0 +1,6 @@
+-- Category reports read the category snapshot on each detail. Past sales are backfilled with the
+-- current category of their product, the best history available.
+UPDATE transaction_details td
+SET category_id = p.category_id
+FROM products p
+WHERE td.product_id = p.id AND td.category_id IS NULL;
@@ -0,0 +1,3 @@
+-- created_at was stored as UTC wall time without a zone. Reading it as UTC keeps every instant,
+-- and from now on comparisons against store-timezone midnights are exact.
+ALTER TABLE transactions ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC';
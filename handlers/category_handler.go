@@ -3,6 +3,7 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	helper "kasir-api/helpers"
 	model "kasir-api/models"
@@ -22,40 +23,35 @@ func NewCategoryHandler(svc *service.CategoryService) *CategoryHandler {
 }
 
 // HandleGetAll handles GET /api/categories.
-// Supports query parameters: ?page=1&limit=20 for pagination.
+// Supports query parameters: ?include_archived=true, ?page=1&limit=20 for pagination.
+// With ?tree=true the categories are returned as a nested tree without pagination.
 func (h *CategoryHandler) HandleGetAll(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetAll()
-	if err != nil {
-		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve categories", err)
+	includeArchived := helper.ParseBoolQuery(r, "include_archived")
+	if helper.ParseBoolQuery(r, "tree") {
+		tree, err := h.service.GetTree(includeArchived)
+		if err != nil {
+			helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve categories", err)
+			return
+		}
+		helper.WriteSuccess(w, http.StatusOK, "Success", tree)
 		return
 	}
 
-	page, limit := helper.ParsePagination(r, 20)
-	total := len(categories)
-
-	start := (page - 1) * limit
-	if start > total {
-		start = total
-	}
-	end := start + limit
-	if end > total {
-		end = total
-	}
-
-	totalPages := (total + limit - 1) / limit
-	if totalPages == 0 {
-		totalPages = 1
+	pageReq, ok := parsePageRequest(w, r)
+	if !ok {
+		return
 	}
-
-	paged := &model.PaginatedResponse{
-		Items:      categories[start:end],
-		Page:       page,
-		Limit:      limit,
-		TotalItems: total,
-		TotalPages: totalPages,
+	result, err := h.service.GetPage(includeArchived, pageReq)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve categories", err)
+		return
 	}
 
-	helper.WriteSuccess(w, http.StatusOK, "Success", paged)
+	helper.WriteSuccess(w, http.StatusOK, "Success", model.NewPaginatedResponse(result, pageReq))
 }
 
 // HandleGetByID handles GET /api/categories/{id}.
@@ -74,6 +70,7 @@ func (h *CategoryHandler) HandleGetByID(w http.ResponseWriter, r *http.Request)
 		helper.WriteError(w, r, http.StatusBadRequest, "Invalid request", err)
 		return
 	}
+	helper.SetETag(w, category.Version)
 	helper.WriteSuccess(w, http.StatusOK, "Success", category)
 }
 
@@ -90,50 +87,161 @@ func (h *CategoryHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	helper.SetETag(w, createdCategory.Version)
 	helper.WriteSuccess(w, http.StatusCreated, "Category created successfully", createdCategory)
 }
 
 // HandleUpdate handles PUT /api/categories/{id}.
+// With If-Match the update only succeeds while the category still has that ETag (412 otherwise).
+// A version in the body is ignored; only If-Match makes the update conditional.
 func (h *CategoryHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 	id, ok := helper.ParseIDFromPath(w, r, "/api/categories/", model.ErrCategoryNotFound)
 	if !ok {
 		return
 	}
+	version, ok := helper.ParseIfMatch(w, r)
+	if !ok {
+		return
+	}
 
 	var category model.Category
 	if !helper.ValidatePayload(w, r, &category) {
 		return
 	}
+	category.Version = version
+	h.update(w, r, id, &category)
+}
+
+// HandlePatch handles PATCH /api/categories/{id} with an RFC 7396 JSON merge patch.
+// Only the members sent change; "parent_id": null moves the category to the top level.
+// The merged category is validated like a full update. The patch is applied to the version that
+// was read, so a concurrent change fails with 409, or 412 when the client sent If-Match.
+func (h *CategoryHandler) HandlePatch(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseIDFromPath(w, r, "/api/categories/", model.ErrCategoryNotFound)
+	if !ok {
+		return
+	}
+	version, ok := helper.ParseIfMatch(w, r)
+	if !ok {
+		return
+	}
 
-	updatedCategory, err := h.service.Update(id, &category)
+	current, err := h.service.GetByID(id)
 	if err != nil {
 		if errors.Is(err, model.ErrCategoryNotFound) {
 			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
 			return
 		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve category", err)
+		return
+	}
+	if version != 0 && version != current.Version {
+		helper.WriteVersionMismatch(w, r, model.ErrVersionMismatch)
+		return
+	}
+
+	var input model.CategoryInput
+	if !helper.ApplyMergePatch(w, r, model.NewCategoryInput(current), &input) {
+		return
+	}
+	h.update(w, r, id, &model.Category{
+		Name:        input.Name,
+		Description: input.Description,
+		ParentID:    input.ParentID,
+		Version:     current.Version,
+	})
+}
+
+// update saves category over id and writes the response shared by PUT and PATCH.
+func (h *CategoryHandler) update(w http.ResponseWriter, r *http.Request, id int, category *model.Category) {
+	updatedCategory, err := h.service.Update(id, category)
+	if err != nil {
+		if errors.Is(err, model.ErrCategoryNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrVersionMismatch) {
+			helper.WriteVersionMismatch(w, r, err)
+			return
+		}
 		helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
+	helper.SetETag(w, updatedCategory.Version)
 	helper.WriteSuccess(w, http.StatusOK, "Category updated successfully", updatedCategory)
 }
 
 // HandleDelete handles DELETE /api/categories/{id}.
+// Categories are archived, not removed. Active products block the archive (409) unless
+// ?on_products=reassign&to={id} or ?on_products=unset is given. With If-Match the category is
+// only archived while it still has that ETag (412 otherwise).
 func (h *CategoryHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
 	id, ok := helper.ParseIDFromPath(w, r, "/api/categories/", model.ErrCategoryNotFound)
 	if !ok {
 		return
 	}
+	version, ok := helper.ParseIfMatch(w, r)
+	if !ok {
+		return
+	}
 
-	err := h.service.Delete(id)
+	policy := model.CategoryProductPolicy{OnProducts: r.URL.Query().Get("on_products")}
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		to, err := strconv.Atoi(raw)
+		if err != nil {
+			helper.WriteError(w, r, http.StatusBadRequest, model.ErrReassignTargetRequired.Error(), err)
+			return
+		}
+		policy.ReassignTo = to
+	}
+
+	err := h.service.Archive(id, policy, version)
 	if err != nil {
 		if errors.Is(err, model.ErrCategoryNotFound) {
 			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
 			return
 		}
-		helper.WriteError(w, r, http.StatusBadRequest, "Failed to delete category", err)
+		if errors.Is(err, model.ErrVersionMismatch) {
+			helper.WriteVersionMismatch(w, r, err)
+			return
+		}
+		if errors.Is(err, model.ErrCategoryHasChildren) || errors.Is(err, model.ErrCategoryHasProducts) {
+			helper.WriteError(w, r, http.StatusConflict, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
 		return
 	}
 
-	helper.WriteSuccess(w, http.StatusOK, "Category deleted successfully", nil)
+	helper.WriteSuccess(w, http.StatusOK, "Category archived successfully", nil)
+}
+
+// HandleRestore handles POST /api/categories/{id}/restore.
+func (h *CategoryHandler) HandleRestore(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/categories/", 0, model.ErrCategoryNotFound)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Restore(id); err != nil {
+		if errors.Is(err, model.ErrCategoryNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrParentCategoryArchived) {
+			helper.WriteError(w, r, http.StatusConflict, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusBadRequest, "Failed to restore category", err)
+		return
+	}
+
+	category, err := h.service.GetByID(id)
+	if err != nil {
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve category", err)
+		return
+	}
+	helper.SetETag(w, category.Version)
+	helper.WriteSuccess(w, http.StatusOK, "Category restored successfully", category)
 }
@@ -12,6 +12,18 @@ import (
 	service "kasir-api/services"
 )
 
+func setupCategoryHandlerWithProducts() (*CategoryHandler, *memory.CategoryRepository, *memory.ProductRepository) {
+	categoryRepo := memory.NewCategoryRepository()
+	productRepo := memory.NewProductRepository(categoryRepo)
+	handler := NewCategoryHandler(service.NewCategoryService(categoryRepo))
+
+	categoryRepo.Create(&model.Category{Name: "Rokok"})
+	categoryRepo.Create(&model.Category{Name: "Tembakau"})
+	categoryID := 1
+	productRepo.Create(&model.Product{Name: "Sampoerna", Price: 30000, Stock: 10, CategoryID: &categoryID})
+	return handler, categoryRepo, productRepo
+}
+
 func setupCategoryHandler() (*CategoryHandler, *memory.CategoryRepository) {
 	repo := memory.NewCategoryRepository()
 	svc := service.NewCategoryService(repo)
@@ -80,6 +92,73 @@ func TestCategoryHandler_HandleGetAll_Empty(t *testing.T) {
 	}
 }
 
+func TestCategoryHandler_HandleGetAll_Paginated(t *testing.T) {
+	handler, repo := setupCategoryHandler()
+	for _, name := range []string{"Makanan", "Minuman", "Snack"} {
+		repo.Create(&model.Category{Name: name})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/categories?page=2&limit=2", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleGetAll(rr, req)
+
+	var response struct {
+		Data struct {
+			Items      []model.Category `json:"items"`
+			Page       int              `json:"page"`
+			TotalItems int              `json:"total_items"`
+			TotalPages int              `json:"total_pages"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data.Items) != 1 || response.Data.Items[0].Name != "Snack" {
+		t.Errorf("Page 2 should contain only Snack, got: %+v", response.Data.Items)
+	}
+	if response.Data.Page != 2 || response.Data.TotalItems != 3 || response.Data.TotalPages != 2 {
+		t.Errorf("Pagination metadata mismatch, got: %+v", response.Data)
+	}
+}
+
+func TestCategoryHandler_HandleGetAll_Tree(t *testing.T) {
+	handler, repo := setupCategoryHandler()
+
+	minuman := &model.Category{Name: "Minuman"}
+	repo.Create(minuman)
+	repo.Create(&model.Category{Name: "Soda", ParentID: &minuman.ID})
+	repo.Create(&model.Category{Name: "Rokok"})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/categories?tree=true", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleGetAll(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleGetAll with tree should return 200, got: %d", rr.Code)
+	}
+
+	var response struct {
+		Data []struct {
+			Name     string `json:"name"`
+			Children []struct {
+				Name     string `json:"name"`
+				ParentID int    `json:"parent_id"`
+			} `json:"children"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+
+	if len(response.Data) != 2 {
+		t.Fatalf("Tree should have 2 roots, got: %d", len(response.Data))
+	}
+	if len(response.Data[0].Children) != 1 || response.Data[0].Children[0].Name != "Soda" {
+		t.Errorf("Minuman should contain Soda, got: %+v", response.Data[0].Children)
+	}
+	if response.Data[0].Children[0].ParentID != minuman.ID {
+		t.Errorf("Soda should expose parent_id %d, got: %d", minuman.ID, response.Data[0].Children[0].ParentID)
+	}
+}
+
 func TestCategoryHandler_HandleGetByID_Success(t *testing.T) {
 	handler, repo := setupCategoryHandler()
 
@@ -296,6 +375,162 @@ func TestCategoryHandler_HandleDelete_Success(t *testing.T) {
 	}
 }
 
+func TestCategoryHandler_HandleGetAll_IncludeArchived(t *testing.T) {
+	handler, repo := setupCategoryHandler()
+
+	repo.Create(&model.Category{Name: "Electronics"})
+	repo.Create(&model.Category{Name: "Old"})
+	repo.Archive(2, model.CategoryProductPolicy{}, 0)
+
+	testCases := []struct {
+		url      string
+		expected int
+	}{
+		{"/api/categories", 1},
+		{"/api/categories?include_archived=true", 2},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+		rr := httptest.NewRecorder()
+
+		handler.HandleGetAll(rr, req)
+
+		var response map[string]interface{}
+		json.NewDecoder(rr.Body).Decode(&response)
+		data := response["data"].(map[string]interface{})
+		if data["total_items"].(float64) != float64(tc.expected) {
+			t.Errorf("%s should return %d categories, got: %v", tc.url, tc.expected, data["total_items"])
+		}
+	}
+}
+
+func TestCategoryHandler_HandleRestore_Success(t *testing.T) {
+	handler, repo := setupCategoryHandler()
+
+	repo.Create(&model.Category{Name: "Electronics"})
+	repo.Archive(1, model.CategoryProductPolicy{}, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/categories/1/restore", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleRestore(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("HandleRestore should return 200, got: %d", rr.Code)
+	}
+	category, _ := repo.GetByID(1)
+	if category.IsArchived() {
+		t.Error("HandleRestore should clear archived_at")
+	}
+}
+
+func TestCategoryHandler_HandleRestore_NotFound(t *testing.T) {
+	handler, _ := setupCategoryHandler()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/categories/999/restore", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleRestore(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("HandleRestore with non-existent ID should return 404, got: %d", rr.Code)
+	}
+}
+
+func TestCategoryHandler_HandleDelete_WithActiveChildren(t *testing.T) {
+	handler, repo := setupCategoryHandler()
+
+	minuman := &model.Category{Name: "Minuman"}
+	repo.Create(minuman)
+	repo.Create(&model.Category{Name: "Soda", ParentID: &minuman.ID})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/categories/1", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleDelete(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Errorf("HandleDelete with active sub-categories should return 409, got: %d", rr.Code)
+	}
+}
+
+func TestCategoryHandler_HandleDelete_WithProducts(t *testing.T) {
+	handler, categoryRepo, _ := setupCategoryHandlerWithProducts()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/categories/1", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleDelete(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Errorf("HandleDelete with active products should return 409, got: %d", rr.Code)
+	}
+	var response map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if response["message"] != "category still has products: 1 active products" {
+		t.Errorf("Message should include the product count, got: %v", response["message"])
+	}
+	category, _ := categoryRepo.GetByID(1)
+	if category.IsArchived() {
+		t.Error("Category should stay active on 409")
+	}
+}
+
+func TestCategoryHandler_HandleDelete_ProductPolicies(t *testing.T) {
+	testCases := []struct {
+		name       string
+		query      string
+		code       int
+		categoryID *int
+	}{
+		{"reassign", "?on_products=reassign&to=2", http.StatusOK, intPtr(2)},
+		{"unset", "?on_products=unset", http.StatusOK, nil},
+		{"reassign missing target", "?on_products=reassign", http.StatusBadRequest, intPtr(1)},
+		{"reassign invalid target", "?on_products=reassign&to=abc", http.StatusBadRequest, intPtr(1)},
+		{"reassign unknown target", "?on_products=reassign&to=999", http.StatusBadRequest, intPtr(1)},
+		{"unknown policy", "?on_products=cascade", http.StatusBadRequest, intPtr(1)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, _, productRepo := setupCategoryHandlerWithProducts()
+
+			req := httptest.NewRequest(http.MethodDelete, "/api/categories/1"+tc.query, nil)
+			rr := httptest.NewRecorder()
+
+			handler.HandleDelete(rr, req)
+
+			if rr.Code != tc.code {
+				t.Errorf("HandleDelete%s should return %d, got: %d", tc.query, tc.code, rr.Code)
+			}
+			product, _ := productRepo.GetByID(1)
+			if (product.CategoryID == nil) != (tc.categoryID == nil) ||
+				(product.CategoryID != nil && *product.CategoryID != *tc.categoryID) {
+				t.Errorf("Product category should be %v, got: %v", tc.categoryID, product.CategoryID)
+			}
+		})
+	}
+}
+
+func TestCategoryHandler_HandleUpdate_Cycle(t *testing.T) {
+	handler, repo := setupCategoryHandler()
+
+	minuman := &model.Category{Name: "Minuman"}
+	repo.Create(minuman)
+	repo.Create(&model.Category{Name: "Soda", ParentID: &minuman.ID})
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Minuman", "parent_id": 2})
+	req := httptest.NewRequest(http.MethodPut, "/api/categories/1", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	handler.HandleUpdate(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("HandleUpdate creating a cycle should return 400, got: %d", rr.Code)
+	}
+}
+
 func TestCategoryHandler_HandleDelete_InvalidID(t *testing.T) {
 	handler, _ := setupCategoryHandler()
 
@@ -679,3 +914,90 @@ func TestCategoryHandler_ConcurrentRequests(t *testing.T) {
 		<-done
 	}
 }
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCategoryHandler_HandlePatch(t *testing.T) {
+	handler, repo := setupCategoryHandler()
+	minuman := &model.Category{Name: "Minuman", Description: "Semua minuman"}
+	repo.Create(minuman)
+	repo.Create(&model.Category{Name: "Soda", Description: "Minuman bersoda", ParentID: &minuman.ID})
+
+	patch := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/categories/"+id, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		rr := httptest.NewRecorder()
+		handler.HandlePatch(rr, req)
+		return rr
+	}
+
+	if rr := patch("2", `{"parent_id": null}`); rr.Code != http.StatusOK {
+		t.Fatalf("Clearing parent_id should return 200, got: %d %s", rr.Code, rr.Body.String())
+	}
+	soda, _ := repo.GetByID(2)
+	if soda.ParentID != nil || soda.Name != "Soda" || soda.Description != "Minuman bersoda" {
+		t.Errorf("Patch should only clear parent_id, got: %+v", soda)
+	}
+
+	if rr := patch("1", `{"parent_id": 2}`); rr.Code != http.StatusOK {
+		t.Errorf("Moving a category under another should return 200, got: %d", rr.Code)
+	}
+	if rr := patch("2", `{"parent_id": 1}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("A cycle in the merged result should return 400, got: %d", rr.Code)
+	}
+	if rr := patch("1", `{"name": null}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Clearing the name should return 400, got: %d", rr.Code)
+	}
+	if rr := patch("9", `{"name": "X"}`); rr.Code != http.StatusNotFound {
+		t.Errorf("Patching a missing category should return 404, got: %d", rr.Code)
+	}
+}
+
+func TestCategoryHandler_IfMatch(t *testing.T) {
+	handler, repo := setupCategoryHandler()
+	repo.Create(&model.Category{Name: "Minuman"})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/categories/1", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetByID(rr, req)
+	if etag := rr.Header().Get("ETag"); etag != `"1"` {
+		t.Fatalf("GET should return ETag \"1\", got: %q", etag)
+	}
+
+	put := func(ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/api/categories/1", bytes.NewBufferString(body))
+		req.Header.Set("If-Match", ifMatch)
+		rr := httptest.NewRecorder()
+		handler.HandleUpdate(rr, req)
+		return rr
+	}
+	if rr := put(`"1"`, `{"name":"Minuman Dingin","version":7}`); rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"2"` {
+		t.Fatalf("PUT with the current ETag should return 200 and ETag \"2\", got: %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+	if rr := put(`"1"`, `{"name":"Minuman Panas"}`); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT with a stale ETag should return 412, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/api/categories/1", bytes.NewBufferString(`{"description":"x"}`))
+	req.Header.Set("If-Match", `"1"`)
+	rr = httptest.NewRecorder()
+	handler.HandlePatch(rr, req)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("PATCH with a stale ETag should return 412, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/categories/1", nil)
+	req.Header.Set("If-Match", `"1"`)
+	rr = httptest.NewRecorder()
+	handler.HandleDelete(rr, req)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE with a stale ETag should return 412, got: %d", rr.Code)
+	}
+
+	c, _ := repo.GetByID(1)
+	if c.Name != "Minuman Dingin" || c.Description != "" || c.IsArchived() {
+		t.Errorf("Rejected writes should leave the category untouched, got: %+v", c)
+	}
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	helper "kasir-api/helpers"
+	model "kasir-api/models"
+	service "kasir-api/services"
+)
+
+// IngredientHandler handles HTTP requests for ingredients, product recipes and ingredient usage.
+type IngredientHandler struct {
+	service *service.IngredientService
+}
+
+// NewIngredientHandler creates a new instance of IngredientHandler.
+func NewIngredientHandler(svc *service.IngredientService) *IngredientHandler {
+	return &IngredientHandler{
+		service: svc,
+	}
+}
+
+// HandleGetAll handles GET /api/ingredients.
+func (h *IngredientHandler) HandleGetAll(w http.ResponseWriter, r *http.Request) {
+	pageReq, ok := parsePageRequest(w, r)
+	if !ok {
+		return
+	}
+	result, err := h.service.GetPage(pageReq)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve ingredients", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", model.NewPaginatedResponse(result, pageReq))
+}
+
+// HandleGetByID handles GET /api/ingredients/{id}.
+func (h *IngredientHandler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseIDFromPath(w, r, "/api/ingredients/", model.ErrIngredientNotFound)
+	if !ok {
+		return
+	}
+
+	ingredient, err := h.service.GetByID(id)
+	if err != nil {
+		if errors.Is(err, model.ErrIngredientNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve ingredient", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", ingredient)
+}
+
+// HandleCreate handles POST /api/ingredients.
+func (h *IngredientHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var ingredient model.Ingredient
+	if !helper.ValidatePayload(w, r, &ingredient) {
+		return
+	}
+
+	created, err := h.service.Create(&ingredient)
+	if err != nil {
+		helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusCreated, "Ingredient created successfully", created)
+}
+
+// HandleUpdate handles PUT /api/ingredients/{id}. Only the name and unit change.
+func (h *IngredientHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseIDFromPath(w, r, "/api/ingredients/", model.ErrIngredientNotFound)
+	if !ok {
+		return
+	}
+
+	var ingredient model.Ingredient
+	if !helper.ValidatePayload(w, r, &ingredient) {
+		return
+	}
+
+	updated, err := h.service.Update(id, &ingredient)
+	if err != nil {
+		if errors.Is(err, model.ErrIngredientNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Ingredient updated successfully", updated)
+}
+
+// HandleGetMovements handles GET /api/ingredients/{id}/movements.
+func (h *IngredientHandler) HandleGetMovements(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/ingredients/", 0, model.ErrIngredientNotFound)
+	if !ok {
+		return
+	}
+
+	pageReq, ok := parsePageRequest(w, r)
+	if !ok {
+		return
+	}
+	result, err := h.service.GetMovements(id, pageReq)
+	if err != nil {
+		if errors.Is(err, model.ErrIngredientNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrInvalidCursor) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve ingredient movements", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", model.NewPaginatedResponse(result, pageReq))
+}
+
+// HandleRecordMovement handles POST /api/ingredients/{id}/movements: a restock, waste or stock count.
+func (h *IngredientHandler) HandleRecordMovement(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/ingredients/", 0, model.ErrIngredientNotFound)
+	if !ok {
+		return
+	}
+
+	var input model.IngredientMovementInput
+	if !helper.ValidatePayload(w, r, &input) {
+		return
+	}
+
+	movement, err := h.service.RecordMovement(id, &input)
+	if err != nil {
+		if errors.Is(err, model.ErrIngredientNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrMovementInvalid) || errors.Is(err, model.ErrStockInvalid) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to record ingredient movement", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusCreated, "Movement recorded successfully", movement)
+}
+
+// HandleGetRecipe handles GET /api/products/{id}/recipe.
+func (h *IngredientHandler) HandleGetRecipe(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/products/", 0, model.ErrProductNotFound)
+	if !ok {
+		return
+	}
+
+	recipe, err := h.service.GetRecipe(id)
+	if err != nil {
+		if errors.Is(err, model.ErrProductNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve recipe", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", recipe)
+}
+
+// HandleSetRecipe handles PUT /api/products/{id}/recipe, replacing the whole recipe.
+func (h *IngredientHandler) HandleSetRecipe(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/products/", 0, model.ErrProductNotFound)
+	if !ok {
+		return
+	}
+
+	var input model.RecipeInput
+	if !helper.ValidatePayload(w, r, &input) {
+		return
+	}
+
+	recipe, err := h.service.SetRecipe(id, input.Items)
+	if err != nil {
+		if errors.Is(err, model.ErrProductNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrIngredientNotFound) || errors.Is(err, model.ErrRecipeInvalid) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to save recipe", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Recipe saved successfully", recipe)
+}
+
+// HandleGetUsage handles GET /api/report/ingredients?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD.
+// Ingredient stock is shared by all outlets, so an outlet_id filter is rejected rather than
+// answered with a variance that leaves out the waste and counts.
+func (h *IngredientHandler) HandleGetUsage(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, ok := parseDateRange(w, r, h.service.Location())
+	if !ok {
+		return
+	}
+	if r.URL.Query().Has("outlet_id") {
+		helper.WriteError(w, r, http.StatusBadRequest, "outlet_id is not supported: ingredient stock is shared by all outlets", nil)
+		return
+	}
+
+	usage, err := h.service.GetUsage(startDate, endDate)
+	if err != nil {
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve ingredient usage", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", usage)
+}
@@ -0,0 +1,358 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	model "kasir-api/models"
+	"kasir-api/repositories/memory"
+	service "kasir-api/services"
+)
+
+func setupIngredientHandler() (*IngredientHandler, *memory.IngredientRepository, *memory.ProductRepository) {
+	categoryRepo := memory.NewCategoryRepository()
+	productRepo := memory.NewProductRepository(categoryRepo)
+	ingredientRepo := memory.NewIngredientRepository()
+	handler := NewIngredientHandler(service.NewIngredientService(ingredientRepo, productRepo))
+	return handler, ingredientRepo, productRepo
+}
+
+func TestNewIngredientHandler(t *testing.T) {
+	svc := &service.IngredientService{}
+	handler := NewIngredientHandler(svc)
+
+	if handler == nil {
+		t.Error("NewIngredientHandler should return a non-nil handler")
+	}
+	if handler.service != svc {
+		t.Error("NewIngredientHandler should set the service")
+	}
+}
+
+func TestIngredientHandler_HandleGetAll_Paginated(t *testing.T) {
+	handler, ingredientRepo, _ := setupIngredientHandler()
+	for _, name := range []string{"Kopi", "Susu", "Cup"} {
+		ingredientRepo.Create(&model.Ingredient{Name: name, Unit: "g"})
+	}
+
+	type listResponse struct {
+		Data struct {
+			Items      []model.Ingredient `json:"items"`
+			TotalItems int                `json:"total_items"`
+			NextCursor string             `json:"next_cursor"`
+		} `json:"data"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/ingredients?limit=2", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetAll(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleGetAll should return 200, got: %d", rr.Code)
+	}
+	var first listResponse
+	json.NewDecoder(rr.Body).Decode(&first)
+	if len(first.Data.Items) != 2 || first.Data.TotalItems != 3 || first.Data.NextCursor == "" {
+		t.Fatalf("First page should have 2 of 3 ingredients and a next_cursor, got: %+v", first.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/ingredients?limit=2&after="+first.Data.NextCursor, nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetAll(rr, req)
+	var second listResponse
+	json.NewDecoder(rr.Body).Decode(&second)
+	if len(second.Data.Items) != 1 || second.Data.Items[0].Name != "Cup" || second.Data.NextCursor != "" {
+		t.Errorf("Cursor page should hold the last ingredient and no next_cursor, got: %+v", second.Data)
+	}
+
+	for _, after := range []string{"rusak", (&model.Cursor{Desc: true, ID: 5}).Encode()} {
+		req = httptest.NewRequest(http.MethodGet, "/api/ingredients?after="+after, nil)
+		rr = httptest.NewRecorder()
+		handler.HandleGetAll(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("HandleGetAll with cursor %q should return 400, got: %d", after, rr.Code)
+		}
+	}
+}
+
+func TestIngredientHandler_HandleGetByID(t *testing.T) {
+	handler, ingredientRepo, _ := setupIngredientHandler()
+	ingredientRepo.Create(&model.Ingredient{Name: "Kopi", Unit: "g", Stock: model.NewQuantity(1000)})
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/ingredients/1", http.StatusOK},
+		{"/api/ingredients/999", http.StatusNotFound},
+		{"/api/ingredients/0", http.StatusNotFound},
+		{"/api/ingredients/abc", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleGetByID(rr, req)
+
+		if rr.Code != tt.want {
+			t.Errorf("HandleGetByID %s should return %d, got: %d", tt.path, tt.want, rr.Code)
+		}
+	}
+}
+
+func TestIngredientHandler_HandleCreate(t *testing.T) {
+	handler, _, _ := setupIngredientHandler()
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"valid", `{"name":"Susu","unit":"ml","stock":1000.5}`, http.StatusCreated},
+		{"invalid JSON", `invalid`, http.StatusBadRequest},
+		{"missing unit", `{"name":"Susu"}`, http.StatusBadRequest},
+		{"blank name", `{"name":"  ","unit":"ml"}`, http.StatusBadRequest},
+		{"negative stock", `{"name":"Susu","unit":"ml","stock":-1}`, http.StatusBadRequest},
+		{"too many decimals", `{"name":"Susu","unit":"ml","stock":1.2345}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/ingredients", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.HandleCreate(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("HandleCreate should return %d, got: %d", tt.want, rr.Code)
+			}
+		})
+	}
+}
+
+func TestIngredientHandler_HandleUpdate(t *testing.T) {
+	handler, ingredientRepo, _ := setupIngredientHandler()
+	ingredientRepo.Create(&model.Ingredient{Name: "Kopi", Unit: "g", Stock: model.NewQuantity(1000)})
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"valid", "/api/ingredients/1", `{"name":"Kopi Arabika","unit":"g","stock":5}`, http.StatusOK},
+		{"not found", "/api/ingredients/999", `{"name":"Teh","unit":"g"}`, http.StatusNotFound},
+		{"invalid ID", "/api/ingredients/abc", `{"name":"Teh","unit":"g"}`, http.StatusNotFound},
+		{"invalid JSON", "/api/ingredients/1", `invalid`, http.StatusBadRequest},
+		{"blank name", "/api/ingredients/1", `{"name":" ","unit":"g"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, tt.path, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			handler.HandleUpdate(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("HandleUpdate should return %d, got: %d", tt.want, rr.Code)
+			}
+		})
+	}
+
+	ingredient, _ := ingredientRepo.GetByID(1)
+	if ingredient.Name != "Kopi Arabika" || ingredient.Stock != model.NewQuantity(1000) {
+		t.Errorf("Update should rename the ingredient and keep its stock, got: %+v", ingredient)
+	}
+}
+
+func TestIngredientHandler_HandleRecordMovement(t *testing.T) {
+	handler, ingredientRepo, _ := setupIngredientHandler()
+	ingredientRepo.Create(&model.Ingredient{Name: "Susu", Unit: "ml", Stock: model.NewQuantity(1000)})
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"restock", "/api/ingredients/1/movements", `{"type":"restock","quantity":500}`, http.StatusCreated},
+		{"waste", "/api/ingredients/1/movements", `{"type":"waste","quantity":100}`, http.StatusCreated},
+		{"count", "/api/ingredients/1/movements", `{"type":"count","quantity":1350}`, http.StatusCreated},
+		{"unknown ingredient", "/api/ingredients/999/movements", `{"type":"restock","quantity":1}`, http.StatusNotFound},
+		{"invalid ID", "/api/ingredients/abc/movements", `{"type":"restock","quantity":1}`, http.StatusNotFound},
+		{"invalid JSON", "/api/ingredients/1/movements", `invalid`, http.StatusBadRequest},
+		{"unknown type", "/api/ingredients/1/movements", `{"type":"sale","quantity":1}`, http.StatusBadRequest},
+		{"zero restock", "/api/ingredients/1/movements", `{"type":"restock","quantity":0}`, http.StatusBadRequest},
+		{"negative count", "/api/ingredients/1/movements", `{"type":"count","quantity":-1}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			handler.HandleRecordMovement(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("HandleRecordMovement should return %d, got: %d", tt.want, rr.Code)
+			}
+		})
+	}
+
+	ingredient, _ := ingredientRepo.GetByID(1)
+	if ingredient.Stock != model.NewQuantity(1350) {
+		t.Errorf("Restock, waste and count should leave the counted stock, got: %v", ingredient.Stock)
+	}
+}
+
+func TestIngredientHandler_HandleGetMovements(t *testing.T) {
+	handler, ingredientRepo, _ := setupIngredientHandler()
+	ingredientRepo.Create(&model.Ingredient{Name: "Susu", Unit: "ml"})
+	for i := 0; i < 3; i++ {
+		ingredientRepo.RecordMovements([]*model.IngredientMovement{{IngredientID: 1, Type: model.MovementRestock, Quantity: model.NewQuantity(100)}})
+	}
+
+	type movementResponse struct {
+		Data struct {
+			Items      []model.IngredientMovement `json:"items"`
+			TotalItems int                        `json:"total_items"`
+			NextCursor string                     `json:"next_cursor"`
+		} `json:"data"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/ingredients/1/movements?limit=2", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetMovements(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleGetMovements should return 200, got: %d", rr.Code)
+	}
+	var first movementResponse
+	json.NewDecoder(rr.Body).Decode(&first)
+	if len(first.Data.Items) != 2 || first.Data.Items[0].ID != 3 || first.Data.TotalItems != 3 || first.Data.NextCursor == "" {
+		t.Fatalf("First page should hold the 2 newest of 3 movements and a next_cursor, got: %+v", first.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/ingredients/1/movements?limit=2&after="+first.Data.NextCursor, nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetMovements(rr, req)
+	var second movementResponse
+	json.NewDecoder(rr.Body).Decode(&second)
+	if len(second.Data.Items) != 1 || second.Data.Items[0].ID != 1 || second.Data.NextCursor != "" {
+		t.Errorf("Cursor page should hold the oldest movement and no next_cursor, got: %+v", second.Data)
+	}
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/ingredients/999/movements", http.StatusNotFound},
+		{"/api/ingredients/abc/movements", http.StatusNotFound},
+		{"/api/ingredients/1/movements?after=rusak", http.StatusBadRequest},
+		{"/api/ingredients/1/movements?after=" + (&model.Cursor{ID: 1}).Encode(), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleGetMovements(rr, req)
+
+		if rr.Code != tt.want {
+			t.Errorf("HandleGetMovements %s should return %d, got: %d", tt.path, tt.want, rr.Code)
+		}
+	}
+}
+
+func TestIngredientHandler_Recipe(t *testing.T) {
+	handler, ingredientRepo, productRepo := setupIngredientHandler()
+	productRepo.Create(&model.Product{Name: "Kopi Susu", Price: 18000, Stock: 10})
+	ingredientRepo.Create(&model.Ingredient{Name: "Kopi", Unit: "g"})
+	ingredientRepo.Create(&model.Ingredient{Name: "Susu", Unit: "ml"})
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"valid", "/api/products/1/recipe", `{"items":[{"ingredient_id":1,"quantity":18},{"ingredient_id":2,"quantity":150}]}`, http.StatusOK},
+		{"unknown product", "/api/products/999/recipe", `{"items":[]}`, http.StatusNotFound},
+		{"invalid ID", "/api/products/abc/recipe", `{"items":[]}`, http.StatusNotFound},
+		{"invalid JSON", "/api/products/1/recipe", `invalid`, http.StatusBadRequest},
+		{"unknown ingredient", "/api/products/1/recipe", `{"items":[{"ingredient_id":999,"quantity":1}]}`, http.StatusBadRequest},
+		{"listed twice", "/api/products/1/recipe", `{"items":[{"ingredient_id":1,"quantity":1},{"ingredient_id":1,"quantity":2}]}`, http.StatusBadRequest},
+		{"zero quantity", "/api/products/1/recipe", `{"items":[{"ingredient_id":1,"quantity":0}]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, tt.path, bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			handler.HandleSetRecipe(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("HandleSetRecipe should return %d, got: %d", tt.want, rr.Code)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/1/recipe", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetRecipe(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleGetRecipe should return 200, got: %d", rr.Code)
+	}
+	var response struct {
+		Data []model.RecipeItem `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 2 || response.Data[0].Name != "Kopi" || response.Data[1].Quantity != model.NewQuantity(150) {
+		t.Errorf("Rejected recipes should keep the saved one, got: %+v", response.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/products/999/recipe", nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetRecipe(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("HandleGetRecipe for non-existent product should return 404, got: %d", rr.Code)
+	}
+}
+
+func TestIngredientHandler_HandleGetUsage(t *testing.T) {
+	handler, ingredientRepo, _ := setupIngredientHandler()
+	ingredientRepo.Create(&model.Ingredient{Name: "Susu", Unit: "ml", Stock: model.NewQuantity(1000)})
+	ingredientRepo.RecordMovements([]*model.IngredientMovement{
+		{IngredientID: 1, Type: model.MovementSale, Quantity: -model.NewQuantity(300), OutletID: 1},
+		{IngredientID: 1, Type: model.MovementWaste, Quantity: -model.NewQuantity(50)},
+	})
+	today := time.Now().In(handler.service.Location()).Format("2006-01-02")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/report/ingredients?start_date="+today+"&end_date="+today, nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetUsage(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleGetUsage should return 200, got: %d", rr.Code)
+	}
+	var response struct {
+		Data []model.IngredientUsage `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].Variance != model.NewQuantity(50) {
+		t.Errorf("Usage should count the waste as variance, got: %+v", response.Data)
+	}
+
+	for _, query := range []string{
+		"",
+		"start_date=" + today,
+		"start_date=19-10-2026&end_date=" + today,
+		"start_date=" + today + "&end_date=2000-01-01",
+		"start_date=" + today + "&end_date=" + today + "&outlet_id=1",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/report/ingredients?"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleGetUsage(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("HandleGetUsage with %q should return 400, got: %d", query, rr.Code)
+		}
+	}
+}
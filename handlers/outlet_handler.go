@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	helper "kasir-api/helpers"
+	model "kasir-api/models"
+	service "kasir-api/services"
+)
+
+// OutletHandler handles HTTP requests for outlets, their stock and stock transfers.
+type OutletHandler struct {
+	service *service.OutletService
+}
+
+// NewOutletHandler creates a new instance of OutletHandler.
+func NewOutletHandler(svc *service.OutletService) *OutletHandler {
+	return &OutletHandler{
+		service: svc,
+	}
+}
+
+// HandleGetAll handles GET /api/outlets.
+func (h *OutletHandler) HandleGetAll(w http.ResponseWriter, r *http.Request) {
+	pageReq, ok := parsePageRequest(w, r)
+	if !ok {
+		return
+	}
+	result, err := h.service.GetPage(pageReq)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve outlets", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", model.NewPaginatedResponse(result, pageReq))
+}
+
+// HandleGetByID handles GET /api/outlets/{id}.
+func (h *OutletHandler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseIDFromPath(w, r, "/api/outlets/", model.ErrOutletNotFound)
+	if !ok {
+		return
+	}
+
+	outlet, err := h.service.GetByID(id)
+	if err != nil {
+		if errors.Is(err, model.ErrOutletNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve outlet", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", outlet)
+}
+
+// HandleCreate handles POST /api/outlets.
+func (h *OutletHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
+	var outlet model.Outlet
+	if !helper.ValidatePayload(w, r, &outlet) {
+		return
+	}
+
+	created, err := h.service.Create(&outlet)
+	if err != nil {
+		helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusCreated, "Outlet created successfully", created)
+}
+
+// HandleGetStock handles GET /api/outlets/{id}/stock.
+func (h *OutletHandler) HandleGetStock(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/outlets/", 0, model.ErrOutletNotFound)
+	if !ok {
+		return
+	}
+
+	pageReq, ok := parsePageRequest(w, r)
+	if !ok {
+		return
+	}
+	result, err := h.service.GetStock(id, pageReq)
+	if err != nil {
+		if errors.Is(err, model.ErrOutletNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrInvalidCursor) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve outlet stock", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", model.NewPaginatedResponse(result, pageReq))
+}
+
+// HandleGetTransfers handles GET /api/outlets/transfers.
+func (h *OutletHandler) HandleGetTransfers(w http.ResponseWriter, r *http.Request) {
+	pageReq, ok := parsePageRequest(w, r)
+	if !ok {
+		return
+	}
+	result, err := h.service.GetTransfers(pageReq)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve stock transfers", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", model.NewPaginatedResponse(result, pageReq))
+}
+
+// HandleTransfer handles POST /api/outlets/transfers.
+func (h *OutletHandler) HandleTransfer(w http.ResponseWriter, r *http.Request) {
+	var transfer model.StockTransfer
+	if !helper.ValidatePayload(w, r, &transfer) {
+		return
+	}
+
+	created, err := h.service.Transfer(&transfer)
+	if err != nil {
+		if errors.Is(err, model.ErrProductNotFound) || errors.Is(err, model.ErrOutletNotFound) ||
+			errors.Is(err, model.ErrInsufficientStock) || errors.Is(err, model.ErrInvalidQuantity) ||
+			errors.Is(err, model.ErrTransferSameOutlet) || errors.Is(err, model.ErrProductArchived) ||
+			errors.Is(err, model.ErrBundleStock) ||
+			errors.Is(err, model.ErrNotStocked) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to transfer stock", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusCreated, "Stock transferred successfully", created)
+}
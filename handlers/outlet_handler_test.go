@@ -0,0 +1,328 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	model "kasir-api/models"
+	"kasir-api/repositories/memory"
+	service "kasir-api/services"
+)
+
+func setupOutletHandler() (*OutletHandler, *memory.OutletRepository, *memory.ProductRepository) {
+	categoryRepo := memory.NewCategoryRepository()
+	productRepo := memory.NewProductRepository(categoryRepo)
+	outletRepo := memory.NewOutletRepository(productRepo)
+	handler := NewOutletHandler(service.NewOutletService(outletRepo, productRepo))
+	return handler, outletRepo, productRepo
+}
+
+func TestNewOutletHandler(t *testing.T) {
+	svc := &service.OutletService{}
+	handler := NewOutletHandler(svc)
+
+	if handler == nil {
+		t.Error("NewOutletHandler should return a non-nil handler")
+	}
+	if handler.service != svc {
+		t.Error("NewOutletHandler should set the service")
+	}
+}
+
+func TestOutletHandler_HandleGetAll_Paginated(t *testing.T) {
+	handler, outletRepo, _ := setupOutletHandler()
+	for _, name := range []string{"Cabang Pasar", "Cabang Terminal"} {
+		outletRepo.Create(&model.Outlet{Name: name})
+	}
+
+	type listResponse struct {
+		Data struct {
+			Items      []model.Outlet `json:"items"`
+			Page       int            `json:"page"`
+			TotalItems int            `json:"total_items"`
+			TotalPages int            `json:"total_pages"`
+			NextCursor string         `json:"next_cursor"`
+		} `json:"data"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/outlets?page=2&limit=2", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetAll(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleGetAll should return 200, got: %d", rr.Code)
+	}
+	var paged listResponse
+	json.NewDecoder(rr.Body).Decode(&paged)
+	if len(paged.Data.Items) != 1 || paged.Data.Items[0].Name != "Cabang Terminal" {
+		t.Errorf("Page 2 should contain only the last outlet, got: %+v", paged.Data.Items)
+	}
+	if paged.Data.Page != 2 || paged.Data.TotalItems != 3 || paged.Data.TotalPages != 2 {
+		t.Errorf("Pagination metadata mismatch, got: %+v", paged.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/outlets?limit=2", nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetAll(rr, req)
+	var first listResponse
+	json.NewDecoder(rr.Body).Decode(&first)
+	if len(first.Data.Items) != 2 || first.Data.NextCursor == "" {
+		t.Fatalf("First page should have 2 outlets and a next_cursor, got: %+v", first.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/outlets?limit=2&after="+first.Data.NextCursor, nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetAll(rr, req)
+	var second listResponse
+	json.NewDecoder(rr.Body).Decode(&second)
+	if len(second.Data.Items) != 1 || second.Data.Items[0].ID != 3 || second.Data.NextCursor != "" {
+		t.Errorf("Cursor page should hold the last outlet and no next_cursor, got: %+v", second.Data)
+	}
+}
+
+func TestOutletHandler_HandleGetAll_InvalidCursor(t *testing.T) {
+	handler, _, _ := setupOutletHandler()
+
+	// A cursor from the transfer list runs newest first, so the outlet list must refuse it.
+	for _, after := range []string{"rusak", (&model.Cursor{Desc: true, ID: 5}).Encode()} {
+		req := httptest.NewRequest(http.MethodGet, "/api/outlets?after="+after, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleGetAll(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("HandleGetAll with cursor %q should return 400, got: %d", after, rr.Code)
+		}
+	}
+}
+
+func TestOutletHandler_HandleGetByID(t *testing.T) {
+	handler, _, _ := setupOutletHandler()
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/outlets/1", http.StatusOK},
+		{"/api/outlets/999", http.StatusNotFound},
+		{"/api/outlets/0", http.StatusNotFound},
+		{"/api/outlets/abc", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleGetByID(rr, req)
+
+		if rr.Code != tt.want {
+			t.Errorf("HandleGetByID %s should return %d, got: %d", tt.path, tt.want, rr.Code)
+		}
+	}
+}
+
+func TestOutletHandler_HandleCreate(t *testing.T) {
+	handler, _, _ := setupOutletHandler()
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"valid", `{"name":"Cabang Pasar","address":"Jl. Pasar 1"}`, http.StatusCreated},
+		{"invalid JSON", `invalid`, http.StatusBadRequest},
+		{"missing name", `{"address":"Jl. Pasar 1"}`, http.StatusBadRequest},
+		{"blank name", `{"name":"   "}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/outlets", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rr := httptest.NewRecorder()
+			handler.HandleCreate(rr, req)
+
+			if rr.Code != tt.want {
+				t.Errorf("HandleCreate should return %d, got: %d", tt.want, rr.Code)
+			}
+		})
+	}
+}
+
+func TestOutletHandler_HandleGetStock(t *testing.T) {
+	handler, _, productRepo := setupOutletHandler()
+	for _, name := range []string{"Kopi", "Teh", "Gula"} {
+		productRepo.Create(&model.Product{Name: name, Price: 5000, Stock: 10})
+	}
+
+	type stockResponse struct {
+		Data struct {
+			Items      []model.OutletStock `json:"items"`
+			TotalItems int                 `json:"total_items"`
+			NextCursor string              `json:"next_cursor"`
+		} `json:"data"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/outlets/1/stock?limit=2", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetStock(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleGetStock should return 200, got: %d", rr.Code)
+	}
+	var first stockResponse
+	json.NewDecoder(rr.Body).Decode(&first)
+	if len(first.Data.Items) != 2 || first.Data.TotalItems != 3 || first.Data.NextCursor == "" {
+		t.Fatalf("First page should have 2 of 3 products and a next_cursor, got: %+v", first.Data)
+	}
+	if first.Data.Items[0].Stock != 10 {
+		t.Errorf("Default outlet should hold the product stock, got: %+v", first.Data.Items[0])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/outlets/1/stock?limit=2&after="+first.Data.NextCursor, nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetStock(rr, req)
+	var second stockResponse
+	json.NewDecoder(rr.Body).Decode(&second)
+	if len(second.Data.Items) != 1 || second.Data.Items[0].ProductID != 3 || second.Data.NextCursor != "" {
+		t.Errorf("Cursor page should hold the last product and no next_cursor, got: %+v", second.Data)
+	}
+}
+
+func TestOutletHandler_HandleGetStock_Errors(t *testing.T) {
+	handler, _, _ := setupOutletHandler()
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/api/outlets/999/stock", http.StatusNotFound},
+		{"/api/outlets/abc/stock", http.StatusNotFound},
+		{"/api/outlets/1/stock?after=rusak", http.StatusBadRequest},
+		{"/api/outlets/1/stock?after=" + (&model.Cursor{Desc: true, ID: 5}).Encode(), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleGetStock(rr, req)
+
+		if rr.Code != tt.want {
+			t.Errorf("HandleGetStock %s should return %d, got: %d", tt.path, tt.want, rr.Code)
+		}
+	}
+}
+
+func TestOutletHandler_HandleTransfer_Success(t *testing.T) {
+	handler, outletRepo, productRepo := setupOutletHandler()
+	productRepo.Create(&model.Product{Name: "Kopi", Price: 5000, Stock: 10})
+	outletRepo.Create(&model.Outlet{Name: "Cabang Pasar"})
+
+	body := `{"product_id":1,"from_outlet_id":1,"to_outlet_id":2,"quantity":4}`
+	req := httptest.NewRequest(http.MethodPost, "/api/outlets/transfers", bytes.NewBufferString(body))
+	rr := httptest.NewRecorder()
+	handler.HandleTransfer(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("HandleTransfer should return 201, got: %d", rr.Code)
+	}
+	var response struct {
+		Data model.StockTransfer `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data.Movements) != 2 || response.Data.Movements[0].Quantity != -4 || response.Data.Movements[1].Quantity != 4 {
+		t.Errorf("Transfer should return its paired movements, got: %+v", response.Data.Movements)
+	}
+
+	stock, _ := outletRepo.GetStock(2, model.PageRequest{Page: 1, Limit: 20})
+	if len(stock.Items) != 1 || stock.Items[0].Stock != 4 {
+		t.Errorf("Destination outlet should hold the transferred stock, got: %+v", stock.Items)
+	}
+}
+
+func TestOutletHandler_HandleTransfer_Errors(t *testing.T) {
+	handler, outletRepo, productRepo := setupOutletHandler()
+	productRepo.Create(&model.Product{Name: "Kopi", Price: 5000, Stock: 10})
+	productRepo.Create(&model.Product{Name: "Paket", Price: 9000, Components: []model.BundleComponent{{ProductID: 1, Quantity: 1}}})
+	productRepo.Create(&model.Product{Name: "Servis", Price: 20000, Type: model.ProductTypeService})
+	productRepo.Create(&model.Product{Name: "Lama", Price: 1000, Stock: 5})
+	productRepo.Archive(4, 0)
+	outletRepo.Create(&model.Outlet{Name: "Cabang Pasar"})
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid JSON", `invalid`},
+		{"zero quantity", `{"product_id":1,"from_outlet_id":1,"to_outlet_id":2,"quantity":0}`},
+		{"missing product", `{"from_outlet_id":1,"to_outlet_id":2,"quantity":1}`},
+		{"same outlet", `{"product_id":1,"from_outlet_id":1,"to_outlet_id":1,"quantity":1}`},
+		{"unknown product", `{"product_id":999,"from_outlet_id":1,"to_outlet_id":2,"quantity":1}`},
+		{"unknown outlet", `{"product_id":1,"from_outlet_id":1,"to_outlet_id":999,"quantity":1}`},
+		{"insufficient stock", `{"product_id":1,"from_outlet_id":1,"to_outlet_id":2,"quantity":11}`},
+		{"bundle", `{"product_id":2,"from_outlet_id":1,"to_outlet_id":2,"quantity":1}`},
+		{"not stocked", `{"product_id":3,"from_outlet_id":1,"to_outlet_id":2,"quantity":1}`},
+		{"archived", `{"product_id":4,"from_outlet_id":1,"to_outlet_id":2,"quantity":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/outlets/transfers", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+			handler.HandleTransfer(rr, req)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("HandleTransfer should return 400, got: %d", rr.Code)
+			}
+		})
+	}
+
+	transfers, _ := outletRepo.GetTransfers(model.PageRequest{Page: 1, Limit: 20})
+	if transfers.Total != 0 {
+		t.Errorf("Rejected transfers should not be recorded, got: %d", transfers.Total)
+	}
+}
+
+func TestOutletHandler_HandleGetTransfers(t *testing.T) {
+	handler, outletRepo, productRepo := setupOutletHandler()
+	productRepo.Create(&model.Product{Name: "Kopi", Price: 5000, Stock: 10})
+	outletRepo.Create(&model.Outlet{Name: "Cabang Pasar"})
+	for i := 0; i < 3; i++ {
+		outletRepo.Transfer(&model.StockTransfer{ProductID: 1, FromOutletID: 1, ToOutletID: 2, Quantity: 1})
+	}
+
+	type transferResponse struct {
+		Data struct {
+			Items      []model.StockTransfer `json:"items"`
+			TotalItems int                   `json:"total_items"`
+			NextCursor string                `json:"next_cursor"`
+		} `json:"data"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/outlets/transfers?limit=2", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetTransfers(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleGetTransfers should return 200, got: %d", rr.Code)
+	}
+	var first transferResponse
+	json.NewDecoder(rr.Body).Decode(&first)
+	if len(first.Data.Items) != 2 || first.Data.Items[0].ID != 3 || first.Data.TotalItems != 3 || first.Data.NextCursor == "" {
+		t.Fatalf("First page should hold the 2 newest of 3 transfers and a next_cursor, got: %+v", first.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/outlets/transfers?limit=2&after="+first.Data.NextCursor, nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetTransfers(rr, req)
+	var second transferResponse
+	json.NewDecoder(rr.Body).Decode(&second)
+	if len(second.Data.Items) != 1 || second.Data.Items[0].ID != 1 || second.Data.NextCursor != "" {
+		t.Errorf("Cursor page should hold the oldest transfer and no next_cursor, got: %+v", second.Data)
+	}
+
+	// The outlet list runs in ascending ID order, so its cursor does not fit the transfer list.
+	req = httptest.NewRequest(http.MethodGet, "/api/outlets/transfers?after="+(&model.Cursor{ID: 1}).Encode(), nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetTransfers(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("HandleGetTransfers with a foreign cursor should return 400, got: %d", rr.Code)
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"net/http"
+
+	helper "kasir-api/helpers"
+	model "kasir-api/models"
+)
+
+// parsePageRequest reads ?page=&limit= and the opaque ?after= cursor shared by the list endpoints.
+// Writes a 400 error and returns false when the cursor cannot be decoded.
+func parsePageRequest(w http.ResponseWriter, r *http.Request) (model.PageRequest, bool) {
+	page, limit := helper.ParsePagination(r, 20)
+	req := model.PageRequest{Page: page, Limit: limit}
+	if raw := r.URL.Query().Get("after"); raw != "" {
+		after, err := model.DecodeCursor(raw)
+		if err != nil {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return req, false
+		}
+		req.After = after
+	}
+	return req, true
+}
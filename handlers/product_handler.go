@@ -4,9 +4,16 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
 	helper "kasir-api/helpers"
+	"kasir-api/helpers/logger"
 	model "kasir-api/models"
 	service "kasir-api/services"
 )
@@ -24,43 +31,132 @@ func NewProductHandler(svc *service.ProductService) *ProductHandler {
 }
 
 // HandleGetAll handles GET /api/products.
-// Supports query parameters: ?name=searchTerm, ?page=1&limit=20.
+// Supports query parameters: ?name=searchTerm (fuzzy), ?category_id=1 (includes sub-categories),
+// ?min_price=&max_price=, ?in_stock=true, ?sort=name|price|stock|relevance&order=asc|desc,
+// ?include_archived=true, ?page=1&limit=20.
 func (h *ProductHandler) HandleGetAll(w http.ResponseWriter, r *http.Request) {
-	name := r.URL.Query().Get("name")
+	filter, ok := parseProductFilter(w, r)
+	if !ok {
+		return
+	}
 
-	products, err := h.service.GetAll(name)
+	pageReq, ok := parsePageRequest(w, r)
+	if !ok {
+		return
+	}
+	result, err := h.service.GetPage(filter, pageReq)
 	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
 		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve products", err)
 		return
 	}
 
-	page, limit := helper.ParsePagination(r, 20)
-	total := len(products)
+	helper.WriteSuccess(w, http.StatusOK, "Success", model.NewPaginatedResponse(result, pageReq))
+}
 
-	// Apply pagination
-	start := (page - 1) * limit
-	if start > total {
-		start = total
+// parseProductFilter reads the product list filters shared by HandleGetAll and HandleExport.
+// Writes a 400 error and returns false when a parameter is invalid.
+func parseProductFilter(w http.ResponseWriter, r *http.Request) (model.ProductFilter, bool) {
+	query := r.URL.Query()
+	filter := model.ProductFilter{
+		Name:            query.Get("name"),
+		IncludeArchived: helper.ParseBoolQuery(r, "include_archived"),
+		InStockOnly:     helper.ParseBoolQuery(r, "in_stock"),
+		Sort:            query.Get("sort"),
+	}
+	if raw := query.Get("category_id"); raw != "" {
+		categoryID, err := strconv.Atoi(raw)
+		if err != nil || categoryID <= 0 {
+			helper.WriteError(w, r, http.StatusBadRequest, "Invalid category_id", err)
+			return filter, false
+		}
+		filter.CategoryID = categoryID
+	}
+	prices := []struct {
+		key    string
+		target *int
+	}{{"min_price", &filter.MinPrice}, {"max_price", &filter.MaxPrice}}
+	for _, price := range prices {
+		raw := query.Get(price.key)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			helper.WriteError(w, r, http.StatusBadRequest, "Invalid "+price.key, err)
+			return filter, false
+		}
+		*price.target = value
+	}
+	if filter.MinPrice > 0 && filter.MaxPrice > 0 && filter.MinPrice > filter.MaxPrice {
+		helper.WriteError(w, r, http.StatusBadRequest, "min_price must not be greater than max_price", nil)
+		return filter, false
 	}
-	end := start + limit
-	if end > total {
-		end = total
+	if filter.Sort != "" && !slices.Contains(model.ProductSorts, filter.Sort) {
+		helper.WriteError(w, r, http.StatusBadRequest, fmt.Sprintf("sort must be one of %v", model.ProductSorts), nil)
+		return filter, false
 	}
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		helper.WriteError(w, r, http.StatusBadRequest, "order must be one of [asc desc]", nil)
+		return filter, false
+	}
+	return filter, true
+}
 
-	totalPages := (total + limit - 1) / limit
-	if totalPages == 0 {
-		totalPages = 1
+// HandleSearch handles GET /api/products/search?q=...&limit=10.
+// Typeahead for the cashier search box: active products only, best name matches first.
+func (h *ProductHandler) HandleSearch(w http.ResponseWriter, r *http.Request) {
+	limit := 10
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		l, err := strconv.Atoi(raw)
+		if err != nil || l <= 0 {
+			helper.WriteError(w, r, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		limit = min(l, 20)
 	}
 
-	paged := &model.PaginatedResponse{
-		Items:      products[start:end],
-		Page:       page,
-		Limit:      limit,
-		TotalItems: total,
-		TotalPages: totalPages,
+	suggestions, err := h.service.Suggest(r.URL.Query().Get("q"), limit)
+	if err != nil {
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to search products", err)
+		return
 	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", suggestions)
+}
 
-	helper.WriteSuccess(w, http.StatusOK, "Success", paged)
+// HandleScan handles GET /api/products/scan?barcode=, resolving a scale label to its product
+// and the quantity and subtotal it stands for.
+func (h *ProductHandler) HandleScan(w http.ResponseWriter, r *http.Request) {
+	scan, err := h.service.ScanBarcode(r.URL.Query().Get("barcode"))
+	if err != nil {
+		if errors.Is(err, model.ErrProductNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		if isScaleLabelError(err) || errors.Is(err, model.ErrInvalidQuantity) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to scan barcode", err)
+		return
+	}
+	helper.WriteSuccess(w, http.StatusOK, "Success", scan)
+}
+
+// isScaleLabelError reports whether err rejects a scale label: a malformed code, a wrong check
+// digit, an unknown prefix or a label that does not fit the product's unit.
+func isScaleLabelError(err error) bool {
+	return errors.Is(err, model.ErrBarcodeInvalid) ||
+		errors.Is(err, model.ErrBarcodeCheckDigit) ||
+		errors.Is(err, model.ErrBarcodeNotScale) ||
+		errors.Is(err, model.ErrScaleLabelUnit)
 }
 
 // HandleGetByID handles GET /api/products/{id}.
@@ -79,6 +175,7 @@ func (h *ProductHandler) HandleGetByID(w http.ResponseWriter, r *http.Request) {
 		helper.WriteError(w, r, http.StatusBadRequest, "Invalid request", err)
 		return
 	}
+	helper.SetETag(w, product.Version)
 	helper.WriteSuccess(w, http.StatusOK, "Success", product)
 }
 
@@ -93,10 +190,22 @@ func (h *ProductHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
 		Name:       input.Name,
 		Price:      input.Price,
 		Stock:      input.Stock,
+		Unit:       input.Unit,
+		Type:       input.Type,
+		SKU:        input.SKU,
+		Barcode:    input.Barcode,
+		PLU:        input.PLU,
 		CategoryID: input.CategoryID,
+		Components: input.Components,
+
+		AllowNegativeStock: input.AllowNegativeStock,
 	}
 	createdProduct, err := h.service.Create(product)
 	if err != nil {
+		if errors.Is(err, model.ErrSKUExists) || errors.Is(err, model.ErrPLUExists) {
+			helper.WriteError(w, r, http.StatusConflict, err.Error(), err)
+			return
+		}
 		if errors.Is(err, model.ErrCategoryNotFound) {
 			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
 			return
@@ -105,26 +214,82 @@ func (h *ProductHandler) HandleCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	helper.SetETag(w, createdProduct.Version)
 	helper.WriteSuccess(w, http.StatusCreated, "Product created successfully", createdProduct)
 }
 
 // HandleUpdate handles PUT /api/products/{id}.
+// With If-Match the update only succeeds while the product still has that ETag (412 otherwise).
 func (h *ProductHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 	id, ok := helper.ParseIDFromPath(w, r, "/api/products/", model.ErrProductNotFound)
 	if !ok {
 		return
 	}
+	version, ok := helper.ParseIfMatch(w, r)
+	if !ok {
+		return
+	}
 
 	var input model.ProductInput
 	if !helper.ValidatePayload(w, r, &input) {
 		return
 	}
+	h.update(w, r, id, &input, version)
+}
+
+// HandlePatch handles PATCH /api/products/{id} with an RFC 7396 JSON merge patch.
+// Only the members sent change; null clears a field (e.g. "category_id": null). The merged
+// product is validated like a full update. The patch is applied to the version that was read,
+// so a concurrent change fails with 409, or 412 when the client sent If-Match.
+func (h *ProductHandler) HandlePatch(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseIDFromPath(w, r, "/api/products/", model.ErrProductNotFound)
+	if !ok {
+		return
+	}
+	version, ok := helper.ParseIfMatch(w, r)
+	if !ok {
+		return
+	}
 
+	current, err := h.service.GetByID(id)
+	if err != nil {
+		if errors.Is(err, model.ErrProductNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve product", err)
+		return
+	}
+	if version != 0 && version != current.Version {
+		helper.WriteVersionMismatch(w, r, model.ErrVersionMismatch)
+		return
+	}
+
+	var input model.ProductInput
+	if !helper.ApplyMergePatch(w, r, model.NewProductInput(current), &input) {
+		return
+	}
+	h.update(w, r, id, &input, current.Version)
+}
+
+// update saves input over product id and writes the response shared by PUT and PATCH.
+// A non-zero version must still be current when the product is written.
+func (h *ProductHandler) update(w http.ResponseWriter, r *http.Request, id int, input *model.ProductInput, version int) {
 	product := &model.Product{
 		Name:       input.Name,
 		Price:      input.Price,
 		Stock:      input.Stock,
+		Unit:       input.Unit,
+		Type:       input.Type,
+		SKU:        input.SKU,
+		Barcode:    input.Barcode,
+		PLU:        input.PLU,
 		CategoryID: input.CategoryID,
+		Components: input.Components,
+		Version:    version,
+		ChangedBy:  helper.ActorFromRequest(r),
+
+		AllowNegativeStock: input.AllowNegativeStock,
 	}
 	updatedProduct, err := h.service.Update(id, product)
 	if err != nil {
@@ -132,6 +297,14 @@ func (h *ProductHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
 			return
 		}
+		if errors.Is(err, model.ErrVersionMismatch) {
+			helper.WriteVersionMismatch(w, r, err)
+			return
+		}
+		if errors.Is(err, model.ErrSKUExists) || errors.Is(err, model.ErrPLUExists) {
+			helper.WriteError(w, r, http.StatusConflict, err.Error(), err)
+			return
+		}
 		if errors.Is(err, model.ErrCategoryNotFound) {
 			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
 			return
@@ -140,25 +313,381 @@ func (h *ProductHandler) HandleUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	helper.SetETag(w, updatedProduct.Version)
 	helper.WriteSuccess(w, http.StatusOK, "Product updated successfully", updatedProduct)
 }
 
 // HandleDelete handles DELETE /api/products/{id}.
+// Products are archived, not removed, so transaction history can still resolve them.
+// With If-Match the product is only archived while it still has that ETag (412 otherwise).
 func (h *ProductHandler) HandleDelete(w http.ResponseWriter, r *http.Request) {
 	id, ok := helper.ParseIDFromPath(w, r, "/api/products/", model.ErrProductNotFound)
 	if !ok {
 		return
 	}
+	version, ok := helper.ParseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.service.Archive(id, version)
+	if err != nil {
+		if errors.Is(err, model.ErrProductNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrVersionMismatch) {
+			helper.WriteVersionMismatch(w, r, err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusBadRequest, "Failed to archive product", err)
+		return
+	}
+
+	helper.WriteSuccess(w, http.StatusOK, "Product archived successfully", nil)
+}
+
+// HandleRestore handles POST /api/products/{id}/restore.
+func (h *ProductHandler) HandleRestore(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/products/", 0, model.ErrProductNotFound)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Restore(id); err != nil {
+		if errors.Is(err, model.ErrProductNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusBadRequest, "Failed to restore product", err)
+		return
+	}
+
+	product, err := h.service.GetByID(id)
+	if err != nil {
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve product", err)
+		return
+	}
+	helper.SetETag(w, product.Version)
+	helper.WriteSuccess(w, http.StatusOK, "Product restored successfully", product)
+}
+
+// HandleGetPriceChanges handles GET /api/products/{id}/prices.
+// Supports query parameters ?status=upcoming|past and ?page=&limit= or ?after=.
+func (h *ProductHandler) HandleGetPriceChanges(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/products/", 0, model.ErrProductNotFound)
+	if !ok {
+		return
+	}
 
-	err := h.service.Delete(id)
+	status := r.URL.Query().Get("status")
+	if status != "" && status != model.PriceChangeUpcoming && status != model.PriceChangePast {
+		helper.WriteError(w, r, http.StatusBadRequest, "status must be one of [upcoming past]", nil)
+		return
+	}
+
+	pageReq, ok := parsePageRequest(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.GetPriceChanges(id, status, pageReq)
 	if err != nil {
 		if errors.Is(err, model.ErrProductNotFound) {
 			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
 			return
 		}
-		helper.WriteError(w, r, http.StatusBadRequest, "Failed to delete product", err)
+		if errors.Is(err, model.ErrInvalidCursor) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve price history", err)
 		return
 	}
 
-	helper.WriteSuccess(w, http.StatusOK, "Product deleted successfully", nil)
+	helper.WriteSuccess(w, http.StatusOK, "Success", model.NewPaginatedResponse(result, pageReq))
+}
+
+// HandleSchedulePriceChange handles POST /api/products/{id}/prices.
+func (h *ProductHandler) HandleSchedulePriceChange(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/products/", 0, model.ErrProductNotFound)
+	if !ok {
+		return
+	}
+
+	var input model.PriceChangeInput
+	if !helper.ValidatePayload(w, r, &input) {
+		return
+	}
+
+	change := &model.PriceChange{
+		NewPrice:    input.Price,
+		EffectiveAt: input.EffectiveAt,
+		ChangedBy:   helper.ActorFromRequest(r),
+	}
+	scheduled, err := h.service.SchedulePriceChange(id, change)
+	if err != nil {
+		if errors.Is(err, model.ErrProductNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+		return
+	}
+
+	helper.WriteSuccess(w, http.StatusCreated, "Price change scheduled successfully", scheduled)
+}
+
+// HandleCancelPriceChange handles DELETE /api/products/{id}/prices/{changeID}.
+func (h *ProductHandler) HandleCancelPriceChange(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/products/", 0, model.ErrProductNotFound)
+	if !ok {
+		return
+	}
+	changeID, ok := helper.ParseNestedIDFromPath(w, r, "/api/products/", 2, model.ErrPriceChangeNotFound)
+	if !ok {
+		return
+	}
+
+	err := h.service.CancelPriceChange(id, changeID)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrPriceChangeApplied) {
+			helper.WriteError(w, r, http.StatusConflict, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to cancel price change", err)
+		return
+	}
+
+	helper.WriteSuccess(w, http.StatusOK, "Price change cancelled successfully", nil)
+}
+
+// HandleImport handles POST /api/products/import.
+// Expects a multipart form with the file in field "file". The format comes from ?format=csv|xlsx
+// or the file extension. Supports ?dry_run=true and ?create_categories=true.
+// Invalid rows reject the whole import with 422 and the per-row errors in data.
+func (h *ProductHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		helper.WriteError(w, r, http.StatusBadRequest, "Missing import file in form field \"file\"", err)
+		return
+	}
+	defer file.Close() //nolint:errcheck // multipart file close only releases temp storage
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+	}
+	opts := model.ImportOptions{
+		DryRun:           helper.ParseBoolQuery(r, "dry_run"),
+		CreateCategories: helper.ParseBoolQuery(r, "create_categories"),
+		ChangedBy:        helper.ActorFromRequest(r),
+	}
+
+	result, err := h.service.Import(file, format, opts)
+	if err != nil {
+		if errors.Is(err, model.ErrImportInvalid) {
+			if opts.DryRun {
+				helper.WriteSuccess(w, http.StatusOK, err.Error(), result)
+				return
+			}
+			helper.WriteJSON(w, http.StatusUnprocessableEntity, helper.Response{
+				Status:  "ERROR",
+				Message: err.Error(),
+				Data:    result,
+			})
+			return
+		}
+		if errors.Is(err, model.ErrImportFormat) || errors.Is(err, model.ErrImportHeader) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrSKUExists) {
+			helper.WriteError(w, r, http.StatusConflict, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to import products", err)
+		return
+	}
+
+	message := "Products imported successfully"
+	if opts.DryRun {
+		message = "Dry run completed, nothing was imported"
+	}
+	helper.WriteSuccess(w, http.StatusOK, message, result)
+}
+
+// HandleBulk handles POST /api/products/bulk.
+// Applies one operation to the products given by ids or a filter, all-or-nothing. Supports
+// ?dry_run=true to preview the changes. Invalid products reject the whole operation with 422 and
+// the per-product errors in data; a product changed concurrently rejects it with 409, as does a
+// filter matching another number of products than expected_matched, with the preview in data.
+func (h *ProductHandler) HandleBulk(w http.ResponseWriter, r *http.Request) {
+	var req model.ProductBulkRequest
+	if !helper.ValidatePayload(w, r, &req) {
+		return
+	}
+	req.DryRun = helper.ParseBoolQuery(r, "dry_run")
+	req.ChangedBy = helper.ActorFromRequest(r)
+
+	result, err := h.service.Bulk(&req)
+	if err != nil {
+		if errors.Is(err, model.ErrBulkInvalid) {
+			if req.DryRun {
+				helper.WriteSuccess(w, http.StatusOK, err.Error(), result)
+				return
+			}
+			helper.WriteJSON(w, http.StatusUnprocessableEntity, helper.Response{
+				Status:  "ERROR",
+				Message: err.Error(),
+				Data:    result,
+			})
+			return
+		}
+		if errors.Is(err, model.ErrBulkMatched) {
+			helper.WriteJSON(w, http.StatusConflict, helper.Response{
+				Status:  "ERROR",
+				Message: err.Error(),
+				Data:    result,
+			})
+			return
+		}
+		if errors.Is(err, model.ErrVersionMismatch) {
+			helper.WriteError(w, r, http.StatusConflict, err.Error(), err)
+			return
+		}
+		if errors.Is(err, model.ErrBulkOperation) ||
+			errors.Is(err, model.ErrBulkTarget) ||
+			errors.Is(err, model.ErrBulkValue) ||
+			errors.Is(err, model.ErrPriceInvalid) ||
+			errors.Is(err, model.ErrCategoryNotFound) ||
+			errors.Is(err, model.ErrCategoryArchived) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to apply bulk operation", err)
+		return
+	}
+
+	message := "Bulk operation applied successfully"
+	if req.DryRun {
+		message = "Dry run completed, nothing was changed"
+	}
+	helper.WriteSuccess(w, http.StatusOK, message, result)
+}
+
+// exportContentTypes maps the supported export formats to their response content type.
+var exportContentTypes = map[string]string{
+	model.ImportFormatCSV:    "text/csv; charset=utf-8",
+	model.ImportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	model.ExportFormatNDJSON: "application/x-ndjson",
+}
+
+// HandleExport handles GET /api/products/export?format=csv|xlsx|ndjson.
+// Accepts the same filters as HandleGetAll. The file is streamed, so a failure after the
+// first bytes are sent can only be logged, not reported with an error status.
+func (h *ProductHandler) HandleExport(w http.ResponseWriter, r *http.Request) {
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = model.ImportFormatCSV
+	}
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		helper.WriteError(w, r, http.StatusBadRequest, model.ErrExportFormat.Error(), nil)
+		return
+	}
+	filter, ok := parseProductFilter(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, format))
+	out := &writeTracker{w: w}
+	if err := h.service.Export(out, format, filter); err != nil {
+		if !out.written {
+			w.Header().Del("Content-Disposition")
+			helper.WriteError(w, r, http.StatusInternalServerError, "Failed to export products", err)
+			return
+		}
+		logger.Error("%s %s - export failed after streaming started: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+// writeTracker records whether anything has been written through it.
+type writeTracker struct {
+	w       io.Writer
+	written bool
+}
+
+func (t *writeTracker) Write(p []byte) (int, error) {
+	t.written = true
+	return t.w.Write(p)
+}
+
+// HandleUploadImage handles POST /api/products/{id}/images.
+// Expects a multipart form with a JPEG or PNG file in field "image"; a thumbnail is generated server-side.
+func (h *ProductHandler) HandleUploadImage(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/products/", 0, model.ErrProductNotFound)
+	if !ok {
+		return
+	}
+
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helper.WriteError(w, r, http.StatusRequestEntityTooLarge, model.ErrImageTooLarge.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusBadRequest, "Missing image in form field \"image\"", err)
+		return
+	}
+	defer file.Close() //nolint:errcheck // multipart file close only releases temp storage
+
+	product, err := h.service.UploadImage(id, file)
+	if err != nil {
+		writeImageError(w, r, err, "Failed to upload image")
+		return
+	}
+	helper.SetETag(w, product.Version)
+	helper.WriteSuccess(w, http.StatusOK, "Image uploaded successfully", product)
+}
+
+// HandleDeleteImage handles DELETE /api/products/{id}/images.
+func (h *ProductHandler) HandleDeleteImage(w http.ResponseWriter, r *http.Request) {
+	id, ok := helper.ParseNestedIDFromPath(w, r, "/api/products/", 0, model.ErrProductNotFound)
+	if !ok {
+		return
+	}
+
+	product, err := h.service.DeleteImage(id)
+	if err != nil {
+		writeImageError(w, r, err, "Failed to delete image")
+		return
+	}
+	helper.SetETag(w, product.Version)
+	helper.WriteSuccess(w, http.StatusOK, "Image deleted successfully", product)
+}
+
+// writeImageError maps product image errors to HTTP status codes.
+func writeImageError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
+	case errors.Is(err, model.ErrImageTooLarge):
+		helper.WriteError(w, r, http.StatusRequestEntityTooLarge, err.Error(), err)
+	case errors.Is(err, model.ErrImageType):
+		helper.WriteError(w, r, http.StatusUnsupportedMediaType, err.Error(), err)
+	case errors.Is(err, model.ErrImageDimensions):
+		helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+	case errors.Is(err, model.ErrImageStoreDisabled):
+		helper.WriteError(w, r, http.StatusServiceUnavailable, err.Error(), err)
+	default:
+		helper.WriteError(w, r, http.StatusInternalServerError, fallback, err)
+	}
 }
@@ -3,13 +3,21 @@ package handler
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
 
 	model "kasir-api/models"
 	"kasir-api/repositories/memory"
 	service "kasir-api/services"
+	"kasir-api/storage"
 )
 
 func setupProductHandler() (*ProductHandler, *memory.ProductRepository, *memory.CategoryRepository) {
@@ -20,6 +28,12 @@ func setupProductHandler() (*ProductHandler, *memory.ProductRepository, *memory.
 	return handler, productRepo, categoryRepo
 }
 
+// priceHistory returns the whole price history of a product.
+func priceHistory(repo *memory.ProductRepository, productID int) []*model.PriceChange {
+	page, _ := repo.GetPriceChanges(productID, model.PriceChangeQuery{}, model.PageRequest{Page: 1, Limit: 100})
+	return page.Items
+}
+
 func TestNewProductHandler(t *testing.T) {
 	svc := &service.ProductService{}
 	handler := NewProductHandler(svc)
@@ -97,6 +111,48 @@ func TestProductHandler_HandleGetAll_WithFilter(t *testing.T) {
 	}
 }
 
+func TestProductHandler_HandleGetAll_CategoryFilter(t *testing.T) {
+	handler, productRepo, categoryRepo := setupProductHandler()
+
+	minuman := &model.Category{Name: "Minuman"}
+	categoryRepo.Create(minuman)
+	soda := &model.Category{Name: "Soda", ParentID: &minuman.ID}
+	categoryRepo.Create(soda)
+	rokok := &model.Category{Name: "Rokok"}
+	categoryRepo.Create(rokok)
+
+	productRepo.Create(&model.Product{Name: "Teh Botol", Price: 5000, Stock: 10, CategoryID: &minuman.ID})
+	productRepo.Create(&model.Product{Name: "Coca Cola", Price: 7000, Stock: 10, CategoryID: &soda.ID})
+	productRepo.Create(&model.Product{Name: "Sampoerna", Price: 30000, Stock: 10, CategoryID: &rokok.ID})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products?category_id=1", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleGetAll(rr, req)
+
+	var response map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&response)
+	data := response["data"].(map[string]interface{})
+	if data["total_items"].(float64) != 2 {
+		t.Errorf("category_id filter should include sub-categories, got: %v", data["total_items"])
+	}
+}
+
+func TestProductHandler_HandleGetAll_InvalidCategoryID(t *testing.T) {
+	handler, _, _ := setupProductHandler()
+
+	for _, raw := range []string{"abc", "0", "-1"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/products?category_id="+raw, nil)
+		rr := httptest.NewRecorder()
+
+		handler.HandleGetAll(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("category_id=%s should return 400, got: %d", raw, rr.Code)
+		}
+	}
+}
+
 func TestProductHandler_HandleGetAll_Empty(t *testing.T) {
 	handler, _, _ := setupProductHandler()
 
@@ -317,6 +373,24 @@ func TestProductHandler_HandleUpdate_Success(t *testing.T) {
 	}
 }
 
+func TestProductHandler_HandleUpdate_RecordsActor(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+
+	productRepo.Create(&model.Product{Name: "Laptop", Price: 1000, Stock: 10})
+
+	body, _ := json.Marshal(model.ProductInput{Name: "Laptop", Price: 1200, Stock: 10})
+	req := httptest.NewRequest(http.MethodPut, "/api/products/1", bytes.NewBuffer(body))
+	req.Header.Set("X-User", "budi")
+	rr := httptest.NewRecorder()
+
+	handler.HandleUpdate(rr, req)
+
+	changes := priceHistory(productRepo, 1)
+	if len(changes) != 1 || changes[0].ChangedBy != "budi" {
+		t.Errorf("HandleUpdate should record price change by X-User, got: %v", changes)
+	}
+}
+
 func TestProductHandler_HandleUpdate_InvalidID(t *testing.T) {
 	handler, _, _ := setupProductHandler()
 
@@ -429,6 +503,89 @@ func TestProductHandler_HandleDelete_Success(t *testing.T) {
 	}
 }
 
+func TestProductHandler_HandleGetAll_IncludeArchived(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+
+	productRepo.Create(&model.Product{Name: "Laptop", Price: 1000, Stock: 10})
+	productRepo.Create(&model.Product{Name: "Phone", Price: 500, Stock: 20})
+	productRepo.Archive(2, 0)
+
+	testCases := []struct {
+		url      string
+		expected int
+	}{
+		{"/api/products", 1},
+		{"/api/products?include_archived=false", 1},
+		{"/api/products?include_archived=true", 2},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+		rr := httptest.NewRecorder()
+
+		handler.HandleGetAll(rr, req)
+
+		var response map[string]interface{}
+		json.NewDecoder(rr.Body).Decode(&response)
+		data := response["data"].(map[string]interface{})
+		if data["total_items"].(float64) != float64(tc.expected) {
+			t.Errorf("%s should return %d products, got: %v", tc.url, tc.expected, data["total_items"])
+		}
+	}
+}
+
+func TestProductHandler_HandleDelete_ArchivesProduct(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+
+	productRepo.Create(&model.Product{Name: "Laptop", Price: 1000, Stock: 10})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/products/1", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleDelete(rr, req)
+
+	product, err := productRepo.GetByID(1)
+	if err != nil {
+		t.Fatalf("Archived product should still be resolvable, got: %v", err)
+	}
+	if !product.IsArchived() {
+		t.Error("HandleDelete should archive the product")
+	}
+}
+
+func TestProductHandler_HandleRestore_Success(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+
+	productRepo.Create(&model.Product{Name: "Laptop", Price: 1000, Stock: 10})
+	productRepo.Archive(1, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/products/1/restore", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleRestore(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("HandleRestore should return 200, got: %d", rr.Code)
+	}
+	product, _ := productRepo.GetByID(1)
+	if product.IsArchived() {
+		t.Error("HandleRestore should clear archived_at")
+	}
+}
+
+func TestProductHandler_HandleRestore_NotFound(t *testing.T) {
+	handler, _, _ := setupProductHandler()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/products/999/restore", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleRestore(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("HandleRestore with non-existent ID should return 404, got: %d", rr.Code)
+	}
+}
+
 func TestProductHandler_HandleDelete_InvalidID(t *testing.T) {
 	handler, _, _ := setupProductHandler()
 
@@ -1068,9 +1225,769 @@ func TestProductHandler_QueryParameterInjection(t *testing.T) {
 }
 
 // Helper function for min
+func TestProductHandler_HandleSchedulePriceChange_Success(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+
+	productRepo.Create(&model.Product{Name: "Rokok", Price: 28000, Stock: 10})
+
+	input := model.PriceChangeInput{Price: 30000, EffectiveAt: time.Now().Add(24 * time.Hour)}
+	body, _ := json.Marshal(input)
+	req := httptest.NewRequest(http.MethodPost, "/api/products/1/prices", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	handler.HandleSchedulePriceChange(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("HandleSchedulePriceChange should return 201, got: %d", rr.Code)
+	}
+	product, _ := productRepo.GetByID(1)
+	if product.Price != 28000 {
+		t.Errorf("Scheduled price should not apply yet, got: %d", product.Price)
+	}
+}
+
+func TestProductHandler_HandleSchedulePriceChange_PastEffectiveAt(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+
+	productRepo.Create(&model.Product{Name: "Rokok", Price: 28000, Stock: 10})
+
+	input := model.PriceChangeInput{Price: 30000, EffectiveAt: time.Now().Add(-time.Hour)}
+	body, _ := json.Marshal(input)
+	req := httptest.NewRequest(http.MethodPost, "/api/products/1/prices", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	handler.HandleSchedulePriceChange(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("HandleSchedulePriceChange with past effective_at should return 400, got: %d", rr.Code)
+	}
+}
+
+func TestProductHandler_HandleGetPriceChanges(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+
+	productRepo.Create(&model.Product{Name: "Rokok", Price: 28000, Stock: 10})
+	productRepo.CreatePriceChange(&model.PriceChange{ProductID: 1, NewPrice: 29000, EffectiveAt: time.Now().Add(-time.Minute)})
+	productRepo.CreatePriceChange(&model.PriceChange{ProductID: 1, NewPrice: 30000, EffectiveAt: time.Now().Add(time.Hour)})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/1/prices?status=upcoming", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleGetPriceChanges(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleGetPriceChanges should return 200, got: %d", rr.Code)
+	}
+	type priceResponse struct {
+		Data struct {
+			Items      []model.PriceChange `json:"items"`
+			NextCursor string              `json:"next_cursor"`
+		} `json:"data"`
+	}
+	var response priceResponse
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data.Items) != 1 || response.Data.Items[0].NewPrice != 30000 {
+		t.Errorf("Should return only the upcoming change, got: %v", response.Data.Items)
+	}
+
+	// The full history pages newest first and follows the cursor to the older change.
+	req = httptest.NewRequest(http.MethodGet, "/api/products/1/prices?limit=1", nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetPriceChanges(rr, req)
+	var first priceResponse
+	json.NewDecoder(rr.Body).Decode(&first)
+	if len(first.Data.Items) != 1 || first.Data.Items[0].NewPrice != 30000 || first.Data.NextCursor == "" {
+		t.Fatalf("First page should hold the newest change and a next cursor, got: %+v", first.Data)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/api/products/1/prices?limit=1&after="+first.Data.NextCursor, nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetPriceChanges(rr, req)
+	var second priceResponse
+	json.NewDecoder(rr.Body).Decode(&second)
+	if len(second.Data.Items) != 1 || second.Data.Items[0].NewPrice != 29000 || second.Data.NextCursor != "" {
+		t.Errorf("Second page should hold the older change and end the list, got: %+v", second.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/products/1/prices?after=rusak", nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetPriceChanges(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("HandleGetPriceChanges with an invalid cursor should return 400, got: %d", rr.Code)
+	}
+
+	// The due change shows on reads right away but is only written by the scheduler.
+	req = httptest.NewRequest(http.MethodGet, "/api/products/1", nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetByID(rr, req)
+	var productResponse struct {
+		Data model.Product `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&productResponse)
+	if productResponse.Data.Price != 29000 || productResponse.Data.Version != 1 {
+		t.Errorf("Read just after effective_at should return the new price unwritten, got: %+v", productResponse.Data)
+	}
+	if changes := priceHistory(productRepo, 1); changes[1].IsApplied() {
+		t.Errorf("Reads should not apply the due change, got: %+v", changes[1])
+	}
+	if err := handler.service.ApplyDuePriceChanges(); err != nil {
+		t.Fatalf("ApplyDuePriceChanges should not return error, got: %v", err)
+	}
+	if product, _ := productRepo.GetByID(1); product.Price != 29000 || product.Version != 2 {
+		t.Errorf("The scheduler should apply the due change, got: %+v", product)
+	}
+}
+
+func TestProductHandler_HandleGetPriceChanges_InvalidStatus(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+	productRepo.Create(&model.Product{Name: "Rokok", Price: 28000, Stock: 10})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/1/prices?status=soon", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleGetPriceChanges(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("HandleGetPriceChanges with invalid status should return 400, got: %d", rr.Code)
+	}
+}
+
+func TestProductHandler_HandleCancelPriceChange(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+
+	productRepo.Create(&model.Product{Name: "Rokok", Price: 28000, Stock: 10})
+	productRepo.CreatePriceChange(&model.PriceChange{ProductID: 1, NewPrice: 30000, EffectiveAt: time.Now().Add(time.Hour)})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/products/1/prices/1", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleCancelPriceChange(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("HandleCancelPriceChange should return 200, got: %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.HandleCancelPriceChange(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Cancelling twice should return 404, got: %d", rr.Code)
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
 	}
 	return b
 }
+
+// newImportRequest builds a multipart POST with content in the "file" field.
+func newImportRequest(t *testing.T, url, filename string, content []byte) *http.Request {
+	return newMultipartRequest(t, url, "file", filename, content)
+}
+
+// newMultipartRequest builds a multipart POST with content in the given file field.
+func newMultipartRequest(t *testing.T, url, field, filename string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	part.Write(content)
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, url, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestProductHandler_HandleImport_CSV(t *testing.T) {
+	handler, productRepo, categoryRepo := setupProductHandler()
+	categoryRepo.Create(&model.Category{Name: "Makanan"})
+	productRepo.Create(&model.Product{Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01"})
+
+	file := "name,price,stock,category,sku,barcode\nIndomie,4000,30,Makanan,IDM-01,\nAir Mineral,3000,24,Minuman,AM-01,\n"
+	req := newImportRequest(t, "/api/products/import?create_categories=true", "produk.csv", []byte(file))
+	rr := httptest.NewRecorder()
+
+	handler.HandleImport(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleImport should return 200, got: %d (%s)", rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Data model.ImportResult `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if response.Data.Created != 1 || response.Data.Updated != 1 {
+		t.Errorf("Import should create 1 and update 1, got: %+v", response.Data)
+	}
+	product, _ := productRepo.GetBySKU("AM-01")
+	if product == nil || product.Category == nil || product.Category.Name != "Minuman" {
+		t.Errorf("AM-01 should be created in the new category, got: %+v", product)
+	}
+}
+
+func TestProductHandler_HandleImport_XLSX(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	f.SetSheetRow(sheet, "A1", &[]any{"name", "price", "stock", "category", "sku", "barcode"})
+	f.SetSheetRow(sheet, "A2", &[]any{"Kopi Kapal Api", 2000, 50, "", "KKA-01", "8991002101234"})
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatalf("write workbook: %v", err)
+	}
+
+	req := newImportRequest(t, "/api/products/import", "produk.xlsx", buf.Bytes())
+	rr := httptest.NewRecorder()
+
+	handler.HandleImport(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleImport should return 200, got: %d (%s)", rr.Code, rr.Body.String())
+	}
+	product, err := productRepo.GetBySKU("KKA-01")
+	if err != nil || product.Price != 2000 || product.Barcode != "8991002101234" {
+		t.Errorf("XLSX row should be imported, got: %+v, %v", product, err)
+	}
+}
+
+func TestProductHandler_HandleImport_InvalidRows(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+
+	file := "name,price,stock\nKopi,5000,10\nTeh,0,10\n"
+
+	req := newImportRequest(t, "/api/products/import?dry_run=true", "produk.csv", []byte(file))
+	rr := httptest.NewRecorder()
+	handler.HandleImport(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("Dry run with invalid rows should return 200, got: %d", rr.Code)
+	}
+
+	req = newImportRequest(t, "/api/products/import", "produk.csv", []byte(file))
+	rr = httptest.NewRecorder()
+	handler.HandleImport(rr, req)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Import with invalid rows should return 422, got: %d", rr.Code)
+	}
+	var response struct {
+		Data model.ImportResult `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data.Errors) != 1 || response.Data.Errors[0].Row != 3 {
+		t.Errorf("Should report row 3 as invalid, got: %+v", response.Data.Errors)
+	}
+	products, _ := productRepo.GetAll(model.ProductFilter{})
+	if len(products) != 0 {
+		t.Errorf("Nothing should be imported, got: %d products", len(products))
+	}
+}
+
+func TestProductHandler_HandleImport_BadRequest(t *testing.T) {
+	handler, _, _ := setupProductHandler()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/products/import", bytes.NewBufferString("name,price,stock"))
+	rr := httptest.NewRecorder()
+	handler.HandleImport(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Import without multipart file should return 400, got: %d", rr.Code)
+	}
+
+	req = newImportRequest(t, "/api/products/import", "produk.txt", []byte("name,price,stock\n"))
+	rr = httptest.NewRecorder()
+	handler.HandleImport(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Import with unknown format should return 400, got: %d", rr.Code)
+	}
+}
+
+func TestProductHandler_HandleCreate_DuplicateSKU(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+	productRepo.Create(&model.Product{Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01"})
+
+	body, _ := json.Marshal(model.ProductInput{Name: "Mie Sedaap", Price: 3000, Stock: 5, SKU: "IDM-01"})
+	req := httptest.NewRequest(http.MethodPost, "/api/products", bytes.NewBuffer(body))
+	rr := httptest.NewRecorder()
+
+	handler.HandleCreate(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Errorf("HandleCreate with a used SKU should return 409, got: %d", rr.Code)
+	}
+}
+
+func TestProductHandler_HandleExport_CSV(t *testing.T) {
+	handler, productRepo, categoryRepo := setupProductHandler()
+	categoryRepo.Create(&model.Category{Name: "Makanan"})
+	categoryID := 1
+	productRepo.Create(&model.Product{Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01", CategoryID: &categoryID})
+	productRepo.Create(&model.Product{Name: "Sabun", Price: 5000, Stock: 3})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/export?format=csv&category_id=1", nil)
+	rr := httptest.NewRecorder()
+
+	handler.HandleExport(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleExport should return 200, got: %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type should be CSV, got: %s", ct)
+	}
+	want := "name,price,stock,category,sku,barcode\nIndomie,3500,10,Makanan,IDM-01,\n"
+	if rr.Body.String() != want {
+		t.Errorf("Export should honour the category filter, got: %q", rr.Body.String())
+	}
+}
+
+func TestProductHandler_HandleExport_XLSXRoundTrip(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+	productRepo.Create(&model.Product{Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01"})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/export?format=xlsx", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleExport(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleExport should return 200, got: %d", rr.Code)
+	}
+
+	importReq := newImportRequest(t, "/api/products/import?dry_run=true", "products.xlsx", rr.Body.Bytes())
+	importRR := httptest.NewRecorder()
+	handler.HandleImport(importRR, importReq)
+
+	var response struct {
+		Data model.ImportResult `json:"data"`
+	}
+	json.NewDecoder(importRR.Body).Decode(&response)
+	if importRR.Code != http.StatusOK || response.Data.Updated != 1 || len(response.Data.Errors) != 0 {
+		t.Errorf("Exported XLSX should re-import as an update, got: %d %+v", importRR.Code, response.Data)
+	}
+}
+
+func TestProductHandler_HandleExport_BadRequest(t *testing.T) {
+	handler, _, _ := setupProductHandler()
+
+	for _, url := range []string{"/api/products/export?format=pdf", "/api/products/export?category_id=abc"} {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleExport(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("GET %s should return 400, got: %d", url, rr.Code)
+		}
+	}
+}
+
+func setupProductImageHandler(t *testing.T) (*ProductHandler, *memory.ProductRepository) {
+	t.Helper()
+	categoryRepo := memory.NewCategoryRepository()
+	productRepo := memory.NewProductRepository(categoryRepo)
+	svc := service.NewProductService(productRepo, categoryRepo)
+	store, err := storage.NewLocalStore(t.TempDir(), "/media")
+	if err != nil {
+		t.Fatalf("NewLocalStore: %v", err)
+	}
+	svc.SetImageStore(store, 1<<20)
+	return NewProductHandler(svc), productRepo
+}
+
+func testJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 640, 480)), nil); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestProductHandler_HandleUploadImage_Success(t *testing.T) {
+	handler, productRepo := setupProductImageHandler(t)
+	productRepo.Create(&model.Product{Name: "Kopi", Price: 5000, Stock: 10})
+
+	req := newMultipartRequest(t, "/api/products/1/images", "image", "kopi.jpg", testJPEG(t))
+	rr := httptest.NewRecorder()
+	handler.HandleUploadImage(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleUploadImage should return 200, got: %d (%s)", rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Data model.Product `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if response.Data.ImageURL == "" || response.Data.ThumbnailURL == "" {
+		t.Errorf("Response should include image URLs, got: %+v", response.Data)
+	}
+
+	getReq := httptest.NewRequest(http.MethodGet, "/api/products/1", nil)
+	getRR := httptest.NewRecorder()
+	handler.HandleGetByID(getRR, getReq)
+	var getResponse struct {
+		Data model.Product `json:"data"`
+	}
+	json.NewDecoder(getRR.Body).Decode(&getResponse)
+	if getResponse.Data.ImageURL != response.Data.ImageURL {
+		t.Errorf("GET product should include image_url, got: %q", getResponse.Data.ImageURL)
+	}
+
+	delReq := httptest.NewRequest(http.MethodDelete, "/api/products/1/images", nil)
+	delRR := httptest.NewRecorder()
+	handler.HandleDeleteImage(delRR, delReq)
+	if delRR.Code != http.StatusOK {
+		t.Errorf("HandleDeleteImage should return 200, got: %d", delRR.Code)
+	}
+	delRR = httptest.NewRecorder()
+	handler.HandleDeleteImage(delRR, httptest.NewRequest(http.MethodDelete, "/api/products/1/images", nil))
+	if delRR.Code != http.StatusNotFound {
+		t.Errorf("Deleting a missing image should return 404, got: %d", delRR.Code)
+	}
+}
+
+func TestProductHandler_HandleUploadImage_Errors(t *testing.T) {
+	handler, productRepo := setupProductImageHandler(t)
+	productRepo.Create(&model.Product{Name: "Kopi", Price: 5000, Stock: 10})
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"wrong type", newMultipartRequest(t, "/api/products/1/images", "image", "kopi.txt", []byte("hello")), http.StatusUnsupportedMediaType},
+		{"too large", newMultipartRequest(t, "/api/products/1/images", "image", "big.jpg", make([]byte, 1<<20+1)), http.StatusRequestEntityTooLarge},
+		{"missing field", newMultipartRequest(t, "/api/products/1/images", "file", "kopi.jpg", testJPEG(t)), http.StatusBadRequest},
+		{"unknown product", newMultipartRequest(t, "/api/products/99/images", "image", "kopi.jpg", testJPEG(t)), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.HandleUploadImage(rr, tt.req)
+			if rr.Code != tt.want {
+				t.Errorf("HandleUploadImage should return %d, got: %d", tt.want, rr.Code)
+			}
+		})
+	}
+}
+
+func TestProductHandler_HandleUploadImage_StoreDisabled(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+	productRepo.Create(&model.Product{Name: "Kopi", Price: 5000, Stock: 10})
+
+	req := newMultipartRequest(t, "/api/products/1/images", "image", "kopi.jpg", testJPEG(t))
+	rr := httptest.NewRecorder()
+	handler.HandleUploadImage(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Upload without image store should return 503, got: %d", rr.Code)
+	}
+}
+
+func TestProductHandler_HandleGetAll_SearchAndSort(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+	productRepo.Create(&model.Product{Name: "Indomie Goreng", Price: 3500, Stock: 10})
+	productRepo.Create(&model.Product{Name: "Indomie Soto", Price: 3000, Stock: 0})
+	productRepo.Create(&model.Product{Name: "Kopi Kapal Api", Price: 2000, Stock: 50})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products?name=indomei&sort=price&order=desc&in_stock=false", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetAll(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleGetAll should return 200, got: %d", rr.Code)
+	}
+	var response struct {
+		Data struct {
+			Items []model.Product `json:"items"`
+		} `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data.Items) != 2 || response.Data.Items[0].ID != 1 || response.Data.Items[1].ID != 2 {
+		t.Errorf("Typo search sorted by price desc should return [1 2], got: %+v", response.Data.Items)
+	}
+}
+
+func TestProductHandler_HandleGetAll_InvalidSearchParams(t *testing.T) {
+	handler, _, _ := setupProductHandler()
+
+	for _, query := range []string{
+		"min_price=abc",
+		"max_price=-1",
+		"min_price=5000&max_price=1000",
+		"sort=popularity",
+		"order=up",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/products?"+query, nil)
+		rr := httptest.NewRecorder()
+		handler.HandleGetAll(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/products?%s should return 400, got: %d", query, rr.Code)
+		}
+	}
+}
+
+func TestProductHandler_HandleSearch(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+	productRepo.Create(&model.Product{Name: "Indomie Goreng", Price: 3500, Stock: 10})
+	productRepo.Create(&model.Product{Name: "Indomie Soto", Price: 3000, Stock: 10})
+	productRepo.Create(&model.Product{Name: "Kopi Kapal Api", Price: 2000, Stock: 50})
+	productRepo.Archive(2, 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/search?q=indomie&limit=5", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleSearch(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("HandleSearch should return 200, got: %d", rr.Code)
+	}
+	var response struct {
+		Data []model.ProductSuggestion `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].Name != "Indomie Goreng" {
+		t.Errorf("Search should return only active matches, got: %+v", response.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/products/search?q=kopi&limit=zero", nil)
+	rr = httptest.NewRecorder()
+	handler.HandleSearch(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("HandleSearch with invalid limit should return 400, got: %d", rr.Code)
+	}
+}
+
+func TestProductHandler_HandleGetAll_Cursor(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+	for i := 1; i <= 3; i++ {
+		productRepo.Create(&model.Product{Name: fmt.Sprintf("Produk %d", i), Price: 1000 * i, Stock: 1})
+	}
+
+	type listResponse struct {
+		Data struct {
+			Items      []model.Product `json:"items"`
+			Page       int             `json:"page"`
+			TotalItems int             `json:"total_items"`
+			NextCursor string          `json:"next_cursor"`
+		} `json:"data"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products?sort=price&order=desc&limit=2", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetAll(rr, req)
+	var first listResponse
+	json.NewDecoder(rr.Body).Decode(&first)
+	if len(first.Data.Items) != 2 || first.Data.NextCursor == "" {
+		t.Fatalf("First page should have 2 items and a next_cursor, got: %+v", first.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/products?sort=price&order=desc&limit=2&after="+first.Data.NextCursor, nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetAll(rr, req)
+	var second listResponse
+	json.NewDecoder(rr.Body).Decode(&second)
+	if rr.Code != http.StatusOK || len(second.Data.Items) != 1 || second.Data.Items[0].ID != 1 {
+		t.Errorf("Cursor page should continue with the cheapest product, got: %d %+v", rr.Code, second.Data)
+	}
+	if second.Data.NextCursor != "" || second.Data.Page != 0 || second.Data.TotalItems != 3 {
+		t.Errorf("Last cursor page should have no next_cursor, no page and the total, got: %+v", second.Data)
+	}
+
+	for _, query := range []string{
+		"after=bogus",
+		"sort=name&after=" + first.Data.NextCursor,
+	} {
+		req = httptest.NewRequest(http.MethodGet, "/api/products?"+query, nil)
+		rr = httptest.NewRecorder()
+		handler.HandleGetAll(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/products?%s should return 400, got: %d", query, rr.Code)
+		}
+	}
+}
+
+func TestProductHandler_HandlePatch(t *testing.T) {
+	handler, productRepo, categoryRepo := setupProductHandler()
+	categoryRepo.Create(&model.Category{Name: "Minuman"})
+	categoryID := 1
+	productRepo.Create(&model.Product{Name: "Teh Botol", Price: 5000, Stock: 10, SKU: "TEH-01", CategoryID: &categoryID})
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/api/products/1", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		rr := httptest.NewRecorder()
+		handler.HandlePatch(rr, req)
+		return rr
+	}
+
+	if rr := patch(`{"stock": 25}`); rr.Code != http.StatusOK {
+		t.Fatalf("Patching stock should return 200, got: %d %s", rr.Code, rr.Body.String())
+	}
+	product, _ := productRepo.GetByID(1)
+	if product.Stock != 25 || product.Name != "Teh Botol" || product.Price != 5000 || product.SKU != "TEH-01" || product.CategoryID == nil {
+		t.Errorf("Patch should only change stock, got: %+v", product)
+	}
+
+	if rr := patch(`{"category_id": null, "sku": null}`); rr.Code != http.StatusOK {
+		t.Fatalf("Clearing fields should return 200, got: %d", rr.Code)
+	}
+	product, _ = productRepo.GetByID(1)
+	if product.CategoryID != nil || product.SKU != "" || product.Stock != 25 {
+		t.Errorf("null should clear category_id and sku, got: %+v", product)
+	}
+
+	for body, want := range map[string]int{
+		`{"price": null}`:     http.StatusBadRequest,
+		`{"stock": -1}`:       http.StatusBadRequest,
+		`{"category_id": 99}`: http.StatusBadRequest,
+		`{"colour": "green"}`: http.StatusBadRequest,
+		`[{"op": "replace"}]`: http.StatusBadRequest,
+	} {
+		if rr := patch(body); rr.Code != want {
+			t.Errorf("PATCH %s should return %d, got: %d", body, want, rr.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/products/99", bytes.NewBufferString(`{"stock": 1}`))
+	rr := httptest.NewRecorder()
+	handler.HandlePatch(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Patching a missing product should return 404, got: %d", rr.Code)
+	}
+}
+
+func TestProductHandler_IfMatch(t *testing.T) {
+	handler, productRepo, _ := setupProductHandler()
+	productRepo.Create(&model.Product{Name: "Teh Botol", Price: 5000, Stock: 10})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/1", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetByID(rr, req)
+	etag := rr.Header().Get("ETag")
+	if etag != `"1"` {
+		t.Fatalf("GET should return ETag \"1\", got: %q", etag)
+	}
+
+	send := func(method, ifMatch, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/products/1", bytes.NewBufferString(body))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rr := httptest.NewRecorder()
+		switch method {
+		case http.MethodPut:
+			handler.HandleUpdate(rr, req)
+		case http.MethodPatch:
+			handler.HandlePatch(rr, req)
+		case http.MethodDelete:
+			handler.HandleDelete(rr, req)
+		}
+		return rr
+	}
+
+	rr = send(http.MethodPut, etag, `{"name":"Teh Botol","price":5000,"stock":9}`)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"2"` {
+		t.Fatalf("PUT with the current ETag should return 200 and ETag \"2\", got: %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		ifMatch string
+		body    string
+		want    int
+	}{
+		{"PUT with stale ETag", http.MethodPut, etag, `{"name":"Teh","price":5000,"stock":1}`, http.StatusPreconditionFailed},
+		{"PATCH with stale ETag", http.MethodPatch, etag, `{"stock":1}`, http.StatusPreconditionFailed},
+		{"DELETE with stale ETag", http.MethodDelete, etag, "", http.StatusPreconditionFailed},
+		{"weak ETag", http.MethodPut, `W/"2"`, `{"name":"Teh","price":5000,"stock":1}`, http.StatusPreconditionFailed},
+		{"malformed If-Match", http.MethodPut, `2`, `{"name":"Teh","price":5000,"stock":1}`, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if rr := send(tc.method, tc.ifMatch, tc.body); rr.Code != tc.want {
+				t.Errorf("%s should return %d, got: %d", tc.name, tc.want, rr.Code)
+			}
+		})
+	}
+	if p, _ := productRepo.GetByID(1); p.Stock != 9 || p.IsArchived() || p.Version != 2 {
+		t.Errorf("Rejected writes should leave the product untouched, got: %+v", p)
+	}
+
+	if rr := send(http.MethodPatch, `"2"`, `{"stock":8}`); rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"3"` {
+		t.Errorf("PATCH with the current ETag should return 200 and ETag \"3\", got: %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+	if rr := send(http.MethodPatch, "*", `{"stock":7}`); rr.Code != http.StatusOK {
+		t.Errorf("PATCH with If-Match * should return 200, got: %d", rr.Code)
+	}
+	if rr := send(http.MethodDelete, `"4"`, ""); rr.Code != http.StatusOK {
+		t.Errorf("DELETE with the current ETag should return 200, got: %d", rr.Code)
+	}
+}
+
+func TestProductHandler_HandleBulk(t *testing.T) {
+	handler, productRepo, categoryRepo := setupProductHandler()
+	categoryRepo.Create(&model.Category{Name: "Minuman"})
+	categoryID := 1
+	productRepo.Create(&model.Product{Name: "Teh Botol", Price: 5000, Stock: 10, CategoryID: &categoryID})
+	productRepo.Create(&model.Product{Name: "Kopi Susu", Price: 8000, Stock: 2, CategoryID: &categoryID})
+	productRepo.Create(&model.Product{Name: "Roti", Price: 12000, Stock: 4})
+
+	bulk := func(query, body string) (*httptest.ResponseRecorder, model.ProductBulkResult) {
+		req := httptest.NewRequest(http.MethodPost, "/api/products/bulk"+query, bytes.NewBufferString(body))
+		req.Header.Set("X-User", "budi")
+		rr := httptest.NewRecorder()
+		handler.HandleBulk(rr, req)
+		var resp struct {
+			Data model.ProductBulkResult `json:"data"`
+		}
+		json.NewDecoder(rr.Body).Decode(&resp)
+		return rr, resp.Data
+	}
+
+	rr, preview := bulk("?dry_run=true", `{"operation":"adjust_price","filter":{"category_id":1},"percent":10}`)
+	if rr.Code != http.StatusOK || !preview.DryRun || preview.Affected != 2 {
+		t.Fatalf("Preview should return 200 with 2 affected products, got: %d %+v", rr.Code, preview)
+	}
+	if p, _ := productRepo.GetByID(1); p.Price != 5000 {
+		t.Errorf("Preview should not change prices, got: %d", p.Price)
+	}
+
+	rr, result := bulk("", `{"operation":"adjust_price","filter":{"category_id":1},"expected_matched":3,"percent":10}`)
+	if rr.Code != http.StatusConflict || result.Matched != 2 {
+		t.Errorf("A filter matching another count than expected_matched should return 409 with the preview, got: %d %+v", rr.Code, result)
+	}
+	if p, _ := productRepo.GetByID(1); p.Price != 5000 {
+		t.Errorf("A rejected filter write should not change prices, got: %d", p.Price)
+	}
+
+	rr, result = bulk("", `{"operation":"adjust_price","filter":{"category_id":1},"expected_matched":2,"percent":10}`)
+	if rr.Code != http.StatusOK || result.Affected != 2 {
+		t.Fatalf("Bulk should return 200 with 2 affected products, got: %d %+v", rr.Code, result)
+	}
+	teh, _ := productRepo.GetByID(1)
+	roti, _ := productRepo.GetByID(3)
+	if teh.Price != 5500 || roti.Price != 12000 {
+		t.Errorf("Only the filtered products should change, got: %d and %d", teh.Price, roti.Price)
+	}
+	if changes := priceHistory(productRepo, 1); len(changes) != 1 || changes[0].ChangedBy != "budi" {
+		t.Errorf("Bulk should record the price change by X-User, got: %+v", changes)
+	}
+
+	rr, result = bulk("", `{"operation":"adjust_stock","ids":[1,2],"amount":-3}`)
+	if rr.Code != http.StatusUnprocessableEntity || len(result.Errors) != 1 || result.Errors[0].ProductID != 2 {
+		t.Errorf("A product going below zero stock should return 422 with its error, got: %d %+v", rr.Code, result)
+	}
+	if teh, _ := productRepo.GetByID(1); teh.Stock != 10 {
+		t.Errorf("A rejected bulk operation should change nothing, got stock: %d", teh.Stock)
+	}
+
+	for body, want := range map[string]int{
+		`{"operation":"explode","ids":[1]}`:                      http.StatusBadRequest,
+		`{"operation":"archive"}`:                                http.StatusBadRequest,
+		`{"operation":"set_category","ids":[1],"category_id":9}`: http.StatusBadRequest,
+		`{"operation":"archive","ids":[3]}`:                      http.StatusOK,
+	} {
+		if rr, _ := bulk("", body); rr.Code != want {
+			t.Errorf("POST /api/products/bulk %s should return %d, got: %d", body, want, rr.Code)
+		}
+	}
+}
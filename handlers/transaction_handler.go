@@ -4,7 +4,10 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
 	"time"
 
 	helper "kasir-api/helpers"
@@ -30,6 +33,7 @@ func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Reque
 	if !helper.ValidatePayload(w, r, &request) {
 		return
 	}
+	request.Role = helper.RoleFromRequest(r)
 
 	transaction, err := h.service.Checkout(&request)
 	if err != nil {
@@ -37,9 +41,19 @@ func (h *TransactionHandler) HandleCheckout(w http.ResponseWriter, r *http.Reque
 			helper.WriteError(w, r, http.StatusNotFound, err.Error(), err)
 			return
 		}
+		if errors.Is(err, model.ErrCustomItemDisabled) || errors.Is(err, model.ErrCustomItemRole) {
+			helper.WriteError(w, r, http.StatusForbidden, err.Error(), err)
+			return
+		}
 		if errors.Is(err, model.ErrInsufficientStock) ||
+			errors.Is(err, model.ErrOutletNotFound) ||
+			errors.Is(err, model.ErrProductArchived) ||
 			errors.Is(err, model.ErrEmptyCheckout) ||
-			errors.Is(err, model.ErrInvalidQuantity) {
+			errors.Is(err, model.ErrInvalidQuantity) ||
+			errors.Is(err, model.ErrCustomItemInvalid) ||
+			errors.Is(err, model.ErrCategoryNotFound) ||
+			errors.Is(err, model.ErrCategoryArchived) ||
+			isScaleLabelError(err) {
 			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
 			return
 		}
@@ -70,9 +84,14 @@ func (h *TransactionHandler) HandleGetByID(w http.ResponseWriter, r *http.Reques
 	helper.WriteSuccess(w, http.StatusOK, "Success", transaction)
 }
 
-// HandleGetTodayReport handles GET /api/report/hari-ini.
+// HandleGetTodayReport handles GET /api/report/hari-ini[?outlet_id=N].
 func (h *TransactionHandler) HandleGetTodayReport(w http.ResponseWriter, r *http.Request) {
-	report, err := h.service.GetTodayReport()
+	outletID, ok := parseOutletFilter(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetTodayReport(outletID)
 	if err != nil {
 		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve report", err)
 		return
@@ -81,38 +100,176 @@ func (h *TransactionHandler) HandleGetTodayReport(w http.ResponseWriter, r *http
 	helper.WriteSuccess(w, http.StatusOK, "Success", report)
 }
 
-// HandleGetReport handles GET /api/report?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD.
+// HandleGetReport handles GET /api/report?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD[&outlet_id=N].
 func (h *TransactionHandler) HandleGetReport(w http.ResponseWriter, r *http.Request) {
-	startDateStr := r.URL.Query().Get("start_date")
-	endDateStr := r.URL.Query().Get("end_date")
+	startDate, endDate, ok := parseDateRange(w, r, h.service.Location())
+	if !ok {
+		return
+	}
+	outletID, ok := parseOutletFilter(w, r)
+	if !ok {
+		return
+	}
 
-	if startDateStr == "" || endDateStr == "" {
-		helper.WriteError(w, r, http.StatusBadRequest, "start_date and end_date are required", model.ErrInvalidDateRange)
+	report, err := h.service.GetReportByDateRange(startDate, endDate, outletID)
+	if err != nil {
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve report", err)
+		return
+	}
+
+	helper.WriteSuccess(w, http.StatusOK, "Success", report)
+}
+
+// HandleGetTimeSeries handles
+// GET /api/report/timeseries?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD[&granularity=hour|day|week|month][&outlet_id=N].
+func (h *TransactionHandler) HandleGetTimeSeries(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, ok := parseDateRange(w, r, h.service.Location())
+	if !ok {
+		return
+	}
+	outletID, ok := parseOutletFilter(w, r)
+	if !ok {
 		return
 	}
 
-	startDate, err := time.Parse("2006-01-02", startDateStr)
+	report, err := h.service.GetTimeSeries(startDate, endDate, r.URL.Query().Get("granularity"), outletID)
 	if err != nil {
-		helper.WriteError(w, r, http.StatusBadRequest, "invalid start_date format, use YYYY-MM-DD", err)
+		if errors.Is(err, model.ErrGranularityInvalid) || errors.Is(err, model.ErrInvalidDateRange) {
+			helper.WriteError(w, r, http.StatusBadRequest, err.Error(), err)
+			return
+		}
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve report", err)
 		return
 	}
 
-	endDate, err := time.Parse("2006-01-02", endDateStr)
+	helper.WriteSuccess(w, http.StatusOK, "Success", report)
+}
+
+// HandleGetCategorySales handles GET /api/report/categories?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD[&outlet_id=N].
+func (h *TransactionHandler) HandleGetCategorySales(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, ok := parseDateRange(w, r, h.service.Location())
+	if !ok {
+		return
+	}
+	outletID, ok := parseOutletFilter(w, r)
+	if !ok {
+		return
+	}
+
+	sales, err := h.service.GetCategorySales(startDate, endDate, outletID)
 	if err != nil {
-		helper.WriteError(w, r, http.StatusBadRequest, "invalid end_date format, use YYYY-MM-DD", err)
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve report", err)
 		return
 	}
 
-	if endDate.Before(startDate) {
-		helper.WriteError(w, r, http.StatusBadRequest, "end_date must be after start_date", model.ErrInvalidDateRange)
+	helper.WriteSuccess(w, http.StatusOK, "Success", sales)
+}
+
+// HandleGetProductSales handles GET /api/report/products?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD
+// [&sort=qty|revenue][&order=desc|asc][&limit=10][&outlet_id=N].
+func (h *TransactionHandler) HandleGetProductSales(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, ok := parseDateRange(w, r, h.service.Location())
+	if !ok {
+		return
+	}
+	outletID, ok := parseOutletFilter(w, r)
+	if !ok {
 		return
 	}
 
-	report, err := h.service.GetReportByDateRange(startDate, endDate)
+	q := r.URL.Query()
+	query := model.ProductSalesQuery{Sort: q.Get("sort"), Desc: true, Limit: model.DefaultProductSalesLimit}
+	if query.Sort != "" && !slices.Contains(model.ProductSalesSorts, query.Sort) {
+		helper.WriteError(w, r, http.StatusBadRequest, fmt.Sprintf("sort must be one of %v", model.ProductSalesSorts), nil)
+		return
+	}
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		query.Desc = false
+	default:
+		helper.WriteError(w, r, http.StatusBadRequest, "order must be one of [desc asc]", nil)
+		return
+	}
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			helper.WriteError(w, r, http.StatusBadRequest, "Invalid limit", err)
+			return
+		}
+		query.Limit = min(limit, model.MaxProductSalesLimit)
+	}
+
+	sales, err := h.service.GetProductSales(startDate, endDate, outletID, query)
 	if err != nil {
 		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve report", err)
 		return
 	}
 
-	helper.WriteSuccess(w, http.StatusOK, "Success", report)
+	helper.WriteSuccess(w, http.StatusOK, "Success", sales)
+}
+
+// HandleGetHeatmap handles GET /api/report/heatmap?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD[&outlet_id=N].
+func (h *TransactionHandler) HandleGetHeatmap(w http.ResponseWriter, r *http.Request) {
+	startDate, endDate, ok := parseDateRange(w, r, h.service.Location())
+	if !ok {
+		return
+	}
+	outletID, ok := parseOutletFilter(w, r)
+	if !ok {
+		return
+	}
+
+	heatmap, err := h.service.GetHeatmap(startDate, endDate, outletID)
+	if err != nil {
+		helper.WriteError(w, r, http.StatusInternalServerError, "Failed to retrieve report", err)
+		return
+	}
+
+	helper.WriteSuccess(w, http.StatusOK, "Success", heatmap)
+}
+
+// parseDateRange reads the required ?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD of a report as
+// midnights in loc, the store timezone. Returns false after writing an error response.
+func parseDateRange(w http.ResponseWriter, r *http.Request, loc *time.Location) (startDate, endDate time.Time, ok bool) {
+	startDateStr := r.URL.Query().Get("start_date")
+	endDateStr := r.URL.Query().Get("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		helper.WriteError(w, r, http.StatusBadRequest, "start_date and end_date are required", model.ErrInvalidDateRange)
+		return startDate, endDate, false
+	}
+
+	startDate, err := time.ParseInLocation("2006-01-02", startDateStr, loc)
+	if err != nil {
+		helper.WriteError(w, r, http.StatusBadRequest, "invalid start_date format, use YYYY-MM-DD", err)
+		return startDate, endDate, false
+	}
+
+	endDate, err = time.ParseInLocation("2006-01-02", endDateStr, loc)
+	if err != nil {
+		helper.WriteError(w, r, http.StatusBadRequest, "invalid end_date format, use YYYY-MM-DD", err)
+		return startDate, endDate, false
+	}
+
+	if endDate.Before(startDate) {
+		helper.WriteError(w, r, http.StatusBadRequest, "end_date must be after start_date", model.ErrInvalidDateRange)
+		return startDate, endDate, false
+	}
+	return startDate, endDate, true
+}
+
+// parseOutletFilter reads the optional ?outlet_id= of a report; 0 (absent) covers all outlets.
+// Returns false after writing an error response.
+func parseOutletFilter(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("outlet_id")
+	if value == "" {
+		return 0, true
+	}
+	outletID, err := strconv.Atoi(value)
+	if err != nil || outletID <= 0 {
+		helper.WriteError(w, r, http.StatusBadRequest, "invalid outlet_id, use a positive outlet ID", model.ErrOutletNotFound)
+		return 0, false
+	}
+	return outletID, true
 }
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	model "kasir-api/models"
 	"kasir-api/repositories/memory"
@@ -15,7 +16,7 @@ import (
 func setupTransactionHandler() (*TransactionHandler, *memory.TransactionRepository, *memory.ProductRepository, *memory.CategoryRepository) {
 	categoryRepo := memory.NewCategoryRepository()
 	productRepo := memory.NewProductRepository(categoryRepo)
-	transactionRepo := memory.NewTransactionRepository()
+	transactionRepo := memory.NewTransactionRepository(productRepo, nil)
 	svc := service.NewTransactionService(transactionRepo, productRepo)
 	handler := NewTransactionHandler(svc)
 	return handler, transactionRepo, productRepo, categoryRepo
@@ -163,6 +164,80 @@ func TestTransactionHandler_HandleCheckout_InsufficientStock(t *testing.T) {
 	}
 }
 
+func TestTransactionHandler_HandleCheckout_ArchivedProduct(t *testing.T) {
+	handler, _, productRepo, _ := setupTransactionHandler()
+
+	productRepo.Create(&model.Product{Name: "Laptop", Price: 1000, Stock: 5})
+	productRepo.Archive(1, 0)
+
+	request := model.CheckoutRequest{
+		Items: []model.CheckoutItem{
+			{ProductID: 1, Quantity: 1},
+		},
+	}
+	body, _ := json.Marshal(request)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/checkout", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	handler.HandleCheckout(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("HandleCheckout with archived product should return 400, got: %d", rr.Code)
+	}
+}
+
+func TestTransactionHandler_HandleCheckout_Bundle(t *testing.T) {
+	handler, _, productRepo, _ := setupTransactionHandler()
+
+	productRepo.Create(&model.Product{Name: "Kopi", Price: 8000, Stock: 5})
+	productRepo.Create(&model.Product{Name: "Roti", Price: 6000, Stock: 10})
+	productRepo.Create(&model.Product{Name: "Paket Sarapan", Price: 12000, Components: []model.BundleComponent{
+		{ProductID: 1, Quantity: 1},
+		{ProductID: 2, Quantity: 2},
+	}})
+
+	checkout := func(quantity int) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 3, Quantity: quantity}}})
+		req := httptest.NewRequest(http.MethodPost, "/api/checkout", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		handler.HandleCheckout(rr, req)
+		return rr
+	}
+
+	rr := checkout(3)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("HandleCheckout of a bundle should return 201, got: %d", rr.Code)
+	}
+	var response struct {
+		Data model.Transaction `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if response.Data.TotalAmount != 36000 {
+		t.Errorf("A bundle should be sold at its own price, want 36000, got: %d", response.Data.TotalAmount)
+	}
+	if len(response.Data.Details) != 3 {
+		t.Fatalf("HandleCheckout should add a detail per component, want 3, got: %d", len(response.Data.Details))
+	}
+	roti := response.Data.Details[2]
+	if roti.Quantity != 6 || roti.Price != 0 || roti.BundleProductID == nil || *roti.BundleProductID != 3 {
+		t.Errorf("A component detail should carry the used quantity and point at the bundle, got: %+v", roti)
+	}
+
+	if kopi, _ := productRepo.GetByID(1); kopi.Stock != 2 {
+		t.Errorf("Component stock should be deducted, want 2, got: %d", kopi.Stock)
+	}
+	if roti, _ := productRepo.GetByID(2); roti.Stock != 4 {
+		t.Errorf("Component stock should be deducted, want 4, got: %d", roti.Stock)
+	}
+
+	if rr := checkout(3); rr.Code != http.StatusBadRequest {
+		t.Errorf("HandleCheckout beyond the derived bundle stock should return 400, got: %d", rr.Code)
+	}
+}
+
 func TestTransactionHandler_HandleGetByID_Success(t *testing.T) {
 	handler, _, productRepo, _ := setupTransactionHandler()
 
@@ -278,6 +353,54 @@ func TestTransactionHandler_HandleGetReport_Success(t *testing.T) {
 	}
 }
 
+func TestTransactionHandler_HandleGetReport_StoreTimezoneMidnight(t *testing.T) {
+	handler, transactionRepo, _, _ := setupTransactionHandler()
+	handler.service.SetLocation(time.FixedZone("WIB", 7*60*60))
+
+	// Around the Jakarta midnights that start and end 2024-01-15 (17:00 UTC the day before and of).
+	sales := []struct {
+		at     time.Time
+		amount int
+	}{
+		{time.Date(2024, 1, 14, 16, 59, 59, 0, time.UTC), 1},   // 14th 23:59:59 WIB
+		{time.Date(2024, 1, 14, 17, 0, 0, 0, time.UTC), 10},    // 15th 00:00 WIB
+		{time.Date(2024, 1, 15, 16, 59, 59, 0, time.UTC), 100}, // 15th 23:59:59 WIB
+		{time.Date(2024, 1, 15, 17, 0, 0, 0, time.UTC), 1000},  // 16th 00:00 WIB
+	}
+	for _, sale := range sales {
+		transactionRepo.Create(&model.Transaction{TotalAmount: sale.amount, CreatedAt: sale.at})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/report?start_date=2024-01-15&end_date=2024-01-15", nil)
+	rr := httptest.NewRecorder()
+	handler.HandleGetReport(rr, req)
+
+	var report struct {
+		Data model.ReportResponse `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&report)
+	if report.Data.TotalRevenue != 110 || report.Data.TotalTransaksi != 2 {
+		t.Errorf("The Jakarta day should hold the sales from 00:00 to 23:59:59 WIB, got: %+v", report.Data)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/report/timeseries?granularity=day&start_date=2024-01-14&end_date=2024-01-16", nil)
+	rr = httptest.NewRecorder()
+	handler.HandleGetTimeSeries(rr, req)
+
+	var series struct {
+		Data model.TimeSeriesReport `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&series)
+	if len(series.Data.Buckets) != 3 {
+		t.Fatalf("Three days should have 3 buckets, got: %d", len(series.Data.Buckets))
+	}
+	for i, want := range []int{1, 110, 1000} {
+		if got := series.Data.Buckets[i].TotalRevenue; got != want {
+			t.Errorf("Day bucket %d should hold %d, got: %d", i, want, got)
+		}
+	}
+}
+
 func TestTransactionHandler_HandleGetReport_MissingStartDate(t *testing.T) {
 	handler, _, _, _ := setupTransactionHandler()
 
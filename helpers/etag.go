@@ -0,0 +1,58 @@
+package helper
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	model "kasir-api/models"
+)
+
+// errIfMatchInvalid rejects If-Match values that are not one of our strong ETags.
+var errIfMatchInvalid = errors.New(`If-Match must be "*" or a single ETag such as "3"`)
+
+// ETag formats a resource version as a strong entity tag, e.g. version 3 becomes "3" (quotes included).
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// SetETag sets the ETag header for a resource version. Call it before writing the response.
+func SetETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", ETag(version))
+}
+
+// ParseIfMatch reads the expected version from the If-Match header.
+// An absent header or "*" returns 0, which skips the version check. A weak tag can never match
+// a strong comparison and writes 412; anything else that is not a single ETag writes 400.
+// Returns false after writing an error response.
+func ParseIfMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, true
+	}
+	if strings.HasPrefix(value, "W/") {
+		WriteError(w, r, http.StatusPreconditionFailed, model.ErrVersionMismatch.Error(), model.ErrVersionMismatch)
+		return 0, false
+	}
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		WriteError(w, r, http.StatusBadRequest, errIfMatchInvalid.Error(), errIfMatchInvalid)
+		return 0, false
+	}
+	version, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || version <= 0 {
+		WriteError(w, r, http.StatusBadRequest, errIfMatchInvalid.Error(), errIfMatchInvalid)
+		return 0, false
+	}
+	return version, true
+}
+
+// WriteVersionMismatch answers a write that lost a version check: 412 Precondition Failed when
+// the client sent If-Match, 409 Conflict when the version came from the server's own read.
+func WriteVersionMismatch(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusConflict
+	if r.Header.Get("If-Match") != "" {
+		status = http.StatusPreconditionFailed
+	}
+	WriteError(w, r, status, err.Error(), err)
+}
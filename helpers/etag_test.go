@@ -0,0 +1,59 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header      string
+		wantVersion int
+		wantOK      bool
+		wantStatus  int
+	}{
+		{"", 0, true, 0},
+		{"*", 0, true, 0},
+		{`"3"`, 3, true, 0},
+		{` "12" `, 12, true, 0},
+		{`W/"3"`, 0, false, http.StatusPreconditionFailed},
+		{`3`, 0, false, http.StatusBadRequest},
+		{`"abc"`, 0, false, http.StatusBadRequest},
+		{`"0"`, 0, false, http.StatusBadRequest},
+		{`"1", "2"`, 0, false, http.StatusBadRequest},
+	}
+	for _, tc := range tests {
+		t.Run(tc.header, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/api/products/1", nil)
+			if tc.header != "" {
+				req.Header.Set("If-Match", tc.header)
+			}
+			rr := httptest.NewRecorder()
+
+			version, ok := ParseIfMatch(rr, req)
+			if version != tc.wantVersion || ok != tc.wantOK {
+				t.Errorf("ParseIfMatch(%q) = %d, %v, want %d, %v", tc.header, version, ok, tc.wantVersion, tc.wantOK)
+			}
+			if !ok && rr.Code != tc.wantStatus {
+				t.Errorf("ParseIfMatch(%q) should write %d, got: %d", tc.header, tc.wantStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestWriteVersionMismatch(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPatch, "/api/products/1", nil)
+	rr := httptest.NewRecorder()
+	WriteVersionMismatch(rr, req, errIfMatchInvalid)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("Without If-Match a version mismatch should return 409, got: %d", rr.Code)
+	}
+
+	req.Header.Set("If-Match", `"1"`)
+	rr = httptest.NewRecorder()
+	WriteVersionMismatch(rr, req, errIfMatchInvalid)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("With If-Match a version mismatch should return 412, got: %d", rr.Code)
+	}
+}
@@ -0,0 +1,101 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// MergePatchContentType is the media type of an RFC 7396 JSON merge patch.
+const MergePatchContentType = "application/merge-patch+json"
+
+// errPatchNotObject rejects patches that would replace the whole resource instead of editing it.
+var errPatchNotObject = errors.New("merge patch must be a JSON object")
+
+// MergePatch applies an RFC 7396 JSON merge patch to doc and returns the merged document.
+// Objects are merged recursively, null removes a member and any other value replaces it.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, changes any
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, fmt.Errorf("invalid document: %w", err)
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+	return json.Marshal(mergeValue(target, changes))
+}
+
+// mergeValue is the MergePatch algorithm from RFC 7396 section 2.
+func mergeValue(target, patch any) any {
+	changes, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	merged, ok := target.(map[string]any)
+	if !ok {
+		merged = map[string]any{}
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = mergeValue(merged[key], value)
+	}
+	return merged
+}
+
+// ApplyMergePatch reads a JSON merge patch from the request body, applies it to current and decodes
+// the merged document into dst, then validates struct tags. Members removed with null take their zero
+// value in dst and members dst does not know are rejected. The body must be sent as
+// application/merge-patch+json or application/json.
+// Returns false after writing an error response.
+func ApplyMergePatch(w http.ResponseWriter, r *http.Request, current, dst any) bool {
+	defer r.Body.Close() //nolint:errcheck // body close error is non-actionable
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != MergePatchContentType && mediaType != "application/json") {
+			WriteError(w, r, http.StatusUnsupportedMediaType, "Content-Type must be "+MergePatchContentType, err)
+			return false
+		}
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid JSON", err)
+		return false
+	}
+	if trimmed := bytes.TrimSpace(patch); len(trimmed) == 0 || trimmed[0] != '{' {
+		WriteError(w, r, http.StatusBadRequest, errPatchNotObject.Error(), errPatchNotObject)
+		return false
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		WriteError(w, r, http.StatusInternalServerError, "Failed to apply merge patch", err)
+		return false
+	}
+	merged, err := MergePatch(doc, patch)
+	if err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid JSON", err)
+		return false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		WriteError(w, r, http.StatusBadRequest, "Invalid merge patch: "+err.Error(), err)
+		return false
+	}
+
+	if err := ValidateStruct(dst); err != nil {
+		WriteError(w, r, http.StatusBadRequest, FormatValidationErrors(err), err)
+		return false
+	}
+	return true
+}
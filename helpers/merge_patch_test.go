@@ -0,0 +1,94 @@
+package helper
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergePatch_RFC7396Examples(t *testing.T) {
+	// Test cases from RFC 7396 Appendix A.
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("MergePatch(%s, %s) returned error: %v", tt.doc, tt.patch, err)
+			continue
+		}
+		var gotValue, wantValue any
+		json.Unmarshal(got, &gotValue)
+		json.Unmarshal([]byte(tt.want), &wantValue)
+		if !reflect.DeepEqual(gotValue, wantValue) {
+			t.Errorf("MergePatch(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	type item struct {
+		Name  string `json:"name" validate:"required"`
+		Stock int    `json:"stock" validate:"gte=0"`
+		Group *int   `json:"group,omitempty"`
+	}
+	group := 3
+	current := item{Name: "Kopi", Stock: 5, Group: &group}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		want        item
+	}{
+		{"changes only sent members", MergePatchContentType, `{"stock":9}`, http.StatusOK, item{Name: "Kopi", Stock: 9, Group: &group}},
+		{"null clears a member", "application/json", `{"group":null}`, http.StatusOK, item{Name: "Kopi", Stock: 5}},
+		{"merged result is validated", MergePatchContentType, `{"name":null}`, http.StatusBadRequest, item{}},
+		{"unknown member", MergePatchContentType, `{"stok":1}`, http.StatusBadRequest, item{}},
+		{"wrong type", MergePatchContentType, `{"stock":"many"}`, http.StatusBadRequest, item{}},
+		{"not an object", MergePatchContentType, `null`, http.StatusBadRequest, item{}},
+		{"unsupported media type", "text/plain", `{"stock":1}`, http.StatusUnsupportedMediaType, item{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+
+			var got item
+			ok := ApplyMergePatch(rr, req, current, &got)
+			if tt.wantStatus != http.StatusOK {
+				if ok || rr.Code != tt.wantStatus {
+					t.Errorf("ApplyMergePatch should fail with %d, got ok=%v status %d", tt.wantStatus, ok, rr.Code)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("ApplyMergePatch should succeed, got status %d: %s", rr.Code, rr.Body.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ApplyMergePatch = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
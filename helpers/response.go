@@ -70,6 +70,12 @@ func ParsePagination(r *http.Request, defaultLimit int) (page, limit int) {
 	return page, limit
 }
 
+// ParseBoolQuery reports whether the query parameter is set to a true value ("true", "1", ...).
+func ParseBoolQuery(r *http.Request, key string) bool {
+	v, err := strconv.ParseBool(r.URL.Query().Get(key))
+	return err == nil && v
+}
+
 // ParseIDFromPath extracts an integer ID from a URL path by trimming the given prefix.
 // Returns the parsed ID and true on success, or writes a 404 error and returns 0 and false on failure.
 func ParseIDFromPath(w http.ResponseWriter, r *http.Request, prefix string, notFoundErr error) (int, bool) {
@@ -82,6 +88,36 @@ func ParseIDFromPath(w http.ResponseWriter, r *http.Request, prefix string, notF
 	return id, true
 }
 
+// ParseNestedIDFromPath extracts the integer ID at the given segment index of the path after prefix.
+// For prefix "/api/products/" and path "/api/products/3/prices/7", index 0 yields 3 and index 2 yields 7.
+// Writes a 404 error and returns 0 and false on failure, like ParseIDFromPath.
+func ParseNestedIDFromPath(w http.ResponseWriter, r *http.Request, prefix string, index int, notFoundErr error) (int, bool) {
+	segments := strings.Split(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	if index >= len(segments) {
+		WriteError(w, r, http.StatusNotFound, notFoundErr.Error(), notFoundErr)
+		return 0, false
+	}
+	id, err := strconv.Atoi(segments[index])
+	if err != nil {
+		WriteError(w, r, http.StatusNotFound, notFoundErr.Error(), notFoundErr)
+		return 0, false
+	}
+	return id, true
+}
+
+// ActorFromRequest returns the user performing the request, taken from the X-User header.
+// Used for audit fields such as price history; empty when the client does not send it.
+func ActorFromRequest(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get("X-User"))
+}
+
+// RoleFromRequest returns the role of the user performing the request, taken from the X-Role header.
+// The API has no authentication, so any client can send any role: the role is advisory, a guard
+// against mistakes at the till rather than a security boundary. Empty when the client does not send it.
+func RoleFromRequest(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get("X-Role"))
+}
+
 // ValidatePayload decodes JSON payload from request body, validates struct tags, and closes the body.
 // Returns false if decoding or validation fails after writing an error response.
 func ValidatePayload(w http.ResponseWriter, r *http.Request, v any) bool {
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -440,3 +441,27 @@ func TestWriteJSON_EncodesNilCorrectly(t *testing.T) {
 		t.Errorf("WriteJSON should encode nil as null, got: %s", string(body))
 	}
 }
+
+func TestValidatePayload_ProductUnit(t *testing.T) {
+	tests := []struct {
+		body string
+		want bool
+	}{
+		{`{"unit":"kg"}`, true},
+		{`{}`, true},
+		{`{"unit":"ons"}`, false},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/products", bytes.NewBufferString(tt.body))
+		var input struct {
+			Unit string `json:"unit,omitempty" validate:"omitempty,unit"`
+		}
+		if got := ValidatePayload(rr, req, &input); got != tt.want {
+			t.Errorf("ValidatePayload(%s) = %v, want %v", tt.body, got, tt.want)
+		}
+		if !tt.want && !strings.Contains(rr.Body.String(), "unit must be one of [pcs kg l]") {
+			t.Errorf("Invalid unit should name the valid units, got: %s", rr.Body.String())
+		}
+	}
+}
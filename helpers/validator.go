@@ -4,9 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+
+	model "kasir-api/models"
 )
 
 // validate is the package-level validator instance.
@@ -24,6 +27,13 @@ func newValidator() *validator.Validate {
 		return name
 	})
 
+	// unit accepts the product units (pcs, kg, l).
+	if err := v.RegisterValidation("unit", func(fl validator.FieldLevel) bool {
+		return slices.Contains(model.ProductUnits, fl.Field().String())
+	}); err != nil {
+		panic(err)
+	}
+
 	return v
 }
 
@@ -67,6 +77,14 @@ func formatFieldError(fe validator.FieldError) string {
 		return fmt.Sprintf("%s must have at most %s items", field, fe.Param())
 	case "oneof":
 		return fmt.Sprintf("%s must be one of [%s]", field, fe.Param())
+	case "required_without_all":
+		return fmt.Sprintf("%s is required unless one of [%s] is set", field, strings.ToLower(fe.Param()))
+	case "required_without":
+		return fmt.Sprintf("%s is required unless %s is set", field, strings.ToLower(fe.Param()))
+	case "number":
+		return fmt.Sprintf("%s must contain only digits", field)
+	case "unit":
+		return fmt.Sprintf("%s must be one of [%s]", field, strings.Join(model.ProductUnits, " "))
 	default:
 		return fmt.Sprintf("%s is invalid", field)
 	}
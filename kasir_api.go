@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -13,11 +14,13 @@ import (
 	handler "kasir-api/handlers"
 	"kasir-api/helpers/logger"
 	"kasir-api/middleware"
+	model "kasir-api/models"
 	repository "kasir-api/repositories"
 	"kasir-api/repositories/memory"
 	"kasir-api/repositories/postgres"
 	"kasir-api/router"
 	service "kasir-api/services"
+	"kasir-api/storage"
 )
 
 func main() {
@@ -31,6 +34,8 @@ func main() {
 	var productRepo repository.ProductRepository
 	var categoryRepo repository.CategoryRepository
 	var transactionRepo repository.TransactionRepository
+	var ingredientRepo repository.IngredientRepository
+	var outletRepo repository.OutletRepository
 	var pgDB *postgres.DB
 
 	if cfg.DB.Enabled {
@@ -44,37 +49,81 @@ func main() {
 		productRepo = postgres.NewProductRepository(pgDB)
 		categoryRepo = postgres.NewCategoryRepository(pgDB)
 		transactionRepo = postgres.NewTransactionRepository(pgDB)
+		ingredientRepo = postgres.NewIngredientRepository(pgDB)
+		outletRepo = postgres.NewOutletRepository(pgDB)
 	} else {
 		logger.Info("Using in-memory storage")
 		categoryRepo = memory.NewCategoryRepository()
-		productRepo = memory.NewProductRepository(categoryRepo)
-		transactionRepo = memory.NewTransactionRepository()
+		memProducts := memory.NewProductRepository(categoryRepo)
+		productRepo = memProducts
+		memIngredients := memory.NewIngredientRepository()
+		ingredientRepo = memIngredients
+		transactionRepo = memory.NewTransactionRepository(memProducts, memIngredients)
+		outletRepo = memory.NewOutletRepository(memProducts)
 	}
 
 	// Service layer (logic)
 	productService := service.NewProductService(productRepo, categoryRepo)
 	categoryService := service.NewCategoryService(categoryRepo)
 	transactionService := service.NewTransactionService(transactionRepo, productRepo)
+	transactionService.SetIngredientRepository(ingredientRepo)
+	transactionService.SetCategoryRepository(categoryRepo)
+	transactionService.SetCustomItemPolicy(model.CustomItemPolicy{
+		Enabled: cfg.Checkout.CustomItems,
+		Roles:   cfg.Checkout.CustomItemRoles,
+	})
+	ingredientService := service.NewIngredientService(ingredientRepo, productRepo)
+	transactionService.SetLocation(cfg.Store.Location)
+	ingredientService.SetLocation(cfg.Store.Location)
+	outletService := service.NewOutletService(outletRepo, productRepo)
+
+	if cfg.Scale.BarcodeRules != "" {
+		scaleRules, err := model.ParseScaleBarcodeRules(cfg.Scale.BarcodeRules)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		productService.SetScaleBarcodeRules(scaleRules)
+		transactionService.SetScaleBarcodeRules(scaleRules)
+	}
+
+	imageStore, err := storage.NewLocalStore(cfg.Media.Dir, cfg.Media.BaseURL)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	productService.SetImageStore(imageStore, cfg.Media.MaxUploadBytes)
 
 	// Handler layer (request/response)
 	productHandler := handler.NewProductHandler(productService)
 	categoryHandler := handler.NewCategoryHandler(categoryService)
 	transactionHandler := handler.NewTransactionHandler(transactionService)
+	ingredientHandler := handler.NewIngredientHandler(ingredientService)
+	outletHandler := handler.NewOutletHandler(outletService)
 
 	rt := router.NewRouter(productHandler, categoryHandler, transactionHandler)
+	rt.SetIngredientHandler(ingredientHandler)
+	rt.SetOutletHandler(outletHandler)
 
 	if pgDB != nil {
 		rt.SetHealthChecker(pgDB)
 	}
+	if strings.HasPrefix(cfg.Media.BaseURL, "/") {
+		rt.SetMediaHandler(cfg.Media.BaseURL, imageStore)
+	}
+
+	// Uploads get a larger body limit than the 1MB default.
+	bodyLimit := middleware.BodyLimit(1<<20,
+		middleware.BodyLimitRule{Method: http.MethodPost, Pattern: "/api/products/import", MaxBytes: 10 << 20},
+		middleware.BodyLimitRule{Method: http.MethodPost, Pattern: "/api/products/*/images", MaxBytes: cfg.Media.MaxUploadBytes + 1<<20},
+	)
 
 	// Apply middleware (outermost runs first).
 	limiter := middleware.NewRateLimiter(cfg.RateLimit.Rate, cfg.RateLimit.Burst)
 	var mux http.Handler = rt
-	mux = middleware.RequestLogger(mux)      // log after request completes
-	mux = middleware.BodyLimit(1 << 20)(mux) // 1MB max request body
-	mux = limiter.Limit(mux)                 // rate limit before processing
-	mux = middleware.RequestID(mux)          // assign request ID first
-	mux = middleware.RecoverPanic(mux)       // recover from panics
+	mux = middleware.RequestLogger(mux) // log after request completes
+	mux = bodyLimit(mux)                // 1MB max request body, per-route overrides
+	mux = limiter.Limit(mux)            // rate limit before processing
+	mux = middleware.RequestID(mux)     // assign request ID first
+	mux = middleware.RecoverPanic(mux)  // recover from panics
 
 	port := cfg.Server.Port
 
@@ -86,6 +135,13 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Apply scheduled prices in the background, so product reads never write.
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go productService.RunPriceScheduler(schedulerCtx, cfg.Prices.ScheduleInterval, func(err error) {
+		logger.Error("Failed to apply scheduled prices: %v", err)
+	})
+
 	// Start server in a goroutine
 	go func() {
 		logger.Info("Listening on port %s", port)
@@ -94,20 +150,53 @@ func main() {
 		logger.Info("  GET     /docs                (Swagger UI)")
 		logger.Info("  GET     /docs/openapi.yaml   (OpenAPI spec)")
 		logger.Info("  GET     /health")
-		logger.Info("  GET     /api/products")
+		logger.Info("  GET     /api/products?name=&category_id=&min_price=&max_price=&in_stock=&sort=&order=")
+		logger.Info("  GET     /api/products/search?q=&limit=")
+		logger.Info("  GET     /api/products/scan?barcode=")
 		logger.Info("  POST    /api/products")
+		logger.Info("  POST    /api/products/import?dry_run=true&create_categories=true")
+		logger.Info("  POST    /api/products/bulk?dry_run=true")
+		logger.Info("  GET     /api/products/export?format=csv|xlsx|ndjson")
 		logger.Info("  GET     /api/products/{id}")
 		logger.Info("  PUT     /api/products/{id}")
+		logger.Info("  PATCH   /api/products/{id}")
 		logger.Info("  DELETE  /api/products/{id}")
-		logger.Info("  GET     /api/categories")
+		logger.Info("  POST    /api/products/{id}/restore")
+		logger.Info("  POST    /api/products/{id}/images")
+		logger.Info("  DELETE  /api/products/{id}/images")
+		logger.Info("  GET     /api/products/{id}/prices?status=upcoming|past")
+		logger.Info("  POST    /api/products/{id}/prices")
+		logger.Info("  DELETE  /api/products/{id}/prices/{changeId}")
+		logger.Info("  GET     /api/products/{id}/recipe")
+		logger.Info("  PUT     /api/products/{id}/recipe")
+		logger.Info("  GET     /api/categories?include_archived=true")
 		logger.Info("  POST    /api/categories")
 		logger.Info("  GET     /api/categories/{id}")
 		logger.Info("  PUT     /api/categories/{id}")
+		logger.Info("  PATCH   /api/categories/{id}")
 		logger.Info("  DELETE  /api/categories/{id}")
+		logger.Info("  POST    /api/categories/{id}/restore")
+		logger.Info("  GET     /api/ingredients")
+		logger.Info("  POST    /api/ingredients")
+		logger.Info("  GET     /api/ingredients/{id}")
+		logger.Info("  PUT     /api/ingredients/{id}")
+		logger.Info("  GET     /api/ingredients/{id}/movements")
+		logger.Info("  POST    /api/ingredients/{id}/movements")
+		logger.Info("  GET     /api/outlets")
+		logger.Info("  POST    /api/outlets")
+		logger.Info("  GET     /api/outlets/{id}")
+		logger.Info("  GET     /api/outlets/{id}/stock")
+		logger.Info("  GET     /api/outlets/transfers")
+		logger.Info("  POST    /api/outlets/transfers")
 		logger.Info("  POST    /api/checkout")
 		logger.Info("  GET     /api/transactions/{id}")
-		logger.Info("  GET     /api/report/hari-ini")
-		logger.Info("  GET     /api/report?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD")
+		logger.Info("  GET     /api/report/hari-ini?outlet_id=")
+		logger.Info("  GET     /api/report?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&outlet_id=")
+		logger.Info("  GET     /api/report/timeseries?start_date=&end_date=&granularity=hour|day|week|month&outlet_id=")
+		logger.Info("  GET     /api/report/categories?start_date=&end_date=&outlet_id=")
+		logger.Info("  GET     /api/report/products?start_date=&end_date=&sort=qty|revenue&order=desc|asc&limit=&outlet_id=")
+		logger.Info("  GET     /api/report/heatmap?start_date=&end_date=&outlet_id=")
+		logger.Info("  GET     /api/report/ingredients?start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&outlet_id=")
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Fatal(err)
@@ -2,23 +2,55 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 
 	helper "kasir-api/helpers"
 )
 
+// BodyLimitRule overrides the default body limit for one route, e.g. file uploads.
+// Pattern is a path where "*" matches exactly one segment: "/api/products/*/images".
+type BodyLimitRule struct {
+	Method   string
+	Pattern  string
+	MaxBytes int64
+}
+
 // BodyLimit restricts the maximum request body size.
 // Prevents memory exhaustion attacks from oversized payloads.
-func BodyLimit(maxBytes int64) func(http.Handler) http.Handler {
+// The first rule matching the request's method and path replaces maxBytes.
+func BodyLimit(maxBytes int64, rules ...BodyLimitRule) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.Body != nil {
-				r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+				limit := maxBytes
+				for _, rule := range rules {
+					if rule.Method == r.Method && matchPathPattern(rule.Pattern, r.URL.Path) {
+						limit = rule.MaxBytes
+						break
+					}
+				}
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
 			}
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// matchPathPattern reports whether path matches pattern segment by segment, "*" matching any one segment.
+func matchPathPattern(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+	for i, segment := range patternSegments {
+		if segment != "*" && segment != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // RecoverPanic recovers from panics and returns a 500 error.
 func RecoverPanic(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"sort"
 	"time"
 
 	model "kasir-api/models"
@@ -8,13 +9,16 @@ import (
 
 // MockCategoryRepository is a mock implementation of repository.CategoryRepository.
 type MockCategoryRepository struct {
-	Categories  map[int]*model.Category
-	NextID      int
-	GetAllFunc  func() ([]*model.Category, error)
-	GetByIDFunc func(id int) (*model.Category, error)
-	CreateFunc  func(category *model.Category) error
-	UpdateFunc  func(category *model.Category) error
-	DeleteFunc  func(id int) error
+	Categories           map[int]*model.Category
+	NextID               int
+	GetAllFunc           func(includeArchived bool) ([]*model.Category, error)
+	GetPageFunc          func(includeArchived bool, page model.PageRequest) (*model.Page[*model.Category], error)
+	GetByIDFunc          func(id int) (*model.Category, error)
+	CreateFunc           func(category *model.Category) error
+	UpdateFunc           func(category *model.Category) error
+	ArchiveFunc          func(id int, policy model.CategoryProductPolicy, version int) error
+	RestoreFunc          func(id int) error
+	GetDescendantIDsFunc func(id int) ([]int, error)
 }
 
 func NewMockCategoryRepository() *MockCategoryRepository {
@@ -24,17 +28,41 @@ func NewMockCategoryRepository() *MockCategoryRepository {
 	}
 }
 
-func (m *MockCategoryRepository) GetAll() ([]*model.Category, error) {
+func (m *MockCategoryRepository) GetAll(includeArchived bool) ([]*model.Category, error) {
 	if m.GetAllFunc != nil {
-		return m.GetAllFunc()
+		return m.GetAllFunc(includeArchived)
 	}
 	categories := make([]*model.Category, 0, len(m.Categories))
 	for _, c := range m.Categories {
+		if c.IsArchived() && !includeArchived {
+			continue
+		}
 		categories = append(categories, c)
 	}
 	return categories, nil
 }
 
+func (m *MockCategoryRepository) GetPage(includeArchived bool, page model.PageRequest) (*model.Page[*model.Category], error) {
+	if m.GetPageFunc != nil {
+		return m.GetPageFunc(includeArchived, page)
+	}
+	categories, err := m.GetAll(includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	start := min(page.Offset(), len(categories))
+	if page.After != nil {
+		start = sort.Search(len(categories), func(i int) bool { return categories[i].ID > page.After.ID })
+	}
+	end := min(start+page.Limit, len(categories))
+	result := &model.Page[*model.Category]{Items: categories[start:end], Total: len(categories)}
+	if end < len(categories) && end > start {
+		result.Next = &model.Cursor{ID: categories[end-1].ID}
+	}
+	return result, nil
+}
+
 func (m *MockCategoryRepository) GetByID(id int) (*model.Category, error) {
 	if m.GetByIDFunc != nil {
 		return m.GetByIDFunc(id)
@@ -67,26 +95,78 @@ func (m *MockCategoryRepository) Update(category *model.Category) error {
 	return nil
 }
 
-func (m *MockCategoryRepository) Delete(id int) error {
-	if m.DeleteFunc != nil {
-		return m.DeleteFunc(id)
+func (m *MockCategoryRepository) Archive(id int, policy model.CategoryProductPolicy, version int) error {
+	if m.ArchiveFunc != nil {
+		return m.ArchiveFunc(id, policy, version)
 	}
-	if _, exists := m.Categories[id]; !exists {
+	c, exists := m.Categories[id]
+	if !exists {
 		return model.ErrCategoryNotFound
 	}
-	delete(m.Categories, id)
+	now := time.Now()
+	c.ArchivedAt = &now
 	return nil
 }
 
+func (m *MockCategoryRepository) Restore(id int) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(id)
+	}
+	c, exists := m.Categories[id]
+	if !exists {
+		return model.ErrCategoryNotFound
+	}
+	c.ArchivedAt = nil
+	return nil
+}
+
+func (m *MockCategoryRepository) GetDescendantIDs(id int) ([]int, error) {
+	if m.GetDescendantIDsFunc != nil {
+		return m.GetDescendantIDsFunc(id)
+	}
+	if _, exists := m.Categories[id]; !exists {
+		return nil, model.ErrCategoryNotFound
+	}
+	var descendants []int
+	queue := []int{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, c := range m.Categories {
+			if c.ParentID != nil && *c.ParentID == current && c.ID != id {
+				descendants = append(descendants, c.ID)
+				queue = append(queue, c.ID)
+			}
+		}
+	}
+	return descendants, nil
+}
+
 // MockProductRepository is a mock implementation of repository.ProductRepository.
 type MockProductRepository struct {
-	Products    map[int]*model.Product
-	NextID      int
-	GetAllFunc  func(name string) ([]*model.Product, error)
-	GetByIDFunc func(id int) (*model.Product, error)
-	CreateFunc  func(product *model.Product) error
-	UpdateFunc  func(product *model.Product) error
-	DeleteFunc  func(id int) error
+	Products                 map[int]*model.Product
+	PriceChanges             []*model.PriceChange
+	NextID                   int
+	GetAllFunc               func(filter model.ProductFilter) ([]*model.Product, error)
+	GetPageFunc              func(filter model.ProductFilter, page model.PageRequest) (*model.Page[*model.Product], error)
+	GetByIDFunc              func(id int) (*model.Product, error)
+	CreateFunc               func(product *model.Product) error
+	UpdateFunc               func(product *model.Product) error
+	ArchiveFunc              func(id, version int) error
+	RestoreFunc              func(id int) error
+	ForEachFunc              func(filter model.ProductFilter, fn func(p *model.Product) error) error
+	GetBySKUFunc             func(sku string) (*model.Product, error)
+	GetByPLUFunc             func(plu string) (*model.Product, error)
+	ImportFunc               func(batch *model.ProductImportBatch) error
+	ApplyBulkFunc            func(batch *model.ProductBulkBatch) error
+	IsBundleComponentFunc    func(productID int) (bool, error)
+	GetOutletStockFunc       func(productID, outletID int) (int, error)
+	GetNegativeStockFunc     func(outletID int) ([]*model.OutletStock, error)
+	ReplaceImageFunc         func(id int, imageKey string) (string, error)
+	GetPriceChangesFunc      func(productID int, query model.PriceChangeQuery, page model.PageRequest) (*model.Page[*model.PriceChange], error)
+	CreatePriceChangeFunc    func(change *model.PriceChange) error
+	DeletePriceChangeFunc    func(productID, id int) error
+	ApplyDuePriceChangesFunc func(now time.Time) error
 }
 
 func NewMockProductRepository() *MockProductRepository {
@@ -96,17 +176,42 @@ func NewMockProductRepository() *MockProductRepository {
 	}
 }
 
-func (m *MockProductRepository) GetAll(name string) ([]*model.Product, error) {
+func (m *MockProductRepository) GetAll(filter model.ProductFilter) ([]*model.Product, error) {
 	if m.GetAllFunc != nil {
-		return m.GetAllFunc(name)
+		return m.GetAllFunc(filter)
 	}
 	products := make([]*model.Product, 0, len(m.Products))
 	for _, p := range m.Products {
+		if p.IsArchived() && !filter.IncludeArchived {
+			continue
+		}
 		products = append(products, p)
 	}
 	return products, nil
 }
 
+// GetPage pages through products in ID order; the filter's sort is ignored.
+func (m *MockProductRepository) GetPage(filter model.ProductFilter, page model.PageRequest) (*model.Page[*model.Product], error) {
+	if m.GetPageFunc != nil {
+		return m.GetPageFunc(filter, page)
+	}
+	products, err := m.GetAll(filter)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	start := min(page.Offset(), len(products))
+	if page.After != nil {
+		start = sort.Search(len(products), func(i int) bool { return products[i].ID > page.After.ID })
+	}
+	end := min(start+page.Limit, len(products))
+	result := &model.Page[*model.Product]{Items: products[start:end], Total: len(products)}
+	if end < len(products) && end > start {
+		result.Next = &model.Cursor{ID: products[end-1].ID}
+	}
+	return result, nil
+}
+
 func (m *MockProductRepository) GetByID(id int) (*model.Product, error) {
 	if m.GetByIDFunc != nil {
 		return m.GetByIDFunc(id)
@@ -139,24 +244,235 @@ func (m *MockProductRepository) Update(product *model.Product) error {
 	return nil
 }
 
-func (m *MockProductRepository) Delete(id int) error {
-	if m.DeleteFunc != nil {
-		return m.DeleteFunc(id)
+func (m *MockProductRepository) Archive(id, version int) error {
+	if m.ArchiveFunc != nil {
+		return m.ArchiveFunc(id, version)
+	}
+	p, exists := m.Products[id]
+	if !exists {
+		return model.ErrProductNotFound
+	}
+	now := time.Now()
+	p.ArchivedAt = &now
+	return nil
+}
+
+func (m *MockProductRepository) Restore(id int) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(id)
+	}
+	p, exists := m.Products[id]
+	if !exists {
+		return model.ErrProductNotFound
+	}
+	p.ArchivedAt = nil
+	return nil
+}
+
+func (m *MockProductRepository) ForEach(filter model.ProductFilter, fn func(p *model.Product) error) error {
+	if m.ForEachFunc != nil {
+		return m.ForEachFunc(filter, fn)
+	}
+	products, err := m.GetAll(filter)
+	if err != nil {
+		return err
+	}
+	sort.Slice(products, func(i, j int) bool { return products[i].ID < products[j].ID })
+	for _, p := range products {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockProductRepository) GetBySKU(sku string) (*model.Product, error) {
+	if m.GetBySKUFunc != nil {
+		return m.GetBySKUFunc(sku)
 	}
-	if _, exists := m.Products[id]; !exists {
+	for _, p := range m.Products {
+		if p.SKU != "" && p.SKU == sku {
+			return p, nil
+		}
+	}
+	return nil, model.ErrProductNotFound
+}
+
+func (m *MockProductRepository) GetByPLU(plu string) (*model.Product, error) {
+	if m.GetByPLUFunc != nil {
+		return m.GetByPLUFunc(plu)
+	}
+	for _, p := range m.Products {
+		if p.PLU != "" && p.PLU == plu {
+			return p, nil
+		}
+	}
+	return nil, model.ErrProductNotFound
+}
+
+func (m *MockProductRepository) ApplyBulk(batch *model.ProductBulkBatch) error {
+	if m.ApplyBulkFunc != nil {
+		return m.ApplyBulkFunc(batch)
+	}
+	for _, p := range batch.Products {
+		if _, exists := m.Products[p.ID]; !exists {
+			return model.ErrProductNotFound
+		}
+	}
+	now := time.Now()
+	for _, p := range batch.Products {
+		if batch.Archive {
+			m.Products[p.ID].ArchivedAt = &now
+			continue
+		}
+		m.Products[p.ID] = p
+	}
+	return nil
+}
+
+func (m *MockProductRepository) Import(batch *model.ProductImportBatch) error {
+	if m.ImportFunc != nil {
+		return m.ImportFunc(batch)
+	}
+	for _, p := range batch.Products {
+		if p.ID > 0 {
+			m.Products[p.ID] = p
+			continue
+		}
+		p.ID = m.NextID
+		m.Products[p.ID] = p
+		m.NextID++
+	}
+	return nil
+}
+
+// GetOutletStock treats the mock as a single outlet holding all stock.
+func (m *MockProductRepository) GetOutletStock(productID, outletID int) (int, error) {
+	if m.GetOutletStockFunc != nil {
+		return m.GetOutletStockFunc(productID, outletID)
+	}
+	p, exists := m.Products[productID]
+	if !exists {
+		return 0, model.ErrProductNotFound
+	}
+	if outletID != model.DefaultOutletID {
+		return 0, model.ErrOutletNotFound
+	}
+	return p.Stock, nil
+}
+
+// GetNegativeStock reports the products with negative stock, all held at the default outlet.
+func (m *MockProductRepository) GetNegativeStock(outletID int) ([]*model.OutletStock, error) {
+	if m.GetNegativeStockFunc != nil {
+		return m.GetNegativeStockFunc(outletID)
+	}
+	negative := make([]*model.OutletStock, 0)
+	if outletID != 0 && outletID != model.DefaultOutletID {
+		return negative, nil
+	}
+	for _, p := range m.Products {
+		if p.Stock < 0 {
+			negative = append(negative, &model.OutletStock{
+				OutletID: model.DefaultOutletID, ProductID: p.ID, ProductName: p.Name, Stock: p.Stock,
+			})
+		}
+	}
+	sort.Slice(negative, func(a, b int) bool { return negative[a].ProductID < negative[b].ProductID })
+	return negative, nil
+}
+
+func (m *MockProductRepository) IsBundleComponent(productID int) (bool, error) {
+	if m.IsBundleComponentFunc != nil {
+		return m.IsBundleComponentFunc(productID)
+	}
+	for _, p := range m.Products {
+		for _, c := range p.Components {
+			if c.ProductID == productID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func (m *MockProductRepository) ReplaceImage(id int, imageKey string) (string, error) {
+	if m.ReplaceImageFunc != nil {
+		return m.ReplaceImageFunc(id, imageKey)
+	}
+	p, exists := m.Products[id]
+	if !exists {
+		return "", model.ErrProductNotFound
+	}
+	previous := p.ImageKey
+	p.ImageKey = imageKey
+	return previous, nil
+}
+
+// GetPriceChanges returns the changes matching query as a single page, in recording order.
+func (m *MockProductRepository) GetPriceChanges(productID int, query model.PriceChangeQuery, page model.PageRequest) (*model.Page[*model.PriceChange], error) {
+	if m.GetPriceChangesFunc != nil {
+		return m.GetPriceChangesFunc(productID, query, page)
+	}
+	changes := make([]*model.PriceChange, 0)
+	for _, c := range m.PriceChanges {
+		if c.ProductID != productID {
+			continue
+		}
+		if query.Status != "" && (query.Status == model.PriceChangeUpcoming) != c.IsUpcoming(query.Now) {
+			continue
+		}
+		changes = append(changes, c)
+	}
+	return &model.Page[*model.PriceChange]{Items: changes, Total: len(changes)}, nil
+}
+
+func (m *MockProductRepository) CreatePriceChange(change *model.PriceChange) error {
+	if m.CreatePriceChangeFunc != nil {
+		return m.CreatePriceChangeFunc(change)
+	}
+	if _, exists := m.Products[change.ProductID]; !exists {
 		return model.ErrProductNotFound
 	}
-	delete(m.Products, id)
+	change.ID = len(m.PriceChanges) + 1
+	m.PriceChanges = append(m.PriceChanges, change)
+	return nil
+}
+
+func (m *MockProductRepository) DeletePriceChange(productID, id int) error {
+	if m.DeletePriceChangeFunc != nil {
+		return m.DeletePriceChangeFunc(productID, id)
+	}
+	for i, c := range m.PriceChanges {
+		if c.ID == id && c.ProductID == productID {
+			m.PriceChanges = append(m.PriceChanges[:i], m.PriceChanges[i+1:]...)
+			return nil
+		}
+	}
+	return model.ErrPriceChangeNotFound
+}
+
+func (m *MockProductRepository) ApplyDuePriceChanges(now time.Time) error {
+	if m.ApplyDuePriceChangesFunc != nil {
+		return m.ApplyDuePriceChangesFunc(now)
+	}
 	return nil
 }
 
 // MockTransactionRepository is a mock implementation of repository.TransactionRepository.
+// Checkout takes stock from Products and records movements in Ingredients when they are set.
 type MockTransactionRepository struct {
 	Transactions             map[int]*model.Transaction
 	NextID                   int
+	Products                 *MockProductRepository
+	Ingredients              *MockIngredientRepository
 	CreateFunc               func(transaction *model.Transaction) error
+	CheckoutFunc             func(batch *model.CheckoutBatch) error
 	GetByIDFunc              func(id int) (*model.Transaction, error)
-	GetReportByDateRangeFunc func(startDate, endDate time.Time) (*model.ReportResponse, error)
+	GetReportByDateRangeFunc func(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error)
+	GetTimeSeriesFunc        func(startDate, endDate time.Time, granularity string, outletID int) (*model.TimeSeriesReport, error)
+	GetCategorySalesFunc     func(startDate, endDate time.Time, outletID int) ([]*model.CategorySales, error)
+	GetProductSalesFunc      func(startDate, endDate time.Time, outletID int, query model.ProductSalesQuery) ([]*model.ProductSales, error)
+	GetHeatmapFunc           func(startDate, endDate time.Time, loc *time.Location, outletID int) (*model.SalesHeatmap, error)
 }
 
 func NewMockTransactionRepository() *MockTransactionRepository {
@@ -176,6 +492,42 @@ func (m *MockTransactionRepository) Create(transaction *model.Transaction) error
 	return nil
 }
 
+// Checkout treats the linked product mock as a single outlet holding all stock.
+func (m *MockTransactionRepository) Checkout(batch *model.CheckoutBatch) error {
+	if m.CheckoutFunc != nil {
+		return m.CheckoutFunc(batch)
+	}
+	takes := batch.Transaction.StockTakes()
+	if m.Products != nil {
+		for _, take := range takes {
+			p, exists := m.Products.Products[take.ProductID]
+			if !exists {
+				return model.ErrProductNotFound
+			}
+			if !p.IsBundle() && p.TracksStock() && p.Stock < take.Quantity && !p.AllowNegativeStock {
+				return model.ErrInsufficientStock
+			}
+		}
+	}
+	if err := m.Create(batch.Transaction); err != nil {
+		return err
+	}
+	if m.Products != nil {
+		for _, take := range takes {
+			if p := m.Products.Products[take.ProductID]; !p.IsBundle() && p.TracksStock() {
+				p.Stock -= take.Quantity
+			}
+		}
+	}
+	for _, mv := range batch.Movements {
+		mv.TransactionID = &batch.Transaction.ID
+	}
+	if m.Ingredients != nil {
+		return m.Ingredients.RecordMovements(batch.Movements)
+	}
+	return nil
+}
+
 func (m *MockTransactionRepository) GetByID(id int) (*model.Transaction, error) {
 	if m.GetByIDFunc != nil {
 		return m.GetByIDFunc(id)
@@ -187,9 +539,247 @@ func (m *MockTransactionRepository) GetByID(id int) (*model.Transaction, error)
 	return t, nil
 }
 
-func (m *MockTransactionRepository) GetReportByDateRange(startDate, endDate time.Time) (*model.ReportResponse, error) {
+func (m *MockTransactionRepository) GetReportByDateRange(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
 	if m.GetReportByDateRangeFunc != nil {
-		return m.GetReportByDateRangeFunc(startDate, endDate)
+		return m.GetReportByDateRangeFunc(startDate, endDate, outletID)
 	}
 	return &model.ReportResponse{}, nil
 }
+
+func (m *MockTransactionRepository) GetTimeSeries(startDate, endDate time.Time, granularity string, outletID int) (*model.TimeSeriesReport, error) {
+	if m.GetTimeSeriesFunc != nil {
+		return m.GetTimeSeriesFunc(startDate, endDate, granularity, outletID)
+	}
+	return model.NewTimeSeriesReport(startDate, endDate, granularity)
+}
+
+func (m *MockTransactionRepository) GetCategorySales(startDate, endDate time.Time, outletID int) ([]*model.CategorySales, error) {
+	if m.GetCategorySalesFunc != nil {
+		return m.GetCategorySalesFunc(startDate, endDate, outletID)
+	}
+	return []*model.CategorySales{}, nil
+}
+
+func (m *MockTransactionRepository) GetProductSales(startDate, endDate time.Time, outletID int, query model.ProductSalesQuery) ([]*model.ProductSales, error) {
+	if m.GetProductSalesFunc != nil {
+		return m.GetProductSalesFunc(startDate, endDate, outletID, query)
+	}
+	return []*model.ProductSales{}, nil
+}
+
+func (m *MockTransactionRepository) GetHeatmap(startDate, endDate time.Time, loc *time.Location, outletID int) (*model.SalesHeatmap, error) {
+	if m.GetHeatmapFunc != nil {
+		return m.GetHeatmapFunc(startDate, endDate, loc, outletID)
+	}
+	return model.NewSalesHeatmap(loc), nil
+}
+
+// MockIngredientRepository is a mock implementation of repository.IngredientRepository.
+type MockIngredientRepository struct {
+	Ingredients         map[int]*model.Ingredient
+	Recipes             map[int][]model.RecipeItem
+	Movements           []*model.IngredientMovement
+	NextID              int
+	GetPageFunc         func(page model.PageRequest) (*model.Page[*model.Ingredient], error)
+	GetByIDFunc         func(id int) (*model.Ingredient, error)
+	CreateFunc          func(ingredient *model.Ingredient) error
+	UpdateFunc          func(ingredient *model.Ingredient) error
+	GetRecipeFunc       func(productID int) ([]model.RecipeItem, error)
+	SetRecipeFunc       func(productID int, items []model.RecipeItem) error
+	RecordMovementsFunc func(movements []*model.IngredientMovement) error
+	GetMovementsFunc    func(ingredientID int, page model.PageRequest) (*model.Page[*model.IngredientMovement], error)
+	GetUsageFunc        func(startDate, endDate time.Time) ([]*model.IngredientUsage, error)
+}
+
+func NewMockIngredientRepository() *MockIngredientRepository {
+	return &MockIngredientRepository{
+		Ingredients: make(map[int]*model.Ingredient),
+		Recipes:     make(map[int][]model.RecipeItem),
+		NextID:      1,
+	}
+}
+
+// GetPage returns every ingredient in ID order as a single page.
+func (m *MockIngredientRepository) GetPage(page model.PageRequest) (*model.Page[*model.Ingredient], error) {
+	if m.GetPageFunc != nil {
+		return m.GetPageFunc(page)
+	}
+	ingredients := make([]*model.Ingredient, 0, len(m.Ingredients))
+	for _, i := range m.Ingredients {
+		ingredients = append(ingredients, i)
+	}
+	sort.Slice(ingredients, func(a, b int) bool { return ingredients[a].ID < ingredients[b].ID })
+	return &model.Page[*model.Ingredient]{Items: ingredients, Total: len(ingredients)}, nil
+}
+
+func (m *MockIngredientRepository) GetByID(id int) (*model.Ingredient, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	i, exists := m.Ingredients[id]
+	if !exists {
+		return nil, model.ErrIngredientNotFound
+	}
+	return i, nil
+}
+
+func (m *MockIngredientRepository) Create(ingredient *model.Ingredient) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(ingredient)
+	}
+	ingredient.ID = m.NextID
+	m.Ingredients[ingredient.ID] = ingredient
+	m.NextID++
+	return nil
+}
+
+func (m *MockIngredientRepository) Update(ingredient *model.Ingredient) error {
+	if m.UpdateFunc != nil {
+		return m.UpdateFunc(ingredient)
+	}
+	stored, exists := m.Ingredients[ingredient.ID]
+	if !exists {
+		return model.ErrIngredientNotFound
+	}
+	stored.Name, stored.Unit = ingredient.Name, ingredient.Unit
+	ingredient.Stock = stored.Stock
+	return nil
+}
+
+func (m *MockIngredientRepository) GetRecipe(productID int) ([]model.RecipeItem, error) {
+	if m.GetRecipeFunc != nil {
+		return m.GetRecipeFunc(productID)
+	}
+	if items, ok := m.Recipes[productID]; ok {
+		return items, nil
+	}
+	return []model.RecipeItem{}, nil
+}
+
+func (m *MockIngredientRepository) SetRecipe(productID int, items []model.RecipeItem) error {
+	if m.SetRecipeFunc != nil {
+		return m.SetRecipeFunc(productID, items)
+	}
+	m.Recipes[productID] = items
+	return nil
+}
+
+func (m *MockIngredientRepository) RecordMovements(movements []*model.IngredientMovement) error {
+	if m.RecordMovementsFunc != nil {
+		return m.RecordMovementsFunc(movements)
+	}
+	for _, mv := range movements {
+		if i, ok := m.Ingredients[mv.IngredientID]; ok {
+			if mv.Type == model.MovementCount {
+				mv.Quantity -= i.Stock
+			}
+			i.Stock += mv.Quantity
+		}
+	}
+	m.Movements = append(m.Movements, movements...)
+	return nil
+}
+
+// GetMovements returns every movement of the ingredient as a single page, in recording order.
+func (m *MockIngredientRepository) GetMovements(ingredientID int, page model.PageRequest) (*model.Page[*model.IngredientMovement], error) {
+	if m.GetMovementsFunc != nil {
+		return m.GetMovementsFunc(ingredientID, page)
+	}
+	movements := make([]*model.IngredientMovement, 0)
+	for _, mv := range m.Movements {
+		if mv.IngredientID == ingredientID {
+			movements = append(movements, mv)
+		}
+	}
+	return &model.Page[*model.IngredientMovement]{Items: movements, Total: len(movements)}, nil
+}
+
+func (m *MockIngredientRepository) GetUsage(startDate, endDate time.Time) ([]*model.IngredientUsage, error) {
+	if m.GetUsageFunc != nil {
+		return m.GetUsageFunc(startDate, endDate)
+	}
+	return []*model.IngredientUsage{}, nil
+}
+
+// MockOutletRepository is a mock implementation of repository.OutletRepository.
+type MockOutletRepository struct {
+	Outlets          map[int]*model.Outlet
+	Transfers        []*model.StockTransfer
+	NextID           int
+	GetPageFunc      func(page model.PageRequest) (*model.Page[*model.Outlet], error)
+	GetByIDFunc      func(id int) (*model.Outlet, error)
+	CreateFunc       func(outlet *model.Outlet) error
+	GetStockFunc     func(outletID int, page model.PageRequest) (*model.Page[*model.OutletStock], error)
+	TransferFunc     func(transfer *model.StockTransfer) error
+	GetTransfersFunc func(page model.PageRequest) (*model.Page[*model.StockTransfer], error)
+}
+
+// NewMockOutletRepository returns a mock holding only the default outlet.
+func NewMockOutletRepository() *MockOutletRepository {
+	return &MockOutletRepository{
+		Outlets: map[int]*model.Outlet{model.DefaultOutletID: {ID: model.DefaultOutletID, Name: "Outlet Utama"}},
+		NextID:  model.DefaultOutletID + 1,
+	}
+}
+
+// GetPage returns every outlet as a single page, ordered by ID.
+func (m *MockOutletRepository) GetPage(page model.PageRequest) (*model.Page[*model.Outlet], error) {
+	if m.GetPageFunc != nil {
+		return m.GetPageFunc(page)
+	}
+	outlets := make([]*model.Outlet, 0, len(m.Outlets))
+	for _, o := range m.Outlets {
+		outlets = append(outlets, o)
+	}
+	sort.Slice(outlets, func(a, b int) bool { return outlets[a].ID < outlets[b].ID })
+	return &model.Page[*model.Outlet]{Items: outlets, Total: len(outlets)}, nil
+}
+
+func (m *MockOutletRepository) GetByID(id int) (*model.Outlet, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(id)
+	}
+	o, exists := m.Outlets[id]
+	if !exists {
+		return nil, model.ErrOutletNotFound
+	}
+	return o, nil
+}
+
+func (m *MockOutletRepository) Create(outlet *model.Outlet) error {
+	if m.CreateFunc != nil {
+		return m.CreateFunc(outlet)
+	}
+	outlet.ID = m.NextID
+	m.Outlets[outlet.ID] = outlet
+	m.NextID++
+	return nil
+}
+
+func (m *MockOutletRepository) GetStock(outletID int, page model.PageRequest) (*model.Page[*model.OutletStock], error) {
+	if m.GetStockFunc != nil {
+		return m.GetStockFunc(outletID, page)
+	}
+	if _, exists := m.Outlets[outletID]; !exists {
+		return nil, model.ErrOutletNotFound
+	}
+	return &model.Page[*model.OutletStock]{Items: []*model.OutletStock{}}, nil
+}
+
+func (m *MockOutletRepository) Transfer(transfer *model.StockTransfer) error {
+	if m.TransferFunc != nil {
+		return m.TransferFunc(transfer)
+	}
+	transfer.ID = len(m.Transfers) + 1
+	transfer.Movements = model.NewTransferMovements(transfer)
+	m.Transfers = append(m.Transfers, transfer)
+	return nil
+}
+
+// GetTransfers returns every transfer as a single page, in recording order.
+func (m *MockOutletRepository) GetTransfers(page model.PageRequest) (*model.Page[*model.StockTransfer], error) {
+	if m.GetTransfersFunc != nil {
+		return m.GetTransfersFunc(page)
+	}
+	return &model.Page[*model.StockTransfer]{Items: m.Transfers, Total: len(m.Transfers)}, nil
+}
@@ -9,11 +9,12 @@ import (
 // MockCategoryService is a mock implementation for testing handlers.
 type MockCategoryService struct {
 	Categories  map[int]*model.Category
-	GetAllFunc  func() ([]*model.Category, error)
+	GetAllFunc  func(includeArchived bool) ([]*model.Category, error)
 	GetByIDFunc func(id int) (*model.Category, error)
 	CreateFunc  func(category *model.Category) (*model.Category, error)
 	UpdateFunc  func(id int, category *model.Category) (*model.Category, error)
-	DeleteFunc  func(id int) error
+	ArchiveFunc func(id int, policy model.CategoryProductPolicy) error
+	RestoreFunc func(id int) error
 }
 
 func NewMockCategoryService() *MockCategoryService {
@@ -22,12 +23,15 @@ func NewMockCategoryService() *MockCategoryService {
 	}
 }
 
-func (m *MockCategoryService) GetAll() ([]*model.Category, error) {
+func (m *MockCategoryService) GetAll(includeArchived bool) ([]*model.Category, error) {
 	if m.GetAllFunc != nil {
-		return m.GetAllFunc()
+		return m.GetAllFunc(includeArchived)
 	}
 	categories := make([]*model.Category, 0, len(m.Categories))
 	for _, c := range m.Categories {
+		if c.IsArchived() && !includeArchived {
+			continue
+		}
 		categories = append(categories, c)
 	}
 	return categories, nil
@@ -65,25 +69,40 @@ func (m *MockCategoryService) Update(id int, category *model.Category) (*model.C
 	return category, nil
 }
 
-func (m *MockCategoryService) Delete(id int) error {
-	if m.DeleteFunc != nil {
-		return m.DeleteFunc(id)
+func (m *MockCategoryService) Archive(id int, policy model.CategoryProductPolicy) error {
+	if m.ArchiveFunc != nil {
+		return m.ArchiveFunc(id, policy)
 	}
-	if _, exists := m.Categories[id]; !exists {
+	c, exists := m.Categories[id]
+	if !exists {
+		return model.ErrCategoryNotFound
+	}
+	now := time.Now()
+	c.ArchivedAt = &now
+	return nil
+}
+
+func (m *MockCategoryService) Restore(id int) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(id)
+	}
+	c, exists := m.Categories[id]
+	if !exists {
 		return model.ErrCategoryNotFound
 	}
-	delete(m.Categories, id)
+	c.ArchivedAt = nil
 	return nil
 }
 
 // MockProductService is a mock implementation for testing handlers.
 type MockProductService struct {
 	Products    map[int]*model.Product
-	GetAllFunc  func(name string) ([]*model.Product, error)
+	GetAllFunc  func(filter model.ProductFilter) ([]*model.Product, error)
 	GetByIDFunc func(id int) (*model.Product, error)
 	CreateFunc  func(product *model.Product) (*model.Product, error)
 	UpdateFunc  func(id int, product *model.Product) (*model.Product, error)
-	DeleteFunc  func(id int) error
+	ArchiveFunc func(id int) error
+	RestoreFunc func(id int) error
 }
 
 func NewMockProductService() *MockProductService {
@@ -92,12 +111,15 @@ func NewMockProductService() *MockProductService {
 	}
 }
 
-func (m *MockProductService) GetAll(name string) ([]*model.Product, error) {
+func (m *MockProductService) GetAll(filter model.ProductFilter) ([]*model.Product, error) {
 	if m.GetAllFunc != nil {
-		return m.GetAllFunc(name)
+		return m.GetAllFunc(filter)
 	}
 	products := make([]*model.Product, 0, len(m.Products))
 	for _, p := range m.Products {
+		if p.IsArchived() && !filter.IncludeArchived {
+			continue
+		}
 		products = append(products, p)
 	}
 	return products, nil
@@ -135,14 +157,28 @@ func (m *MockProductService) Update(id int, product *model.Product) (*model.Prod
 	return product, nil
 }
 
-func (m *MockProductService) Delete(id int) error {
-	if m.DeleteFunc != nil {
-		return m.DeleteFunc(id)
+func (m *MockProductService) Archive(id int) error {
+	if m.ArchiveFunc != nil {
+		return m.ArchiveFunc(id)
 	}
-	if _, exists := m.Products[id]; !exists {
+	p, exists := m.Products[id]
+	if !exists {
+		return model.ErrProductNotFound
+	}
+	now := time.Now()
+	p.ArchivedAt = &now
+	return nil
+}
+
+func (m *MockProductService) Restore(id int) error {
+	if m.RestoreFunc != nil {
+		return m.RestoreFunc(id)
+	}
+	p, exists := m.Products[id]
+	if !exists {
 		return model.ErrProductNotFound
 	}
-	delete(m.Products, id)
+	p.ArchivedAt = nil
 	return nil
 }
 
@@ -151,8 +187,8 @@ type MockTransactionService struct {
 	Transactions             map[int]*model.Transaction
 	CheckoutFunc             func(request *model.CheckoutRequest) (*model.Transaction, error)
 	GetByIDFunc              func(id int) (*model.Transaction, error)
-	GetTodayReportFunc       func() (*model.ReportResponse, error)
-	GetReportByDateRangeFunc func(startDate, endDate time.Time) (*model.ReportResponse, error)
+	GetTodayReportFunc       func(outletID int) (*model.ReportResponse, error)
+	GetReportByDateRangeFunc func(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error)
 }
 
 func NewMockTransactionService() *MockTransactionService {
@@ -185,16 +221,16 @@ func (m *MockTransactionService) GetByID(id int) (*model.Transaction, error) {
 	return t, nil
 }
 
-func (m *MockTransactionService) GetTodayReport() (*model.ReportResponse, error) {
+func (m *MockTransactionService) GetTodayReport(outletID int) (*model.ReportResponse, error) {
 	if m.GetTodayReportFunc != nil {
-		return m.GetTodayReportFunc()
+		return m.GetTodayReportFunc(outletID)
 	}
 	return &model.ReportResponse{}, nil
 }
 
-func (m *MockTransactionService) GetReportByDateRange(startDate, endDate time.Time) (*model.ReportResponse, error) {
+func (m *MockTransactionService) GetReportByDateRange(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
 	if m.GetReportByDateRangeFunc != nil {
-		return m.GetReportByDateRangeFunc(startDate, endDate)
+		return m.GetReportByDateRangeFunc(startDate, endDate, outletID)
 	}
 	return &model.ReportResponse{}, nil
 }
@@ -1,9 +1,52 @@
 package model
 
+import "time"
+
 // Category represents a category in the kasir system.
 // Model layer: definisi bentuk data.
+// ParentID is optional; categories form a tree such as "Minuman > Minuman Dingin > Soda".
 type Category struct {
-	ID          int    `json:"id"`
+	ID          int        `json:"id"`
+	Name        string     `json:"name" validate:"required"`
+	Description string     `json:"description"`
+	ParentID    *int       `json:"parent_id,omitempty" validate:"omitempty,gt=0"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	Version     int        `json:"version,omitempty"` // naik setiap kali kategori diubah, dipakai untuk ETag
+}
+
+// CategoryInput holds the editable fields of a category, the document a PATCH merge patch applies to.
+type CategoryInput struct {
 	Name        string `json:"name" validate:"required"`
 	Description string `json:"description"`
+	ParentID    *int   `json:"parent_id,omitempty" validate:"omitempty,gt=0"`
+}
+
+// NewCategoryInput returns the editable fields of c.
+func NewCategoryInput(c *Category) CategoryInput {
+	return CategoryInput{Name: c.Name, Description: c.Description, ParentID: c.ParentID}
+}
+
+// IsArchived reports whether the category has been archived (soft-deleted).
+func (c *Category) IsArchived() bool {
+	return c.ArchivedAt != nil
+}
+
+// CategoryNode is a category with its sub-categories, used for GET /api/categories?tree=true.
+type CategoryNode struct {
+	*Category
+	Children []*CategoryNode `json:"children"`
+}
+
+// Values for ?on_products= when archiving a category that still has active products.
+const (
+	OnProductsRestrict = "restrict" // default: refuse while products reference the category
+	OnProductsReassign = "reassign" // move the products to ReassignTo
+	OnProductsUnset    = "unset"    // clear the products' category
+)
+
+// CategoryProductPolicy decides what happens to the active products of a category being archived.
+// Archived products keep their category reference as history.
+type CategoryProductPolicy struct {
+	OnProducts string
+	ReassignTo int
 }
@@ -12,15 +12,100 @@ var (
 	ErrCategoryNotFound    = fmt.Errorf("category is not found: %w", ErrNotFound)
 	ErrProductNotFound     = fmt.Errorf("product is not found: %w", ErrNotFound)
 	ErrTransactionNotFound = fmt.Errorf("transaction is not found: %w", ErrNotFound)
+	ErrPriceChangeNotFound = fmt.Errorf("price change is not found: %w", ErrNotFound)
+	ErrImageNotFound       = fmt.Errorf("product image is not found: %w", ErrNotFound)
+	ErrIngredientNotFound  = fmt.Errorf("ingredient is not found: %w", ErrNotFound)
+	ErrOutletNotFound      = fmt.Errorf("outlet is not found: %w", ErrNotFound)
+
+	ErrParentCategoryNotFound = fmt.Errorf("parent category is not found: %w", ErrNotFound)
+	ErrTargetCategoryNotFound = fmt.Errorf("target category is not found: %w", ErrNotFound)
 
 	ErrNameRequired = errors.New("name should not be empty")
 	ErrPriceInvalid = errors.New("price must be greater than 0")
 	ErrStockInvalid = errors.New("stock must be greater than or equal to 0")
+	ErrUnitInvalid  = errors.New("unit must be one of pcs, kg, l")
+	ErrTypeInvalid  = errors.New("type must be one of stocked, non_stocked, service")
+	ErrNotStocked   = errors.New("product does not keep stock")
 	ErrIDRequired   = errors.New("id is required")
+	ErrSKUExists    = errors.New("sku is already used by another product")
+
+	// Archive errors.
+	ErrProductArchived  = errors.New("product is archived")
+	ErrCategoryArchived = errors.New("category is archived")
+
+	// Category hierarchy errors.
+	ErrParentCategoryArchived = errors.New("parent category is archived")
+	ErrCategoryCycle          = errors.New("category cannot be moved under itself or its descendants")
+	ErrCategoryHasChildren    = errors.New("category has active sub-categories")
+
+	// Category product policy errors.
+	ErrCategoryHasProducts    = errors.New("category still has products")
+	ErrInvalidProductPolicy   = errors.New("on_products must be restrict, reassign or unset")
+	ErrReassignTargetRequired = errors.New("reassign requires a different target category")
+	ErrTargetCategoryArchived = errors.New("target category is archived")
 
 	// Transaction errors.
 	ErrEmptyCheckout     = errors.New("checkout items cannot be empty")
 	ErrInvalidQuantity   = errors.New("quantity must be greater than 0")
 	ErrInsufficientStock = errors.New("insufficient stock")
 	ErrInvalidDateRange  = errors.New("invalid date range")
+
+	// Report errors.
+	ErrGranularityInvalid = errors.New("granularity must be one of hour, day, week, month")
+	ErrTooManyBuckets     = fmt.Errorf("%w: too many buckets, use a shorter range or a larger granularity", ErrInvalidDateRange)
+
+	// Custom item errors.
+	ErrCustomItemDisabled = errors.New("custom items are not enabled")
+	ErrCustomItemRole     = errors.New("role is not allowed to sell custom items")
+	ErrCustomItemInvalid  = errors.New("custom item needs a name and a price greater than 0")
+
+	// Concurrency errors.
+	ErrVersionMismatch = errors.New("version does not match, the resource was changed by someone else")
+
+	// Pagination errors.
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// Import and export errors.
+	ErrImportFormat  = errors.New("unsupported import format, use csv or xlsx")
+	ErrImportHeader  = errors.New("import file must have a header row with name, price and stock columns")
+	ErrImportInvalid = errors.New("import has invalid rows, nothing was imported")
+	ErrExportFormat  = errors.New("unsupported export format, use csv, xlsx or ndjson")
+
+	// Bundle errors.
+	ErrBundleInvalid = errors.New("invalid bundle components")
+	ErrBundleStock   = errors.New("stock of a bundle is derived from its components")
+
+	// Outlet errors.
+	ErrTransferSameOutlet = errors.New("a transfer needs two different outlets")
+	ErrOutletStock        = fmt.Errorf("%w at the outlet", ErrInsufficientStock)
+
+	// Ingredient and recipe errors.
+	ErrRecipeInvalid   = errors.New("invalid recipe")
+	ErrMovementInvalid = errors.New("restock and waste quantity must be greater than 0")
+
+	// Scale barcode errors.
+	ErrBarcodeInvalid    = errors.New("barcode must be 13 digits")
+	ErrBarcodeCheckDigit = errors.New("barcode check digit does not match")
+	ErrBarcodeNotScale   = errors.New("barcode is not a scale label")
+	ErrScaleRuleInvalid  = errors.New("invalid scale barcode rule")
+	ErrScaleLabelUnit    = errors.New("scale label does not fit the product unit")
+	ErrPLUExists         = errors.New("plu is already used by another product")
+	ErrPLUInvalid        = errors.New("plu must contain a non-zero digit")
+
+	// Bulk operation errors.
+	ErrBulkOperation = errors.New("operation must be set_price, adjust_price, set_category, adjust_stock or archive")
+	ErrBulkTarget    = errors.New("bulk operation needs either ids or a filter with category_id or name")
+	ErrBulkValue     = errors.New("invalid bulk operation value")
+	ErrBulkInvalid   = errors.New("bulk operation has invalid products, nothing was changed")
+	ErrBulkMatched   = errors.New("filter matches a different number of products than expected_matched, nothing was changed")
+
+	// Product image errors.
+	ErrImageTooLarge      = errors.New("image exceeds the maximum upload size")
+	ErrImageType          = errors.New("image must be a JPEG or PNG file")
+	ErrImageDimensions    = errors.New("image dimensions are too large")
+	ErrImageStoreDisabled = errors.New("image storage is not configured")
+
+	// Price change errors.
+	ErrEffectiveAtNotInFuture = errors.New("effective_at must be in the future")
+	ErrPriceChangeApplied     = errors.New("price change has already been applied")
 )
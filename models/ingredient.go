@@ -0,0 +1,94 @@
+package model
+
+import "time"
+
+// Ingredient is a raw material such as coffee beans, milk or cups. It is never sold itself;
+// recipes deduct it when the products that use it are sold.
+type Ingredient struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name" validate:"required"`
+	Unit      string    `json:"unit" validate:"required,max=16"` // misal g, ml, pcs
+	Stock     Quantity  `json:"stock" validate:"gte=0"`          // hanya diisi saat create, setelah itu lewat movement
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RecipeItem is how much of one ingredient a single unit of a product uses: one piece, or one
+// kilogram or liter of a product sold by weight or volume.
+type RecipeItem struct {
+	IngredientID int      `json:"ingredient_id" validate:"gt=0"`
+	Name         string   `json:"name,omitempty"`
+	Unit         string   `json:"unit,omitempty"`
+	Quantity     Quantity `json:"quantity" validate:"gt=0"`
+}
+
+// Usage returns how much of the ingredient a sold line uses. The line's quantity is in pieces, or in
+// grams or milliliters for unit kg or l, which are scaled to kilograms or liters and rounded to the
+// nearest thousandth.
+func (item RecipeItem) Usage(quantity int, unit string) Quantity {
+	if unit != UnitKilogram && unit != UnitLiter {
+		return item.Quantity.Mul(quantity)
+	}
+	return (item.Quantity.Mul(quantity) + QuantityScale/2) / QuantityScale
+}
+
+// RecipeInput is the request body for PUT /api/products/{id}/recipe; an empty list removes the recipe.
+type RecipeInput struct {
+	Items []RecipeItem `json:"items" validate:"dive"`
+}
+
+// Ingredient movement types. Sale movements come from checkout; the others are recorded by staff.
+const (
+	MovementSale    = "sale"    // dipakai oleh produk yang terjual, sesuai resep
+	MovementRestock = "restock" // bahan masuk
+	MovementWaste   = "waste"   // terbuang atau rusak
+	MovementCount   = "count"   // koreksi hasil stock opname
+)
+
+// IngredientMovement is one change to an ingredient's stock. Quantity is the signed change:
+// negative for sales and waste, positive for restocks, either way for a count correction.
+// Ingredient stock is shared by all outlets; only a sale movement belongs to an outlet, the one
+// of its transaction.
+type IngredientMovement struct {
+	ID            int       `json:"id"`
+	IngredientID  int       `json:"ingredient_id"`
+	Type          string    `json:"type"`
+	Quantity      Quantity  `json:"quantity"`
+	TransactionID *int      `json:"transaction_id,omitempty"`
+	OutletID      int       `json:"outlet_id,omitempty"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// IngredientMovementInput is the request body for POST /api/ingredients/{id}/movements.
+// Restock and waste take the amount that came in or was thrown away; count takes the counted stock,
+// and the difference with the recorded stock is booked.
+type IngredientMovementInput struct {
+	Type     string   `json:"type" validate:"required,oneof=restock waste count"`
+	Quantity Quantity `json:"quantity" validate:"gte=0"`
+	Note     string   `json:"note"`
+}
+
+// IngredientUsage compares what the recipes say was used in a period with what actually left stock.
+// Theoretical is the recipe usage of the products sold. Actual adds waste and stock count
+// corrections, so Variance (Actual - Theoretical) is the usage no sale accounts for.
+// Waste and counts belong to no outlet, so the usage is only reported across all outlets.
+type IngredientUsage struct {
+	IngredientID int      `json:"ingredient_id"`
+	Name         string   `json:"name"`
+	Unit         string   `json:"unit"`
+	Theoretical  Quantity `json:"theoretical"`
+	Actual       Quantity `json:"actual"`
+	Variance     Quantity `json:"variance"`
+}
+
+// AddMovement books one movement of the period into the usage.
+func (u *IngredientUsage) AddMovement(movementType string, quantity Quantity) {
+	if movementType == MovementRestock {
+		return
+	}
+	if movementType == MovementSale {
+		u.Theoretical -= quantity
+	}
+	u.Actual -= quantity
+	u.Variance = u.Actual - u.Theoretical
+}
@@ -2,6 +2,8 @@ package model
 
 import (
 	"encoding/json"
+	"errors"
+	"slices"
 	"testing"
 	"time"
 )
@@ -425,3 +427,267 @@ func TestErrors_AreDefinedCorrectly(t *testing.T) {
 		})
 	}
 }
+
+func TestNewPaginatedResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		page       PageRequest
+		total      int
+		wantPages  int
+		wantOffset int
+	}{
+		{"empty list has one page", PageRequest{Page: 1, Limit: 20}, 0, 1, 0},
+		{"exact fit", PageRequest{Page: 2, Limit: 10}, 20, 2, 10},
+		{"partial last page", PageRequest{Page: 3, Limit: 10}, 21, 3, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := NewPaginatedResponse(&Page[int]{Items: []int{}, Total: tt.total}, tt.page)
+			if resp.TotalPages != tt.wantPages || resp.TotalItems != tt.total || resp.Page != tt.page.Page || resp.Limit != tt.page.Limit {
+				t.Errorf("NewPaginatedResponse = %+v, want %d pages of %d items", resp, tt.wantPages, tt.total)
+			}
+			if got := tt.page.Offset(); got != tt.wantOffset {
+				t.Errorf("Offset() = %d, want %d", got, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestCursor_EncodeDecode(t *testing.T) {
+	cursor := &Cursor{Sort: ProductSortName, Desc: true, Text: "kopi", ID: 42}
+
+	decoded, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("DecodeCursor should accept an encoded cursor, got: %v", err)
+	}
+	if *decoded != *cursor {
+		t.Errorf("DecodeCursor = %+v, want %+v", decoded, cursor)
+	}
+
+	for _, raw := range []string{"not base64!", "bm90IGpzb24", (&Cursor{}).Encode()} {
+		if _, err := DecodeCursor(raw); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("DecodeCursor(%q) should return ErrInvalidCursor, got: %v", raw, err)
+		}
+	}
+}
+
+func TestNewPaginatedResponse_Cursor(t *testing.T) {
+	next := &Cursor{ID: 20}
+	resp := NewPaginatedResponse(&Page[int]{Items: []int{1}, Total: 30, Next: next}, PageRequest{Page: 1, Limit: 10, After: &Cursor{ID: 10}})
+
+	if resp.Page != 0 {
+		t.Errorf("Cursor pages should omit page, got: %d", resp.Page)
+	}
+	if resp.NextCursor != next.Encode() || resp.TotalPages != 3 {
+		t.Errorf("NewPaginatedResponse should carry the next cursor and totals, got: %+v", resp)
+	}
+}
+
+func TestQuantity_ParseAndFormat(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Quantity
+		text  string
+	}{
+		{"18", 18000, "18"},
+		{"18.5", 18500, "18.5"},
+		{"0.125", 125, "0.125"},
+		{"-2.50", -2500, "-2.5"},
+		{"0.001", 1, "0.001"},
+	}
+	for _, tt := range tests {
+		got, err := ParseQuantity(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseQuantity(%q) = %d, %v, want %d", tt.input, got, err, tt.want)
+		}
+		if got.String() != tt.text {
+			t.Errorf("Quantity(%d).String() = %q, want %q", got, got.String(), tt.text)
+		}
+	}
+
+	for _, input := range []string{"", "-", "1.2345", "1e3", "abc", "1.-5", ".5"} {
+		if _, err := ParseQuantity(input); !errors.Is(err, ErrQuantityFormat) {
+			t.Errorf("ParseQuantity(%q) should return ErrQuantityFormat, got: %v", input, err)
+		}
+	}
+}
+
+func TestQuantity_JSON(t *testing.T) {
+	var item RecipeItem
+	if err := json.Unmarshal([]byte(`{"ingredient_id":1,"quantity":0.1}`), &item); err != nil {
+		t.Fatalf("RecipeItem should unmarshal, got error: %v", err)
+	}
+	// Ten times 0.1 is exactly 1, which float64 would not give.
+	if total := item.Quantity.Mul(10); total != NewQuantity(1) {
+		t.Errorf("Quantity should not pick up rounding, got: %s", total)
+	}
+
+	data, _ := json.Marshal(item)
+	if string(data) != `{"ingredient_id":1,"quantity":0.1}` {
+		t.Errorf("Quantity should marshal as a JSON number, got: %s", data)
+	}
+}
+
+func TestIngredientUsage_AddMovement(t *testing.T) {
+	var u IngredientUsage
+	u.AddMovement(MovementSale, -NewQuantity(36))
+	u.AddMovement(MovementRestock, NewQuantity(500))
+	u.AddMovement(MovementWaste, -NewQuantity(10))
+	u.AddMovement(MovementCount, NewQuantity(4))
+
+	if u.Theoretical != NewQuantity(36) || u.Actual != NewQuantity(42) || u.Variance != NewQuantity(6) {
+		t.Errorf("AddMovement should count sales as theoretical and skip restocks, got: %+v", u)
+	}
+}
+
+func TestProduct_LineTotal(t *testing.T) {
+	tests := []struct {
+		name     string
+		product  Product
+		quantity int
+		want     int
+	}{
+		{"pieces", Product{Price: 3500}, 3, 10500},
+		{"pieces with explicit unit", Product{Price: 3500, Unit: UnitPiece}, 2, 7000},
+		{"1.25 kg", Product{Price: 14000, Unit: UnitKilogram}, 1250, 17500},
+		{"333 g rounds down", Product{Price: 12345, Unit: UnitKilogram}, 333, 4111},
+		{"half rupiah rounds up", Product{Price: 500, Unit: UnitKilogram}, 1, 1},
+		{"below half rupiah rounds down", Product{Price: 499, Unit: UnitKilogram}, 1, 0},
+		{"750 ml", Product{Price: 18000, Unit: UnitLiter}, 750, 13500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.product.LineTotal(tt.quantity); got != tt.want {
+				t.Errorf("LineTotal(%d) = %d, want %d", tt.quantity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEAN13CheckDigit(t *testing.T) {
+	if got := EAN13CheckDigit("400638133393"); got != '1' {
+		t.Errorf("EAN13CheckDigit should return '1' for 400638133393, got: %q", got)
+	}
+	if got := EAN13CheckDigit("200012301250"); got != '6' {
+		t.Errorf("EAN13CheckDigit should return '6' for 200012301250, got: %q", got)
+	}
+}
+
+func TestParseScaleBarcode(t *testing.T) {
+	rules, err := ParseScaleBarcodeRules(DefaultScaleBarcodeRules)
+	if err != nil {
+		t.Fatalf("Default rules should parse, got: %v", err)
+	}
+	tests := []struct {
+		name    string
+		code    string
+		want    ScaleLabel
+		wantErr error
+	}{
+		{"weight label", "2000123012506", ScaleLabel{Barcode: "2000123012506", PLU: "123", Kind: ScaleValueWeight, Value: 1250}, nil},
+		{"price label", "2500123185007", ScaleLabel{Barcode: "2500123185007", PLU: "123", Kind: ScaleValuePrice, Value: 18500}, nil},
+		{"wrong check digit", "2000123012507", ScaleLabel{}, ErrBarcodeCheckDigit},
+		{"too short", "200012301250", ScaleLabel{}, ErrBarcodeInvalid},
+		{"not digits", "20001230125A6", ScaleLabel{}, ErrBarcodeInvalid},
+		{"regular product barcode", "4006381333931", ScaleLabel{}, ErrBarcodeNotScale},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScaleBarcode(tt.code, rules)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseScaleBarcode(%q) error = %v, want %v", tt.code, err, tt.wantErr)
+			}
+			if err == nil && *got != tt.want {
+				t.Errorf("ParseScaleBarcode(%q) = %+v, want %+v", tt.code, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScaleBarcodeRules(t *testing.T) {
+	rules, err := ParseScaleBarcodeRules("21:price:1:6, 22-23:weight:2:4")
+	if err != nil {
+		t.Fatalf("ParseScaleBarcodeRules should not return error, got: %v", err)
+	}
+	want := []ScaleBarcodeRule{
+		{PrefixFrom: 21, PrefixTo: 21, Value: ScaleValuePrice, PLUStart: 1, PLULength: 6},
+		{PrefixFrom: 22, PrefixTo: 23, Value: ScaleValueWeight, PLUStart: 2, PLULength: 4},
+	}
+	if !slices.Equal(rules, want) {
+		t.Errorf("ParseScaleBarcodeRules = %+v, want %+v", rules, want)
+	}
+
+	for _, spec := range []string{"", "20-24:weight:2", "20-24:volume:2:5", "24-20:weight:2:5", "20:weight:2:10", "20:weight:0:5", "2x:price:2:5"} {
+		if _, err := ParseScaleBarcodeRules(spec); !errors.Is(err, ErrScaleRuleInvalid) {
+			t.Errorf("ParseScaleBarcodeRules(%q) should return ErrScaleRuleInvalid, got: %v", spec, err)
+		}
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+	at := time.Date(2024, 3, 14, 15, 42, 7, 0, time.UTC) // Thursday
+	tests := map[string]time.Time{
+		GranularityHour:  time.Date(2024, 3, 14, 15, 0, 0, 0, time.UTC),
+		GranularityDay:   time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC),
+		GranularityWeek:  time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC),
+		GranularityMonth: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	for granularity, want := range tests {
+		if got := BucketStart(at, granularity); !got.Equal(want) {
+			t.Errorf("BucketStart(%s) should be %v, got: %v", granularity, want, got)
+		}
+	}
+	sunday := time.Date(2024, 3, 17, 23, 0, 0, 0, time.UTC)
+	if got := BucketStart(sunday, GranularityWeek); got.Day() != 11 {
+		t.Errorf("Sunday should belong to the week starting Monday the 11th, got: %v", got)
+	}
+}
+
+func TestNewTimeSeriesReport(t *testing.T) {
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	report, err := NewTimeSeriesReport(start, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), GranularityMonth)
+	if err != nil {
+		t.Fatalf("NewTimeSeriesReport should not return error, got: %v", err)
+	}
+	if len(report.Buckets) != 2 || report.Buckets[1].Start.Month() != time.February {
+		t.Errorf("January 31 to March 1 should have the buckets January and February, got: %+v", report.Buckets)
+	}
+	if b := report.Bucket(time.Date(2024, 2, 29, 23, 59, 0, 0, time.UTC)); b != &report.Buckets[1] {
+		t.Errorf("February 29 should fall in the February bucket, got: %+v", b)
+	}
+	if b := report.Bucket(time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC)); b != nil {
+		t.Errorf("A time before the first bucket should have no bucket, got: %+v", b)
+	}
+
+	report.Buckets[0].TotalRevenue, report.Buckets[0].TotalTransaksi = 10000, 3
+	report.SetAverages()
+	if report.Buckets[0].RataRataTransaksi != 3333 || report.Buckets[1].RataRataTransaksi != 0 {
+		t.Errorf("Averages should be 3333 and 0, got: %+v", report.Buckets)
+	}
+
+	_, err = NewTimeSeriesReport(start, start.AddDate(1, 0, 0), GranularityHour)
+	if !errors.Is(err, ErrTooManyBuckets) {
+		t.Errorf("A year by the hour should return ErrTooManyBuckets, got: %v", err)
+	}
+}
+
+func TestSalesHeatmap_Add(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	heatmap := NewSalesHeatmap(jakarta)
+
+	// Sunday 20:30 UTC is Monday 03:30 in Jakarta.
+	heatmap.Add(time.Date(2024, 3, 17, 20, 30, 0, 0, time.UTC), 15000)
+	heatmap.Add(time.Date(2024, 3, 17, 20, 59, 0, 0, time.UTC), 5000)
+	// Sunday 16:59 UTC is still Sunday 23:59 in Jakarta.
+	heatmap.Add(time.Date(2024, 3, 17, 16, 59, 0, 0, time.UTC), 7000)
+
+	if heatmap.TotalTransaksi[0][3] != 2 || heatmap.Revenue[0][3] != 20000 {
+		t.Errorf("Monday 03:00 should have 2 sales of 20000, got: %d, %d", heatmap.TotalTransaksi[0][3], heatmap.Revenue[0][3])
+	}
+	if heatmap.TotalTransaksi[6][23] != 1 || heatmap.Hari[6] != "Minggu" {
+		t.Errorf("Sunday 23:00 should be the last row with 1 sale, got: %d in %s", heatmap.TotalTransaksi[6][23], heatmap.Hari[6])
+	}
+	if heatmap.Timezone != "WIB" {
+		t.Errorf("Heatmap should name its timezone, got: %s", heatmap.Timezone)
+	}
+}
@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// DefaultOutletID is the outlet that existing stock was moved to when outlets were introduced.
+// Stock changes without an outlet, such as product updates and imports, are booked there too.
+const DefaultOutletID = 1
+
+// Outlet is one store (warung) sharing the product catalog with the others.
+type Outlet struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name" validate:"required"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OutletStock is the stock of one product at one outlet.
+type OutletStock struct {
+	OutletID    int    `json:"outlet_id"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Stock       int    `json:"stock"`
+}
+
+// StockTransfer moves stock of a product from one outlet to another. It is recorded as two
+// movements, one leaving FromOutletID and one arriving at ToOutletID, which always net to zero.
+type StockTransfer struct {
+	ID           int             `json:"id"`
+	ProductID    int             `json:"product_id" validate:"gt=0"`
+	FromOutletID int             `json:"from_outlet_id" validate:"gt=0"`
+	ToOutletID   int             `json:"to_outlet_id" validate:"gt=0"`
+	Quantity     int             `json:"quantity" validate:"gt=0"`
+	Note         string          `json:"note"`
+	CreatedAt    time.Time       `json:"created_at"`
+	Movements    []StockMovement `json:"movements"`
+}
+
+// StockMovement is one signed change of a product's stock at an outlet.
+type StockMovement struct {
+	ID         int       `json:"id"`
+	ProductID  int       `json:"product_id"`
+	OutletID   int       `json:"outlet_id"`
+	Quantity   int       `json:"quantity"`
+	TransferID int       `json:"transfer_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewTransferMovements returns the paired movements of a transfer.
+func NewTransferMovements(t *StockTransfer) []StockMovement {
+	return []StockMovement{
+		{ProductID: t.ProductID, OutletID: t.FromOutletID, Quantity: -t.Quantity, TransferID: t.ID, CreatedAt: t.CreatedAt},
+		{ProductID: t.ProductID, OutletID: t.ToOutletID, Quantity: t.Quantity, TransferID: t.ID, CreatedAt: t.CreatedAt},
+	}
+}
@@ -1,10 +1,91 @@
 package model
 
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
 // PaginatedResponse wraps a list response with pagination metadata.
+// NextCursor continues the list after the last item with ?after=; it is empty on the last page.
+// Page is omitted when the list was requested by cursor.
 type PaginatedResponse struct {
-	Items      any `json:"items"`
-	Page       int `json:"page"`
-	Limit      int `json:"limit"`
-	TotalItems int `json:"total_items"`
-	TotalPages int `json:"total_pages"`
+	Items      any    `json:"items"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	TotalItems int    `json:"total_items"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// PageRequest selects one page of a list. Page is 1-based and Limit is the page size.
+// When After is set the page starts right after that cursor and Page is ignored.
+type PageRequest struct {
+	Page  int
+	Limit int
+	After *Cursor
+}
+
+// Offset returns the number of items before the requested page.
+func (p PageRequest) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// Page is one page of a repository listing.
+// Total counts the matches across all pages; Next is nil when nothing follows the page.
+type Page[T any] struct {
+	Items []T
+	Total int
+	Next  *Cursor
+}
+
+// Cursor is the keyset position of a list item: the sort it was taken from, the item's sort key and its ID.
+// Text holds string keys (lowercased names), Num holds numeric keys; plain ID order needs neither.
+type Cursor struct {
+	Sort string `json:"s,omitempty"`
+	Desc bool   `json:"d,omitempty"`
+	Text string `json:"t,omitempty"`
+	Num  int    `json:"n,omitempty"`
+	ID   int    `json:"i"`
+}
+
+// Encode returns the opaque form of the cursor used in ?after= and next_cursor.
+func (c *Cursor) Encode() string {
+	raw, _ := json.Marshal(c) //nolint:errcheck // plain struct, cannot fail
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor produced by Encode.
+func DecodeCursor(s string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// NewPaginatedResponse wraps one page of a listing with its pagination metadata.
+// An empty list still reports one page.
+func NewPaginatedResponse[T any](result *Page[T], page PageRequest) *PaginatedResponse {
+	totalPages := (result.Total + page.Limit - 1) / page.Limit
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	resp := &PaginatedResponse{
+		Items:      result.Items,
+		Page:       page.Page,
+		Limit:      page.Limit,
+		TotalItems: result.Total,
+		TotalPages: totalPages,
+	}
+	if page.After != nil {
+		resp.Page = 0
+	}
+	if result.Next != nil {
+		resp.NextCursor = result.Next.Encode()
+	}
+	return resp
 }
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// PriceChange represents a product price change, either already applied or scheduled.
+// OldPrice and AppliedAt stay nil until the change takes effect.
+type PriceChange struct {
+	ID          int        `json:"id"`
+	ProductID   int        `json:"product_id"`
+	OldPrice    *int       `json:"old_price"`
+	NewPrice    int        `json:"new_price"`
+	ChangedBy   string     `json:"changed_by"`
+	EffectiveAt time.Time  `json:"effective_at"`
+	AppliedAt   *time.Time `json:"applied_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// IsApplied reports whether the change has already taken effect.
+func (c *PriceChange) IsApplied() bool {
+	return c.AppliedAt != nil
+}
+
+// PriceChangeInput is the request body for scheduling a price change.
+type PriceChangeInput struct {
+	Price       int       `json:"price" validate:"gt=0"`
+	EffectiveAt time.Time `json:"effective_at" validate:"required"`
+}
+
+// Price change status filters for listing history.
+const (
+	PriceChangeUpcoming = "upcoming"
+	PriceChangePast     = "past"
+)
+
+// PriceChangeSortEffective names the order of price history, newest effective time first, in cursors.
+const PriceChangeSortEffective = "effective_at"
+
+// PriceChangeQuery filters a product's price history. Status is PriceChangeUpcoming for the changes
+// not applied and effective after Now, PriceChangePast for the others, or empty for all of them.
+// A due change is past even before the price scheduler applies it, since reads already show its price.
+type PriceChangeQuery struct {
+	Status string
+	Now    time.Time
+}
+
+// IsUpcoming reports whether the change still lies ahead at now.
+func (c *PriceChange) IsUpcoming(now time.Time) bool {
+	return !c.IsApplied() && c.EffectiveAt.After(now)
+}
+
+// NewPriceChangeCursor returns the keyset position of c in price history. The effective time is kept
+// in microseconds, the precision PostgreSQL stores.
+func NewPriceChangeCursor(c *PriceChange) *Cursor {
+	return &Cursor{Sort: PriceChangeSortEffective, Desc: true, Num: int(c.EffectiveAt.UnixMicro()), ID: c.ID}
+}
@@ -1,15 +1,112 @@
 package model
 
+import (
+	"strings"
+	"time"
+)
+
 // Product represents a product in the kasir system.
 // Model layer: definisi bentuk data.
 // CategoryID is internal only, tidak diexpose di JSON response.
 type Product struct {
-	ID         int              `json:"id"`
-	Name       string           `json:"name"`
-	Price      int              `json:"price"`
-	Stock      int              `json:"stock"`
-	CategoryID *int             `json:"-"` // internal only, tidak tampil di response
-	Category   *ProductCategory `json:"category,omitempty"`
+	ID           int               `json:"id"`
+	Name         string            `json:"name"`
+	Price        int               `json:"price"`          // per kg atau liter untuk produk timbangan
+	Stock        int               `json:"stock"`          // dalam gram atau ml untuk produk timbangan
+	Unit         string            `json:"unit,omitempty"` // pcs, kg atau l
+	Type         string            `json:"type,omitempty"` // stocked, non_stocked atau service
+	SKU          string            `json:"sku,omitempty"`
+	Barcode      string            `json:"barcode,omitempty"`
+	PLU          string            `json:"plu,omitempty"` // kode produk pada label timbangan
+	CategoryID   *int              `json:"-"`             // internal only, tidak tampil di response
+	Category     *ProductCategory  `json:"category,omitempty"`
+	ImageKey     string            `json:"-"` // internal only, key gambar di blob store
+	ImageURL     string            `json:"image_url,omitempty"`
+	ThumbnailURL string            `json:"thumbnail_url,omitempty"`
+	ArchivedAt   *time.Time        `json:"archived_at,omitempty"`
+	Components   []BundleComponent `json:"components,omitempty"` // hanya untuk bundle, stok diturunkan dari komponen
+	OutletID     int               `json:"-"`                    // internal only, outlet tempat perubahan stok dibukukan
+	Version      int               `json:"version,omitempty"`    // naik setiap kali produk diubah, dipakai untuk ETag
+	ChangedBy    string            `json:"-"`                    // internal only, dicatat di price history
+	// AllowNegativeStock lets checkout sell past zero, for goods sold before their delivery is booked.
+	AllowNegativeStock bool `json:"allow_negative_stock,omitempty"`
+}
+
+// Product units. Pieces are counted one by one. Products sold by weight or volume keep their stock
+// and quantities in grams or milliliters as whole numbers, and their price is per kilogram or liter.
+const (
+	UnitPiece    = "pcs"
+	UnitKilogram = "kg"
+	UnitLiter    = "l"
+)
+
+// ProductUnits lists the valid Product.Unit values.
+var ProductUnits = []string{UnitPiece, UnitKilogram, UnitLiter}
+
+// Product types. Only stocked products keep stock; non-stocked goods (such as photocopies) and
+// services (such as delivery or service fees) are sold without stock checks.
+const (
+	ProductTypeStocked    = "stocked"
+	ProductTypeNonStocked = "non_stocked"
+	ProductTypeService    = "service"
+)
+
+// ProductTypes lists the valid Product.Type values.
+var ProductTypes = []string{ProductTypeStocked, ProductTypeNonStocked, ProductTypeService}
+
+// TracksStock reports whether the product keeps stock. An empty type counts as stocked.
+func (p *Product) TracksStock() bool {
+	return p.Type == "" || p.Type == ProductTypeStocked
+}
+
+// measureScale is the number of grams or milliliters in the unit a measured product is priced in.
+const measureScale = 1000
+
+// IsMeasured reports whether the product is sold by weight or volume.
+func (p *Product) IsMeasured() bool {
+	return p.Unit == UnitKilogram || p.Unit == UnitLiter
+}
+
+// LineTotal returns the price of quantity of the product: pieces, or grams or milliliters for a
+// measured product. A measured total is rounded half up to the rupiah in integer arithmetic,
+// so totals never pick up float rounding errors.
+func (p *Product) LineTotal(quantity int) int {
+	if !p.IsMeasured() {
+		return p.Price * quantity
+	}
+	return (p.Price*quantity + measureScale/2) / measureScale
+}
+
+// IsArchived reports whether the product has been archived (soft-deleted).
+func (p *Product) IsArchived() bool {
+	return p.ArchivedAt != nil
+}
+
+// IsBundle reports whether the product is a bundle of other products.
+// A bundle has no stock of its own; its Stock is how many complete bundles the components allow.
+func (p *Product) IsBundle() bool {
+	return len(p.Components) > 0
+}
+
+// BundleComponent is one product inside a bundle and how many of it a single bundle uses.
+// Name is filled in on reads and ignored on writes.
+type BundleComponent struct {
+	ProductID int    `json:"product_id" validate:"gt=0"`
+	Name      string `json:"name,omitempty"`
+	Quantity  int    `json:"quantity" validate:"gt=0"`
+}
+
+// BundleStock returns how many complete bundles the component stock allows.
+// stock looks up the current stock of a component by product ID.
+func BundleStock(components []BundleComponent, stock func(productID int) int) int {
+	available := -1
+	for _, c := range components {
+		n := max(stock(c.ProductID), 0) / c.Quantity
+		if available < 0 || n < available {
+			available = n
+		}
+	}
+	return max(available, 0)
 }
 
 // ProductCategory represents category info embedded in product response.
@@ -18,11 +115,94 @@ type ProductCategory struct {
 	Description string `json:"description"`
 }
 
+// Product sort keys accepted by ProductFilter.Sort. An empty Sort orders by ID.
+const (
+	ProductSortName      = "name"
+	ProductSortPrice     = "price"
+	ProductSortStock     = "stock"
+	ProductSortRelevance = "relevance"
+)
+
+// ProductSorts lists the valid ProductFilter.Sort values.
+var ProductSorts = []string{ProductSortName, ProductSortPrice, ProductSortStock, ProductSortRelevance}
+
+// ProductFilter narrows down and orders product listings.
+// Name is a fuzzy, typo-tolerant match: substrings always match, otherwise names sharing enough
+// trigrams with the search do. NameSubstring turns the typo tolerance off, leaving the
+// case-insensitive substring match only. CategoryID matches the category and all of its descendants; 0 means
+// any category. MinPrice and MaxPrice are inclusive, 0 means unbounded. Relevance sorting puts the
+// best name matches first and ignores Desc; every sort breaks ties by ID. Limit 0 means no limit.
+type ProductFilter struct {
+	Name            string
+	NameSubstring   bool
+	CategoryID      int
+	IncludeArchived bool
+	MinPrice        int
+	MaxPrice        int
+	InStockOnly     bool
+	Sort            string
+	Desc            bool
+	Limit           int
+}
+
+// NewProductCursor returns the keyset position of p in a listing ordered as filter asks.
+func NewProductCursor(p *Product, filter ProductFilter) *Cursor {
+	c := &Cursor{Sort: filter.Sort, Desc: filter.Desc, ID: p.ID}
+	switch filter.Sort {
+	case ProductSortName:
+		c.Text = strings.ToLower(p.Name)
+	case ProductSortPrice:
+		c.Num = p.Price
+	case ProductSortStock:
+		c.Num = p.Stock
+	}
+	return c
+}
+
+// ProductSuggestion is a compact search result for the cashier typeahead.
+type ProductSuggestion struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Price        int    `json:"price"`
+	Stock        int    `json:"stock"`
+	Unit         string `json:"unit,omitempty"`
+	SKU          string `json:"sku,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+}
+
 // ProductInput is the request body for Create/Update product.
-// Digunakan untuk parse category_id dari client.
+// Digunakan untuk parse category_id dari client. Unit defaults to pcs and Type to stocked;
+// Stock may only be negative with AllowNegativeStock.
 type ProductInput struct {
 	Name       string `json:"name" validate:"required"`
 	Price      int    `json:"price" validate:"gt=0"`
-	Stock      int    `json:"stock" validate:"gte=0"`
+	Stock      int    `json:"stock"`
+	Unit       string `json:"unit,omitempty" validate:"omitempty,unit"`
+	Type       string `json:"type,omitempty" validate:"omitempty,oneof=stocked non_stocked service"`
+	SKU        string `json:"sku,omitempty" validate:"max=64"`
+	Barcode    string `json:"barcode,omitempty" validate:"max=64"`
+	PLU        string `json:"plu,omitempty" validate:"omitempty,number,max=10"`
 	CategoryID *int   `json:"category_id,omitempty" validate:"omitempty,gt=0"`
+	// Components turns the product into a bundle; its stock is then derived and Stock is ignored.
+	Components []BundleComponent `json:"components,omitempty" validate:"omitempty,dive"`
+	// AllowNegativeStock lets checkout take the stock below zero.
+	AllowNegativeStock bool `json:"allow_negative_stock,omitempty"`
+}
+
+// NewProductInput returns the editable fields of p, the document a PATCH merge patch applies to.
+func NewProductInput(p *Product) ProductInput {
+	return ProductInput{
+		Name:       p.Name,
+		Price:      p.Price,
+		Stock:      p.Stock,
+		Unit:       p.Unit,
+		Type:       p.Type,
+		SKU:        p.SKU,
+		Barcode:    p.Barcode,
+		PLU:        p.PLU,
+		CategoryID: p.CategoryID,
+		Components: p.Components,
+
+		AllowNegativeStock: p.AllowNegativeStock,
+	}
 }
@@ -0,0 +1,70 @@
+package model
+
+// Operations accepted by POST /api/products/bulk.
+const (
+	BulkSetPrice    = "set_price"
+	BulkAdjustPrice = "adjust_price"
+	BulkSetCategory = "set_category"
+	BulkAdjustStock = "adjust_stock"
+	BulkArchive     = "archive"
+)
+
+// ProductBulkRequest is one operation applied to many products.
+// Targets are either IDs or Filter, never both. Amount is the new price for set_price and the
+// difference for adjust_price and adjust_stock; adjust_price takes Percent instead of Amount for a
+// relative change. set_category moves the products to CategoryID, or clears it when CategoryID is nil.
+// A Filter write must carry ExpectedMatched, the Matched count of a dry run, so the caller sees how
+// many products a filter hits before anything is written.
+type ProductBulkRequest struct {
+	Operation       string             `json:"operation" validate:"required"`
+	IDs             []int              `json:"ids,omitempty"`
+	Filter          *ProductBulkFilter `json:"filter,omitempty"`
+	ExpectedMatched *int               `json:"expected_matched,omitempty"`
+	Amount          *int               `json:"amount,omitempty"`
+	Percent         *float64           `json:"percent,omitempty"`
+	CategoryID      *int               `json:"category_id,omitempty"`
+	DryRun          bool               `json:"-"`
+	ChangedBy       string             `json:"-"` // internal only, dicatat di price history
+}
+
+// ProductBulkFilter selects the active products a bulk operation targets: CategoryID includes
+// sub-categories and Name is a case-insensitive substring match, without the typo tolerance of the
+// list filters. At least one must be set.
+type ProductBulkFilter struct {
+	CategoryID int    `json:"category_id,omitempty"`
+	Name       string `json:"name,omitempty"`
+}
+
+// ProductBulkChange is the before and after value of one product touched by a bulk operation:
+// the price, stock, category ID (null when unset) or archived flag, depending on the operation.
+type ProductBulkChange struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	Old       any    `json:"old"`
+	New       any    `json:"new"`
+}
+
+// ProductBulkError describes why a targeted product rejected the operation.
+type ProductBulkError struct {
+	ProductID int    `json:"product_id"`
+	Message   string `json:"message"`
+}
+
+// ProductBulkResult summarizes a bulk operation or its preview. Matched counts the targeted
+// products; Affected of them change and Unchanged already had the requested value.
+type ProductBulkResult struct {
+	DryRun    bool                `json:"dry_run"`
+	Operation string              `json:"operation"`
+	Matched   int                 `json:"matched"`
+	Affected  int                 `json:"affected"`
+	Unchanged int                 `json:"unchanged"`
+	Changes   []ProductBulkChange `json:"changes"`
+	Errors    []ProductBulkError  `json:"errors"`
+}
+
+// ProductBulkBatch is a validated bulk operation written by the repository in one atomic step.
+// With Archive set the products are archived, otherwise each is saved like Update.
+type ProductBulkBatch struct {
+	Products []*Product
+	Archive  bool
+}
@@ -0,0 +1,74 @@
+package model
+
+// Import formats accepted by POST /api/products/import. Export accepts these plus NDJSON.
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatXLSX   = "xlsx"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// ImportColumns is the header row shared by product import and export files.
+var ImportColumns = []string{"name", "price", "stock", "category", "sku", "barcode"}
+
+// ImportOptions controls a product import.
+type ImportOptions struct {
+	DryRun           bool
+	CreateCategories bool
+	ChangedBy        string
+}
+
+// ImportRow is one data row of an import file. Values are raw cell text.
+// Row is the 1-based line in the file, so the first data row after the header is 2.
+type ImportRow struct {
+	Row      int
+	Name     string
+	Price    string
+	Stock    string
+	Category string
+	SKU      string
+	Barcode  string
+}
+
+// ImportRowError describes why a row was rejected.
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportResult summarizes a product import or dry run.
+type ImportResult struct {
+	DryRun            bool             `json:"dry_run"`
+	TotalRows         int              `json:"total_rows"`
+	Created           int              `json:"created"`
+	Updated           int              `json:"updated"`
+	CategoriesCreated []string         `json:"categories_created"`
+	Errors            []ImportRowError `json:"errors"`
+}
+
+// ProductImportBatch is a validated import written by the repository in one atomic step.
+// NewCategories are created first; products may point CategoryID at &NewCategories[i].ID,
+// which the repository fills in before writing them. Products with ID > 0 are updated, the rest created.
+type ProductImportBatch struct {
+	NewCategories []*Category
+	Products      []*Product
+}
+
+// ProductRecord is a product in the import column layout. NDJSON export writes one per line.
+type ProductRecord struct {
+	Name     string `json:"name"`
+	Price    int    `json:"price"`
+	Stock    int    `json:"stock"`
+	Category string `json:"category"`
+	SKU      string `json:"sku"`
+	Barcode  string `json:"barcode"`
+}
+
+// NewProductRecord flattens a product, using its category name.
+func NewProductRecord(p *Product) ProductRecord {
+	record := ProductRecord{Name: p.Name, Price: p.Price, Stock: p.Stock, SKU: p.SKU, Barcode: p.Barcode}
+	if p.Category != nil {
+		record.Category = p.Category.Name
+	}
+	return record
+}
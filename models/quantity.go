@@ -0,0 +1,118 @@
+package model
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// QuantityScale is how many units of a Quantity make one whole: quantities keep three decimals.
+const QuantityScale = 1000
+
+// ErrQuantityFormat rejects quantities that are not a decimal number with at most three decimals.
+var ErrQuantityFormat = errors.New("quantity must be a decimal number with at most 3 decimals")
+
+// Quantity is a fractional amount such as 18.5 grams or 0.25 liter, kept as a whole number of
+// thousandths so sums and products never pick up float rounding. It reads and writes JSON as a
+// plain number (18.5) and is stored as NUMERIC in PostgreSQL.
+type Quantity int64
+
+// NewQuantity returns the quantity for a whole number of units.
+func NewQuantity(units int) Quantity {
+	return Quantity(units) * QuantityScale
+}
+
+// ParseQuantity parses a decimal such as "18", "-2.5" or "0.125".
+func ParseQuantity(s string) (Quantity, error) {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	whole, frac, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	if whole == "" || len(frac) > 3 || strings.ContainsAny(whole+frac, "+-") {
+		return 0, fmt.Errorf("%w: %q", ErrQuantityFormat, s)
+	}
+	w, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrQuantityFormat, s)
+	}
+	var f int64
+	if frac != "" {
+		f, err = strconv.ParseInt(frac+strings.Repeat("0", 3-len(frac)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q", ErrQuantityFormat, s)
+		}
+	}
+	q := Quantity(w*QuantityScale + f)
+	if neg {
+		q = -q
+	}
+	return q, nil
+}
+
+// Mul returns the quantity n times over, e.g. the ingredients used by n portions.
+func (q Quantity) Mul(n int) Quantity {
+	return q * Quantity(n)
+}
+
+// String formats the quantity without trailing zeros: 18.5, 150, -0.125.
+func (q Quantity) String() string {
+	sign := ""
+	if q < 0 {
+		sign, q = "-", -q
+	}
+	whole, frac := int64(q)/QuantityScale, int64(q)%QuantityScale
+	if frac == 0 {
+		return sign + strconv.FormatInt(whole, 10)
+	}
+	return sign + strconv.FormatInt(whole, 10) + "." + strings.TrimRight(fmt.Sprintf("%03d", frac), "0")
+}
+
+// MarshalJSON writes the quantity as a JSON number.
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number, or a string holding one. Exponents are not accepted.
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	parsed, err := ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
+
+// Value stores the quantity as a decimal string for a NUMERIC column.
+func (q Quantity) Value() (driver.Value, error) {
+	return q.String(), nil
+}
+
+// Scan reads a NUMERIC column.
+func (q *Quantity) Scan(src any) error {
+	switch v := src.(type) {
+	case int64:
+		*q = NewQuantity(int(v))
+		return nil
+	case []byte:
+		return q.scanString(string(v))
+	case string:
+		return q.scanString(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Quantity", src)
+	}
+}
+
+func (q *Quantity) scanString(s string) error {
+	// NUMERIC(14,3) may come back with trailing zeros beyond what we keep, e.g. "18.500".
+	if whole, frac, ok := strings.Cut(s, "."); ok && len(frac) > 3 {
+		s = whole + "." + strings.TrimRight(frac, "0")
+	}
+	parsed, err := ParseQuantity(s)
+	if err != nil {
+		return err
+	}
+	*q = parsed
+	return nil
+}
@@ -0,0 +1,253 @@
+package model
+
+import (
+	"cmp"
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultStoreTimezone is the IANA timezone of the store when none is configured.
+const DefaultStoreTimezone = "Asia/Jakarta"
+
+// Time-series granularities, named after PostgreSQL date_trunc fields.
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// Granularities lists the valid time-series granularities.
+var Granularities = []string{GranularityHour, GranularityDay, GranularityWeek, GranularityMonth}
+
+// MaxTimeSeriesBuckets caps the buckets of one time-series report, e.g. hourly over about six weeks.
+const MaxTimeSeriesBuckets = 1000
+
+// SalesBucket is the sales of one time-series bucket, from Start up to the start of the next bucket.
+// ItemTerjual counts pieces, and each line of a product sold by weight or volume as one item.
+type SalesBucket struct {
+	Start             time.Time `json:"start"`
+	TotalRevenue      int       `json:"total_revenue"`
+	TotalTransaksi    int       `json:"total_transaksi"`
+	ItemTerjual       int       `json:"item_terjual"`
+	RataRataTransaksi int       `json:"rata_rata_transaksi"`
+}
+
+// TimeSeriesReport is the sales of a date range split into zero-filled buckets.
+type TimeSeriesReport struct {
+	Granularity string        `json:"granularity"`
+	Buckets     []SalesBucket `json:"buckets"`
+}
+
+// BucketStart truncates t to the start of its bucket in t's location. Weeks start on Monday,
+// like date_trunc('week') in PostgreSQL.
+func BucketStart(t time.Time, granularity string) time.Time {
+	y, m, d := t.Date()
+	switch granularity {
+	case GranularityHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location())
+	case GranularityWeek:
+		return time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case GranularityMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// nextBucket returns the start of the bucket after the one starting at start.
+func nextBucket(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case GranularityHour:
+		return start.Add(time.Hour)
+	case GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// NewTimeSeriesReport returns the zero-filled buckets covering [startDate, endDate). The first
+// bucket starts at or before startDate, so a week or month is never cut at the range start.
+// Both repositories fill these buckets so they agree on the boundaries.
+func NewTimeSeriesReport(startDate, endDate time.Time, granularity string) (*TimeSeriesReport, error) {
+	report := &TimeSeriesReport{Granularity: granularity, Buckets: []SalesBucket{}}
+	for start := BucketStart(startDate, granularity); start.Before(endDate); start = nextBucket(start, granularity) {
+		if len(report.Buckets) == MaxTimeSeriesBuckets {
+			return nil, ErrTooManyBuckets
+		}
+		report.Buckets = append(report.Buckets, SalesBucket{Start: start})
+	}
+	return report, nil
+}
+
+// Bucket returns the bucket t falls in, or nil when t is before the first bucket.
+func (r *TimeSeriesReport) Bucket(t time.Time) *SalesBucket {
+	i := sort.Search(len(r.Buckets), func(i int) bool { return r.Buckets[i].Start.After(t) }) - 1
+	if i < 0 {
+		return nil
+	}
+	return &r.Buckets[i]
+}
+
+// SetAverages sets the average transaction value of every bucket, rounded to the nearest rupiah.
+func (r *TimeSeriesReport) SetAverages() {
+	for i := range r.Buckets {
+		b := &r.Buckets[i]
+		if b.TotalTransaksi > 0 {
+			b.RataRataTransaksi = (b.TotalRevenue + b.TotalTransaksi/2) / b.TotalTransaksi
+		}
+	}
+}
+
+// ItemCount is the number of items a detail line adds to a report: its quantity in pieces,
+// or one for a product sold by weight or volume.
+func (d *TransactionDetail) ItemCount() int {
+	if d.Unit == UnitKilogram || d.Unit == UnitLiter {
+		return 1
+	}
+	return d.Quantity
+}
+
+// UncategorizedName names the group of lines sold without a category.
+const UncategorizedName = "Tanpa Kategori"
+
+// CustomItemsName names the group of custom items, which is kept apart from the categories.
+const CustomItemsName = "Item Custom"
+
+// CategorySales is the sales of one category in a date range. CategoryID is the category snapshot on
+// the transaction details, nil for lines sold without a category and for the Custom row, which sums
+// the custom items whatever their category. ItemTerjual and Revenue count the category's own lines;
+// the Total fields add those of all its sub-categories, so a parent without sales of its own still
+// has a row. Persentase and TotalPersentase are shares of the revenue of all rows, in percent with
+// two decimals.
+type CategorySales struct {
+	CategoryID       *int    `json:"category_id"`
+	ParentID         *int    `json:"parent_id,omitempty"`
+	Custom           bool    `json:"custom,omitempty"`
+	Nama             string  `json:"nama"`
+	ItemTerjual      int     `json:"item_terjual"`
+	Revenue          int     `json:"revenue"`
+	Persentase       float64 `json:"persentase"`
+	TotalItemTerjual int     `json:"total_item_terjual"`
+	TotalRevenue     int     `json:"total_revenue"`
+	TotalPersentase  float64 `json:"total_persentase"`
+}
+
+// SetCategoryShares sets the own and total share of every row in the revenue of all rows.
+func SetCategoryShares(rows []*CategorySales) {
+	total := 0
+	for _, row := range rows {
+		total += row.Revenue
+	}
+	if total == 0 {
+		return
+	}
+	for _, row := range rows {
+		row.Persentase = Share(row.Revenue, total)
+		row.TotalPersentase = Share(row.TotalRevenue, total)
+	}
+}
+
+// CompareCategorySales orders rows by total revenue descending, then by category ID with
+// uncategorized and then custom items last.
+func CompareCategorySales(a, b *CategorySales) int {
+	if a.TotalRevenue != b.TotalRevenue {
+		return cmp.Compare(b.TotalRevenue, a.TotalRevenue)
+	}
+	switch {
+	case a.Custom != b.Custom:
+		if a.Custom {
+			return 1
+		}
+		return -1
+	case a.CategoryID == nil && b.CategoryID == nil:
+		return 0
+	case a.CategoryID == nil:
+		return 1
+	case b.CategoryID == nil:
+		return -1
+	}
+	return cmp.Compare(*a.CategoryID, *b.CategoryID)
+}
+
+// Share returns part as a percentage of total with two decimals, 0 when total is 0.
+func Share(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*10000/float64(total)) / 100
+}
+
+// Product ranking sort keys accepted by ProductSalesQuery.Sort. An empty Sort ranks by quantity.
+const (
+	ProductSalesSortQty     = "qty"
+	ProductSalesSortRevenue = "revenue"
+)
+
+// ProductSalesSorts lists the valid ProductSalesQuery.Sort values.
+var ProductSalesSorts = []string{ProductSalesSortQty, ProductSalesSortRevenue}
+
+// Product ranking limits.
+const (
+	DefaultProductSalesLimit = 10
+	MaxProductSalesLimit     = 100
+)
+
+// ProductSalesQuery orders and limits a product ranking. Ties are broken by product ID ascending
+// in either direction, so both repositories return the same ranking.
+type ProductSalesQuery struct {
+	Sort  string
+	Desc  bool
+	Limit int
+}
+
+// ProductSales is the sales of one product in a date range, grouped by product ID. Nama and Unit are
+// the product's current ones. QtyTerjual is the quantity sold in pieces, grams or milliliters, while
+// ItemTerjual counts every line of a product sold by weight or volume as one item, like ItemCount;
+// the qty ranking uses ItemTerjual so grams are never compared with pieces. Persentase is the share
+// of the revenue of all products, custom items excluded, in percent with two decimals.
+type ProductSales struct {
+	ProductID   int     `json:"product_id"`
+	Nama        string  `json:"nama"`
+	Unit        string  `json:"unit,omitempty"`
+	QtyTerjual  int     `json:"qty_terjual"`
+	ItemTerjual int     `json:"item_terjual"`
+	Revenue     int     `json:"revenue"`
+	Persentase  float64 `json:"persentase"`
+}
+
+// Heatmap rows are days of the week starting Monday, columns are hours of the day.
+var heatmapDays = [7]string{"Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu", "Minggu"}
+
+// SalesHeatmap counts transactions and revenue by day of week (rows, Monday first, named in Hari)
+// and hour of day (columns 0-23), both taken in Timezone.
+type SalesHeatmap struct {
+	Timezone       string     `json:"timezone"`
+	Hari           [7]string  `json:"hari"`
+	TotalTransaksi [7][24]int `json:"total_transaksi"`
+	Revenue        [7][24]int `json:"revenue"`
+	loc            *time.Location
+}
+
+// NewSalesHeatmap returns an empty heatmap in loc.
+func NewSalesHeatmap(loc *time.Location) *SalesHeatmap {
+	return &SalesHeatmap{Timezone: loc.String(), Hari: heatmapDays, loc: loc}
+}
+
+// Add counts a transaction made at t in the cell of its local day and hour.
+func (h *SalesHeatmap) Add(t time.Time, revenue int) {
+	t = t.In(h.loc)
+	h.AddCell(int(t.Weekday()), t.Hour(), 1, revenue)
+}
+
+// AddCell adds count transactions and their revenue to a cell. weekday follows time.Weekday,
+// Sunday being 0, and is moved to the Monday-first rows.
+func (h *SalesHeatmap) AddCell(weekday, hour, count, revenue int) {
+	day := (weekday + 6) % 7
+	h.TotalTransaksi[day][hour] += count
+	h.Revenue[day][hour] += revenue
+}
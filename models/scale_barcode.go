@@ -0,0 +1,157 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Scale label value kinds: what the digits after the PLU stand for.
+const (
+	ScaleValueWeight = "weight" // gram atau ml
+	ScaleValuePrice  = "price"  // harga label dalam rupiah
+)
+
+// ean13Length is the number of digits in an EAN-13 barcode, the check digit included.
+const ean13Length = 13
+
+// DefaultScaleBarcodeRules is used when no rules are configured: prefixes 20-24 carry a weight,
+// 25-29 a price, both with a 5-digit PLU right after the prefix.
+const DefaultScaleBarcodeRules = "20-24:weight:2:5,25-29:price:2:5"
+
+// ScaleBarcodeRule describes the EAN-13 labels a scale prints for one range of 2-digit prefixes.
+// The PLU takes PLULength digits from the 0-based position PLUStart; the value is every digit
+// after the PLU up to the check digit.
+type ScaleBarcodeRule struct {
+	PrefixFrom int
+	PrefixTo   int
+	Value      string // weight atau price
+	PLUStart   int
+	PLULength  int
+}
+
+// ScaleLabel is a decoded scale label. Value is grams or milliliters for a weight label
+// and rupiah for a price label.
+type ScaleLabel struct {
+	Barcode string `json:"barcode"`
+	PLU     string `json:"plu"`
+	Kind    string `json:"kind"`
+	Value   int    `json:"value"`
+}
+
+// ScaleScan is a scale label resolved to its product: the quantity to sell and what it costs.
+type ScaleScan struct {
+	Label    ScaleLabel `json:"label"`
+	Product  *Product   `json:"product"`
+	Quantity int        `json:"quantity"`
+	Subtotal int        `json:"subtotal"`
+}
+
+// ParseScaleBarcodeRules parses comma-separated rules of the form from-to:kind:plu_start:plu_length,
+// e.g. "20-24:weight:2:5,25-29:price:2:5". A single prefix such as "21:price:2:5" is allowed too.
+func ParseScaleBarcodeRules(spec string) ([]ScaleBarcodeRule, error) {
+	var rules []ScaleBarcodeRule
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Split(part, ":")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("%w: %q is not from-to:kind:plu_start:plu_length", ErrScaleRuleInvalid, part)
+		}
+		from, to, isRange := strings.Cut(fields[0], "-")
+		if !isRange {
+			to = from
+		}
+		rule := ScaleBarcodeRule{Value: fields[1]}
+		var err error
+		for _, n := range []struct {
+			dst *int
+			s   string
+		}{{&rule.PrefixFrom, from}, {&rule.PrefixTo, to}, {&rule.PLUStart, fields[2]}, {&rule.PLULength, fields[3]}} {
+			if *n.dst, err = strconv.Atoi(strings.TrimSpace(n.s)); err != nil {
+				return nil, fmt.Errorf("%w: %q has a non-numeric field", ErrScaleRuleInvalid, part)
+			}
+		}
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("%w: %q %s", ErrScaleRuleInvalid, part, err.Error())
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%w: no rules", ErrScaleRuleInvalid)
+	}
+	return rules, nil
+}
+
+// validate checks that the rule fits an EAN-13 and leaves at least one value digit.
+func (r ScaleBarcodeRule) validate() error {
+	switch {
+	case r.PrefixFrom < 0 || r.PrefixTo > 99 || r.PrefixFrom > r.PrefixTo:
+		return fmt.Errorf("prefix range must lie within 00-99")
+	case r.Value != ScaleValueWeight && r.Value != ScaleValuePrice:
+		return fmt.Errorf("kind must be weight or price")
+	case r.PLUStart < 1 || r.PLULength < 1 || r.PLUStart+r.PLULength > ean13Length-2:
+		return fmt.Errorf("plu must start after the first digit and leave room for a value")
+	}
+	return nil
+}
+
+// ParseScaleBarcode decodes a variable-measure EAN-13 with the first rule whose prefix range
+// matches. The check digit is verified before anything else is read.
+func ParseScaleBarcode(code string, rules []ScaleBarcodeRule) (*ScaleLabel, error) {
+	code = strings.TrimSpace(code)
+	if len(code) != ean13Length || strings.Trim(code, "0123456789") != "" {
+		return nil, ErrBarcodeInvalid
+	}
+	if EAN13CheckDigit(code[:ean13Length-1]) != code[ean13Length-1] {
+		return nil, ErrBarcodeCheckDigit
+	}
+	prefix, _ := strconv.Atoi(code[:2])
+	for _, rule := range rules {
+		if prefix < rule.PrefixFrom || prefix > rule.PrefixTo {
+			continue
+		}
+		pluEnd := rule.PLUStart + rule.PLULength
+		value, _ := strconv.Atoi(code[pluEnd : ean13Length-1])
+		return &ScaleLabel{
+			Barcode: code,
+			PLU:     NormalizePLU(code[rule.PLUStart:pluEnd]),
+			Kind:    rule.Value,
+			Value:   value,
+		}, nil
+	}
+	return nil, ErrBarcodeNotScale
+}
+
+// EAN13CheckDigit returns the check digit for the first 12 digits of an EAN-13:
+// digits are weighted 1 and 3 alternately from the left.
+func EAN13CheckDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// NormalizePLU drops leading zeros, so the PLU 00123 on a label matches a product with PLU 123.
+func NormalizePLU(plu string) string {
+	return strings.TrimLeft(strings.TrimSpace(plu), "0")
+}
+
+// QuantityFor returns the quantity amount rupiah buys: grams or milliliters rounded to the nearest
+// whole one for a measured product, whole pieces (rounded down) otherwise.
+func (p *Product) QuantityFor(amount int) int {
+	if p.Price <= 0 {
+		return 0
+	}
+	if !p.IsMeasured() {
+		return amount / p.Price
+	}
+	return (amount*measureScale + p.Price/2) / p.Price
+}
@@ -1,46 +1,133 @@
 package model
 
-import "time"
+import (
+	"sort"
+	"time"
+)
 
 // Transaction represents a transaction in the kasir system.
 type Transaction struct {
 	ID          int                 `json:"id"`
+	OutletID    int                 `json:"outlet_id"`
 	TotalAmount int                 `json:"total_amount"`
 	CreatedAt   time.Time           `json:"created_at"`
 	Details     []TransactionDetail `json:"details,omitempty"`
 }
 
 // TransactionDetail represents a detail item in a transaction.
+// Selling a bundle adds one detail for the bundle itself, carrying the price, followed by one per
+// component with BundleProductID set, the quantity taken from stock and a zero price, so revenue
+// is counted once while quantities can be attributed to both.
+// A custom line sells something outside the catalog: it has no ProductID (stored as NULL),
+// only the name, price and optional category the cashier entered.
 type TransactionDetail struct {
-	ID            int    `json:"id"`
-	TransactionID int    `json:"transaction_id"`
-	ProductID     int    `json:"product_id"`
-	ProductName   string `json:"product_name"`
-	Quantity      int    `json:"quantity"`
-	Price         int    `json:"price"`
-	Subtotal      int    `json:"subtotal"`
+	ID              int    `json:"id"`
+	TransactionID   int    `json:"transaction_id"`
+	ProductID       int    `json:"product_id,omitempty"` // kosong untuk item custom
+	ProductName     string `json:"product_name"`
+	Quantity        int    `json:"quantity"` // gram atau ml untuk produk timbangan
+	Unit            string `json:"unit,omitempty"`
+	Price           int    `json:"price"` // per unit, per kg atau liter untuk produk timbangan
+	Subtotal        int    `json:"subtotal"`
+	BundleProductID *int   `json:"bundle_product_id,omitempty"`
+	CategoryID      *int   `json:"category_id,omitempty"`
+}
+
+// IsCustom reports whether the line is a custom item rather than a catalog product.
+func (d *TransactionDetail) IsCustom() bool {
+	return d.ProductID == 0
+}
+
+// StockTake is the stock a checkout takes of one product at the transaction's outlet.
+type StockTake struct {
+	ProductID int
+	Quantity  int
+}
+
+// StockTakes sums the quantity of every catalog product on the transaction's lines, by product ID
+// ascending so concurrent checkouts lock products in the same order. Bundle lines are included;
+// a bundle holds no stock of its own, so repositories skip it and take its component lines instead.
+func (t *Transaction) StockTakes() []StockTake {
+	quantities := make(map[int]int)
+	for _, d := range t.Details {
+		if !d.IsCustom() {
+			quantities[d.ProductID] += d.Quantity
+		}
+	}
+	takes := make([]StockTake, 0, len(quantities))
+	for id, quantity := range quantities {
+		takes = append(takes, StockTake{ProductID: id, Quantity: quantity})
+	}
+	sort.Slice(takes, func(a, b int) bool { return takes[a].ProductID < takes[b].ProductID })
+	return takes
+}
+
+// CheckoutBatch is everything one checkout writes, all-or-nothing: the transaction with its details,
+// the stock its lines take at the transaction's outlet and the ingredient sale movements of the
+// recipes sold. The repository fills in the movements' TransactionID.
+type CheckoutBatch struct {
+	Transaction *Transaction
+	Movements   []*IngredientMovement
 }
 
 // CheckoutItem represents an item in the checkout request.
+// Quantity counts pieces, or grams or milliliters for a product sold by weight or volume.
+// An item with a scale label Barcode takes its product and quantity from the label instead.
+// An item with neither is a custom item: Name and Price describe it, CategoryID optionally groups it.
 type CheckoutItem struct {
-	ProductID int `json:"product_id" validate:"gt=0"`
-	Quantity  int `json:"quantity" validate:"gt=0"`
+	ProductID  int    `json:"product_id,omitempty" validate:"required_without_all=Barcode Name,omitempty,gt=0"`
+	Quantity   int    `json:"quantity,omitempty" validate:"required_without=Barcode,omitempty,gt=0"`
+	Barcode    string `json:"barcode,omitempty"`
+	Name       string `json:"name,omitempty" validate:"max=255"`
+	Price      int    `json:"price,omitempty" validate:"omitempty,gt=0"`
+	CategoryID *int   `json:"category_id,omitempty" validate:"omitempty,gt=0"`
+}
+
+// IsCustom reports whether the item is a custom item, naming neither a product nor a barcode.
+func (i *CheckoutItem) IsCustom() bool {
+	return i.ProductID == 0 && i.Barcode == ""
+}
+
+// CustomItemPolicy decides who may sell custom items at checkout. With Roles empty every cashier
+// may once Enabled; otherwise only the listed roles may. Custom items are off by default, and the
+// role comes from the unauthenticated X-Role header, so Roles is advisory only.
+type CustomItemPolicy struct {
+	Enabled bool
+	Roles   []string
 }
 
 // CheckoutRequest represents the request body for checkout.
+// OutletID is the outlet selling the items; it defaults to DefaultOutletID.
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items" validate:"required,min=1,dive"`
+	OutletID int            `json:"outlet_id,omitempty" validate:"omitempty,gt=0"`
+	Items    []CheckoutItem `json:"items" validate:"required,min=1,dive"`
+	Role     string         `json:"-"` // internal only, role kasir dari header X-Role
 }
 
 // ReportResponse represents the response for daily/range report.
+// StokNegatif flags the products whose current stock at an outlet is below zero.
 type ReportResponse struct {
 	TotalRevenue   int             `json:"total_revenue"`
 	TotalTransaksi int             `json:"total_transaksi"`
 	ProdukTerlaris *ProdukTerlaris `json:"produk_terlaris"`
+	StokNegatif    []*OutletStock  `json:"stok_negatif,omitempty"`
+	ItemCustom     *ItemCustom     `json:"item_custom,omitempty"`
+}
+
+// ItemCustom sums the custom items sold, which belong to no product and are reported as one group.
+type ItemCustom struct {
+	JumlahBaris int `json:"jumlah_baris"`
+	QtyTerjual  int `json:"qty_terjual"`
+	Revenue     int `json:"revenue"`
 }
 
-// ProdukTerlaris represents the best selling product.
+// ProdukTerlaris represents the best selling product, grouped by product ID and named after its
+// latest line. It is ranked by ItemTerjual, which counts a line sold by weight or volume as one item
+// like ItemCount, so grams are never compared with pieces; ties go to the lowest product ID.
+// QtyTerjual is the quantity sold in the product's own pieces, grams or milliliters.
 type ProdukTerlaris struct {
-	Nama       string `json:"nama"`
-	QtyTerjual int    `json:"qty_terjual"`
+	ProductID   int    `json:"product_id"`
+	Nama        string `json:"nama"`
+	QtyTerjual  int    `json:"qty_terjual"`
+	ItemTerjual int    `json:"item_terjual"`
 }
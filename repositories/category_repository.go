@@ -4,10 +4,24 @@ import model "kasir-api/models"
 
 // CategoryRepository defines data access for categories.
 // Repository layer: data buat logic. Error database → cek sini.
+// Categories are never hard-deleted; Archive hides them from GetAll unless includeArchived is set.
 type CategoryRepository interface {
-	GetAll() ([]*model.Category, error)
+	GetAll(includeArchived bool) ([]*model.Category, error)
+	// GetPage returns one page of categories ordered by ID, together with the total number of matches.
+	// With page.After set the page starts right after that cursor's ID; Next is set when more follow.
+	GetPage(includeArchived bool, page model.PageRequest) (*model.Page[*model.Category], error)
 	GetByID(id int) (*model.Category, error)
 	Create(category *model.Category) error
+	// Update checks a non-zero category.Version like ProductRepository.Update does.
 	Update(category *model.Category) error
-	Delete(id int) error
+	Restore(id int) error
+
+	// Archive soft-deletes a category and applies policy to its active products in one atomic step.
+	// With the restrict policy it fails with ErrCategoryHasProducts (wrapped with the count) when any exist.
+	// A non-zero version must match the stored one, otherwise it fails with ErrVersionMismatch.
+	Archive(id int, policy model.CategoryProductPolicy, version int) error
+
+	// GetDescendantIDs returns the IDs of every category below id (children, grandchildren, ...),
+	// archived ones included. The category itself is not part of the result.
+	GetDescendantIDs(id int) ([]int, error)
 }
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"time"
+
+	model "kasir-api/models"
+)
+
+// IngredientRepository defines data access for raw-material ingredients, product recipes and
+// the stock movements between them.
+type IngredientRepository interface {
+	// GetPage returns one page of ingredients ordered by ID with the total count, starting after
+	// page.After when set.
+	GetPage(page model.PageRequest) (*model.Page[*model.Ingredient], error)
+	GetByID(id int) (*model.Ingredient, error)
+	Create(ingredient *model.Ingredient) error
+	// Update changes the name and unit; the stock only changes through movements.
+	Update(ingredient *model.Ingredient) error
+
+	// GetRecipe returns the recipe of a product with ingredient names and units, ordered by
+	// ingredient ID. A product without a recipe returns an empty slice.
+	GetRecipe(productID int) ([]model.RecipeItem, error)
+	// SetRecipe replaces the recipe of a product; an empty list removes it.
+	SetRecipe(productID int, items []model.RecipeItem) error
+
+	// RecordMovements applies the movements to ingredient stock in one atomic step and fills in
+	// their IDs and times. A count movement carries the counted stock, which is turned into the
+	// difference with the recorded stock. Stock may go negative: sales are never blocked by it.
+	RecordMovements(movements []*model.IngredientMovement) error
+	// GetMovements returns one page of the movements of an ingredient, newest first, with the total
+	// count. A cursor page starts at the movements older than page.After.
+	GetMovements(ingredientID int, page model.PageRequest) (*model.Page[*model.IngredientMovement], error)
+
+	// GetUsage returns theoretical and actual usage per ingredient for movements in [startDate, endDate),
+	// ordered by ingredient ID. Every ingredient is listed, unused ones with zeros.
+	GetUsage(startDate, endDate time.Time) ([]*model.IngredientUsage, error)
+}
@@ -1,7 +1,10 @@
 package memory
 
 import (
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	model "kasir-api/models"
 )
@@ -11,6 +14,9 @@ type CategoryRepository struct {
 	mu             sync.RWMutex
 	categories     map[int]*model.Category
 	nextCategoryID int
+	// products is linked by NewProductRepository so Archive can apply its product policy atomically.
+	// Lock order is products before categories, matching product reads that look up categories.
+	products *ProductRepository
 }
 
 // NewCategoryRepository creates a new in-memory category repository.
@@ -21,17 +27,42 @@ func NewCategoryRepository() *CategoryRepository {
 	}
 }
 
-func (r *CategoryRepository) GetAll() ([]*model.Category, error) {
+func (r *CategoryRepository) GetAll(includeArchived bool) ([]*model.Category, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	categories := make([]*model.Category, 0, len(r.categories))
 	for _, c := range r.categories {
+		if c.IsArchived() && !includeArchived {
+			continue
+		}
 		categories = append(categories, c)
 	}
 	return categories, nil
 }
 
+// GetPage returns one page of categories ordered by ID and the total number of matches,
+// starting after page.After when set.
+func (r *CategoryRepository) GetPage(includeArchived bool, page model.PageRequest) (*model.Page[*model.Category], error) {
+	categories, err := r.GetAll(includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+
+	start := min(page.Offset(), len(categories))
+	if page.After != nil {
+		start = sort.Search(len(categories), func(i int) bool { return categories[i].ID > page.After.ID })
+	}
+	end := min(start+page.Limit, len(categories))
+
+	result := &model.Page[*model.Category]{Items: categories[start:end], Total: len(categories)}
+	if end < len(categories) && end > start {
+		result.Next = &model.Cursor{ID: categories[end-1].ID}
+	}
+	return result, nil
+}
+
 func (r *CategoryRepository) GetByID(id int) (*model.Category, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -48,6 +79,7 @@ func (r *CategoryRepository) Create(category *model.Category) error {
 	defer r.mu.Unlock()
 
 	category.ID = r.nextCategoryID
+	category.Version = 1
 	r.categories[category.ID] = category
 	r.nextCategoryID++
 	return nil
@@ -57,20 +89,131 @@ func (r *CategoryRepository) Update(category *model.Category) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.categories[category.ID]; !exists {
+	existing, exists := r.categories[category.ID]
+	if !exists {
 		return model.ErrCategoryNotFound
 	}
+	if category.Version != 0 && category.Version != existing.Version {
+		return model.ErrVersionMismatch
+	}
+	category.ArchivedAt = existing.ArchivedAt
+	category.Version = existing.Version + 1
 	r.categories[category.ID] = category
 	return nil
 }
 
-func (r *CategoryRepository) Delete(id int) error {
+func (r *CategoryRepository) Archive(id int, policy model.CategoryProductPolicy, version int) error {
+	if r.products != nil {
+		r.products.mu.Lock()
+		defer r.products.mu.Unlock()
+	}
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.categories[id]; !exists {
+	c, exists := r.categories[id]
+	if !exists {
 		return model.ErrCategoryNotFound
 	}
-	delete(r.categories, id)
+	if version != 0 && version != c.Version {
+		return model.ErrVersionMismatch
+	}
+	if err := r.applyProductPolicy(id, policy); err != nil {
+		return err
+	}
+	if !c.IsArchived() {
+		now := time.Now()
+		c.ArchivedAt = &now
+	}
+	c.Version++
+	return nil
+}
+
+// applyProductPolicy updates the active products of the category. Callers hold both locks.
+func (r *CategoryRepository) applyProductPolicy(id int, policy model.CategoryProductPolicy) error {
+	if r.products == nil {
+		return nil
+	}
+
+	var affected []*model.Product
+	for _, p := range r.products.products {
+		if !p.IsArchived() && p.CategoryID != nil && *p.CategoryID == id {
+			affected = append(affected, p)
+		}
+	}
+
+	switch policy.OnProducts {
+	case model.OnProductsReassign:
+		target, exists := r.categories[policy.ReassignTo]
+		if !exists {
+			return model.ErrTargetCategoryNotFound
+		}
+		if target.IsArchived() {
+			return model.ErrTargetCategoryArchived
+		}
+		for _, p := range affected {
+			targetID := policy.ReassignTo
+			p.CategoryID = &targetID
+			p.Category = nil // re-enriched on read
+			p.Version++
+		}
+	case model.OnProductsUnset:
+		for _, p := range affected {
+			p.CategoryID = nil
+			p.Category = nil
+			p.Version++
+		}
+	default:
+		if len(affected) > 0 {
+			return fmt.Errorf("%w: %d active products", model.ErrCategoryHasProducts, len(affected))
+		}
+	}
 	return nil
 }
+
+func (r *CategoryRepository) Restore(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.categories[id]
+	if !exists {
+		return model.ErrCategoryNotFound
+	}
+	c.ArchivedAt = nil
+	c.Version++
+	return nil
+}
+
+func (r *CategoryRepository) GetDescendantIDs(id int) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.categories[id]; !exists {
+		return nil, model.ErrCategoryNotFound
+	}
+
+	children := make(map[int][]int)
+	for _, c := range r.categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+
+	// Breadth-first walk; visited guards against cycles in corrupted data.
+	var descendants []int
+	visited := map[int]bool{id: true}
+	queue := []int{id}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, childID := range children[current] {
+			if visited[childID] {
+				continue
+			}
+			visited[childID] = true
+			descendants = append(descendants, childID)
+			queue = append(queue, childID)
+		}
+	}
+	sort.Ints(descendants)
+	return descendants, nil
+}
@@ -24,7 +24,7 @@ func TestNewCategoryRepository(t *testing.T) {
 func TestCategoryRepository_GetAll_Empty(t *testing.T) {
 	repo := NewCategoryRepository()
 
-	categories, err := repo.GetAll()
+	categories, err := repo.GetAll(false)
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -39,7 +39,7 @@ func TestCategoryRepository_GetAll_WithData(t *testing.T) {
 	repo.Create(&model.Category{Name: "Electronics", Description: "Electronic items"})
 	repo.Create(&model.Category{Name: "Food", Description: "Food items"})
 
-	categories, err := repo.GetAll()
+	categories, err := repo.GetAll(false)
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -144,29 +144,72 @@ func TestCategoryRepository_Update_NotFound(t *testing.T) {
 	}
 }
 
-func TestCategoryRepository_Delete_Success(t *testing.T) {
+func TestCategoryRepository_Archive_Success(t *testing.T) {
 	repo := NewCategoryRepository()
 
 	category := &model.Category{Name: "Electronics", Description: "Electronic items"}
 	repo.Create(category)
 
-	err := repo.Delete(1)
+	err := repo.Archive(1, model.CategoryProductPolicy{}, 0)
 	if err != nil {
-		t.Errorf("Delete should not return error, got: %v", err)
+		t.Errorf("Archive should not return error, got: %v", err)
 	}
 
-	_, err = repo.GetByID(1)
-	if !errors.Is(err, model.ErrNotFound) {
-		t.Error("Delete should remove category from storage")
+	archived, err := repo.GetByID(1)
+	if err != nil {
+		t.Errorf("Archived category should still be resolvable by ID, got: %v", err)
+	}
+	if !archived.IsArchived() {
+		t.Error("Archive should set archived_at")
+	}
+
+	categories, _ := repo.GetAll(false)
+	if len(categories) != 0 {
+		t.Errorf("GetAll should hide archived categories, got: %d", len(categories))
+	}
+	categories, _ = repo.GetAll(true)
+	if len(categories) != 1 {
+		t.Errorf("GetAll with includeArchived should return 1 category, got: %d", len(categories))
 	}
 }
 
-func TestCategoryRepository_Delete_NotFound(t *testing.T) {
+func TestCategoryRepository_Archive_NotFound(t *testing.T) {
 	repo := NewCategoryRepository()
 
-	err := repo.Delete(999)
+	err := repo.Archive(999, model.CategoryProductPolicy{}, 0)
 	if !errors.Is(err, model.ErrNotFound) {
-		t.Errorf("Delete should return ErrNotFound, got: %v", err)
+		t.Errorf("Archive should return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestCategoryRepository_Restore_Success(t *testing.T) {
+	repo := NewCategoryRepository()
+
+	repo.Create(&model.Category{Name: "Electronics", Description: "Electronic items"})
+	repo.Archive(1, model.CategoryProductPolicy{}, 0)
+
+	err := repo.Restore(1)
+	if err != nil {
+		t.Errorf("Restore should not return error, got: %v", err)
+	}
+
+	restored, _ := repo.GetByID(1)
+	if restored.IsArchived() {
+		t.Error("Restore should clear archived_at")
+	}
+}
+
+func TestCategoryRepository_Update_KeepsArchivedAt(t *testing.T) {
+	repo := NewCategoryRepository()
+
+	repo.Create(&model.Category{Name: "Electronics", Description: "Electronic items"})
+	repo.Archive(1, model.CategoryProductPolicy{}, 0)
+
+	repo.Update(&model.Category{ID: 1, Name: "Renamed"})
+
+	updated, _ := repo.GetByID(1)
+	if !updated.IsArchived() {
+		t.Error("Update should not clear archived_at")
 	}
 }
 
@@ -188,7 +231,7 @@ func TestCategoryRepository_Concurrency(t *testing.T) {
 		<-done
 	}
 
-	categories, err := repo.GetAll()
+	categories, err := repo.GetAll(false)
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -197,16 +240,211 @@ func TestCategoryRepository_Concurrency(t *testing.T) {
 	}
 }
 
+func TestCategoryRepository_GetPage(t *testing.T) {
+	repo := NewCategoryRepository()
+	for _, name := range []string{"Makanan", "Minuman", "Snack", "Rokok", "Sabun"} {
+		repo.Create(&model.Category{Name: name})
+	}
+	repo.Archive(2, model.CategoryProductPolicy{OnProducts: model.OnProductsRestrict}, 0)
+
+	result, err := repo.GetPage(false, model.PageRequest{Page: 2, Limit: 2})
+	if err != nil {
+		t.Fatalf("GetPage should not return error, got: %v", err)
+	}
+	if result.Total != 4 {
+		t.Errorf("GetPage total should skip archived categories, got: %d", result.Total)
+	}
+	if len(result.Items) != 2 || result.Items[0].ID != 4 || result.Items[1].ID != 5 || result.Next != nil {
+		t.Errorf("GetPage page 2 should return categories [4 5] and no next cursor, got: %+v", result)
+	}
+
+	result, _ = repo.GetPage(true, model.PageRequest{Page: 4, Limit: 2})
+	if result.Total != 5 || len(result.Items) != 0 {
+		t.Errorf("GetPage past the end should return no items and total 5, got: %d items, total %d", len(result.Items), result.Total)
+	}
+
+	result, _ = repo.GetPage(false, model.PageRequest{Page: 1, Limit: 2})
+	if result.Next == nil || result.Next.ID != 3 {
+		t.Fatalf("GetPage first page should point the next cursor at category 3, got: %+v", result.Next)
+	}
+	result, _ = repo.GetPage(false, model.PageRequest{Limit: 2, After: result.Next})
+	if len(result.Items) != 2 || result.Items[0].ID != 4 {
+		t.Errorf("GetPage after category 3 should start at category 4, got: %+v", result.Items)
+	}
+}
+
 func TestCategoryRepository_GetAll_ReturnsCopy(t *testing.T) {
 	repo := NewCategoryRepository()
 
 	category := &model.Category{Name: "Electronics", Description: "Electronic items"}
 	repo.Create(category)
 
-	categories, _ := repo.GetAll()
+	categories, _ := repo.GetAll(false)
 
 	// Verify we get the same data
 	if categories[0].Name != "Electronics" {
 		t.Errorf("Category name should be Electronics, got: %s", categories[0].Name)
 	}
 }
+
+func TestCategoryRepository_GetDescendantIDs(t *testing.T) {
+	repo := NewCategoryRepository()
+
+	minuman := &model.Category{Name: "Minuman"}
+	repo.Create(minuman)
+	dingin := &model.Category{Name: "Minuman Dingin", ParentID: &minuman.ID}
+	repo.Create(dingin)
+	soda := &model.Category{Name: "Soda", ParentID: &dingin.ID}
+	repo.Create(soda)
+	repo.Create(&model.Category{Name: "Rokok"})
+
+	ids, err := repo.GetDescendantIDs(minuman.ID)
+	if err != nil {
+		t.Fatalf("GetDescendantIDs should not return error, got: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != dingin.ID || ids[1] != soda.ID {
+		t.Errorf("GetDescendantIDs should return [%d %d], got: %v", dingin.ID, soda.ID, ids)
+	}
+
+	ids, _ = repo.GetDescendantIDs(soda.ID)
+	if len(ids) != 0 {
+		t.Errorf("Leaf category should have no descendants, got: %v", ids)
+	}
+}
+
+func TestCategoryRepository_GetDescendantIDs_NotFound(t *testing.T) {
+	repo := NewCategoryRepository()
+
+	_, err := repo.GetDescendantIDs(999)
+	if !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("GetDescendantIDs should return ErrNotFound, got: %v", err)
+	}
+}
+
+func setupCategoryWithProducts() (*CategoryRepository, *ProductRepository) {
+	categoryRepo := NewCategoryRepository()
+	productRepo := NewProductRepository(categoryRepo)
+
+	categoryRepo.Create(&model.Category{Name: "Rokok"})
+	categoryRepo.Create(&model.Category{Name: "Tembakau"})
+
+	categoryID := 1
+	productRepo.Create(&model.Product{Name: "Sampoerna", Price: 30000, Stock: 10, CategoryID: &categoryID})
+	productRepo.Create(&model.Product{Name: "Gudang Garam", Price: 28000, Stock: 10, CategoryID: &categoryID})
+	return categoryRepo, productRepo
+}
+
+func TestCategoryRepository_Archive_RestrictWithProducts(t *testing.T) {
+	categoryRepo, _ := setupCategoryWithProducts()
+
+	err := categoryRepo.Archive(1, model.CategoryProductPolicy{OnProducts: model.OnProductsRestrict}, 0)
+	if !errors.Is(err, model.ErrCategoryHasProducts) {
+		t.Fatalf("Archive should return ErrCategoryHasProducts, got: %v", err)
+	}
+	if err.Error() != "category still has products: 2 active products" {
+		t.Errorf("Error should carry the product count, got: %v", err)
+	}
+	category, _ := categoryRepo.GetByID(1)
+	if category.IsArchived() {
+		t.Error("Category should not be archived when products block it")
+	}
+}
+
+func TestCategoryRepository_Archive_IgnoresArchivedProducts(t *testing.T) {
+	categoryRepo, productRepo := setupCategoryWithProducts()
+	productRepo.Archive(1, 0)
+	productRepo.Archive(2, 0)
+
+	err := categoryRepo.Archive(1, model.CategoryProductPolicy{}, 0)
+	if err != nil {
+		t.Errorf("Archived products should not block archiving the category, got: %v", err)
+	}
+	product, _ := productRepo.GetByID(1)
+	if product.CategoryID == nil || *product.CategoryID != 1 {
+		t.Error("Archived products should keep their category reference")
+	}
+}
+
+func TestCategoryRepository_Archive_Reassign(t *testing.T) {
+	categoryRepo, productRepo := setupCategoryWithProducts()
+
+	err := categoryRepo.Archive(1, model.CategoryProductPolicy{OnProducts: model.OnProductsReassign, ReassignTo: 2}, 0)
+	if err != nil {
+		t.Fatalf("Archive with reassign should not return error, got: %v", err)
+	}
+	products, _ := productRepo.GetAll(model.ProductFilter{CategoryID: 2})
+	if len(products) != 2 {
+		t.Errorf("Products should move to the target category, got: %d", len(products))
+	}
+	category, _ := categoryRepo.GetByID(1)
+	if !category.IsArchived() {
+		t.Error("Category should be archived after reassigning its products")
+	}
+}
+
+func TestCategoryRepository_Archive_ReassignToArchivedTarget(t *testing.T) {
+	categoryRepo, productRepo := setupCategoryWithProducts()
+	categoryRepo.Archive(2, model.CategoryProductPolicy{}, 0)
+
+	err := categoryRepo.Archive(1, model.CategoryProductPolicy{OnProducts: model.OnProductsReassign, ReassignTo: 2}, 0)
+	if !errors.Is(err, model.ErrTargetCategoryArchived) {
+		t.Fatalf("Archive should return ErrTargetCategoryArchived, got: %v", err)
+	}
+	products, _ := productRepo.GetAll(model.ProductFilter{CategoryID: 1})
+	if len(products) != 2 {
+		t.Errorf("Products should be untouched when the archive fails, got: %d", len(products))
+	}
+	category, _ := categoryRepo.GetByID(1)
+	if category.IsArchived() {
+		t.Error("Category should not be archived when the archive fails")
+	}
+}
+
+func TestCategoryRepository_Archive_Unset(t *testing.T) {
+	categoryRepo, productRepo := setupCategoryWithProducts()
+
+	err := categoryRepo.Archive(1, model.CategoryProductPolicy{OnProducts: model.OnProductsUnset}, 0)
+	if err != nil {
+		t.Fatalf("Archive with unset should not return error, got: %v", err)
+	}
+	for _, id := range []int{1, 2} {
+		product, _ := productRepo.GetByID(id)
+		if product.CategoryID != nil || product.Category != nil {
+			t.Errorf("Product %d should have no category after unset", id)
+		}
+	}
+}
+
+func TestCategoryRepository_Version(t *testing.T) {
+	categoryRepo := NewCategoryRepository()
+	productRepo := NewProductRepository(categoryRepo)
+	categoryRepo.Create(&model.Category{Name: "Minuman"})
+	categoryRepo.Create(&model.Category{Name: "Makanan"})
+	categoryID := 1
+	productRepo.Create(&model.Product{Name: "Teh", Price: 5000, Stock: 10, CategoryID: &categoryID})
+
+	if err := categoryRepo.Update(&model.Category{ID: 1, Name: "Minuman Dingin", Version: 1}); err != nil {
+		t.Fatalf("Update with the current version should succeed, got: %v", err)
+	}
+	if err := categoryRepo.Update(&model.Category{ID: 1, Name: "Minuman Panas", Version: 1}); !errors.Is(err, model.ErrVersionMismatch) {
+		t.Errorf("Update with a stale version should return ErrVersionMismatch, got: %v", err)
+	}
+	policy := model.CategoryProductPolicy{OnProducts: model.OnProductsReassign, ReassignTo: 2}
+	if err := categoryRepo.Archive(1, policy, 1); !errors.Is(err, model.ErrVersionMismatch) {
+		t.Errorf("Archive with a stale version should return ErrVersionMismatch, got: %v", err)
+	}
+	if p, _ := productRepo.GetByID(1); *p.CategoryID != 1 || p.Version != 1 {
+		t.Errorf("A rejected archive should not reassign products, got: %+v", p)
+	}
+
+	if err := categoryRepo.Archive(1, policy, 2); err != nil {
+		t.Fatalf("Archive with the current version should succeed, got: %v", err)
+	}
+	c, _ := categoryRepo.GetByID(1)
+	if c.Name != "Minuman Dingin" || c.Version != 3 {
+		t.Errorf("Archive should bump the version to 3, got: %+v", c)
+	}
+	if p, _ := productRepo.GetByID(1); p.Version != 2 {
+		t.Errorf("Reassigning a product should bump its version, got: %d", p.Version)
+	}
+}
@@ -0,0 +1,229 @@
+package memory
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	model "kasir-api/models"
+)
+
+// IngredientRepository holds in-memory ingredients, recipes and movements and implements
+// repository.IngredientRepository.
+type IngredientRepository struct {
+	mu             sync.RWMutex
+	ingredients    map[int]*model.Ingredient
+	recipes        map[int][]model.RecipeItem // by product ID, without names
+	movements      []*model.IngredientMovement
+	nextID         int
+	nextMovementID int
+}
+
+// NewIngredientRepository creates a new in-memory ingredient repository.
+func NewIngredientRepository() *IngredientRepository {
+	return &IngredientRepository{
+		ingredients:    make(map[int]*model.Ingredient),
+		recipes:        make(map[int][]model.RecipeItem),
+		nextID:         1,
+		nextMovementID: 1,
+	}
+}
+
+// GetPage returns one page of ingredients ordered by ID, starting after page.After when set.
+func (r *IngredientRepository) GetPage(page model.PageRequest) (*model.Page[*model.Ingredient], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ingredients := make([]*model.Ingredient, 0, len(r.ingredients))
+	for _, i := range r.ingredients {
+		iCopy := *i
+		ingredients = append(ingredients, &iCopy)
+	}
+	sort.Slice(ingredients, func(a, b int) bool { return ingredients[a].ID < ingredients[b].ID })
+
+	start := min(page.Offset(), len(ingredients))
+	if page.After != nil {
+		start = sort.Search(len(ingredients), func(i int) bool { return ingredients[i].ID > page.After.ID })
+	}
+	end := min(start+page.Limit, len(ingredients))
+
+	result := &model.Page[*model.Ingredient]{Items: ingredients[start:end], Total: len(ingredients)}
+	if end < len(ingredients) && end > start {
+		result.Next = &model.Cursor{ID: ingredients[end-1].ID}
+	}
+	return result, nil
+}
+
+// GetByID returns a copy of the ingredient with the given ID.
+func (r *IngredientRepository) GetByID(id int) (*model.Ingredient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i, exists := r.ingredients[id]
+	if !exists {
+		return nil, model.ErrIngredientNotFound
+	}
+	iCopy := *i
+	return &iCopy, nil
+}
+
+// Create stores an ingredient, assigning its ID and creation time.
+func (r *IngredientRepository) Create(ingredient *model.Ingredient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ingredient.ID = r.nextID
+	ingredient.CreatedAt = time.Now()
+	r.nextID++
+	stored := *ingredient
+	r.ingredients[ingredient.ID] = &stored
+	return nil
+}
+
+// Update changes the name and unit and returns the stored stock in ingredient.
+func (r *IngredientRepository) Update(ingredient *model.Ingredient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.ingredients[ingredient.ID]
+	if !exists {
+		return model.ErrIngredientNotFound
+	}
+	stored.Name = ingredient.Name
+	stored.Unit = ingredient.Unit
+	*ingredient = *stored
+	return nil
+}
+
+// GetRecipe returns the recipe of a product with ingredient names and units filled in.
+func (r *IngredientRepository) GetRecipe(productID int) ([]model.RecipeItem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	items := slices.Clone(r.recipes[productID])
+	if items == nil {
+		items = []model.RecipeItem{}
+	}
+	for i, item := range items {
+		if ingredient, ok := r.ingredients[item.IngredientID]; ok {
+			items[i].Name = ingredient.Name
+			items[i].Unit = ingredient.Unit
+		}
+	}
+	return items, nil
+}
+
+// SetRecipe replaces the recipe of a product; an empty list removes it.
+func (r *IngredientRepository) SetRecipe(productID int, items []model.RecipeItem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range items {
+		if _, exists := r.ingredients[item.IngredientID]; !exists {
+			return model.ErrIngredientNotFound
+		}
+	}
+	if len(items) == 0 {
+		delete(r.recipes, productID)
+		return nil
+	}
+	stored := make([]model.RecipeItem, len(items))
+	for i, item := range items {
+		stored[i] = model.RecipeItem{IngredientID: item.IngredientID, Quantity: item.Quantity}
+	}
+	slices.SortFunc(stored, func(a, b model.RecipeItem) int { return a.IngredientID - b.IngredientID })
+	r.recipes[productID] = stored
+	return nil
+}
+
+// RecordMovements checks every ingredient first so a batch with an unknown one changes nothing.
+func (r *IngredientRepository) RecordMovements(movements []*model.IngredientMovement) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.checkMovementsLocked(movements); err != nil {
+		return err
+	}
+	r.recordMovementsLocked(movements)
+	return nil
+}
+
+// checkMovementsLocked fails when a movement names an unknown ingredient. Caller must hold r.mu.
+func (r *IngredientRepository) checkMovementsLocked(movements []*model.IngredientMovement) error {
+	for _, m := range movements {
+		if _, exists := r.ingredients[m.IngredientID]; !exists {
+			return model.ErrIngredientNotFound
+		}
+	}
+	return nil
+}
+
+// recordMovementsLocked applies checked movements to stock and stores them. Caller must hold the write lock.
+func (r *IngredientRepository) recordMovementsLocked(movements []*model.IngredientMovement) {
+	now := time.Now()
+	for _, m := range movements {
+		ingredient := r.ingredients[m.IngredientID]
+		if m.Type == model.MovementCount {
+			m.Quantity -= ingredient.Stock
+		}
+		ingredient.Stock += m.Quantity
+		m.ID = r.nextMovementID
+		m.CreatedAt = now
+		r.nextMovementID++
+		stored := *m
+		r.movements = append(r.movements, &stored)
+	}
+}
+
+// GetMovements returns one page of an ingredient's movements, newest first. Movements are stored
+// in ID order, so walking them backwards gives the page order.
+func (r *IngredientRepository) GetMovements(ingredientID int, page model.PageRequest) (*model.Page[*model.IngredientMovement], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.ingredients[ingredientID]; !exists {
+		return nil, model.ErrIngredientNotFound
+	}
+	movements := make([]*model.IngredientMovement, 0)
+	for i := len(r.movements) - 1; i >= 0; i-- {
+		if m := r.movements[i]; m.IngredientID == ingredientID {
+			mCopy := *m
+			movements = append(movements, &mCopy)
+		}
+	}
+
+	start := min(page.Offset(), len(movements))
+	if page.After != nil {
+		start = sort.Search(len(movements), func(i int) bool { return movements[i].ID < page.After.ID })
+	}
+	end := min(start+page.Limit, len(movements))
+
+	result := &model.Page[*model.IngredientMovement]{Items: movements[start:end], Total: len(movements)}
+	if end < len(movements) && end > start {
+		result.Next = &model.Cursor{Desc: true, ID: movements[end-1].ID}
+	}
+	return result, nil
+}
+
+// GetUsage returns usage for movements in [startDate, endDate), consistent with PostgreSQL.
+func (r *IngredientRepository) GetUsage(startDate, endDate time.Time) ([]*model.IngredientUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byID := make(map[int]*model.IngredientUsage, len(r.ingredients))
+	usage := make([]*model.IngredientUsage, 0, len(r.ingredients))
+	for _, i := range r.ingredients {
+		u := &model.IngredientUsage{IngredientID: i.ID, Name: i.Name, Unit: i.Unit}
+		byID[i.ID] = u
+		usage = append(usage, u)
+	}
+	for _, m := range r.movements {
+		if m.CreatedAt.Before(startDate) || !m.CreatedAt.Before(endDate) {
+			continue
+		}
+		byID[m.IngredientID].AddMovement(m.Type, m.Quantity)
+	}
+	sort.Slice(usage, func(a, b int) bool { return usage[a].IngredientID < usage[b].IngredientID })
+	return usage, nil
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	model "kasir-api/models"
+)
+
+func TestIngredientRepository_RecordMovements(t *testing.T) {
+	repo := NewIngredientRepository()
+	repo.Create(&model.Ingredient{Name: "Susu", Unit: "ml", Stock: model.NewQuantity(1000)})
+
+	sale := &model.IngredientMovement{IngredientID: 1, Type: model.MovementSale, Quantity: -model.NewQuantity(300), OutletID: 2}
+	count := &model.IngredientMovement{IngredientID: 1, Type: model.MovementCount, Quantity: model.NewQuantity(650)}
+	if err := repo.RecordMovements([]*model.IngredientMovement{sale, count}); err != nil {
+		t.Fatalf("RecordMovements should not return error, got: %v", err)
+	}
+	if count.Quantity != -model.NewQuantity(50) {
+		t.Errorf("A count should be booked as the difference with the recorded stock, got: %s", count.Quantity)
+	}
+	if got, _ := repo.GetByID(1); got.Stock != model.NewQuantity(650) {
+		t.Errorf("Stock should match the count, got: %s", got.Stock)
+	}
+
+	err := repo.RecordMovements([]*model.IngredientMovement{
+		{IngredientID: 1, Type: model.MovementRestock, Quantity: model.NewQuantity(100)},
+		{IngredientID: 99, Type: model.MovementRestock, Quantity: model.NewQuantity(100)},
+	})
+	if !errors.Is(err, model.ErrIngredientNotFound) {
+		t.Errorf("RecordMovements with an unknown ingredient should return ErrIngredientNotFound, got: %v", err)
+	}
+	if got, _ := repo.GetByID(1); got.Stock != model.NewQuantity(650) {
+		t.Errorf("A rejected batch should change nothing, got stock: %s", got.Stock)
+	}
+
+	movements, _ := repo.GetMovements(1, model.PageRequest{Page: 1, Limit: 1})
+	if movements.Total != 2 || len(movements.Items) != 1 || movements.Items[0].Type != model.MovementCount {
+		t.Errorf("GetMovements should page the movements newest first, got: %+v", movements)
+	}
+	older, _ := repo.GetMovements(1, model.PageRequest{Limit: 1, After: movements.Next})
+	if len(older.Items) != 1 || older.Items[0].ID >= movements.Items[0].ID || older.Next != nil {
+		t.Errorf("The cursor should continue with the older movement, got: %+v", older)
+	}
+
+	now := time.Now()
+	usage, _ := repo.GetUsage(now.Add(-time.Hour), now.Add(time.Hour))
+	if len(usage) != 1 || usage[0].Theoretical != model.NewQuantity(300) || usage[0].Actual != model.NewQuantity(350) {
+		t.Errorf("GetUsage should sum the movements in range, got: %+v", usage[0])
+	}
+	usage, _ = repo.GetUsage(now.Add(time.Hour), now.Add(2*time.Hour))
+	if usage[0].Actual != 0 {
+		t.Errorf("GetUsage should skip movements outside the range, got: %+v", usage[0])
+	}
+}
+
+func TestIngredientRepository_Recipe(t *testing.T) {
+	repo := NewIngredientRepository()
+	repo.Create(&model.Ingredient{Name: "Kopi", Unit: "g"})
+	repo.Create(&model.Ingredient{Name: "Cup", Unit: "pcs"})
+
+	err := repo.SetRecipe(1, []model.RecipeItem{
+		{IngredientID: 2, Quantity: model.NewQuantity(1)},
+		{IngredientID: 1, Quantity: 18000},
+	})
+	if err != nil {
+		t.Fatalf("SetRecipe should not return error, got: %v", err)
+	}
+	recipe, _ := repo.GetRecipe(1)
+	if len(recipe) != 2 || recipe[0].Name != "Kopi" || recipe[0].Unit != "g" {
+		t.Errorf("GetRecipe should return the items by ingredient ID with names, got: %+v", recipe)
+	}
+
+	repo.SetRecipe(1, nil)
+	if recipe, _ := repo.GetRecipe(1); len(recipe) != 0 || recipe == nil {
+		t.Errorf("An empty recipe should remove it and return an empty slice, got: %v", recipe)
+	}
+}
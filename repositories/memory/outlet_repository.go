@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	model "kasir-api/models"
+)
+
+// OutletRepository holds in-memory outlets and transfers and implements repository.OutletRepository.
+// Stock per outlet lives in the linked product repository; lock order is products, then outlets.
+type OutletRepository struct {
+	mu             sync.RWMutex
+	outlets        map[int]*model.Outlet
+	transfers      []*model.StockTransfer
+	nextID         int
+	nextTransferID int
+	nextMovementID int
+	products       *ProductRepository
+}
+
+// NewOutletRepository creates a new in-memory outlet repository holding the default outlet,
+// and links it to the product repository that keeps the stock per outlet.
+func NewOutletRepository(products *ProductRepository) *OutletRepository {
+	r := &OutletRepository{
+		outlets: map[int]*model.Outlet{
+			model.DefaultOutletID: {ID: model.DefaultOutletID, Name: "Outlet Utama", CreatedAt: time.Now()},
+		},
+		nextID:         model.DefaultOutletID + 1,
+		nextTransferID: 1,
+		nextMovementID: 1,
+		products:       products,
+	}
+	products.mu.Lock()
+	products.outlets = r
+	products.mu.Unlock()
+	return r
+}
+
+// exists reports whether the outlet is known.
+func (r *OutletRepository) exists(id int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.outlets[id]
+	return ok
+}
+
+// GetPage returns one page of outlets ordered by ID, starting after page.After when set.
+func (r *OutletRepository) GetPage(page model.PageRequest) (*model.Page[*model.Outlet], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	outlets := make([]*model.Outlet, 0, len(r.outlets))
+	for _, o := range r.outlets {
+		oCopy := *o
+		outlets = append(outlets, &oCopy)
+	}
+	sort.Slice(outlets, func(a, b int) bool { return outlets[a].ID < outlets[b].ID })
+	return pageByID(outlets, page, func(o *model.Outlet) int { return o.ID }), nil
+}
+
+func (r *OutletRepository) GetByID(id int) (*model.Outlet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	o, exists := r.outlets[id]
+	if !exists {
+		return nil, model.ErrOutletNotFound
+	}
+	oCopy := *o
+	return &oCopy, nil
+}
+
+func (r *OutletRepository) Create(outlet *model.Outlet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	outlet.ID = r.nextID
+	outlet.CreatedAt = time.Now()
+	r.nextID++
+	stored := *outlet
+	r.outlets[outlet.ID] = &stored
+	return nil
+}
+
+// GetStock returns one page of the stock at the outlet of the stocked products that are not archived
+// and not bundles, ordered by product ID.
+func (r *OutletRepository) GetStock(outletID int, page model.PageRequest) (*model.Page[*model.OutletStock], error) {
+	r.products.mu.RLock()
+	defer r.products.mu.RUnlock()
+
+	if !r.exists(outletID) {
+		return nil, model.ErrOutletNotFound
+	}
+	stock := make([]*model.OutletStock, 0)
+	for _, p := range r.products.products {
+		if p.IsArchived() || p.IsBundle() || !p.TracksStock() {
+			continue
+		}
+		stock = append(stock, &model.OutletStock{
+			OutletID:    outletID,
+			ProductID:   p.ID,
+			ProductName: p.Name,
+			Stock:       r.products.outletStock[p.ID][outletID],
+		})
+	}
+	sort.Slice(stock, func(a, b int) bool { return stock[a].ProductID < stock[b].ProductID })
+	return pageByID(stock, page, func(s *model.OutletStock) int { return s.ProductID }), nil
+}
+
+// pageByID cuts one page out of items sorted by ascending ID, starting after page.After when set.
+func pageByID[T any](items []T, page model.PageRequest, id func(T) int) *model.Page[T] {
+	start := min(page.Offset(), len(items))
+	if page.After != nil {
+		start = sort.Search(len(items), func(i int) bool { return id(items[i]) > page.After.ID })
+	}
+	end := min(start+page.Limit, len(items))
+
+	result := &model.Page[T]{Items: items[start:end], Total: len(items)}
+	if end < len(items) && end > start {
+		result.Next = &model.Cursor{ID: id(items[end-1])}
+	}
+	return result
+}
+
+// Transfer moves the stock under the product lock, so it cannot interleave with a checkout.
+func (r *OutletRepository) Transfer(transfer *model.StockTransfer) error {
+	r.products.mu.Lock()
+	defer r.products.mu.Unlock()
+
+	p, exists := r.products.products[transfer.ProductID]
+	if !exists {
+		return model.ErrProductNotFound
+	}
+	if p.IsBundle() {
+		return model.ErrBundleStock
+	}
+	if !p.TracksStock() {
+		return model.ErrNotStocked
+	}
+	if !r.exists(transfer.FromOutletID) || !r.exists(transfer.ToOutletID) {
+		return model.ErrOutletNotFound
+	}
+	stock := r.products.outletStock[p.ID]
+	if stock[transfer.FromOutletID] < transfer.Quantity {
+		return model.ErrOutletStock
+	}
+	stock[transfer.FromOutletID] -= transfer.Quantity
+	stock[transfer.ToOutletID] += transfer.Quantity
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	transfer.ID = r.nextTransferID
+	transfer.CreatedAt = time.Now()
+	r.nextTransferID++
+	transfer.Movements = model.NewTransferMovements(transfer)
+	for i := range transfer.Movements {
+		transfer.Movements[i].ID = r.nextMovementID
+		r.nextMovementID++
+	}
+	stored := *transfer
+	stored.Movements = slices.Clone(transfer.Movements)
+	r.transfers = append(r.transfers, &stored)
+	return nil
+}
+
+// GetTransfers returns one page of transfers, newest first. Transfers are stored in ID order, so
+// walking them backwards gives the page order.
+func (r *OutletRepository) GetTransfers(page model.PageRequest) (*model.Page[*model.StockTransfer], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n := len(r.transfers)
+	start := min(page.Offset(), n)
+	if page.After != nil {
+		start = sort.Search(n, func(i int) bool { return r.transfers[n-1-i].ID < page.After.ID })
+	}
+	end := min(start+page.Limit, n)
+
+	result := &model.Page[*model.StockTransfer]{Items: make([]*model.StockTransfer, 0, end-start), Total: n}
+	for i := start; i < end; i++ {
+		tCopy := *r.transfers[n-1-i]
+		tCopy.Movements = slices.Clone(tCopy.Movements)
+		result.Items = append(result.Items, &tCopy)
+	}
+	if end < n && end > start {
+		result.Next = &model.Cursor{Desc: true, ID: result.Items[len(result.Items)-1].ID}
+	}
+	return result, nil
+}
@@ -0,0 +1,140 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+
+	model "kasir-api/models"
+)
+
+func TestOutletRepository_Transfer(t *testing.T) {
+	products := NewProductRepository(nil)
+	outlets := NewOutletRepository(products)
+	outlets.Create(&model.Outlet{Name: "Cabang"})
+	products.Create(&model.Product{Name: "Kopi", Price: 5000, Stock: 10})
+
+	transfer := &model.StockTransfer{ProductID: 1, FromOutletID: model.DefaultOutletID, ToOutletID: 2, Quantity: 4}
+	if err := outlets.Transfer(transfer); err != nil {
+		t.Fatalf("Transfer should not return error, got: %v", err)
+	}
+	if len(transfer.Movements) != 2 || transfer.Movements[0].Quantity+transfer.Movements[1].Quantity != 0 {
+		t.Errorf("Transfer should record paired movements that net to zero, got: %+v", transfer.Movements)
+	}
+	if stock, _ := products.GetOutletStock(1, 2); stock != 4 {
+		t.Errorf("Destination outlet should hold 4, got: %d", stock)
+	}
+	if stock, _ := products.GetOutletStock(1, model.DefaultOutletID); stock != 6 {
+		t.Errorf("Source outlet should hold 6, got: %d", stock)
+	}
+	if p, _ := products.GetByID(1); p.Stock != 10 || p.Version != 1 {
+		t.Errorf("Transfer should keep the total stock and the version, got stock %d version %d", p.Stock, p.Version)
+	}
+
+	err := outlets.Transfer(&model.StockTransfer{ProductID: 1, FromOutletID: 2, ToOutletID: model.DefaultOutletID, Quantity: 5})
+	if !errors.Is(err, model.ErrOutletStock) {
+		t.Errorf("Transfer above the source stock should return ErrOutletStock, got: %v", err)
+	}
+	err = outlets.Transfer(&model.StockTransfer{ProductID: 1, FromOutletID: 2, ToOutletID: 3, Quantity: 1})
+	if !errors.Is(err, model.ErrOutletNotFound) {
+		t.Errorf("Transfer to an unknown outlet should return ErrOutletNotFound, got: %v", err)
+	}
+	if transfers, _ := outlets.GetTransfers(model.PageRequest{Page: 1, Limit: 20}); transfers.Total != 1 {
+		t.Errorf("Only the successful transfer should be stored, got: %d", transfers.Total)
+	}
+
+	outlets.Transfer(&model.StockTransfer{ProductID: 1, FromOutletID: 2, ToOutletID: model.DefaultOutletID, Quantity: 1})
+	newest, _ := outlets.GetTransfers(model.PageRequest{Page: 1, Limit: 1})
+	if newest.Total != 2 || len(newest.Items) != 1 || newest.Items[0].ID != 2 || len(newest.Items[0].Movements) != 2 {
+		t.Errorf("GetTransfers should page the transfers newest first, got: %+v", newest)
+	}
+	older, _ := outlets.GetTransfers(model.PageRequest{Limit: 1, After: newest.Next})
+	if len(older.Items) != 1 || older.Items[0].ID != 1 || older.Next != nil {
+		t.Errorf("The cursor should continue with the older transfer, got: %+v", older)
+	}
+}
+
+func TestProductRepository_GetOutletStock_Bundle(t *testing.T) {
+	products := NewProductRepository(nil)
+	outlets := NewOutletRepository(products)
+	outlets.Create(&model.Outlet{Name: "Cabang"})
+	products.Create(&model.Product{Name: "Kopi", Price: 8000, Stock: 10})
+	products.Create(&model.Product{Name: "Roti", Price: 6000, Stock: 10})
+	products.Create(&model.Product{Name: "Paket", Price: 12000, Components: []model.BundleComponent{
+		{ProductID: 1, Quantity: 1},
+		{ProductID: 2, Quantity: 2},
+	}})
+	outlets.Transfer(&model.StockTransfer{ProductID: 2, FromOutletID: model.DefaultOutletID, ToOutletID: 2, Quantity: 4})
+
+	if stock, _ := products.GetOutletStock(3, model.DefaultOutletID); stock != 3 {
+		t.Errorf("The default outlet's components make 3 bundles, got: %d", stock)
+	}
+	if stock, _ := products.GetOutletStock(3, 2); stock != 0 {
+		t.Errorf("Outlet 2 holds no kopi, so it makes no bundle, got: %d", stock)
+	}
+}
+
+func TestProductRepository_UpdateBooksStockAtOutlet(t *testing.T) {
+	products := NewProductRepository(nil)
+	outlets := NewOutletRepository(products)
+	outlets.Create(&model.Outlet{Name: "Cabang"})
+	products.Create(&model.Product{Name: "Kopi", Price: 5000, Stock: 10})
+
+	p, _ := products.GetByID(1)
+	p.Stock, p.OutletID = 13, 2
+	if err := products.Update(p); err != nil {
+		t.Fatalf("Update should not return error, got: %v", err)
+	}
+	if stock, _ := products.GetOutletStock(1, 2); stock != 3 {
+		t.Errorf("The stock change should be booked at outlet 2, got: %d", stock)
+	}
+
+	// Outlet 2 holds 3, so taking 5 there must fail even though 13 are in stock overall.
+	p, _ = products.GetByID(1)
+	p.Stock, p.OutletID = 8, 2
+	if err := products.Update(p); !errors.Is(err, model.ErrOutletStock) {
+		t.Errorf("Taking more than the outlet holds should return ErrOutletStock, got: %v", err)
+	}
+	if _, err := products.GetOutletStock(1, 7); !errors.Is(err, model.ErrOutletNotFound) {
+		t.Errorf("GetOutletStock of an unknown outlet should return ErrOutletNotFound, got: %v", err)
+	}
+
+	stock, err := outlets.GetStock(2, model.PageRequest{Page: 1, Limit: 20})
+	if err != nil || stock.Total != 1 || len(stock.Items) != 1 || stock.Items[0].Stock != 3 {
+		t.Errorf("GetStock should list the product with its outlet stock, got: %+v, %v", stock, err)
+	}
+}
+
+func TestOutletRepository_GetStockPaginated(t *testing.T) {
+	products := NewProductRepository(nil)
+	outlets := NewOutletRepository(products)
+	for _, name := range []string{"Kopi", "Teh", "Gula"} {
+		products.Create(&model.Product{Name: name, Price: 5000, Stock: 10})
+	}
+	products.Create(&model.Product{Name: "Servis", Price: 5000, Type: model.ProductTypeService})
+
+	first, err := outlets.GetStock(model.DefaultOutletID, model.PageRequest{Page: 1, Limit: 2})
+	if err != nil {
+		t.Fatalf("GetStock should not return error, got: %v", err)
+	}
+	if first.Total != 3 || len(first.Items) != 2 || first.Next == nil || first.Next.ID != 2 {
+		t.Fatalf("First page should hold products 1 and 2 of 3 stocked ones, got: %+v", first)
+	}
+	second, _ := outlets.GetStock(model.DefaultOutletID, model.PageRequest{Limit: 2, After: first.Next})
+	if len(second.Items) != 1 || second.Items[0].ProductID != 3 || second.Next != nil {
+		t.Errorf("Cursor page should hold only product 3, got: %+v", second)
+	}
+	if _, err := outlets.GetStock(9, model.PageRequest{Page: 1, Limit: 2}); !errors.Is(err, model.ErrOutletNotFound) {
+		t.Errorf("GetStock of an unknown outlet should return ErrOutletNotFound, got: %v", err)
+	}
+
+	outlets.Create(&model.Outlet{Name: "Cabang Pasar"})
+	outlets.Create(&model.Outlet{Name: "Cabang Terminal"})
+	page, _ := outlets.GetPage(model.PageRequest{Page: 1, Limit: 2})
+	if page.Total != 3 || len(page.Items) != 2 || page.Next == nil {
+		t.Fatalf("First outlet page should hold 2 of 3 outlets, got: %+v", page)
+	}
+	page, _ = outlets.GetPage(model.PageRequest{Limit: 2, After: page.Next})
+	if len(page.Items) != 1 || page.Items[0].Name != "Cabang Terminal" || page.Next != nil {
+		t.Errorf("Cursor page should hold the last outlet, got: %+v", page)
+	}
+}
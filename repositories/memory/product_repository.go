@@ -1,8 +1,10 @@
 package memory
 
 import (
-	"strings"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 
 	model "kasir-api/models"
 	repository "kasir-api/repositories"
@@ -10,19 +12,30 @@ import (
 
 // ProductRepository holds in-memory product storage and implements repository.ProductRepository.
 type ProductRepository struct {
-	mu            sync.RWMutex
-	products      map[int]*model.Product
-	nextProductID int
-	categoryRepo  repository.CategoryRepository
+	mu                sync.RWMutex
+	products          map[int]*model.Product
+	nextProductID     int
+	priceChanges      []*model.PriceChange
+	nextPriceChangeID int
+	categoryRepo      repository.CategoryRepository
+	outletStock       map[int]map[int]int // product ID → outlet ID → stock, summing to the product's stock
+	outlets           *OutletRepository
 }
 
 // NewProductRepository creates a new in-memory product repository with optional category lookup.
+// An in-memory category repository is linked back so archiving a category can update its products.
 func NewProductRepository(categoryRepo repository.CategoryRepository) *ProductRepository {
-	return &ProductRepository{
-		products:      make(map[int]*model.Product),
-		nextProductID: 1,
-		categoryRepo:  categoryRepo,
+	r := &ProductRepository{
+		products:          make(map[int]*model.Product),
+		nextProductID:     1,
+		nextPriceChangeID: 1,
+		categoryRepo:      categoryRepo,
+		outletStock:       make(map[int]map[int]int),
 	}
+	if memCategories, ok := categoryRepo.(*CategoryRepository); ok {
+		memCategories.products = r
+	}
+	return r
 }
 
 func (r *ProductRepository) enrichWithCategory(p *model.Product) {
@@ -36,25 +49,152 @@ func (r *ProductRepository) enrichWithCategory(p *model.Product) {
 	}
 }
 
-func (r *ProductRepository) GetAll(name string) ([]*model.Product, error) {
+// enrichWithComponents fills in the component names of a bundle and derives its stock.
+// The components are copied so enriching a copy never touches the stored product. Caller must hold r.mu.
+func (r *ProductRepository) enrichWithComponents(p *model.Product) {
+	if !p.IsBundle() {
+		return
+	}
+	p.Components = slices.Clone(p.Components)
+	for i, c := range p.Components {
+		if component, ok := r.products[c.ProductID]; ok {
+			p.Components[i].Name = component.Name
+		}
+	}
+	p.Stock = r.stockLocked(p)
+}
+
+// stockLocked returns the available stock of p, derived from the components for a bundle.
+// Caller must hold r.mu.
+func (r *ProductRepository) stockLocked(p *model.Product) int {
+	if !p.IsBundle() {
+		return p.Stock
+	}
+	return model.BundleStock(p.Components, func(id int) int {
+		if component, ok := r.products[id]; ok {
+			return component.Stock
+		}
+		return 0
+	})
+}
+
+func (r *ProductRepository) GetAll(filter model.ProductFilter) ([]*model.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := r.matchLocked(filter)
+	if filter.Limit > 0 && len(matches) > filter.Limit {
+		matches = matches[:filter.Limit]
+	}
+	return r.copyMatches(matches), nil
+}
+
+// GetPage returns one page of the matching products and the total number of matches,
+// starting at page.After when set. Only the products on the page are copied and enriched.
+func (r *ProductRepository) GetPage(filter model.ProductFilter, page model.PageRequest) (*model.Page[*model.Product], error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	products := make([]*model.Product, 0, len(r.products))
+	matches := r.matchLocked(filter)
+	start := min(page.Offset(), len(matches))
+	if page.After != nil {
+		start = cursorPosition(matches, page.After, filter)
+	}
+	end := min(start+page.Limit, len(matches))
+
+	result := &model.Page[*model.Product]{Items: r.copyMatches(matches[start:end]), Total: len(matches)}
+	if end < len(matches) && end > start && filter.Sort != model.ProductSortRelevance {
+		result.Next = model.NewProductCursor(result.Items[len(result.Items)-1], filter)
+	}
+	return result, nil
+}
+
+// matchLocked returns the products matching filter in the filter's order. Caller must hold r.mu.
+func (r *ProductRepository) matchLocked(filter model.ProductFilter) []scoredProduct {
+	var categoryIDs map[int]bool
+	if filter.CategoryID > 0 {
+		categoryIDs = r.categorySubtree(filter.CategoryID)
+	}
+
+	duePrices := r.duePricesLocked(time.Now())
+	matches := make([]scoredProduct, 0, len(r.products))
 	for _, p := range r.products {
-		// Filter by name if provided (case-insensitive partial match)
-		if name != "" {
-			loweredName := strings.ToLower(name)
-			loweredProductName := strings.ToLower(p.Name)
-			if !strings.Contains(loweredProductName, loweredName) {
+		if p.IsArchived() && !filter.IncludeArchived {
+			continue
+		}
+		if price, ok := duePrices[p.ID]; ok {
+			pCopy := *p
+			pCopy.Price = price
+			p = &pCopy
+		}
+		var relevance float64
+		if filter.Name != "" {
+			relevance = nameRelevance(filter.Name, p.Name)
+			if relevance < nameMatchThreshold || (filter.NameSubstring && relevance < 1) {
 				continue
 			}
 		}
-		pCopy := *p
+		if categoryIDs != nil && (p.CategoryID == nil || !categoryIDs[*p.CategoryID]) {
+			continue
+		}
+		if filter.MinPrice > 0 && p.Price < filter.MinPrice {
+			continue
+		}
+		if filter.MaxPrice > 0 && p.Price > filter.MaxPrice {
+			continue
+		}
+		stock := r.stockLocked(p)
+		if filter.InStockOnly && stock <= 0 {
+			continue
+		}
+		matches = append(matches, scoredProduct{product: p, relevance: relevance, stock: stock})
+	}
+
+	sortProducts(matches, filter)
+	return matches
+}
+
+// copyMatches returns enriched copies of the matched products. Caller must hold r.mu.
+func (r *ProductRepository) copyMatches(matches []scoredProduct) []*model.Product {
+	products := make([]*model.Product, 0, len(matches))
+	for _, m := range matches {
+		pCopy := *m.product
 		r.enrichWithCategory(&pCopy)
+		r.enrichWithComponents(&pCopy)
 		products = append(products, &pCopy)
 	}
-	return products, nil
+	return products
+}
+
+// ForEach calls fn for every product matching the filter, in the filter's order.
+// fn runs without the lock held, on a snapshot taken when the call starts.
+func (r *ProductRepository) ForEach(filter model.ProductFilter, fn func(p *model.Product) error) error {
+	products, err := r.GetAll(filter)
+	if err != nil {
+		return err
+	}
+	for _, p := range products {
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// categorySubtree returns the category ID together with all of its descendants.
+func (r *ProductRepository) categorySubtree(categoryID int) map[int]bool {
+	ids := map[int]bool{categoryID: true}
+	if r.categoryRepo == nil {
+		return ids
+	}
+	descendants, err := r.categoryRepo.GetDescendantIDs(categoryID)
+	if err != nil {
+		return ids
+	}
+	for _, id := range descendants {
+		ids[id] = true
+	}
+	return ids
 }
 
 func (r *ProductRepository) GetByID(id int) (*model.Product, error) {
@@ -67,6 +207,8 @@ func (r *ProductRepository) GetByID(id int) (*model.Product, error) {
 	}
 	pCopy := *p
 	r.enrichWithCategory(&pCopy)
+	r.enrichWithComponents(&pCopy)
+	r.overlayDuePriceLocked(&pCopy)
 	return &pCopy, nil
 }
 
@@ -74,32 +216,577 @@ func (r *ProductRepository) Create(product *model.Product) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.skuTaken(product.SKU, 0) {
+		return model.ErrSKUExists
+	}
+	if r.pluTaken(product.PLU, 0) {
+		return model.ErrPLUExists
+	}
+	if err := r.checkStockChangeLocked(product); err != nil {
+		return err
+	}
+	r.createLocked(product)
+	return nil
+}
+
+func (r *ProductRepository) Update(product *model.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.products[product.ID]
+	if !exists {
+		return model.ErrProductNotFound
+	}
+	if product.Version != 0 && product.Version != existing.Version {
+		return model.ErrVersionMismatch
+	}
+	if r.skuTaken(product.SKU, product.ID) {
+		return model.ErrSKUExists
+	}
+	if r.pluTaken(product.PLU, product.ID) {
+		return model.ErrPLUExists
+	}
+	if err := r.checkStockChangeLocked(product); err != nil {
+		return err
+	}
+	r.updateLocked(product)
+	return nil
+}
+
+// GetOutletStock returns the stock of a product at an outlet. A bundle has no stock of its own
+// at any outlet; it returns the number of complete bundles its components make there.
+func (r *ProductRepository) GetOutletStock(productID, outletID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, exists := r.products[productID]
+	if !exists {
+		return 0, model.ErrProductNotFound
+	}
+	if !r.outletExists(outletID) {
+		return 0, model.ErrOutletNotFound
+	}
+	if p.IsBundle() {
+		return model.BundleStock(p.Components, func(id int) int { return r.outletStock[id][outletID] }), nil
+	}
+	return r.outletStock[productID][outletID], nil
+}
+
+// GetNegativeStock returns the outlet balances below zero, ordered by outlet and product.
+func (r *ProductRepository) GetNegativeStock(outletID int) ([]*model.OutletStock, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	negative := make([]*model.OutletStock, 0)
+	for productID, byOutlet := range r.outletStock {
+		for id, stock := range byOutlet {
+			if stock >= 0 || (outletID != 0 && id != outletID) {
+				continue
+			}
+			negative = append(negative, &model.OutletStock{
+				OutletID:    id,
+				ProductID:   productID,
+				ProductName: r.products[productID].Name,
+				Stock:       stock,
+			})
+		}
+	}
+	sort.Slice(negative, func(a, b int) bool {
+		if negative[a].OutletID != negative[b].OutletID {
+			return negative[a].OutletID < negative[b].OutletID
+		}
+		return negative[a].ProductID < negative[b].ProductID
+	})
+	return negative, nil
+}
+
+// outletExists reports whether the outlet is known. Without a linked outlet repository only the
+// default outlet exists. Caller must hold r.mu.
+func (r *ProductRepository) outletExists(outletID int) bool {
+	if r.outlets == nil {
+		return outletID == model.DefaultOutletID
+	}
+	return r.outlets.exists(outletID)
+}
+
+// stockOutlet returns the outlet a stock change of product is booked at.
+func stockOutlet(product *model.Product) int {
+	if product.OutletID > 0 {
+		return product.OutletID
+	}
+	return model.DefaultOutletID
+}
+
+// totalStockLocked returns the stock of a product summed over all outlets. Caller must hold r.mu.
+func (r *ProductRepository) totalStockLocked(productID int) int {
+	total := 0
+	for _, stock := range r.outletStock[productID] {
+		total += stock
+	}
+	return total
+}
+
+// checkStockChangeLocked fails when booking the stock change of product at its outlet would take
+// that outlet below zero, unless the product allows negative stock. Caller must hold r.mu.
+func (r *ProductRepository) checkStockChangeLocked(product *model.Product) error {
+	if product.IsBundle() || !product.TracksStock() {
+		return nil
+	}
+	outletID := stockOutlet(product)
+	if !r.outletExists(outletID) {
+		return model.ErrOutletNotFound
+	}
+	delta := product.Stock - r.totalStockLocked(product.ID)
+	if r.outletStock[product.ID][outletID]+delta < 0 && !product.AllowNegativeStock {
+		return model.ErrOutletStock
+	}
+	return nil
+}
+
+// bookStockLocked books the difference between the product's stock and its outlet stock total
+// at the product's outlet. Bundles and products without stock hold no outlet stock.
+// Caller must hold the write lock.
+func (r *ProductRepository) bookStockLocked(product *model.Product) {
+	if product.IsBundle() || !product.TracksStock() {
+		delete(r.outletStock, product.ID)
+		return
+	}
+	delta := product.Stock - r.totalStockLocked(product.ID)
+	if delta == 0 {
+		return
+	}
+	if r.outletStock[product.ID] == nil {
+		r.outletStock[product.ID] = make(map[int]int)
+	}
+	r.outletStock[product.ID][stockOutlet(product)] += delta
+}
+
+// checkTakesLocked fails when the outlet lacks the stock a checkout takes. Bundles and products
+// without stock are skipped, and a product allowing negative stock may go below zero.
+// Caller must hold r.mu.
+func (r *ProductRepository) checkTakesLocked(takes []model.StockTake, outletID int) error {
+	if !r.outletExists(outletID) {
+		return model.ErrOutletNotFound
+	}
+	for _, take := range takes {
+		p, exists := r.products[take.ProductID]
+		if !exists {
+			return model.ErrProductNotFound
+		}
+		if p.IsBundle() || !p.TracksStock() {
+			continue
+		}
+		if r.outletStock[p.ID][outletID] < take.Quantity && !p.AllowNegativeStock {
+			return model.ErrInsufficientStock
+		}
+	}
+	return nil
+}
+
+// takeStockLocked takes checked stock at the outlet and bumps the version of every product it
+// changes. Caller must hold the write lock.
+func (r *ProductRepository) takeStockLocked(takes []model.StockTake, outletID int) {
+	for _, take := range takes {
+		p := r.products[take.ProductID]
+		if p.IsBundle() || !p.TracksStock() {
+			continue
+		}
+		p.Stock -= take.Quantity
+		p.Version++
+		if r.outletStock[p.ID] == nil {
+			r.outletStock[p.ID] = make(map[int]int)
+		}
+		r.outletStock[p.ID][outletID] -= take.Quantity
+	}
+}
+
+// GetBySKU returns the product with the given SKU, archived ones included.
+func (r *ProductRepository) GetBySKU(sku string) (*model.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.products {
+		if sku != "" && p.SKU == sku {
+			pCopy := *p
+			r.enrichWithCategory(&pCopy)
+			r.enrichWithComponents(&pCopy)
+			r.overlayDuePriceLocked(&pCopy)
+			return &pCopy, nil
+		}
+	}
+	return nil, model.ErrProductNotFound
+}
+
+// GetByPLU returns the product with the given scale PLU, archived ones included.
+func (r *ProductRepository) GetByPLU(plu string) (*model.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.products {
+		if plu != "" && p.PLU == plu {
+			pCopy := *p
+			r.enrichWithCategory(&pCopy)
+			r.enrichWithComponents(&pCopy)
+			r.overlayDuePriceLocked(&pCopy)
+			return &pCopy, nil
+		}
+	}
+	return nil, model.ErrProductNotFound
+}
+
+// Import writes the batch under the product lock. Every check runs before the first write,
+// so a rejected batch leaves nothing behind.
+func (r *ProductRepository) Import(batch *model.ProductImportBatch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	skus := make(map[string]bool)
+	for _, p := range batch.Products {
+		if p.ID > 0 {
+			if _, exists := r.products[p.ID]; !exists {
+				return model.ErrProductNotFound
+			}
+		}
+		if err := r.checkStockChangeLocked(p); err != nil {
+			return err
+		}
+		if p.SKU == "" {
+			continue
+		}
+		if skus[p.SKU] || r.skuTaken(p.SKU, p.ID) {
+			return model.ErrSKUExists
+		}
+		skus[p.SKU] = true
+	}
+	if len(batch.NewCategories) > 0 && r.categoryRepo == nil {
+		return model.ErrCategoryNotFound
+	}
+
+	for _, c := range batch.NewCategories {
+		if err := r.categoryRepo.Create(c); err != nil {
+			return err
+		}
+	}
+	for _, p := range batch.Products {
+		if p.ID > 0 {
+			r.updateLocked(p)
+		} else {
+			r.createLocked(p)
+		}
+	}
+	return nil
+}
+
+// ApplyBulk writes the batch under the product lock. Every product and version is checked
+// before the first write, so a rejected batch leaves nothing behind.
+func (r *ProductRepository) ApplyBulk(batch *model.ProductBulkBatch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, p := range batch.Products {
+		existing, exists := r.products[p.ID]
+		if !exists {
+			return model.ErrProductNotFound
+		}
+		if p.Version != 0 && p.Version != existing.Version {
+			return model.ErrVersionMismatch
+		}
+		if batch.Archive {
+			continue
+		}
+		if r.skuTaken(p.SKU, p.ID) {
+			return model.ErrSKUExists
+		}
+		if r.pluTaken(p.PLU, p.ID) {
+			return model.ErrPLUExists
+		}
+		if err := r.checkStockChangeLocked(p); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for _, p := range batch.Products {
+		if !batch.Archive {
+			r.updateLocked(p)
+			continue
+		}
+		existing := r.products[p.ID]
+		if !existing.IsArchived() {
+			existing.ArchivedAt = &now
+		}
+		existing.Version++
+	}
+	return nil
+}
+
+// IsBundleComponent reports whether any stored bundle uses the product as a component.
+func (r *ProductRepository) IsBundleComponent(productID int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.products {
+		for _, c := range p.Components {
+			if c.ProductID == productID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// ReplaceImage sets the product's image key and returns the previous one.
+func (r *ProductRepository) ReplaceImage(id int, imageKey string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, exists := r.products[id]
+	if !exists {
+		return "", model.ErrProductNotFound
+	}
+	previous := p.ImageKey
+	p.ImageKey = imageKey
+	p.Version++
+	return previous, nil
+}
+
+// skuTaken reports whether another product than exceptID already uses sku. Callers hold the lock.
+func (r *ProductRepository) skuTaken(sku string, exceptID int) bool {
+	if sku == "" {
+		return false
+	}
+	for _, p := range r.products {
+		if p.SKU == sku && p.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// pluTaken reports whether another product than exceptID already uses plu. Callers hold the lock.
+func (r *ProductRepository) pluTaken(plu string, exceptID int) bool {
+	if plu == "" {
+		return false
+	}
+	for _, p := range r.products {
+		if p.PLU == plu && p.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ProductRepository) createLocked(product *model.Product) {
 	product.ID = r.nextProductID
+	product.Version = 1
 	r.products[product.ID] = product
 	r.nextProductID++
+	r.bookStockLocked(product)
+	r.enrichWithCategory(product)
+	r.enrichWithComponents(product)
+}
+
+// updateLocked replaces a stored product, recording a price change when the price differs.
+// The version is always bumped; checking the expected version is up to the caller.
+func (r *ProductRepository) updateLocked(product *model.Product) {
+	existing := r.products[product.ID]
+	if existing.Price != product.Price {
+		now := time.Now()
+		oldPrice := existing.Price
+		r.appendPriceChange(&model.PriceChange{
+			ProductID:   product.ID,
+			OldPrice:    &oldPrice,
+			NewPrice:    product.Price,
+			ChangedBy:   product.ChangedBy,
+			EffectiveAt: now,
+			AppliedAt:   &now,
+			CreatedAt:   now,
+		})
+	}
+	product.ArchivedAt = existing.ArchivedAt
+	product.ImageKey = existing.ImageKey
+	product.Version = existing.Version + 1
+	r.products[product.ID] = product
+	r.bookStockLocked(product)
 	r.enrichWithCategory(product)
+	r.enrichWithComponents(product)
+}
+
+func (r *ProductRepository) Archive(id, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, exists := r.products[id]
+	if !exists {
+		return model.ErrProductNotFound
+	}
+	if version != 0 && version != p.Version {
+		return model.ErrVersionMismatch
+	}
+	if !p.IsArchived() {
+		now := time.Now()
+		p.ArchivedAt = &now
+	}
+	p.Version++
 	return nil
 }
 
-func (r *ProductRepository) Update(product *model.Product) error {
+func (r *ProductRepository) Restore(id int) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.products[product.ID]; !exists {
+	p, exists := r.products[id]
+	if !exists {
 		return model.ErrProductNotFound
 	}
-	r.products[product.ID] = product
-	r.enrichWithCategory(product)
+	p.ArchivedAt = nil
+	p.Version++
 	return nil
 }
 
-func (r *ProductRepository) Delete(id int) error {
+// GetPriceChanges returns one page of the price history of a product, newest effective time first.
+// Effective times are compared in microseconds, like the cursor and PostgreSQL.
+func (r *ProductRepository) GetPriceChanges(productID int, query model.PriceChangeQuery, page model.PageRequest) (*model.Page[*model.PriceChange], error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.products[productID]; !exists {
+		return nil, model.ErrProductNotFound
+	}
+
+	changes := make([]*model.PriceChange, 0)
+	for _, c := range r.priceChanges {
+		if c.ProductID != productID {
+			continue
+		}
+		if query.Status != "" && (query.Status == model.PriceChangeUpcoming) != c.IsUpcoming(query.Now) {
+			continue
+		}
+		cCopy := *c
+		changes = append(changes, &cCopy)
+	}
+	// less orders positions (effective micros, ID) newest first, ties by ID, like the cursor.
+	less := func(micros1 int64, id1 int, micros2 int64, id2 int) bool {
+		if micros1 != micros2 {
+			return micros1 > micros2
+		}
+		return id1 > id2
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return less(changes[i].EffectiveAt.UnixMicro(), changes[i].ID, changes[j].EffectiveAt.UnixMicro(), changes[j].ID)
+	})
+
+	start := min(page.Offset(), len(changes))
+	if page.After != nil {
+		start = sort.Search(len(changes), func(i int) bool {
+			return less(int64(page.After.Num), page.After.ID, changes[i].EffectiveAt.UnixMicro(), changes[i].ID)
+		})
+	}
+	end := min(start+page.Limit, len(changes))
+
+	result := &model.Page[*model.PriceChange]{Items: changes[start:end], Total: len(changes)}
+	if end < len(changes) && end > start {
+		result.Next = model.NewPriceChangeCursor(changes[end-1])
+	}
+	return result, nil
+}
+
+// CreatePriceChange stores a scheduled price change.
+func (r *ProductRepository) CreatePriceChange(change *model.PriceChange) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.products[id]; !exists {
+	if _, exists := r.products[change.ProductID]; !exists {
 		return model.ErrProductNotFound
 	}
-	delete(r.products, id)
+	change.CreatedAt = time.Now()
+	r.appendPriceChange(change)
 	return nil
 }
+
+// DeletePriceChange cancels a scheduled price change that has not been applied yet.
+func (r *ProductRepository) DeletePriceChange(productID, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, c := range r.priceChanges {
+		if c.ID != id || c.ProductID != productID {
+			continue
+		}
+		if c.IsApplied() {
+			return model.ErrPriceChangeApplied
+		}
+		r.priceChanges = append(r.priceChanges[:i], r.priceChanges[i+1:]...)
+		return nil
+	}
+	return model.ErrPriceChangeNotFound
+}
+
+// ApplyDuePriceChanges applies every pending price change whose effective time has passed,
+// in effective order, so the latest due change wins.
+func (r *ProductRepository) ApplyDuePriceChanges(now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := make([]*model.PriceChange, 0)
+	for _, c := range r.priceChanges {
+		if !c.IsApplied() && !c.EffectiveAt.After(now) {
+			due = append(due, c)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		if due[i].EffectiveAt.Equal(due[j].EffectiveAt) {
+			return due[i].ID < due[j].ID
+		}
+		return due[i].EffectiveAt.Before(due[j].EffectiveAt)
+	})
+
+	for _, c := range due {
+		p, exists := r.products[c.ProductID]
+		if !exists {
+			continue
+		}
+		oldPrice := p.Price
+		appliedAt := now
+		c.OldPrice = &oldPrice
+		c.AppliedAt = &appliedAt
+		p.Price = c.NewPrice
+		p.Version++
+	}
+	return nil
+}
+
+// duePricesLocked returns, per product, the price of the latest scheduled change that is due at now
+// but not applied yet, so reads see it before the price scheduler writes it. Caller must hold r.mu.
+func (r *ProductRepository) duePricesLocked(now time.Time) map[int]int {
+	prices := make(map[int]int)
+	latest := make(map[int]*model.PriceChange)
+	for _, c := range r.priceChanges {
+		if c.IsApplied() || c.EffectiveAt.After(now) {
+			continue
+		}
+		if l, ok := latest[c.ProductID]; ok && (l.EffectiveAt.After(c.EffectiveAt) ||
+			l.EffectiveAt.Equal(c.EffectiveAt) && l.ID > c.ID) {
+			continue
+		}
+		latest[c.ProductID] = c
+		prices[c.ProductID] = c.NewPrice
+	}
+	return prices
+}
+
+// overlayDuePriceLocked sets the price of a product copy to its due scheduled price, if any.
+// Caller must hold r.mu.
+func (r *ProductRepository) overlayDuePriceLocked(p *model.Product) {
+	if price, ok := r.duePricesLocked(time.Now())[p.ID]; ok {
+		p.Price = price
+	}
+}
+
+// appendPriceChange assigns an ID and stores a copy of the change. Caller must hold the write lock.
+func (r *ProductRepository) appendPriceChange(change *model.PriceChange) {
+	change.ID = r.nextPriceChangeID
+	r.nextPriceChangeID++
+	stored := *change
+	r.priceChanges = append(r.priceChanges, &stored)
+}
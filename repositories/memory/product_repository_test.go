@@ -2,7 +2,10 @@ package memory
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"testing"
+	"time"
 
 	model "kasir-api/models"
 )
@@ -18,7 +21,7 @@ func NewMockCategoryRepo() *MockCategoryRepo {
 	}
 }
 
-func (m *MockCategoryRepo) GetAll() ([]*model.Category, error) {
+func (m *MockCategoryRepo) GetAll(_ bool) ([]*model.Category, error) {
 	categories := make([]*model.Category, 0, len(m.categories))
 	for _, c := range m.categories {
 		categories = append(categories, c)
@@ -26,6 +29,11 @@ func (m *MockCategoryRepo) GetAll() ([]*model.Category, error) {
 	return categories, nil
 }
 
+func (m *MockCategoryRepo) GetPage(includeArchived bool, _ model.PageRequest) (*model.Page[*model.Category], error) {
+	categories, _ := m.GetAll(includeArchived)
+	return &model.Page[*model.Category]{Items: categories, Total: len(categories)}, nil
+}
+
 func (m *MockCategoryRepo) GetByID(id int) (*model.Category, error) {
 	c, exists := m.categories[id]
 	if !exists {
@@ -48,14 +56,35 @@ func (m *MockCategoryRepo) Update(category *model.Category) error {
 	return nil
 }
 
-func (m *MockCategoryRepo) Delete(id int) error {
-	if _, exists := m.categories[id]; !exists {
+func (m *MockCategoryRepo) Archive(id int, _ model.CategoryProductPolicy, _ int) error {
+	c, exists := m.categories[id]
+	if !exists {
 		return model.ErrNotFound
 	}
-	delete(m.categories, id)
+	now := time.Now()
+	c.ArchivedAt = &now
 	return nil
 }
 
+func (m *MockCategoryRepo) Restore(id int) error {
+	c, exists := m.categories[id]
+	if !exists {
+		return model.ErrNotFound
+	}
+	c.ArchivedAt = nil
+	return nil
+}
+
+func (m *MockCategoryRepo) GetDescendantIDs(id int) ([]int, error) {
+	var descendants []int
+	for _, c := range m.categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			descendants = append(descendants, c.ID)
+		}
+	}
+	return descendants, nil
+}
+
 func TestNewProductRepository(t *testing.T) {
 	repo := NewProductRepository(nil)
 
@@ -82,7 +111,7 @@ func TestNewProductRepository_WithCategoryRepo(t *testing.T) {
 func TestProductRepository_GetAll_Empty(t *testing.T) {
 	repo := NewProductRepository(nil)
 
-	products, err := repo.GetAll("")
+	products, err := repo.GetAll(model.ProductFilter{})
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -97,7 +126,7 @@ func TestProductRepository_GetAll_WithData(t *testing.T) {
 	repo.Create(&model.Product{Name: "Laptop", Price: 1000, Stock: 10})
 	repo.Create(&model.Product{Name: "Phone", Price: 500, Stock: 20})
 
-	products, err := repo.GetAll("")
+	products, err := repo.GetAll(model.ProductFilter{})
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -106,6 +135,92 @@ func TestProductRepository_GetAll_WithData(t *testing.T) {
 	}
 }
 
+func TestProductRepository_GetPage(t *testing.T) {
+	repo := NewProductRepository(nil)
+	for i := 1; i <= 5; i++ {
+		repo.Create(&model.Product{Name: fmt.Sprintf("Produk %d", i), Price: 1000 * i, Stock: i})
+	}
+	repo.Create(&model.Product{Name: "Habis", Price: 500, Stock: 0})
+
+	tests := []struct {
+		name      string
+		filter    model.ProductFilter
+		page      model.PageRequest
+		wantIDs   []int
+		wantTotal int
+	}{
+		{"first page", model.ProductFilter{}, model.PageRequest{Page: 1, Limit: 4}, []int{1, 2, 3, 4}, 6},
+		{"last page", model.ProductFilter{}, model.PageRequest{Page: 2, Limit: 4}, []int{5, 6}, 6},
+		{"past the end", model.ProductFilter{}, model.PageRequest{Page: 3, Limit: 4}, []int{}, 6},
+		{"counts after filtering", model.ProductFilter{InStockOnly: true, Sort: model.ProductSortPrice, Desc: true}, model.PageRequest{Page: 1, Limit: 2}, []int{5, 4}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repo.GetPage(tt.filter, tt.page)
+			if err != nil {
+				t.Fatalf("GetPage should not return error, got: %v", err)
+			}
+			if result.Total != tt.wantTotal {
+				t.Errorf("GetPage total = %d, want %d", result.Total, tt.wantTotal)
+			}
+			ids := make([]int, 0, len(result.Items))
+			for _, p := range result.Items {
+				ids = append(ids, p.ID)
+			}
+			if !slices.Equal(ids, tt.wantIDs) {
+				t.Errorf("GetPage IDs = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestProductRepository_GetPage_Cursor(t *testing.T) {
+	repo := NewProductRepository(nil)
+	for _, p := range []struct {
+		name  string
+		price int
+	}{{"Kopi", 3000}, {"Teh", 2000}, {"Susu", 3000}, {"Air", 1000}, {"Soda", 3000}} {
+		repo.Create(&model.Product{Name: p.name, Price: p.price, Stock: 5})
+	}
+
+	for _, filter := range []model.ProductFilter{
+		{},
+		{Sort: model.ProductSortPrice, Desc: true},
+		{Sort: model.ProductSortName},
+	} {
+		all, _ := repo.GetAll(filter)
+		want := make([]int, 0, len(all))
+		for _, p := range all {
+			want = append(want, p.ID)
+		}
+
+		// Walk the list two at a time; a product created mid-walk must not shift the pages.
+		var got []int
+		page := model.PageRequest{Page: 1, Limit: 2}
+		for i := 0; ; i++ {
+			result, err := repo.GetPage(filter, page)
+			if err != nil {
+				t.Fatalf("GetPage should not return error, got: %v", err)
+			}
+			for _, p := range result.Items {
+				got = append(got, p.ID)
+			}
+			if i == 0 {
+				repo.Create(&model.Product{Name: "AAA Baru", Price: 9000, Stock: 1})
+			}
+			if result.Next == nil {
+				break
+			}
+			page.After = result.Next
+		}
+		got = slices.DeleteFunc(got, func(id int) bool { return !slices.Contains(want, id) })
+		if !slices.Equal(got, want) {
+			t.Errorf("cursor walk with %+v = %v, want %v", filter, got, want)
+		}
+		repo.Archive(repo.nextProductID-1, 0)
+	}
+}
+
 func TestProductRepository_GetAll_WithNameFilter(t *testing.T) {
 	repo := NewProductRepository(nil)
 
@@ -113,7 +228,7 @@ func TestProductRepository_GetAll_WithNameFilter(t *testing.T) {
 	repo.Create(&model.Product{Name: "Laptop Basic", Price: 1000, Stock: 15})
 	repo.Create(&model.Product{Name: "Phone", Price: 500, Stock: 20})
 
-	products, err := repo.GetAll("laptop")
+	products, err := repo.GetAll(model.ProductFilter{Name: "laptop"})
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -127,7 +242,7 @@ func TestProductRepository_GetAll_CaseInsensitiveFilter(t *testing.T) {
 
 	repo.Create(&model.Product{Name: "LAPTOP", Price: 1000, Stock: 10})
 
-	products, err := repo.GetAll("laptop")
+	products, err := repo.GetAll(model.ProductFilter{Name: "laptop"})
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -141,7 +256,7 @@ func TestProductRepository_GetAll_NoMatch(t *testing.T) {
 
 	repo.Create(&model.Product{Name: "Laptop", Price: 1000, Stock: 10})
 
-	products, err := repo.GetAll("phone")
+	products, err := repo.GetAll(model.ProductFilter{Name: "phone"})
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -150,6 +265,32 @@ func TestProductRepository_GetAll_NoMatch(t *testing.T) {
 	}
 }
 
+func TestProductRepository_GetAll_CategoryIncludesDescendants(t *testing.T) {
+	categoryRepo := NewCategoryRepository()
+	minuman := &model.Category{Name: "Minuman"}
+	categoryRepo.Create(minuman)
+	soda := &model.Category{Name: "Soda", ParentID: &minuman.ID}
+	categoryRepo.Create(soda)
+	rokok := &model.Category{Name: "Rokok"}
+	categoryRepo.Create(rokok)
+
+	repo := NewProductRepository(categoryRepo)
+	repo.Create(&model.Product{Name: "Teh Botol", Price: 5000, Stock: 10, CategoryID: &minuman.ID})
+	repo.Create(&model.Product{Name: "Coca Cola", Price: 7000, Stock: 10, CategoryID: &soda.ID})
+	repo.Create(&model.Product{Name: "Sampoerna", Price: 30000, Stock: 10, CategoryID: &rokok.ID})
+	repo.Create(&model.Product{Name: "Tanpa Kategori", Price: 1000, Stock: 10})
+
+	products, _ := repo.GetAll(model.ProductFilter{CategoryID: minuman.ID})
+	if len(products) != 2 {
+		t.Errorf("Filtering by parent category should include sub-categories, got: %d products", len(products))
+	}
+
+	products, _ = repo.GetAll(model.ProductFilter{CategoryID: soda.ID})
+	if len(products) != 1 || products[0].Name != "Coca Cola" {
+		t.Errorf("Filtering by leaf category should return only its products, got: %v", products)
+	}
+}
+
 func TestProductRepository_GetByID_Success(t *testing.T) {
 	repo := NewProductRepository(nil)
 
@@ -306,29 +447,237 @@ func TestProductRepository_Update_WithCategory(t *testing.T) {
 	}
 }
 
-func TestProductRepository_Delete_Success(t *testing.T) {
+func TestProductRepository_Archive_Success(t *testing.T) {
 	repo := NewProductRepository(nil)
 
 	product := &model.Product{Name: "Laptop", Price: 1000, Stock: 10}
 	repo.Create(product)
 
-	err := repo.Delete(1)
+	err := repo.Archive(1, 0)
 	if err != nil {
-		t.Errorf("Delete should not return error, got: %v", err)
+		t.Errorf("Archive should not return error, got: %v", err)
+	}
+
+	archived, err := repo.GetByID(1)
+	if err != nil {
+		t.Errorf("Archived product should still be resolvable by ID, got: %v", err)
+	}
+	if !archived.IsArchived() {
+		t.Error("Archive should set archived_at")
+	}
+
+	products, _ := repo.GetAll(model.ProductFilter{})
+	if len(products) != 0 {
+		t.Errorf("GetAll should hide archived products, got: %d", len(products))
+	}
+	products, _ = repo.GetAll(model.ProductFilter{IncludeArchived: true})
+	if len(products) != 1 {
+		t.Errorf("GetAll with includeArchived should return 1 product, got: %d", len(products))
 	}
+}
+
+func TestProductRepository_Archive_NotFound(t *testing.T) {
+	repo := NewProductRepository(nil)
 
-	_, err = repo.GetByID(1)
+	err := repo.Archive(999, 0)
 	if !errors.Is(err, model.ErrProductNotFound) {
-		t.Error("Delete should remove product from storage")
+		t.Errorf("Archive should return ErrProductNotFound, got: %v", err)
+	}
+}
+
+func TestProductRepository_Restore_Success(t *testing.T) {
+	repo := NewProductRepository(nil)
+
+	repo.Create(&model.Product{Name: "Laptop", Price: 1000, Stock: 10})
+	repo.Archive(1, 0)
+
+	err := repo.Restore(1)
+	if err != nil {
+		t.Errorf("Restore should not return error, got: %v", err)
+	}
+
+	restored, _ := repo.GetByID(1)
+	if restored.IsArchived() {
+		t.Error("Restore should clear archived_at")
+	}
+	products, _ := repo.GetAll(model.ProductFilter{})
+	if len(products) != 1 {
+		t.Errorf("Restored product should be listed again, got: %d", len(products))
 	}
 }
 
-func TestProductRepository_Delete_NotFound(t *testing.T) {
+func TestProductRepository_Restore_NotFound(t *testing.T) {
 	repo := NewProductRepository(nil)
 
-	err := repo.Delete(999)
+	err := repo.Restore(999)
 	if !errors.Is(err, model.ErrProductNotFound) {
-		t.Errorf("Delete should return ErrProductNotFound, got: %v", err)
+		t.Errorf("Restore should return ErrProductNotFound, got: %v", err)
+	}
+}
+
+func TestProductRepository_Update_RecordsPriceChange(t *testing.T) {
+	repo := NewProductRepository(nil)
+
+	product := &model.Product{Name: "Rokok", Price: 28000, Stock: 10}
+	repo.Create(product)
+
+	repo.Update(&model.Product{ID: 1, Name: "Rokok", Price: 30000, Stock: 10, ChangedBy: "admin"})
+	repo.Update(&model.Product{ID: 1, Name: "Rokok Surya", Price: 30000, Stock: 8})
+
+	changes, err := priceHistory(repo, 1)
+	if err != nil {
+		t.Fatalf("GetPriceChanges should not return error, got: %v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("Only the price change should be recorded, got: %d entries", len(changes))
+	}
+	if changes[0].OldPrice == nil || *changes[0].OldPrice != 28000 || changes[0].NewPrice != 30000 {
+		t.Errorf("Price change should record 28000 -> 30000, got: %v -> %d", changes[0].OldPrice, changes[0].NewPrice)
+	}
+	if changes[0].ChangedBy != "admin" {
+		t.Errorf("Price change should record the actor, got: %s", changes[0].ChangedBy)
+	}
+	if !changes[0].IsApplied() {
+		t.Error("Price change from Update should be applied immediately")
+	}
+}
+
+func TestProductRepository_ApplyDuePriceChanges(t *testing.T) {
+	repo := NewProductRepository(nil)
+	repo.Create(&model.Product{Name: "Rokok", Price: 28000, Stock: 10})
+
+	now := time.Now()
+	repo.CreatePriceChange(&model.PriceChange{ProductID: 1, NewPrice: 29000, EffectiveAt: now.Add(-2 * time.Hour)})
+	repo.CreatePriceChange(&model.PriceChange{ProductID: 1, NewPrice: 30000, EffectiveAt: now.Add(-time.Hour)})
+	repo.CreatePriceChange(&model.PriceChange{ProductID: 1, NewPrice: 35000, EffectiveAt: now.Add(24 * time.Hour)})
+
+	if err := repo.ApplyDuePriceChanges(now); err != nil {
+		t.Fatalf("ApplyDuePriceChanges should not return error, got: %v", err)
+	}
+
+	product, _ := repo.GetByID(1)
+	if product.Price != 30000 {
+		t.Errorf("Latest due change should win, got price: %d", product.Price)
+	}
+
+	changes, _ := priceHistory(repo, 1)
+	if len(changes) != 3 {
+		t.Fatalf("GetPriceChanges should return 3 entries, got: %d", len(changes))
+	}
+	if changes[0].NewPrice != 35000 || changes[0].IsApplied() {
+		t.Error("Future change should be listed first and remain pending")
+	}
+	if changes[1].OldPrice == nil || *changes[1].OldPrice != 29000 {
+		t.Errorf("Second applied change should record old price 29000, got: %v", changes[1].OldPrice)
+	}
+}
+
+// priceHistory returns the whole price history of a product.
+func priceHistory(repo *ProductRepository, productID int) ([]*model.PriceChange, error) {
+	page, err := repo.GetPriceChanges(productID, model.PriceChangeQuery{}, model.PageRequest{Page: 1, Limit: 100})
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+func TestProductRepository_ReadsSeeDuePriceChanges(t *testing.T) {
+	repo := NewProductRepository(nil)
+	repo.Create(&model.Product{Name: "Rokok", Price: 28000, Stock: 10, PLU: "00042"})
+	repo.Create(&model.Product{Name: "Korek", Price: 29000, Stock: 10})
+
+	now := time.Now()
+	repo.CreatePriceChange(&model.PriceChange{ProductID: 1, NewPrice: 30000, EffectiveAt: now.Add(-2 * time.Second)})
+	repo.CreatePriceChange(&model.PriceChange{ProductID: 1, NewPrice: 31000, EffectiveAt: now.Add(-time.Second)})
+	repo.CreatePriceChange(&model.PriceChange{ProductID: 1, NewPrice: 35000, EffectiveAt: now.Add(time.Hour)})
+
+	product, _ := repo.GetByID(1)
+	if product.Price != 31000 || product.Version != 1 {
+		t.Errorf("Read just after effective_at should see the latest due price unwritten, got: %+v", product)
+	}
+	if product, _ := repo.GetByPLU("00042"); product.Price != 31000 {
+		t.Errorf("GetByPLU should see the due price, got: %d", product.Price)
+	}
+
+	products, _ := repo.GetAll(model.ProductFilter{MinPrice: 30000, Sort: model.ProductSortPrice})
+	if len(products) != 1 || products[0].ID != 1 || products[0].Price != 31000 {
+		t.Errorf("Price filters should use the due price, got: %v", products)
+	}
+	page, _ := repo.GetPage(model.ProductFilter{Sort: model.ProductSortPrice, Desc: true}, model.PageRequest{Page: 1, Limit: 1})
+	if len(page.Items) != 1 || page.Items[0].ID != 1 {
+		t.Errorf("Price order should use the due price, got: %v", page.Items)
+	}
+
+	changes, _ := priceHistory(repo, 1)
+	for _, c := range changes {
+		if c.IsApplied() {
+			t.Errorf("Reads should not apply price changes, got: %+v", c)
+		}
+	}
+}
+
+func TestProductRepository_DeletePriceChange(t *testing.T) {
+	repo := NewProductRepository(nil)
+	repo.Create(&model.Product{Name: "Rokok", Price: 28000, Stock: 10})
+
+	scheduled := &model.PriceChange{ProductID: 1, NewPrice: 30000, EffectiveAt: time.Now().Add(time.Hour)}
+	repo.CreatePriceChange(scheduled)
+	repo.Update(&model.Product{ID: 1, Name: "Rokok", Price: 29000, Stock: 10})
+
+	if err := repo.DeletePriceChange(1, 2); !errors.Is(err, model.ErrPriceChangeApplied) {
+		t.Errorf("Deleting an applied change should return ErrPriceChangeApplied, got: %v", err)
+	}
+	if err := repo.DeletePriceChange(1, scheduled.ID); err != nil {
+		t.Errorf("Deleting a pending change should not return error, got: %v", err)
+	}
+	if err := repo.DeletePriceChange(1, scheduled.ID); !errors.Is(err, model.ErrPriceChangeNotFound) {
+		t.Errorf("Deleting twice should return ErrPriceChangeNotFound, got: %v", err)
+	}
+}
+
+func TestProductRepository_GetPriceChangesPaginated(t *testing.T) {
+	repo := NewProductRepository(nil)
+	repo.Create(&model.Product{Name: "Rokok", Price: 28000, Stock: 10})
+	at := time.Now().Add(time.Hour)
+	for i, offset := range []time.Duration{0, time.Hour, 0, 2 * time.Hour} {
+		repo.CreatePriceChange(&model.PriceChange{ProductID: 1, NewPrice: 29000 + i, EffectiveAt: at.Add(offset)})
+	}
+
+	// Newest effective_at first; the two changes sharing a time go highest ID first.
+	want := []int{4, 2, 3, 1}
+	var got []int
+	page := model.PageRequest{Page: 1, Limit: 3}
+	for {
+		result, err := repo.GetPriceChanges(1, model.PriceChangeQuery{}, page)
+		if err != nil {
+			t.Fatalf("GetPriceChanges should not return error, got: %v", err)
+		}
+		if result.Total != 4 {
+			t.Errorf("GetPriceChanges total = %d, want 4", result.Total)
+		}
+		for _, c := range result.Items {
+			got = append(got, c.ID)
+		}
+		if result.Next == nil {
+			break
+		}
+		page.After = result.Next
+		page.Limit = 1
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("cursor walk = %v, want %v", got, want)
+	}
+}
+
+func TestProductRepository_PriceChanges_ProductNotFound(t *testing.T) {
+	repo := NewProductRepository(nil)
+
+	if _, err := priceHistory(repo, 999); !errors.Is(err, model.ErrProductNotFound) {
+		t.Errorf("GetPriceChanges should return ErrProductNotFound, got: %v", err)
+	}
+	err := repo.CreatePriceChange(&model.PriceChange{ProductID: 999, NewPrice: 1000, EffectiveAt: time.Now()})
+	if !errors.Is(err, model.ErrProductNotFound) {
+		t.Errorf("CreatePriceChange should return ErrProductNotFound, got: %v", err)
 	}
 }
 
@@ -350,7 +699,7 @@ func TestProductRepository_Concurrency(t *testing.T) {
 		<-done
 	}
 
-	products, err := repo.GetAll("")
+	products, err := repo.GetAll(model.ProductFilter{})
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -365,7 +714,7 @@ func TestProductRepository_GetAll_ReturnsCopy(t *testing.T) {
 	product := &model.Product{Name: "Laptop", Price: 1000, Stock: 10}
 	repo.Create(product)
 
-	products, _ := repo.GetAll("")
+	products, _ := repo.GetAll(model.ProductFilter{})
 
 	// Modify the returned product
 	products[0].Name = "Modified"
@@ -432,3 +781,336 @@ func TestProductRepository_EnrichWithCategory_CategoryNotFound(t *testing.T) {
 		t.Error("Product category should be nil when category not found")
 	}
 }
+
+func TestProductRepository_Create_DuplicateSKU(t *testing.T) {
+	repo := NewProductRepository(nil)
+
+	repo.Create(&model.Product{Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01"})
+
+	err := repo.Create(&model.Product{Name: "Mie Sedaap", Price: 3000, Stock: 5, SKU: "IDM-01"})
+	if !errors.Is(err, model.ErrSKUExists) {
+		t.Errorf("Create with a used SKU should return ErrSKUExists, got: %v", err)
+	}
+	if err := repo.Update(&model.Product{ID: 1, Name: "Indomie Goreng", Price: 3500, Stock: 10, SKU: "IDM-01"}); err != nil {
+		t.Errorf("Update keeping its own SKU should succeed, got: %v", err)
+	}
+
+	found, err := repo.GetBySKU("IDM-01")
+	if err != nil || found.ID != 1 {
+		t.Errorf("GetBySKU should find product 1, got: %v, %v", found, err)
+	}
+	if _, err := repo.GetBySKU("missing"); !errors.Is(err, model.ErrProductNotFound) {
+		t.Errorf("GetBySKU with unknown SKU should return ErrProductNotFound, got: %v", err)
+	}
+}
+
+func TestProductRepository_Import(t *testing.T) {
+	categoryRepo := NewCategoryRepository()
+	repo := NewProductRepository(categoryRepo)
+	repo.Create(&model.Product{Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01"})
+
+	newCategory := &model.Category{Name: "Minuman"}
+	batch := &model.ProductImportBatch{
+		NewCategories: []*model.Category{newCategory},
+		Products: []*model.Product{
+			{ID: 1, Name: "Indomie", Price: 4000, Stock: 20, SKU: "IDM-01"},
+			{Name: "Teh Botol", Price: 5000, Stock: 12, SKU: "TB-01", CategoryID: &newCategory.ID},
+		},
+	}
+	if err := repo.Import(batch); err != nil {
+		t.Fatalf("Import should not return error, got: %v", err)
+	}
+
+	if newCategory.ID == 0 {
+		t.Fatal("Import should create the new category")
+	}
+	updated, _ := repo.GetByID(1)
+	if updated.Price != 4000 || updated.Stock != 20 {
+		t.Errorf("Import should update product 1, got: %+v", updated)
+	}
+	created, err := repo.GetBySKU("TB-01")
+	if err != nil {
+		t.Fatalf("Import should create TB-01, got: %v", err)
+	}
+	if created.Category == nil || created.Category.Name != "Minuman" {
+		t.Errorf("Created product should link to the new category, got: %+v", created.Category)
+	}
+	changes, _ := priceHistory(repo, 1)
+	if len(changes) != 1 {
+		t.Errorf("Import update should record the price change, got: %d", len(changes))
+	}
+}
+
+func TestProductRepository_Import_RejectsWholeBatch(t *testing.T) {
+	categoryRepo := NewCategoryRepository()
+	repo := NewProductRepository(categoryRepo)
+	repo.Create(&model.Product{Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01"})
+
+	newCategory := &model.Category{Name: "Minuman"}
+	batch := &model.ProductImportBatch{
+		NewCategories: []*model.Category{newCategory},
+		Products: []*model.Product{
+			{Name: "Teh Botol", Price: 5000, Stock: 12, SKU: "TB-01", CategoryID: &newCategory.ID},
+			{Name: "Mie Sedaap", Price: 3000, Stock: 5, SKU: "IDM-01"},
+		},
+	}
+	if err := repo.Import(batch); !errors.Is(err, model.ErrSKUExists) {
+		t.Fatalf("Import with a taken SKU should return ErrSKUExists, got: %v", err)
+	}
+
+	products, _ := repo.GetAll(model.ProductFilter{})
+	if len(products) != 1 {
+		t.Errorf("Rejected import should not write products, got: %d", len(products))
+	}
+	categories, _ := categoryRepo.GetAll(true)
+	if len(categories) != 0 {
+		t.Errorf("Rejected import should not create categories, got: %d", len(categories))
+	}
+}
+
+func TestProductRepository_ForEach(t *testing.T) {
+	repo := NewProductRepository(nil)
+	for _, name := range []string{"Kopi", "Teh", "Kopi Susu"} {
+		repo.Create(&model.Product{Name: name, Price: 5000, Stock: 1})
+	}
+
+	var ids []int
+	err := repo.ForEach(model.ProductFilter{Name: "kopi"}, func(p *model.Product) error {
+		ids = append(ids, p.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEach should not return error, got: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("ForEach should visit matching products in ID order, got: %v", ids)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.ForEach(model.ProductFilter{}, func(_ *model.Product) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("ForEach should stop at the first error, got: %v after %d calls", err, calls)
+	}
+}
+
+func TestProductRepository_ReplaceImage(t *testing.T) {
+	repo := NewProductRepository(nil)
+	repo.Create(&model.Product{Name: "Kopi", Price: 5000, Stock: 10})
+
+	previous, err := repo.ReplaceImage(1, "products/1/a.jpg")
+	if err != nil || previous != "" {
+		t.Fatalf("First ReplaceImage should return no previous key, got: %q, %v", previous, err)
+	}
+	repo.Update(&model.Product{ID: 1, Name: "Kopi Hitam", Price: 5000, Stock: 10})
+
+	previous, _ = repo.ReplaceImage(1, "products/1/b.jpg")
+	if previous != "products/1/a.jpg" {
+		t.Errorf("Update should keep the image key, got previous: %q", previous)
+	}
+	if _, err := repo.ReplaceImage(99, ""); !errors.Is(err, model.ErrProductNotFound) {
+		t.Errorf("ReplaceImage on unknown product should return ErrProductNotFound, got: %v", err)
+	}
+}
+
+// seedProducts fills repo with n products for the pagination benchmarks.
+func seedProducts(b *testing.B, n int) *ProductRepository {
+	b.Helper()
+	categoryRepo := NewCategoryRepository()
+	categoryRepo.Create(&model.Category{Name: "Sembako"})
+	repo := NewProductRepository(categoryRepo)
+	categoryID := 1
+	for i := 1; i <= n; i++ {
+		if err := repo.Create(&model.Product{Name: fmt.Sprintf("Produk %d", i), Price: 1000 + i, Stock: i % 50, CategoryID: &categoryID}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return repo
+}
+
+// BenchmarkProductRepository_GetAllThenSlice measures the old listing path: load every match, then cut the page.
+func BenchmarkProductRepository_GetAllThenSlice(b *testing.B) {
+	for _, n := range []int{1_000, 10_000} {
+		repo := seedProducts(b, n)
+		page := model.PageRequest{Page: 3, Limit: 20}
+		b.Run(fmt.Sprintf("products=%d", n), func(b *testing.B) {
+			for b.Loop() {
+				products, err := repo.GetAll(model.ProductFilter{})
+				if err != nil {
+					b.Fatal(err)
+				}
+				start := min(page.Offset(), len(products))
+				_ = products[start:min(start+page.Limit, len(products))]
+			}
+		})
+	}
+}
+
+// BenchmarkProductRepository_GetPage measures the paginated path, which only copies the requested page.
+func BenchmarkProductRepository_GetPage(b *testing.B) {
+	for _, n := range []int{1_000, 10_000} {
+		repo := seedProducts(b, n)
+		page := model.PageRequest{Page: 3, Limit: 20}
+		b.Run(fmt.Sprintf("products=%d", n), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := repo.GetPage(model.ProductFilter{}, page); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestProductRepository_Version(t *testing.T) {
+	repo := NewProductRepository(nil)
+
+	product := &model.Product{Name: "Laptop", Price: 1000, Stock: 10}
+	repo.Create(product)
+	if product.Version != 1 {
+		t.Fatalf("Create should start at version 1, got: %d", product.Version)
+	}
+
+	stale := &model.Product{ID: 1, Name: "Laptop", Price: 1000, Stock: 9, Version: 1}
+	fresh := &model.Product{ID: 1, Name: "Laptop", Price: 1000, Stock: 8, Version: 1}
+	if err := repo.Update(fresh); err != nil {
+		t.Fatalf("Update with the current version should succeed, got: %v", err)
+	}
+	if fresh.Version != 2 {
+		t.Errorf("Update should bump the version to 2, got: %d", fresh.Version)
+	}
+	if err := repo.Update(stale); !errors.Is(err, model.ErrVersionMismatch) {
+		t.Errorf("Update with a stale version should return ErrVersionMismatch, got: %v", err)
+	}
+	if err := repo.Archive(1, 1); !errors.Is(err, model.ErrVersionMismatch) {
+		t.Errorf("Archive with a stale version should return ErrVersionMismatch, got: %v", err)
+	}
+
+	got, _ := repo.GetByID(1)
+	if got.Stock != 8 || got.IsArchived() {
+		t.Errorf("Rejected writes should leave the product untouched, got: %+v", got)
+	}
+
+	if err := repo.Update(&model.Product{ID: 1, Name: "Laptop", Price: 1000, Stock: 7}); err != nil {
+		t.Errorf("Update without a version should skip the check, got: %v", err)
+	}
+	if err := repo.Archive(1, 3); err != nil {
+		t.Errorf("Archive with the current version should succeed, got: %v", err)
+	}
+	repo.Restore(1)
+	repo.ReplaceImage(1, "products/1.jpg")
+	got, _ = repo.GetByID(1)
+	if got.Version != 6 {
+		t.Errorf("Every write should bump the version, want 6, got: %d", got.Version)
+	}
+}
+
+func TestProductRepository_ApplyBulk(t *testing.T) {
+	repo := NewProductRepository(nil)
+	repo.Create(&model.Product{Name: "Teh", Price: 5000, Stock: 10})
+	repo.Create(&model.Product{Name: "Kopi", Price: 8000, Stock: 5})
+
+	teh, _ := repo.GetByID(1)
+	kopi, _ := repo.GetByID(2)
+	teh.Price, kopi.Price = 5500, 8800
+	kopi.Version = 9
+	err := repo.ApplyBulk(&model.ProductBulkBatch{Products: []*model.Product{teh, kopi}})
+	if !errors.Is(err, model.ErrVersionMismatch) {
+		t.Fatalf("ApplyBulk with a stale version should return ErrVersionMismatch, got: %v", err)
+	}
+	if got, _ := repo.GetByID(1); got.Price != 5000 {
+		t.Errorf("A rejected batch should leave every product untouched, got price: %d", got.Price)
+	}
+
+	kopi.Version = 1
+	if err := repo.ApplyBulk(&model.ProductBulkBatch{Products: []*model.Product{teh, kopi}}); err != nil {
+		t.Fatalf("ApplyBulk should not return error, got: %v", err)
+	}
+	if got, _ := repo.GetByID(2); got.Price != 8800 || got.Version != 2 {
+		t.Errorf("ApplyBulk should update and bump the version, got: %+v", got)
+	}
+	if changes, _ := priceHistory(repo, 1); len(changes) != 1 {
+		t.Errorf("ApplyBulk should record price changes, got: %d", len(changes))
+	}
+
+	err = repo.ApplyBulk(&model.ProductBulkBatch{Products: []*model.Product{{ID: 1, Version: 2}, {ID: 2}}, Archive: true})
+	if err != nil {
+		t.Fatalf("ApplyBulk archive should not return error, got: %v", err)
+	}
+	if products, _ := repo.GetAll(model.ProductFilter{}); len(products) != 0 {
+		t.Errorf("ApplyBulk archive should archive both products, got: %d active", len(products))
+	}
+}
+
+func TestProductRepository_BundleStock(t *testing.T) {
+	repo := NewProductRepository(nil)
+	repo.Create(&model.Product{Name: "Kopi", Price: 8000, Stock: 7})
+	repo.Create(&model.Product{Name: "Roti", Price: 6000, Stock: 9})
+	repo.Create(&model.Product{Name: "Paket Sarapan", Price: 12000, Components: []model.BundleComponent{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 1},
+	}})
+
+	bundle, _ := repo.GetByID(3)
+	if bundle.Stock != 3 {
+		t.Errorf("Bundle stock should be limited by its scarcest component, want 3, got: %d", bundle.Stock)
+	}
+	if bundle.Components[0].Name != "Kopi" {
+		t.Errorf("Bundle components should carry the component name, got: %q", bundle.Components[0].Name)
+	}
+
+	kopi, _ := repo.GetByID(1)
+	kopi.Stock = 1
+	repo.Update(kopi)
+	if bundle, _ := repo.GetByID(3); bundle.Stock != 0 {
+		t.Errorf("Bundle stock should follow its components, want 0, got: %d", bundle.Stock)
+	}
+	if products, _ := repo.GetAll(model.ProductFilter{InStockOnly: true}); len(products) != 2 {
+		t.Errorf("InStockOnly should use the derived bundle stock, want 2 products, got: %d", len(products))
+	}
+
+	if used, _ := repo.IsBundleComponent(2); !used {
+		t.Error("IsBundleComponent should report a product used by a bundle")
+	}
+	if used, _ := repo.IsBundleComponent(3); used {
+		t.Error("IsBundleComponent should not report a product no bundle uses")
+	}
+}
+
+func TestProductRepository_NegativeStock(t *testing.T) {
+	repo := NewProductRepository(nil)
+	repo.Create(&model.Product{Name: "Gula", Price: 16000, Stock: 2})
+	repo.Create(&model.Product{Name: "Pulsa", Price: 10000, Stock: 3, AllowNegativeStock: true})
+	repo.Create(&model.Product{Name: "Fotocopy", Price: 500, Stock: 5, Type: model.ProductTypeService})
+
+	gula, _ := repo.GetByID(1)
+	gula.Stock = -1
+	if err := repo.Update(gula); !errors.Is(err, model.ErrOutletStock) {
+		t.Errorf("Update below zero should return ErrOutletStock, got: %v", err)
+	}
+
+	pulsa, _ := repo.GetByID(2)
+	pulsa.Stock = -4
+	if err := repo.Update(pulsa); err != nil {
+		t.Fatalf("Update below zero should be allowed with AllowNegativeStock, got: %v", err)
+	}
+	if stock, _ := repo.GetOutletStock(2, model.DefaultOutletID); stock != -4 {
+		t.Errorf("Outlet stock should be -4, got: %d", stock)
+	}
+	if stock, _ := repo.GetOutletStock(3, model.DefaultOutletID); stock != 0 {
+		t.Errorf("A service should hold no outlet stock, got: %d", stock)
+	}
+
+	negative, err := repo.GetNegativeStock(0)
+	if err != nil {
+		t.Fatalf("GetNegativeStock should not return error, got: %v", err)
+	}
+	if len(negative) != 1 || negative[0].ProductID != 2 || negative[0].ProductName != "Pulsa" || negative[0].Stock != -4 {
+		t.Errorf("GetNegativeStock should flag Pulsa at -4, got: %+v", negative)
+	}
+	if negative, _ := repo.GetNegativeStock(2); len(negative) != 0 {
+		t.Errorf("GetNegativeStock for another outlet should be empty, got: %+v", negative)
+	}
+}
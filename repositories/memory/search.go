@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	model "kasir-api/models"
+)
+
+// nameMatchThreshold mirrors pg_trgm's default word_similarity_threshold, so both backends
+// accept roughly the same typos.
+const nameMatchThreshold = 0.6
+
+// nameRelevance scores how well name matches query, from 0 to 1.
+// A case-insensitive substring match scores 1; otherwise the score is the share of the query's
+// trigrams found in the name, the in-memory counterpart of pg_trgm's word_similarity.
+func nameRelevance(query, name string) float64 {
+	if strings.Contains(strings.ToLower(name), strings.ToLower(query)) {
+		return 1
+	}
+	queryTrigrams := trigrams(query)
+	if len(queryTrigrams) == 0 {
+		return 0
+	}
+	nameTrigrams := trigrams(name)
+	shared := 0
+	for t := range queryTrigrams {
+		if nameTrigrams[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(queryTrigrams))
+}
+
+// trigrams splits s into lower-cased alphanumeric words and returns their trigrams,
+// padding each word like pg_trgm does ("  w", " wo", "wor", "ord", "rd ").
+func trigrams(s string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	set := make(map[string]bool)
+	for _, w := range words {
+		padded := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// scoredProduct pairs a product with its name relevance and available stock for sorting.
+type scoredProduct struct {
+	product   *model.Product
+	relevance float64
+	stock     int
+}
+
+// sortProducts orders matches by filter.Sort, breaking ties by ID.
+func sortProducts(matches []scoredProduct, filter model.ProductFilter) {
+	sort.Slice(matches, func(i, j int) bool { return lessProduct(matches[i], matches[j], filter) })
+}
+
+// lessProduct reports whether a comes before b in the filter's order.
+func lessProduct(a, b scoredProduct, filter model.ProductFilter) bool {
+	var cmp int
+	switch filter.Sort {
+	case model.ProductSortName:
+		cmp = strings.Compare(strings.ToLower(a.product.Name), strings.ToLower(b.product.Name))
+	case model.ProductSortPrice:
+		cmp = a.product.Price - b.product.Price
+	case model.ProductSortStock:
+		cmp = a.stock - b.stock
+	case model.ProductSortRelevance:
+		if a.relevance != b.relevance {
+			return a.relevance > b.relevance
+		}
+	}
+	if cmp != 0 {
+		if filter.Desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+	return a.product.ID < b.product.ID
+}
+
+// cursorPosition returns the index of the first match after cursor in sorted matches.
+// The cursor is turned back into a product carrying the same sort key so the order is compared exactly.
+func cursorPosition(matches []scoredProduct, cursor *model.Cursor, filter model.ProductFilter) int {
+	key := scoredProduct{product: &model.Product{ID: cursor.ID, Name: cursor.Text, Price: cursor.Num}, stock: cursor.Num}
+	return sort.Search(len(matches), func(i int) bool { return lessProduct(key, matches[i], filter) })
+}
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"testing"
+
+	model "kasir-api/models"
+)
+
+func TestNameRelevance(t *testing.T) {
+	tests := []struct {
+		query, name string
+		match       bool
+	}{
+		{"goreng", "Indomie Goreng", true},
+		{"INDOMIE", "Indomie Goreng", true},
+		{"indomi goreng", "Indomie Goreng", true},
+		{"indomei", "Indomie Goreng", true},
+		{"kopi", "Teh Botol", false},
+		{"sabun", "Sampo Lifebuoy", false},
+	}
+	for _, tt := range tests {
+		got := nameRelevance(tt.query, tt.name) >= nameMatchThreshold
+		if got != tt.match {
+			t.Errorf("nameRelevance(%q, %q) match = %v, want %v (score %.2f)",
+				tt.query, tt.name, got, tt.match, nameRelevance(tt.query, tt.name))
+		}
+	}
+	if nameRelevance("indomie", "Indomie") <= nameRelevance("indomei", "Indomie") {
+		t.Error("A substring match should score higher than a typo")
+	}
+}
+
+func TestProductRepository_GetAll_SearchFilters(t *testing.T) {
+	repo := NewProductRepository(nil)
+	repo.Create(&model.Product{Name: "Indomie Goreng", Price: 3500, Stock: 10})
+	repo.Create(&model.Product{Name: "Indomie Soto", Price: 3000, Stock: 0})
+	repo.Create(&model.Product{Name: "Kopi Kapal Api", Price: 2000, Stock: 50})
+	repo.Create(&model.Product{Name: "Beras 5kg", Price: 75000, Stock: 4})
+
+	tests := []struct {
+		name   string
+		filter model.ProductFilter
+		want   []int
+	}{
+		{"typo", model.ProductFilter{Name: "indomei"}, []int{1, 2}},
+		{"substring only", model.ProductFilter{Name: "indomei", NameSubstring: true}, []int{}},
+		{"substring only match", model.ProductFilter{Name: "MIE GOR", NameSubstring: true}, []int{1}},
+		{"price range", model.ProductFilter{MinPrice: 2500, MaxPrice: 5000}, []int{1, 2}},
+		{"in stock only", model.ProductFilter{Name: "indomie", InStockOnly: true}, []int{1}},
+		{"sort by price", model.ProductFilter{Sort: model.ProductSortPrice}, []int{3, 2, 1, 4}},
+		{"sort by stock desc", model.ProductFilter{Sort: model.ProductSortStock, Desc: true}, []int{3, 1, 4, 2}},
+		{"sort by name", model.ProductFilter{Sort: model.ProductSortName}, []int{4, 1, 2, 3}},
+		{"relevance", model.ProductFilter{Name: "indomie sot", Sort: model.ProductSortRelevance}, []int{2, 1}},
+		{"limit", model.ProductFilter{Limit: 2}, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, err := repo.GetAll(tt.filter)
+			if err != nil {
+				t.Fatalf("GetAll should not return error, got: %v", err)
+			}
+			got := make([]int, len(products))
+			for i, p := range products {
+				got[i] = p.ID
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("GetAll returned %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("GetAll returned %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
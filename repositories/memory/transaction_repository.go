@@ -1,6 +1,8 @@
 package memory
 
 import (
+	"cmp"
+	"slices"
 	"sync"
 	"time"
 
@@ -8,17 +10,24 @@ import (
 )
 
 // TransactionRepository holds in-memory transaction storage and implements repository.TransactionRepository.
+// Checkout takes stock from the linked product repository and records ingredient movements in the
+// linked ingredient repository, locking products, then ingredients, then transactions.
 type TransactionRepository struct {
 	mu           sync.RWMutex
 	transactions map[int]*model.Transaction
 	nextID       int
+	products     *ProductRepository
+	ingredients  *IngredientRepository
 }
 
-// NewTransactionRepository creates a new in-memory transaction repository.
-func NewTransactionRepository() *TransactionRepository {
+// NewTransactionRepository creates a new in-memory transaction repository booking checkouts against
+// products and ingredients. Either may be nil; a checkout that needs it then fails as not found.
+func NewTransactionRepository(products *ProductRepository, ingredients *IngredientRepository) *TransactionRepository {
 	return &TransactionRepository{
 		transactions: make(map[int]*model.Transaction),
 		nextID:       1,
+		products:     products,
+		ingredients:  ingredients,
 	}
 }
 
@@ -27,6 +36,12 @@ func (r *TransactionRepository) Create(transaction *model.Transaction) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	r.createLocked(transaction)
+	return nil
+}
+
+// createLocked assigns the transaction and detail IDs and stores a copy. Caller must hold the write lock.
+func (r *TransactionRepository) createLocked(transaction *model.Transaction) {
 	transaction.ID = r.nextID
 	r.nextID++
 
@@ -41,7 +56,47 @@ func (r *TransactionRepository) Create(transaction *model.Transaction) error {
 	stored.Details = make([]model.TransactionDetail, len(transaction.Details))
 	copy(stored.Details, transaction.Details)
 	r.transactions[transaction.ID] = &stored
+}
+
+// Checkout writes the batch while holding the product, ingredient and transaction locks. Stock and
+// ingredients are checked before the first write, so a rejected checkout leaves nothing behind.
+func (r *TransactionRepository) Checkout(batch *model.CheckoutBatch) error {
+	transaction := batch.Transaction
+	takes := transaction.StockTakes()
+	if r.products == nil && len(takes) > 0 {
+		return model.ErrProductNotFound
+	}
+	if r.ingredients == nil && len(batch.Movements) > 0 {
+		return model.ErrIngredientNotFound
+	}
+
+	if r.products != nil {
+		r.products.mu.Lock()
+		defer r.products.mu.Unlock()
+		if err := r.products.checkTakesLocked(takes, transaction.OutletID); err != nil {
+			return err
+		}
+	}
+	if r.ingredients != nil {
+		r.ingredients.mu.Lock()
+		defer r.ingredients.mu.Unlock()
+		if err := r.ingredients.checkMovementsLocked(batch.Movements); err != nil {
+			return err
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
+	if r.products != nil {
+		r.products.takeStockLocked(takes, transaction.OutletID)
+	}
+	r.createLocked(transaction)
+	if r.ingredients != nil {
+		for _, m := range batch.Movements {
+			m.TransactionID = &transaction.ID
+		}
+		r.ingredients.recordMovementsLocked(batch.Movements)
+	}
 	return nil
 }
 
@@ -62,13 +117,16 @@ func (r *TransactionRepository) GetByID(id int) (*model.Transaction, error) {
 	return &result, nil
 }
 
-// GetReportByDateRange returns report data for a given date range.
-func (r *TransactionRepository) GetReportByDateRange(startDate, endDate time.Time) (*model.ReportResponse, error) {
+// GetReportByDateRange returns report data for a given date range, for one outlet or all when outletID is 0.
+func (r *TransactionRepository) GetReportByDateRange(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	report := &model.ReportResponse{}
-	productQty := make(map[string]int)
+	byProduct := make(map[int]*model.ProdukTerlaris)
+	// Each product is named after its latest line, by transaction ID then detail ID.
+	latest := make(map[int][2]int)
+	var custom model.ItemCustom
 
 	for _, t := range r.transactions {
 		// Check if transaction is within date range [startDate, endDate)
@@ -76,31 +134,224 @@ func (r *TransactionRepository) GetReportByDateRange(startDate, endDate time.Tim
 		if t.CreatedAt.Before(startDate) || !t.CreatedAt.Before(endDate) {
 			continue
 		}
+		if outletID > 0 && t.OutletID != outletID {
+			continue
+		}
 
 		report.TotalRevenue += t.TotalAmount
 		report.TotalTransaksi++
 
 		for _, d := range t.Details {
-			productQty[d.ProductName] += d.Quantity
+			// Bundle components are stock movements, the bundle line is what was sold.
+			if d.BundleProductID != nil {
+				continue
+			}
+			// Custom items are reported as their own group.
+			if d.IsCustom() {
+				custom.JumlahBaris++
+				custom.QtyTerjual += d.Quantity
+				custom.Revenue += d.Subtotal
+				continue
+			}
+			best, ok := byProduct[d.ProductID]
+			if !ok {
+				best = &model.ProdukTerlaris{ProductID: d.ProductID}
+				byProduct[d.ProductID] = best
+			}
+			if l := latest[d.ProductID]; !ok || cmp.Or(cmp.Compare(t.ID, l[0]), cmp.Compare(d.ID, l[1])) > 0 {
+				best.Nama = d.ProductName
+				latest[d.ProductID] = [2]int{t.ID, d.ID}
+			}
+			best.QtyTerjual += d.Quantity
+			best.ItemTerjual += d.ItemCount()
 		}
 	}
 
-	// Find best selling product
-	var maxQty int
-	var bestProduct string
-	for name, qty := range productQty {
-		if qty > maxQty {
-			maxQty = qty
-			bestProduct = name
+	// Rank by items so grams are never compared with pieces; ties go to the lowest product ID.
+	for _, best := range byProduct {
+		if report.ProdukTerlaris == nil || best.ItemTerjual > report.ProdukTerlaris.ItemTerjual ||
+			best.ItemTerjual == report.ProdukTerlaris.ItemTerjual && best.ProductID < report.ProdukTerlaris.ProductID {
+			report.ProdukTerlaris = best
 		}
 	}
 
-	if bestProduct != "" {
-		report.ProdukTerlaris = &model.ProdukTerlaris{
-			Nama:       bestProduct,
-			QtyTerjual: maxQty,
+	if custom.JumlahBaris > 0 {
+		report.ItemCustom = &custom
+	}
+
+	return report, nil
+}
+
+// GetTimeSeries returns the sales of [startDate, endDate) per bucket, for one outlet or all when outletID is 0.
+func (r *TransactionRepository) GetTimeSeries(startDate, endDate time.Time, granularity string, outletID int) (*model.TimeSeriesReport, error) {
+	report, err := model.NewTimeSeriesReport(startDate, endDate, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, t := range r.transactions {
+		if t.CreatedAt.Before(startDate) || !t.CreatedAt.Before(endDate) {
+			continue
+		}
+		if outletID > 0 && t.OutletID != outletID {
+			continue
+		}
+		bucket := report.Bucket(t.CreatedAt)
+		if bucket == nil {
+			continue
+		}
+		bucket.TotalRevenue += t.TotalAmount
+		bucket.TotalTransaksi++
+		for _, d := range t.Details {
+			// Bundle components are stock movements, the bundle line is what was sold.
+			if d.BundleProductID == nil {
+				bucket.ItemTerjual += d.ItemCount()
+			}
 		}
 	}
 
+	report.SetAverages()
 	return report, nil
 }
+
+// GetCategorySales returns the sales of [startDate, endDate) per category snapshot, for one outlet or all
+// when outletID is 0. Custom items get a row of their own.
+func (r *TransactionRepository) GetCategorySales(startDate, endDate time.Time, outletID int) ([]*model.CategorySales, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	const uncategorized, custom = 0, -1
+	byCategory := make(map[int]*model.CategorySales)
+	for _, t := range r.transactions {
+		if t.CreatedAt.Before(startDate) || !t.CreatedAt.Before(endDate) {
+			continue
+		}
+		if outletID > 0 && t.OutletID != outletID {
+			continue
+		}
+		for _, d := range t.Details {
+			// Bundle components are stock movements, the bundle line is what was sold.
+			if d.BundleProductID != nil {
+				continue
+			}
+			key := uncategorized
+			switch {
+			case d.IsCustom():
+				key = custom
+			case d.CategoryID != nil:
+				key = *d.CategoryID
+			}
+			row, ok := byCategory[key]
+			if !ok {
+				row = &model.CategorySales{Custom: key == custom}
+				if key > 0 {
+					id := *d.CategoryID
+					row.CategoryID = &id
+				}
+				byCategory[key] = row
+			}
+			row.ItemTerjual += d.ItemCount()
+			row.Revenue += d.Subtotal
+		}
+	}
+
+	rows := make([]*model.CategorySales, 0, len(byCategory))
+	for _, row := range byCategory {
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b *model.CategorySales) int {
+		if a.Revenue != b.Revenue {
+			return cmp.Compare(b.Revenue, a.Revenue)
+		}
+		switch {
+		case a.Custom != b.Custom:
+			if a.Custom {
+				return 1
+			}
+			return -1
+		case a.CategoryID == nil:
+			return 1
+		case b.CategoryID == nil:
+			return -1
+		}
+		return cmp.Compare(*a.CategoryID, *b.CategoryID)
+	})
+	return rows, nil
+}
+
+// GetProductSales ranks the products sold in [startDate, endDate), for one outlet or all when outletID is 0.
+func (r *TransactionRepository) GetProductSales(startDate, endDate time.Time, outletID int, query model.ProductSalesQuery) ([]*model.ProductSales, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byProduct := make(map[int]*model.ProductSales)
+	total := 0
+	for _, t := range r.transactions {
+		if t.CreatedAt.Before(startDate) || !t.CreatedAt.Before(endDate) {
+			continue
+		}
+		if outletID > 0 && t.OutletID != outletID {
+			continue
+		}
+		for _, d := range t.Details {
+			// Bundle components are stock movements and custom items belong to no product.
+			if d.BundleProductID != nil || d.IsCustom() {
+				continue
+			}
+			row, ok := byProduct[d.ProductID]
+			if !ok {
+				row = &model.ProductSales{ProductID: d.ProductID}
+				byProduct[d.ProductID] = row
+			}
+			row.QtyTerjual += d.Quantity
+			row.ItemTerjual += d.ItemCount()
+			row.Revenue += d.Subtotal
+			total += d.Subtotal
+		}
+	}
+
+	rows := make([]*model.ProductSales, 0, len(byProduct))
+	for _, row := range byProduct {
+		row.Persentase = model.Share(row.Revenue, total)
+		rows = append(rows, row)
+	}
+	slices.SortFunc(rows, func(a, b *model.ProductSales) int {
+		c := cmp.Compare(a.ItemTerjual, b.ItemTerjual)
+		if query.Sort == model.ProductSalesSortRevenue {
+			c = cmp.Compare(a.Revenue, b.Revenue)
+		}
+		if query.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ProductID, b.ProductID)
+	})
+	if query.Limit > 0 && len(rows) > query.Limit {
+		rows = rows[:query.Limit]
+	}
+	return rows, nil
+}
+
+// GetHeatmap counts the sales of [startDate, endDate) by day and hour in loc, for one outlet or all
+// when outletID is 0.
+func (r *TransactionRepository) GetHeatmap(startDate, endDate time.Time, loc *time.Location, outletID int) (*model.SalesHeatmap, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	heatmap := model.NewSalesHeatmap(loc)
+	for _, t := range r.transactions {
+		if t.CreatedAt.Before(startDate) || !t.CreatedAt.Before(endDate) {
+			continue
+		}
+		if outletID > 0 && t.OutletID != outletID {
+			continue
+		}
+		heatmap.Add(t.CreatedAt, t.TotalAmount)
+	}
+	return heatmap, nil
+}
@@ -9,7 +9,7 @@ import (
 )
 
 func TestNewTransactionRepository(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	if repo == nil {
 		t.Error("NewTransactionRepository should return a non-nil repository")
@@ -23,7 +23,7 @@ func TestNewTransactionRepository(t *testing.T) {
 }
 
 func TestTransactionRepository_Create_Success(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	transaction := &model.Transaction{
 		TotalAmount: 1000,
@@ -46,7 +46,7 @@ func TestTransactionRepository_Create_Success(t *testing.T) {
 }
 
 func TestTransactionRepository_Create_AssignsDetailIDs(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	transaction := &model.Transaction{
 		TotalAmount: 2000,
@@ -80,7 +80,7 @@ func TestTransactionRepository_Create_AssignsDetailIDs(t *testing.T) {
 }
 
 func TestTransactionRepository_Create_Multiple(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	tx1 := &model.Transaction{TotalAmount: 1000, CreatedAt: time.Now()}
 	tx2 := &model.Transaction{TotalAmount: 2000, CreatedAt: time.Now()}
@@ -96,8 +96,54 @@ func TestTransactionRepository_Create_Multiple(t *testing.T) {
 	}
 }
 
+func TestTransactionRepository_Checkout(t *testing.T) {
+	products := NewProductRepository(nil)
+	ingredients := NewIngredientRepository()
+	repo := NewTransactionRepository(products, ingredients)
+
+	kopi := &model.Product{Name: "Kopi", Price: 5000, Stock: 3}
+	products.Create(kopi)
+	susu := &model.Ingredient{Name: "Susu", Unit: "ml", Stock: 1000}
+	ingredients.Create(susu)
+	created, _ := products.GetByID(kopi.ID)
+
+	sale := func(quantity int) *model.CheckoutBatch {
+		return &model.CheckoutBatch{
+			Transaction: &model.Transaction{
+				OutletID: model.DefaultOutletID,
+				Details:  []model.TransactionDetail{{ProductID: kopi.ID, ProductName: "Kopi", Quantity: quantity}},
+			},
+			Movements: []*model.IngredientMovement{{IngredientID: susu.ID, Type: model.MovementSale, Quantity: -100}},
+		}
+	}
+
+	batch := sale(2)
+	if err := repo.Checkout(batch); err != nil {
+		t.Fatalf("Checkout should not return error, got: %v", err)
+	}
+	if stock, _ := products.GetOutletStock(kopi.ID, model.DefaultOutletID); stock != 1 {
+		t.Errorf("Checkout should take the outlet stock, got: %d", stock)
+	}
+	if got, _ := products.GetByID(kopi.ID); got.Stock != 1 || got.Version != created.Version+1 {
+		t.Errorf("Checkout should take the stock and bump the version, got stock %d version %d", got.Stock, got.Version)
+	}
+	if m := batch.Movements[0]; m.TransactionID == nil || *m.TransactionID != batch.Transaction.ID {
+		t.Errorf("Checkout should link the movements to the transaction, got: %+v", m)
+	}
+
+	if err := repo.Checkout(sale(2)); !errors.Is(err, model.ErrInsufficientStock) {
+		t.Errorf("Checkout beyond the outlet stock should return ErrInsufficientStock, got: %v", err)
+	}
+	if len(repo.transactions) != 1 {
+		t.Errorf("A rejected checkout should not store a transaction, got: %d", len(repo.transactions))
+	}
+	if got, _ := ingredients.GetByID(susu.ID); got.Stock != 900 {
+		t.Errorf("A rejected checkout should not record movements, got stock: %s", got.Stock)
+	}
+}
+
 func TestTransactionRepository_GetByID_Success(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	transaction := &model.Transaction{
 		TotalAmount: 1000,
@@ -124,7 +170,7 @@ func TestTransactionRepository_GetByID_Success(t *testing.T) {
 }
 
 func TestTransactionRepository_GetByID_NotFound(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	_, err := repo.GetByID(999)
 	if !errors.Is(err, model.ErrNotFound) {
@@ -133,7 +179,7 @@ func TestTransactionRepository_GetByID_NotFound(t *testing.T) {
 }
 
 func TestTransactionRepository_GetByID_ReturnsCopy(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	transaction := &model.Transaction{
 		TotalAmount: 1000,
@@ -159,12 +205,12 @@ func TestTransactionRepository_GetByID_ReturnsCopy(t *testing.T) {
 }
 
 func TestTransactionRepository_GetReportByDateRange_Empty(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
 
-	report, err := repo.GetReportByDateRange(startDate, endDate)
+	report, err := repo.GetReportByDateRange(startDate, endDate, 0)
 	if err != nil {
 		t.Errorf("GetReportByDateRange should not return error, got: %v", err)
 	}
@@ -180,7 +226,7 @@ func TestTransactionRepository_GetReportByDateRange_Empty(t *testing.T) {
 }
 
 func TestTransactionRepository_GetReportByDateRange_WithData(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	// Create transactions within the date range
 	tx1 := &model.Transaction{
@@ -205,7 +251,7 @@ func TestTransactionRepository_GetReportByDateRange_WithData(t *testing.T) {
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
 
-	report, err := repo.GetReportByDateRange(startDate, endDate)
+	report, err := repo.GetReportByDateRange(startDate, endDate, 0)
 	if err != nil {
 		t.Errorf("GetReportByDateRange should not return error, got: %v", err)
 	}
@@ -227,7 +273,7 @@ func TestTransactionRepository_GetReportByDateRange_WithData(t *testing.T) {
 }
 
 func TestTransactionRepository_GetReportByDateRange_ExcludesOutOfRange(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	// Create transactions - one inside, one outside date range
 	txInRange := &model.Transaction{
@@ -251,7 +297,7 @@ func TestTransactionRepository_GetReportByDateRange_ExcludesOutOfRange(t *testin
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
 
-	report, _ := repo.GetReportByDateRange(startDate, endDate)
+	report, _ := repo.GetReportByDateRange(startDate, endDate, 0)
 
 	if report.TotalRevenue != 1000 {
 		t.Errorf("Should only include in-range transaction, got revenue: %d", report.TotalRevenue)
@@ -262,7 +308,7 @@ func TestTransactionRepository_GetReportByDateRange_ExcludesOutOfRange(t *testin
 }
 
 func TestTransactionRepository_GetReportByDateRange_BeforeStartDate(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	tx := &model.Transaction{
 		TotalAmount: 1000,
@@ -276,7 +322,7 @@ func TestTransactionRepository_GetReportByDateRange_BeforeStartDate(t *testing.T
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
 
-	report, _ := repo.GetReportByDateRange(startDate, endDate)
+	report, _ := repo.GetReportByDateRange(startDate, endDate, 0)
 
 	if report.TotalRevenue != 0 {
 		t.Errorf("Should exclude transactions before start date, got revenue: %d", report.TotalRevenue)
@@ -284,7 +330,7 @@ func TestTransactionRepository_GetReportByDateRange_BeforeStartDate(t *testing.T
 }
 
 func TestTransactionRepository_GetReportByDateRange_AtEndDate(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	// Transaction exactly at end date boundary should be excluded (end date is exclusive)
 	tx := &model.Transaction{
@@ -299,7 +345,7 @@ func TestTransactionRepository_GetReportByDateRange_AtEndDate(t *testing.T) {
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
 
-	report, _ := repo.GetReportByDateRange(startDate, endDate)
+	report, _ := repo.GetReportByDateRange(startDate, endDate, 0)
 
 	if report.TotalRevenue != 0 {
 		t.Errorf("Should exclude transactions at end date boundary, got revenue: %d", report.TotalRevenue)
@@ -307,7 +353,7 @@ func TestTransactionRepository_GetReportByDateRange_AtEndDate(t *testing.T) {
 }
 
 func TestTransactionRepository_GetReportByDateRange_MultipleBestSelling(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	// Create transactions with same qty for different products
 	tx := &model.Transaction{
@@ -323,7 +369,7 @@ func TestTransactionRepository_GetReportByDateRange_MultipleBestSelling(t *testi
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
 
-	report, _ := repo.GetReportByDateRange(startDate, endDate)
+	report, _ := repo.GetReportByDateRange(startDate, endDate, 0)
 
 	if report.ProdukTerlaris == nil {
 		t.Error("Should return one of the best selling products")
@@ -334,7 +380,7 @@ func TestTransactionRepository_GetReportByDateRange_MultipleBestSelling(t *testi
 }
 
 func TestTransactionRepository_Concurrency(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	// Test concurrent writes
 	done := make(chan bool, 10)
@@ -364,7 +410,7 @@ func TestTransactionRepository_Concurrency(t *testing.T) {
 }
 
 func TestTransactionRepository_Create_StoresCopy(t *testing.T) {
-	repo := NewTransactionRepository()
+	repo := NewTransactionRepository(nil, nil)
 
 	transaction := &model.Transaction{
 		TotalAmount: 1000,
@@ -388,3 +434,221 @@ func TestTransactionRepository_Create_StoresCopy(t *testing.T) {
 		t.Error("Create should store a copy of details, not reference")
 	}
 }
+
+func TestTransactionRepository_GetReportByDateRange_FiltersOutlet(t *testing.T) {
+	repo := NewTransactionRepository(nil, nil)
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	repo.Create(&model.Transaction{OutletID: 1, TotalAmount: 1000, CreatedAt: at,
+		Details: []model.TransactionDetail{{ProductID: 1, ProductName: "Laptop", Quantity: 1}}})
+	repo.Create(&model.Transaction{OutletID: 2, TotalAmount: 500, CreatedAt: at,
+		Details: []model.TransactionDetail{{ProductID: 2, ProductName: "Phone", Quantity: 3}}})
+
+	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	report, _ := repo.GetReportByDateRange(startDate, endDate, 1)
+	if report.TotalRevenue != 1000 || report.TotalTransaksi != 1 {
+		t.Errorf("Report for outlet 1 should only count its transaction, got: %+v", report)
+	}
+	if report.ProdukTerlaris == nil || report.ProdukTerlaris.Nama != "Laptop" {
+		t.Errorf("Best seller of outlet 1 should be Laptop, got: %+v", report.ProdukTerlaris)
+	}
+
+	report, _ = repo.GetReportByDateRange(startDate, endDate, 0)
+	if report.TotalRevenue != 1500 || report.TotalTransaksi != 2 {
+		t.Errorf("Report without an outlet should aggregate all outlets, got: %+v", report)
+	}
+}
+
+func TestTransactionRepository_GetReportByDateRange_CustomItems(t *testing.T) {
+	repo := NewTransactionRepository(nil, nil)
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	repo.Create(&model.Transaction{TotalAmount: 31000, CreatedAt: at, Details: []model.TransactionDetail{
+		{ProductID: 1, ProductName: "Laptop", Quantity: 1, Price: 1000, Subtotal: 1000},
+		{ProductName: "Servis Kipas", Quantity: 2, Price: 15000, Subtotal: 30000},
+	}})
+
+	report, err := repo.GetReportByDateRange(at.AddDate(0, 0, -1), at.AddDate(0, 0, 1), 0)
+	if err != nil {
+		t.Fatalf("GetReportByDateRange should not return error, got: %v", err)
+	}
+	if report.ProdukTerlaris == nil || report.ProdukTerlaris.Nama != "Laptop" {
+		t.Errorf("Custom items should not compete for best seller, got: %+v", report.ProdukTerlaris)
+	}
+	if report.ItemCustom == nil || report.ItemCustom.JumlahBaris != 1 || report.ItemCustom.QtyTerjual != 2 ||
+		report.ItemCustom.Revenue != 30000 {
+		t.Errorf("Custom items should be reported as their own group, got: %+v", report.ItemCustom)
+	}
+}
+
+func TestTransactionRepository_GetReportByDateRange_MixedUnits(t *testing.T) {
+	repo := NewTransactionRepository(nil, nil)
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	repo.Create(&model.Transaction{TotalAmount: 106000, CreatedAt: at, Details: []model.TransactionDetail{
+		{ProductID: 3, ProductName: "Rokok", Quantity: 40, Unit: model.UnitPiece, Subtotal: 100000},
+		{ProductID: 4, ProductName: "Beras", Quantity: 500, Unit: model.UnitKilogram, Subtotal: 6000},
+	}})
+	repo.Create(&model.Transaction{TotalAmount: 12000, CreatedAt: at, Details: []model.TransactionDetail{
+		{ProductID: 4, ProductName: "Beras Premium", Quantity: 1000, Unit: model.UnitKilogram, Subtotal: 12000},
+	}})
+
+	report, err := repo.GetReportByDateRange(at.AddDate(0, 0, -1), at.AddDate(0, 0, 1), 0)
+	if err != nil {
+		t.Fatalf("GetReportByDateRange should not return error, got: %v", err)
+	}
+	best := report.ProdukTerlaris
+	if best == nil || best.ProductID != 3 || best.ItemTerjual != 40 || best.QtyTerjual != 40 {
+		t.Fatalf("40 cigarettes should beat 1.5 kg of rice, got: %+v", best)
+	}
+
+	// A tie goes to the lowest product ID, named after its latest line.
+	repo = NewTransactionRepository(nil, nil)
+	repo.Create(&model.Transaction{TotalAmount: 13000, CreatedAt: at, Details: []model.TransactionDetail{
+		{ProductID: 5, ProductName: "Gula", Quantity: 2, Unit: model.UnitPiece, Subtotal: 1000},
+		{ProductID: 4, ProductName: "Beras", Quantity: 500, Unit: model.UnitKilogram, Subtotal: 6000},
+	}})
+	repo.Create(&model.Transaction{TotalAmount: 6000, CreatedAt: at, Details: []model.TransactionDetail{
+		{ProductID: 4, ProductName: "Beras Premium", Quantity: 500, Unit: model.UnitKilogram, Subtotal: 6000},
+	}})
+	report, _ = repo.GetReportByDateRange(at.AddDate(0, 0, -1), at.AddDate(0, 0, 1), 0)
+	best = report.ProdukTerlaris
+	if best == nil || best.ProductID != 4 || best.Nama != "Beras Premium" || best.ItemTerjual != 2 || best.QtyTerjual != 1000 {
+		t.Errorf("Tie should go to product 4 under its latest name, got: %+v", best)
+	}
+}
+
+func TestTransactionRepository_GetTimeSeries(t *testing.T) {
+	repo := NewTransactionRepository(nil, nil)
+	repo.Create(&model.Transaction{OutletID: 1, TotalAmount: 10000, CreatedAt: time.Date(2024, 1, 1, 9, 30, 0, 0, time.UTC),
+		Details: []model.TransactionDetail{
+			{ProductID: 1, ProductName: "Indomie", Quantity: 2, Unit: model.UnitPiece, Subtotal: 7000},
+			{ProductID: 2, ProductName: "Beras", Quantity: 1250, Unit: model.UnitKilogram, Subtotal: 3000},
+		}})
+	repo.Create(&model.Transaction{OutletID: 1, TotalAmount: 5000, CreatedAt: time.Date(2024, 1, 3, 23, 59, 0, 0, time.UTC),
+		Details: []model.TransactionDetail{{ProductID: 1, ProductName: "Indomie", Quantity: 1, Subtotal: 5000}}})
+	repo.Create(&model.Transaction{OutletID: 1, TotalAmount: 1000, CreatedAt: time.Date(2024, 1, 3, 8, 0, 0, 0, time.UTC),
+		Details: []model.TransactionDetail{{ProductID: 1, ProductName: "Indomie", Quantity: 1, Subtotal: 1000}}})
+	repo.Create(&model.Transaction{OutletID: 2, TotalAmount: 7000, CreatedAt: time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)})
+	repo.Create(&model.Transaction{OutletID: 1, TotalAmount: 9000, CreatedAt: time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)})
+
+	report, err := repo.GetTimeSeries(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC), model.GranularityDay, 1)
+	if err != nil {
+		t.Fatalf("GetTimeSeries should not return error, got: %v", err)
+	}
+	if len(report.Buckets) != 3 {
+		t.Fatalf("Three days should have 3 buckets, got: %d", len(report.Buckets))
+	}
+	first, second, third := report.Buckets[0], report.Buckets[1], report.Buckets[2]
+	if first.TotalRevenue != 10000 || first.TotalTransaksi != 1 || first.ItemTerjual != 3 || first.RataRataTransaksi != 10000 {
+		t.Errorf("First day should count one sale of 3 items, got: %+v", first)
+	}
+	if second.TotalRevenue != 0 || second.TotalTransaksi != 0 {
+		t.Errorf("Second day should be zero-filled, the sale was at another outlet, got: %+v", second)
+	}
+	if third.TotalRevenue != 6000 || third.TotalTransaksi != 2 || third.RataRataTransaksi != 3000 {
+		t.Errorf("Third day should count two sales up to 23:59, got: %+v", third)
+	}
+}
+
+func TestTransactionRepository_GetCategorySales(t *testing.T) {
+	repo := NewTransactionRepository(nil, nil)
+	rokok, minuman, bundle := 3, 2, 9
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	repo.Create(&model.Transaction{CreatedAt: at, Details: []model.TransactionDetail{
+		{ProductID: 1, Quantity: 2, Subtotal: 60000, CategoryID: &rokok},
+		{ProductID: 2, Quantity: 3, Subtotal: 15000, CategoryID: &minuman},
+		{ProductName: "Servis Kipas", Quantity: 1, Subtotal: 15000, CategoryID: &minuman},
+		{ProductID: 5, Quantity: 1, Subtotal: 15000},
+		{ProductID: 4, Quantity: 1, Subtotal: 0, CategoryID: &minuman, BundleProductID: &bundle},
+	}})
+	repo.Create(&model.Transaction{CreatedAt: at.AddDate(0, 1, 0), Details: []model.TransactionDetail{
+		{ProductID: 2, Quantity: 10, Subtotal: 50000, CategoryID: &minuman},
+	}})
+
+	rows, err := repo.GetCategorySales(at.AddDate(0, 0, -1), at.AddDate(0, 0, 1), 0)
+	if err != nil {
+		t.Fatalf("GetCategorySales should not return error, got: %v", err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("GetCategorySales should return 4 groups, got: %d", len(rows))
+	}
+	if rows[0].CategoryID == nil || *rows[0].CategoryID != rokok || rows[0].Revenue != 60000 || rows[0].ItemTerjual != 2 {
+		t.Errorf("Rokok should come first with 60000, got: %+v", rows[0])
+	}
+	if rows[1].CategoryID == nil || *rows[1].CategoryID != minuman || rows[1].ItemTerjual != 3 {
+		t.Errorf("Minuman should tie with uncategorized and win on category ID, without the bundle component, got: %+v", rows[1])
+	}
+	if rows[2].CategoryID != nil || rows[2].Custom || rows[2].Revenue != 15000 {
+		t.Errorf("Uncategorized should follow the categories, got: %+v", rows[2])
+	}
+	if !rows[3].Custom || rows[3].CategoryID != nil || rows[3].Revenue != 15000 {
+		t.Errorf("Custom items should be a row of their own whatever their category, got: %+v", rows[3])
+	}
+}
+
+func TestTransactionRepository_GetProductSales(t *testing.T) {
+	repo := NewTransactionRepository(nil, nil)
+	bundle := 9
+	at := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	repo.Create(&model.Transaction{CreatedAt: at, Details: []model.TransactionDetail{
+		{ProductID: 3, ProductName: "Kopi", Quantity: 2, Subtotal: 10000},
+		{ProductID: 1, ProductName: "Teh", Quantity: 2, Subtotal: 6000},
+		{ProductID: 2, ProductName: "Roti", Quantity: 1, Subtotal: 4000},
+		{ProductID: 1, ProductName: "Gula", Quantity: 5, Subtotal: 0, BundleProductID: &bundle},
+		{ProductName: "Servis Kipas", Quantity: 9, Subtotal: 15000},
+	}})
+	// A renamed product is still one product.
+	repo.Create(&model.Transaction{CreatedAt: at, Details: []model.TransactionDetail{
+		{ProductID: 3, ProductName: "Kopi Susu", Quantity: 0, Subtotal: 0},
+	}})
+
+	start, end := at.AddDate(0, 0, -1), at.AddDate(0, 0, 1)
+	rows, _ := repo.GetProductSales(start, end, 0, model.ProductSalesQuery{Sort: model.ProductSalesSortQty, Desc: true})
+	if len(rows) != 3 {
+		t.Fatalf("GetProductSales should group 3 products, got: %d", len(rows))
+	}
+	if rows[0].ProductID != 1 || rows[1].ProductID != 3 || rows[2].ProductID != 2 {
+		t.Errorf("Equal quantities should be ranked by product ID, got: %d, %d, %d", rows[0].ProductID, rows[1].ProductID, rows[2].ProductID)
+	}
+	if rows[1].Persentase != 50 || rows[0].Persentase != 30 {
+		t.Errorf("Shares should be of all product revenue without custom items, got: %v and %v", rows[1].Persentase, rows[0].Persentase)
+	}
+
+	// 1500 grams of beras sold in two lines count as two items, not 1500.
+	repo.Create(&model.Transaction{CreatedAt: at, Details: []model.TransactionDetail{
+		{ProductID: 4, ProductName: "Beras", Quantity: 1000, Unit: model.UnitKilogram, Subtotal: 12000},
+		{ProductID: 4, ProductName: "Beras", Quantity: 500, Unit: model.UnitKilogram, Subtotal: 6000},
+	}})
+	rows, _ = repo.GetProductSales(start, end, 0, model.ProductSalesQuery{Sort: model.ProductSalesSortQty, Desc: true})
+	if rows[0].ProductID != 1 || rows[1].ProductID != 3 || rows[2].ProductID != 4 {
+		t.Errorf("Products sold by weight should rank by item count, got: %d, %d, %d", rows[0].ProductID, rows[1].ProductID, rows[2].ProductID)
+	}
+	if rows[2].QtyTerjual != 1500 || rows[2].ItemTerjual != 2 {
+		t.Errorf("Beras should keep its grams and count 2 items, got: %+v", rows[2])
+	}
+
+	rows, _ = repo.GetProductSales(start, end, 0, model.ProductSalesQuery{Sort: model.ProductSalesSortRevenue, Limit: 2})
+	if len(rows) != 2 || rows[0].ProductID != 2 || rows[1].ProductID != 1 {
+		t.Errorf("Ascending revenue limited to 2 should be Roti then Teh, got: %+v", rows)
+	}
+}
+
+func TestTransactionRepository_GetHeatmap(t *testing.T) {
+	repo := NewTransactionRepository(nil, nil)
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	repo.Create(&model.Transaction{OutletID: 1, TotalAmount: 12000, CreatedAt: time.Date(2024, 1, 1, 1, 15, 0, 0, time.UTC)})
+	repo.Create(&model.Transaction{OutletID: 2, TotalAmount: 8000, CreatedAt: time.Date(2024, 1, 1, 1, 45, 0, 0, time.UTC)})
+	repo.Create(&model.Transaction{OutletID: 1, TotalAmount: 5000, CreatedAt: time.Date(2024, 2, 1, 1, 0, 0, 0, time.UTC)})
+
+	heatmap, err := repo.GetHeatmap(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), jakarta, 1)
+	if err != nil {
+		t.Fatalf("GetHeatmap should not return error, got: %v", err)
+	}
+	// 2024-01-01 01:15 UTC is Monday 08:15 in Jakarta.
+	if heatmap.TotalTransaksi[0][8] != 1 || heatmap.Revenue[0][8] != 12000 {
+		t.Errorf("Monday 08:00 should count the outlet 1 sale only, got: %d, %d", heatmap.TotalTransaksi[0][8], heatmap.Revenue[0][8])
+	}
+	if heatmap.TotalTransaksi[0][1] != 0 {
+		t.Errorf("The sale should not be counted at its UTC hour, got: %d", heatmap.TotalTransaksi[0][1])
+	}
+}
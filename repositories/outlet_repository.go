@@ -0,0 +1,26 @@
+package repository
+
+import model "kasir-api/models"
+
+// OutletRepository defines data access for outlets and the stock transfers between them.
+// The stock of each product per outlet is kept by the product repository: a product's stock is
+// the sum over all outlets, and product writes book their stock change at product.OutletID.
+type OutletRepository interface {
+	// GetPage returns one page of outlets ordered by ID with the total count, starting after
+	// page.After when set.
+	GetPage(page model.PageRequest) (*model.Page[*model.Outlet], error)
+	GetByID(id int) (*model.Outlet, error)
+	Create(outlet *model.Outlet) error
+
+	// GetStock returns one page of the stock at the outlet of every stocked product that is not archived
+	// and not a bundle, ordered by product ID, with the total count. A cursor page starts after the
+	// product ID in page.After.
+	GetStock(outletID int, page model.PageRequest) (*model.Page[*model.OutletStock], error)
+	// Transfer moves stock between two outlets atomically, filling in the transfer's ID, time and
+	// movements. It fails with ErrOutletStock when the source outlet has too little stock.
+	// The product's total stock does not change, so neither does its version, which tracks product edits.
+	Transfer(transfer *model.StockTransfer) error
+	// GetTransfers returns one page of transfers with their movements, newest first, with the total
+	// count. A cursor page starts at the transfers older than page.After.
+	GetTransfers(page model.PageRequest) (*model.Page[*model.StockTransfer], error)
+}
@@ -3,6 +3,7 @@ package postgres
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 
 	model "kasir-api/models"
 )
@@ -17,11 +18,13 @@ func NewCategoryRepository(db *DB) *CategoryRepository {
 	return &CategoryRepository{db: db}
 }
 
-// GetAll returns all categories.
-func (r *CategoryRepository) GetAll() ([]*model.Category, error) {
+// GetAll returns all categories. Archived categories are skipped unless includeArchived is set.
+func (r *CategoryRepository) GetAll(includeArchived bool) ([]*model.Category, error) {
 	rows, err := r.db.Query(`
-		SELECT id, name, description FROM categories ORDER BY id
-	`)
+		SELECT id, name, description, parent_id, archived_at, version FROM categories
+		WHERE $1 OR archived_at IS NULL
+		ORDER BY id
+	`, includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -29,56 +32,195 @@ func (r *CategoryRepository) GetAll() ([]*model.Category, error) {
 
 	var categories []*model.Category
 	for rows.Next() {
-		var c model.Category
-		if err := rows.Scan(&c.ID, &c.Name, &c.Description); err != nil {
+		c, err := scanCategory(rows)
+		if err != nil {
 			return nil, err
 		}
-		categories = append(categories, &c)
+		categories = append(categories, c)
 	}
 	return categories, rows.Err()
 }
 
-// GetByID returns a category by ID.
+// GetPage returns one page of categories ordered by ID with the total from a COUNT. Offset pages use
+// LIMIT/OFFSET; cursor pages seek past the cursor's ID, fetching one extra row to learn whether more follow.
+func (r *CategoryRepository) GetPage(includeArchived bool, page model.PageRequest) (*model.Page[*model.Category], error) {
+	result := &model.Page[*model.Category]{Items: make([]*model.Category, 0, page.Limit)}
+	if err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM categories WHERE $1 OR archived_at IS NULL
+	`, includeArchived).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+
+	limit, offset, afterID := page.Limit, page.Offset(), 0
+	if page.After != nil {
+		limit, offset, afterID = page.Limit+1, 0, page.After.ID
+	} else if offset >= result.Total {
+		return result, nil
+	}
+	rows, err := r.db.Query(`
+		SELECT id, name, description, parent_id, archived_at, version FROM categories
+		WHERE ($1 OR archived_at IS NULL) AND id > $4
+		ORDER BY id
+		LIMIT $2 OFFSET $3
+	`, includeArchived, limit, offset, afterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	more := offset+len(result.Items) < result.Total
+	if page.After != nil {
+		more = len(result.Items) > page.Limit
+		result.Items = result.Items[:min(len(result.Items), page.Limit)]
+	}
+	if more && len(result.Items) > 0 {
+		result.Next = &model.Cursor{ID: result.Items[len(result.Items)-1].ID}
+	}
+	return result, nil
+}
+
+// GetByID returns a category by ID, including archived ones.
 func (r *CategoryRepository) GetByID(id int) (*model.Category, error) {
-	var c model.Category
-	err := r.db.QueryRow(`
-		SELECT id, name, description FROM categories WHERE id = $1
-	`, id).Scan(&c.ID, &c.Name, &c.Description)
+	c, err := scanCategory(r.db.QueryRow(`
+		SELECT id, name, description, parent_id, archived_at, version FROM categories WHERE id = $1
+	`, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, model.ErrCategoryNotFound
 		}
 		return nil, err
 	}
-	return &c, nil
+	return c, nil
 }
 
 // Create inserts a new category and returns the generated ID.
 func (r *CategoryRepository) Create(category *model.Category) error {
 	return r.db.QueryRow(`
-		INSERT INTO categories (name, description) VALUES ($1, $2)
-		RETURNING id
-	`, category.Name, category.Description).Scan(&category.ID)
+		INSERT INTO categories (name, description, parent_id) VALUES ($1, $2, $3)
+		RETURNING id, version
+	`, category.Name, category.Description, category.ParentID).Scan(&category.ID, &category.Version)
 }
 
-// Update updates an existing category.
+// Update updates an existing category. A non-zero category.Version must match the stored
+// version; the check is part of the UPDATE. category.Version holds the new version afterwards.
 func (r *CategoryRepository) Update(category *model.Category) error {
-	result, err := r.db.Exec(`
-		UPDATE categories SET name = $1, description = $2 WHERE id = $3
-	`, category.Name, category.Description, category.ID)
+	var archivedAt sql.NullTime
+	err := r.db.QueryRow(`
+		UPDATE categories SET name = $1, description = $2, parent_id = $3, version = version + 1
+		WHERE id = $4 AND ($5 = 0 OR version = $5)
+		RETURNING archived_at, version
+	`, category.Name, category.Description, category.ParentID, category.ID, category.Version).Scan(&archivedAt, &category.Version)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return r.missingOrChanged(category.ID)
+		}
 		return err
 	}
-	n, _ := result.RowsAffected()
-	if n == 0 {
+	category.ArchivedAt = nullTimePtr(archivedAt)
+	return nil
+}
+
+// Archive soft-deletes a category and applies the product policy in a single transaction.
+// Archiving twice keeps the original timestamp. A non-zero version must match the locked row.
+func (r *CategoryRepository) Archive(id int, policy model.CategoryProductPolicy, version int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	var current int
+	err = tx.QueryRow(`SELECT version FROM categories WHERE id = $1 FOR UPDATE`, id).Scan(&current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrCategoryNotFound
+		}
+		return err
+	}
+	if version != 0 && version != current {
+		return model.ErrVersionMismatch
+	}
+
+	if err := applyProductPolicy(tx, id, policy); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE categories SET archived_at = COALESCE(archived_at, NOW()), version = version + 1 WHERE id = $1
+	`, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyProductPolicy updates the active products of the category inside tx.
+func applyProductPolicy(tx *sql.Tx, id int, policy model.CategoryProductPolicy) error {
+	switch policy.OnProducts {
+	case model.OnProductsReassign:
+		var targetArchivedAt sql.NullTime
+		err := tx.QueryRow(`
+			SELECT archived_at FROM categories WHERE id = $1 FOR SHARE
+		`, policy.ReassignTo).Scan(&targetArchivedAt)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return model.ErrTargetCategoryNotFound
+			}
+			return err
+		}
+		if targetArchivedAt.Valid {
+			return model.ErrTargetCategoryArchived
+		}
+		_, err = tx.Exec(`
+			UPDATE products SET category_id = $1, version = version + 1 WHERE category_id = $2 AND archived_at IS NULL
+		`, policy.ReassignTo, id)
+		return err
+	case model.OnProductsUnset:
+		_, err := tx.Exec(`
+			UPDATE products SET category_id = NULL, version = version + 1 WHERE category_id = $1 AND archived_at IS NULL
+		`, id)
+		return err
+	default:
+		var count int
+		err := tx.QueryRow(`
+			SELECT COUNT(*) FROM products WHERE category_id = $1 AND archived_at IS NULL
+		`, id).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %d active products", model.ErrCategoryHasProducts, count)
+		}
+		return nil
+	}
+}
+
+// missingOrChanged explains why a versioned write touched no row.
+func (r *CategoryRepository) missingOrChanged(id int) error {
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
 		return model.ErrCategoryNotFound
 	}
-	return nil
+	return model.ErrVersionMismatch
 }
 
-// Delete removes a category by ID.
-func (r *CategoryRepository) Delete(id int) error {
-	result, err := r.db.Exec(`DELETE FROM categories WHERE id = $1`, id)
+// Restore brings an archived category back.
+func (r *CategoryRepository) Restore(id int) error {
+	result, err := r.db.Exec(`UPDATE categories SET archived_at = NULL, version = version + 1 WHERE id = $1`, id)
 	if err != nil {
 		return err
 	}
@@ -88,3 +230,49 @@ func (r *CategoryRepository) Delete(id int) error {
 	}
 	return nil
 }
+
+// GetDescendantIDs returns the IDs of every category below id using a recursive CTE.
+func (r *CategoryRepository) GetDescendantIDs(id int) ([]int, error) {
+	if _, err := r.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE parent_id = $1
+			UNION
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		)
+		SELECT id FROM subtree WHERE id <> $1 ORDER BY id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var descendantID int
+		if err := rows.Scan(&descendantID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, descendantID)
+	}
+	return ids, rows.Err()
+}
+
+// scanCategory reads a category row selected as (id, name, description, parent_id, archived_at).
+func scanCategory(row rowScanner) (*model.Category, error) {
+	var c model.Category
+	var parentID sql.NullInt64
+	var archivedAt sql.NullTime
+	if err := row.Scan(&c.ID, &c.Name, &c.Description, &parentID, &archivedAt, &c.Version); err != nil {
+		return nil, err
+	}
+	if parentID.Valid {
+		id := int(parentID.Int64)
+		c.ParentID = &id
+	}
+	c.ArchivedAt = nullTimePtr(archivedAt)
+	return &c, nil
+}
@@ -4,11 +4,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	migratepg "github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/stdlib"
 
 	"kasir-api/config"
@@ -56,29 +58,96 @@ func NewDB(cfg *config.DatabaseConfig) (*DB, error) {
 
 // runMigrations applies all pending database migrations using golang-migrate.
 func (d *DB) runMigrations(dbName string) error {
+	m, err := newMigrate(d.DB, dbName)
+	if err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("run migrations: %w", err)
+	}
+
+	version, dirty, _ := m.Version()
+	logger.Info("Migrations applied — version: %d, dirty: %v", version, dirty)
+
+	return nil
+}
+
+// newMigrate creates a golang-migrate instance over the embedded migrations.
+func newMigrate(sqlDB *sql.DB, dbName string) (*migrate.Migrate, error) {
 	sourceDriver, err := iofs.New(db.Migrations, "migrations")
 	if err != nil {
-		return fmt.Errorf("create migration source: %w", err)
+		return nil, fmt.Errorf("create migration source: %w", err)
 	}
 
-	dbDriver, err := migratepg.WithInstance(d.DB, &migratepg.Config{
+	dbDriver, err := migratepg.WithInstance(sqlDB, &migratepg.Config{
 		DatabaseName: dbName,
 	})
 	if err != nil {
-		return fmt.Errorf("create migration db driver: %w", err)
+		return nil, fmt.Errorf("create migration db driver: %w", err)
 	}
 
 	m, err := migrate.NewWithInstance("iofs", sourceDriver, dbName, dbDriver)
 	if err != nil {
-		return fmt.Errorf("create migrate instance: %w", err)
+		return nil, fmt.Errorf("create migrate instance: %w", err)
 	}
+	return m, nil
+}
 
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("run migrations: %w", err)
+// queryer is satisfied by both *DB and *sql.Tx.
+type queryer interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// SQLSTATE codes for constraint violations.
+const (
+	uniqueViolationCode     = "23505"
+	foreignKeyViolationCode = "23503"
+	checkViolationCode      = "23514"
+)
+
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
+
+// isUniqueViolationOn reports whether err is a unique violation of the named constraint or index.
+func isUniqueViolationOn(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode && pgErr.ConstraintName == constraint
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// nullTimePtr converts a nullable timestamp into a *time.Time.
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
 	}
+	return &t.Time
+}
 
-	version, dirty, _ := m.Version()
-	logger.Info("Migrations applied — version: %d, dirty: %v", version, dirty)
+// nullIntPtr converts a nullable integer column into a *int.
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
 
-	return nil
+// isForeignKeyViolation reports whether err is a PostgreSQL foreign key constraint violation.
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolationCode
+}
+
+// isCheckViolation reports whether err is a PostgreSQL check constraint violation.
+func isCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == checkViolationCode
 }
@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+)
+
+// openTestDB connects to the throwaway database named by TEST_DATABASE_URL and empties it.
+// Tests that need PostgreSQL are skipped when the variable is unset.
+func openTestDB(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	sqlDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() }) //nolint:errcheck // best-effort close in tests
+
+	var dbName string
+	if err := sqlDB.QueryRow(`SELECT current_database()`).Scan(&dbName); err != nil {
+		t.Fatalf("current database: %v", err)
+	}
+	m, err := newMigrate(sqlDB, dbName)
+	if err != nil {
+		t.Fatalf("newMigrate: %v", err)
+	}
+	if err := m.Drop(); err != nil {
+		t.Fatalf("drop: %v", err)
+	}
+	return sqlDB, dbName
+}
+
+func TestMigrations_CustomItemsRoundTrip(t *testing.T) {
+	sqlDB, dbName := openTestDB(t)
+	// Drop removes the version table too, so a fresh instance starts from zero.
+	m, err := newMigrate(sqlDB, dbName)
+	if err != nil {
+		t.Fatalf("newMigrate: %v", err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+
+	var productID, txID int
+	if err := sqlDB.QueryRow(`INSERT INTO products (name, price, stock) VALUES ('Kopi', 5000, 10) RETURNING id`).Scan(&productID); err != nil {
+		t.Fatalf("insert product: %v", err)
+	}
+	if err := sqlDB.QueryRow(`INSERT INTO transactions (total_amount) VALUES (8000) RETURNING id`).Scan(&txID); err != nil {
+		t.Fatalf("insert transaction: %v", err)
+	}
+	if _, err := sqlDB.Exec(`
+		INSERT INTO transaction_details (transaction_id, product_id, product_name, quantity, price, subtotal)
+		VALUES ($1, $2, 'Kopi', 1, 5000, 5000), ($1, NULL, 'Ongkos kirim', 1, 3000, 3000)`,
+		txID, productID); err != nil {
+		t.Fatalf("insert details: %v", err)
+	}
+
+	if err := m.Migrate(17); err != nil {
+		t.Fatalf("down to 17: %v", err)
+	}
+	var placeholders int
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM transaction_details WHERE product_id = 0`).Scan(&placeholders); err != nil {
+		t.Fatalf("count placeholders: %v", err)
+	}
+	if placeholders != 1 {
+		t.Errorf("after down, custom lines with product 0 = %d, want 1", placeholders)
+	}
+
+	if err := m.Up(); err != nil {
+		t.Fatalf("up again: %v", err)
+	}
+	var custom, total int
+	if err := sqlDB.QueryRow(`SELECT COUNT(*) FILTER (WHERE product_id IS NULL), COUNT(*) FROM transaction_details`).Scan(&custom, &total); err != nil {
+		t.Fatalf("count details: %v", err)
+	}
+	if custom != 1 || total != 2 {
+		t.Errorf("after up, custom = %d of %d lines, want 1 of 2", custom, total)
+	}
+}
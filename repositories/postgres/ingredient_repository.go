@@ -0,0 +1,281 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	model "kasir-api/models"
+)
+
+// IngredientRepository implements repository.IngredientRepository using PostgreSQL.
+type IngredientRepository struct {
+	db *DB
+}
+
+// NewIngredientRepository creates a new IngredientRepository.
+func NewIngredientRepository(db *DB) *IngredientRepository {
+	return &IngredientRepository{db: db}
+}
+
+// GetPage returns one page of ingredients ordered by ID with the total from a COUNT, paged like
+// categories: LIMIT/OFFSET, or a seek past the cursor's ID fetching one extra row.
+func (r *IngredientRepository) GetPage(page model.PageRequest) (*model.Page[*model.Ingredient], error) {
+	result := &model.Page[*model.Ingredient]{Items: make([]*model.Ingredient, 0, page.Limit)}
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM ingredients`).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+
+	limit, offset, afterID := page.Limit, page.Offset(), 0
+	if page.After != nil {
+		limit, offset, afterID = page.Limit+1, 0, page.After.ID
+	} else if offset >= result.Total {
+		return result, nil
+	}
+	rows, err := r.db.Query(`
+		SELECT id, name, unit, stock, created_at FROM ingredients
+		WHERE id > $3
+		ORDER BY id
+		LIMIT $1 OFFSET $2
+	`, limit, offset, afterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i model.Ingredient
+		if err := rows.Scan(&i.ID, &i.Name, &i.Unit, &i.Stock, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, &i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	more := offset+len(result.Items) < result.Total
+	if page.After != nil {
+		more = len(result.Items) > page.Limit
+		result.Items = result.Items[:min(len(result.Items), page.Limit)]
+	}
+	if more && len(result.Items) > 0 {
+		result.Next = &model.Cursor{ID: result.Items[len(result.Items)-1].ID}
+	}
+	return result, nil
+}
+
+// GetByID returns an ingredient by ID.
+func (r *IngredientRepository) GetByID(id int) (*model.Ingredient, error) {
+	var i model.Ingredient
+	err := r.db.QueryRow(`SELECT id, name, unit, stock, created_at FROM ingredients WHERE id = $1`, id).
+		Scan(&i.ID, &i.Name, &i.Unit, &i.Stock, &i.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrIngredientNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// Create inserts a new ingredient with its opening stock.
+func (r *IngredientRepository) Create(ingredient *model.Ingredient) error {
+	return r.db.QueryRow(`
+		INSERT INTO ingredients (name, unit, stock) VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, ingredient.Name, ingredient.Unit, ingredient.Stock).Scan(&ingredient.ID, &ingredient.CreatedAt)
+}
+
+// Update changes the name and unit and returns the stored stock in ingredient.
+func (r *IngredientRepository) Update(ingredient *model.Ingredient) error {
+	err := r.db.QueryRow(`
+		UPDATE ingredients SET name = $1, unit = $2 WHERE id = $3
+		RETURNING stock, created_at
+	`, ingredient.Name, ingredient.Unit, ingredient.ID).Scan(&ingredient.Stock, &ingredient.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrIngredientNotFound
+	}
+	return err
+}
+
+// GetRecipe returns the recipe of a product with ingredient names and units.
+func (r *IngredientRepository) GetRecipe(productID int) ([]model.RecipeItem, error) {
+	rows, err := r.db.Query(`
+		SELECT pr.ingredient_id, i.name, i.unit, pr.quantity
+		FROM product_recipes pr
+		JOIN ingredients i ON i.id = pr.ingredient_id
+		WHERE pr.product_id = $1
+		ORDER BY pr.ingredient_id
+	`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]model.RecipeItem, 0)
+	for rows.Next() {
+		var item model.RecipeItem
+		if err := rows.Scan(&item.IngredientID, &item.Name, &item.Unit, &item.Quantity); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// SetRecipe replaces the recipe of a product in a single transaction.
+func (r *IngredientRepository) SetRecipe(productID int, items []model.RecipeItem) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	if _, err := tx.Exec(`DELETE FROM product_recipes WHERE product_id = $1`, productID); err != nil {
+		return err
+	}
+	for _, item := range items {
+		_, err := tx.Exec(`
+			INSERT INTO product_recipes (product_id, ingredient_id, quantity) VALUES ($1, $2, $3)
+		`, productID, item.IngredientID, item.Quantity)
+		if isForeignKeyViolation(err) {
+			return model.ErrIngredientNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// RecordMovements applies the movements in a single transaction. Each ingredient row is locked
+// by its UPDATE, so a count sees the stock no concurrent movement can change before it is booked.
+func (r *IngredientRepository) RecordMovements(movements []*model.IngredientMovement) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	if err := recordMovements(tx, movements); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordMovements locks each movement's ingredient, applies the movement to its stock and inserts it.
+func recordMovements(tx *sql.Tx, movements []*model.IngredientMovement) error {
+	for _, m := range movements {
+		var stock model.Quantity
+		err := tx.QueryRow(`SELECT stock FROM ingredients WHERE id = $1 FOR UPDATE`, m.IngredientID).Scan(&stock)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrIngredientNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if m.Type == model.MovementCount {
+			m.Quantity -= stock
+		}
+		if _, err := tx.Exec(`UPDATE ingredients SET stock = stock + $1 WHERE id = $2`, m.Quantity, m.IngredientID); err != nil {
+			return err
+		}
+		err = tx.QueryRow(`
+			INSERT INTO ingredient_movements (ingredient_id, type, quantity, transaction_id, note)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at
+		`, m.IngredientID, m.Type, m.Quantity, m.TransactionID, m.Note).Scan(&m.ID, &m.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMovements returns one page of the movements of an ingredient, newest first, paged like GetPage
+// but seeking below the cursor's ID.
+func (r *IngredientRepository) GetMovements(ingredientID int, page model.PageRequest) (*model.Page[*model.IngredientMovement], error) {
+	if _, err := r.GetByID(ingredientID); err != nil {
+		return nil, err
+	}
+	result := &model.Page[*model.IngredientMovement]{Items: make([]*model.IngredientMovement, 0, page.Limit)}
+	if err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM ingredient_movements WHERE ingredient_id = $1
+	`, ingredientID).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+
+	limit, offset, beforeID := page.Limit, page.Offset(), 0
+	if page.After != nil {
+		limit, offset, beforeID = page.Limit+1, 0, page.After.ID
+	} else if offset >= result.Total {
+		return result, nil
+	}
+	rows, err := r.db.Query(`
+		SELECT m.id, m.ingredient_id, m.type, m.quantity, m.transaction_id, COALESCE(t.outlet_id, 0), m.note, m.created_at
+		FROM ingredient_movements m
+		LEFT JOIN transactions t ON t.id = m.transaction_id
+		WHERE m.ingredient_id = $1 AND ($4 = 0 OR m.id < $4)
+		ORDER BY m.id DESC
+		LIMIT $2 OFFSET $3
+	`, ingredientID, limit, offset, beforeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m model.IngredientMovement
+		var transactionID sql.NullInt64
+		if err := rows.Scan(&m.ID, &m.IngredientID, &m.Type, &m.Quantity, &transactionID, &m.OutletID, &m.Note, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		m.TransactionID = nullIntPtr(transactionID)
+		result.Items = append(result.Items, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	more := offset+len(result.Items) < result.Total
+	if page.After != nil {
+		more = len(result.Items) > page.Limit
+		result.Items = result.Items[:min(len(result.Items), page.Limit)]
+	}
+	if more && len(result.Items) > 0 {
+		result.Next = &model.Cursor{Desc: true, ID: result.Items[len(result.Items)-1].ID}
+	}
+	return result, nil
+}
+
+// GetUsage sums the movements per ingredient and type, then books them like the memory backend.
+func (r *IngredientRepository) GetUsage(startDate, endDate time.Time) ([]*model.IngredientUsage, error) {
+	rows, err := r.db.Query(`
+		SELECT i.id, i.name, i.unit, COALESCE(m.type, ''), COALESCE(SUM(m.quantity), 0)
+		FROM ingredients i
+		LEFT JOIN ingredient_movements m
+		       ON m.ingredient_id = i.id AND m.created_at >= $1 AND m.created_at < $2
+		GROUP BY i.id, i.name, i.unit, m.type
+		ORDER BY i.id
+	`, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := make([]*model.IngredientUsage, 0)
+	for rows.Next() {
+		var u model.IngredientUsage
+		var movementType string
+		var quantity model.Quantity
+		if err := rows.Scan(&u.IngredientID, &u.Name, &u.Unit, &movementType, &quantity); err != nil {
+			return nil, err
+		}
+		if n := len(usage); n == 0 || usage[n-1].IngredientID != u.IngredientID {
+			usage = append(usage, &u)
+		}
+		usage[len(usage)-1].AddMovement(movementType, quantity)
+	}
+	return usage, rows.Err()
+}
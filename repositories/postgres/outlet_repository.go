@@ -0,0 +1,287 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	model "kasir-api/models"
+)
+
+// OutletRepository implements repository.OutletRepository using PostgreSQL.
+type OutletRepository struct {
+	db *DB
+}
+
+// NewOutletRepository creates a new OutletRepository.
+func NewOutletRepository(db *DB) *OutletRepository {
+	return &OutletRepository{db: db}
+}
+
+// GetPage returns one page of outlets ordered by ID. Cursor pages seek past the cursor's ID,
+// fetching one extra row to learn whether another page follows.
+func (r *OutletRepository) GetPage(page model.PageRequest) (*model.Page[*model.Outlet], error) {
+	result := &model.Page[*model.Outlet]{Items: make([]*model.Outlet, 0, page.Limit)}
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM outlets`).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+
+	limit, offset, afterID := page.Limit, page.Offset(), 0
+	if page.After != nil {
+		limit, offset, afterID = page.Limit+1, 0, page.After.ID
+	} else if offset >= result.Total {
+		return result, nil
+	}
+	rows, err := r.db.Query(`
+		SELECT id, name, address, created_at FROM outlets
+		WHERE id > $3
+		ORDER BY id
+		LIMIT $1 OFFSET $2
+	`, limit, offset, afterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var o model.Outlet
+		if err := rows.Scan(&o.ID, &o.Name, &o.Address, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, &o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	more := offset+len(result.Items) < result.Total
+	if page.After != nil {
+		more = len(result.Items) > page.Limit
+		result.Items = result.Items[:min(len(result.Items), page.Limit)]
+	}
+	if more && len(result.Items) > 0 {
+		result.Next = &model.Cursor{ID: result.Items[len(result.Items)-1].ID}
+	}
+	return result, nil
+}
+
+// GetByID returns an outlet by ID.
+func (r *OutletRepository) GetByID(id int) (*model.Outlet, error) {
+	var o model.Outlet
+	err := r.db.QueryRow(`SELECT id, name, address, created_at FROM outlets WHERE id = $1`, id).
+		Scan(&o.ID, &o.Name, &o.Address, &o.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, model.ErrOutletNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// Create inserts a new outlet.
+func (r *OutletRepository) Create(outlet *model.Outlet) error {
+	return r.db.QueryRow(`
+		INSERT INTO outlets (name, address) VALUES ($1, $2)
+		RETURNING id, created_at
+	`, outlet.Name, outlet.Address).Scan(&outlet.ID, &outlet.CreatedAt)
+}
+
+// outletStockWhere keeps the stocked products that are not archived and not bundles.
+const outletStockWhere = `
+		WHERE p.archived_at IS NULL AND p.type = 'stocked'
+		  AND NOT EXISTS (SELECT 1 FROM bundle_components bc WHERE bc.bundle_id = p.id)`
+
+// GetStock returns one page of the stock at the outlet of the stocked products that are not archived
+// and not bundles, ordered by product ID, paged like GetPage.
+func (r *OutletRepository) GetStock(outletID int, page model.PageRequest) (*model.Page[*model.OutletStock], error) {
+	if _, err := r.GetByID(outletID); err != nil {
+		return nil, err
+	}
+	result := &model.Page[*model.OutletStock]{Items: make([]*model.OutletStock, 0, page.Limit)}
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM products p` + outletStockWhere).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+
+	limit, offset, afterID := page.Limit, page.Offset(), 0
+	if page.After != nil {
+		limit, offset, afterID = page.Limit+1, 0, page.After.ID
+	} else if offset >= result.Total {
+		return result, nil
+	}
+	rows, err := r.db.Query(`
+		SELECT p.id, p.name, COALESCE(os.stock, 0)
+		FROM products p
+		LEFT JOIN outlet_stock os ON os.product_id = p.id AND os.outlet_id = $1`+outletStockWhere+`
+		  AND p.id > $4
+		ORDER BY p.id
+		LIMIT $2 OFFSET $3
+	`, outletID, limit, offset, afterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		s := model.OutletStock{OutletID: outletID}
+		if err := rows.Scan(&s.ProductID, &s.ProductName, &s.Stock); err != nil {
+			return nil, err
+		}
+		result.Items = append(result.Items, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	more := offset+len(result.Items) < result.Total
+	if page.After != nil {
+		more = len(result.Items) > page.Limit
+		result.Items = result.Items[:min(len(result.Items), page.Limit)]
+	}
+	if more && len(result.Items) > 0 {
+		result.Next = &model.Cursor{ID: result.Items[len(result.Items)-1].ProductID}
+	}
+	return result, nil
+}
+
+// Transfer moves the stock in a single transaction. The product row is locked first, as checkout
+// does, and the source outlet is only decremented while it holds at least the quantity, so even a
+// product allowed to go negative cannot transfer stock it does not have.
+func (r *OutletRepository) Transfer(transfer *model.StockTransfer) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	var isBundle bool
+	var productType string
+	err = tx.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM bundle_components WHERE bundle_id = $1), type
+		FROM products WHERE id = $1 FOR UPDATE
+	`, transfer.ProductID).Scan(&isBundle, &productType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if isBundle {
+		return model.ErrBundleStock
+	}
+	if productType != model.ProductTypeStocked {
+		return model.ErrNotStocked
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO stock_transfers (product_id, from_outlet_id, to_outlet_id, quantity, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, transfer.ProductID, transfer.FromOutletID, transfer.ToOutletID, transfer.Quantity, transfer.Note).
+		Scan(&transfer.ID, &transfer.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return model.ErrOutletNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(`
+		UPDATE outlet_stock SET stock = stock - $1 WHERE outlet_id = $2 AND product_id = $3 AND stock >= $1
+	`, transfer.Quantity, transfer.FromOutletID, transfer.ProductID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return model.ErrOutletStock
+	}
+	_, err = tx.Exec(`
+		INSERT INTO outlet_stock (outlet_id, product_id, stock) VALUES ($1, $2, $3)
+		ON CONFLICT (outlet_id, product_id) DO UPDATE SET stock = outlet_stock.stock + EXCLUDED.stock
+	`, transfer.ToOutletID, transfer.ProductID, transfer.Quantity)
+	if err != nil {
+		return err
+	}
+
+	transfer.Movements = model.NewTransferMovements(transfer)
+	for i := range transfer.Movements {
+		m := &transfer.Movements[i]
+		err := tx.QueryRow(`
+			INSERT INTO stock_movements (product_id, outlet_id, quantity, transfer_id, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, m.ProductID, m.OutletID, m.Quantity, m.TransferID, m.CreatedAt).Scan(&m.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTransfers returns one page of transfers with their movements, newest first. The page is
+// picked from stock_transfers alone, with LIMIT/OFFSET or a seek below the cursor's ID fetching
+// one extra row, and only then joined with its movements.
+func (r *OutletRepository) GetTransfers(page model.PageRequest) (*model.Page[*model.StockTransfer], error) {
+	result := &model.Page[*model.StockTransfer]{Items: make([]*model.StockTransfer, 0, page.Limit)}
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM stock_transfers`).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+
+	limit, offset, beforeID := page.Limit, page.Offset(), 0
+	if page.After != nil {
+		limit, offset, beforeID = page.Limit+1, 0, page.After.ID
+	} else if offset >= result.Total {
+		return result, nil
+	}
+	rows, err := r.db.Query(`
+		WITH page AS (
+			SELECT id, product_id, from_outlet_id, to_outlet_id, quantity, note, created_at
+			FROM stock_transfers
+			WHERE $3 = 0 OR id < $3
+			ORDER BY id DESC
+			LIMIT $1 OFFSET $2
+		)
+		SELECT t.id, t.product_id, t.from_outlet_id, t.to_outlet_id, t.quantity, t.note, t.created_at,
+		       m.id, m.outlet_id, m.quantity
+		FROM page t
+		JOIN stock_movements m ON m.transfer_id = t.id
+		ORDER BY t.id DESC, m.id
+	`, limit, offset, beforeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := result.Items
+	for rows.Next() {
+		var t model.StockTransfer
+		var m model.StockMovement
+		if err := rows.Scan(&t.ID, &t.ProductID, &t.FromOutletID, &t.ToOutletID, &t.Quantity, &t.Note, &t.CreatedAt,
+			&m.ID, &m.OutletID, &m.Quantity); err != nil {
+			return nil, err
+		}
+		if n := len(transfers); n == 0 || transfers[n-1].ID != t.ID {
+			transfers = append(transfers, &t)
+		}
+		last := transfers[len(transfers)-1]
+		m.ProductID, m.TransferID, m.CreatedAt = last.ProductID, last.ID, last.CreatedAt
+		last.Movements = append(last.Movements, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	more := offset+len(transfers) < result.Total
+	if page.After != nil {
+		more = len(transfers) > page.Limit
+		transfers = transfers[:min(len(transfers), page.Limit)]
+	}
+	result.Items = transfers
+	if more && len(transfers) > 0 {
+		result.Next = &model.Cursor{Desc: true, ID: transfers[len(transfers)-1].ID}
+	}
+	return result, nil
+}
@@ -2,7 +2,9 @@ package postgres
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"time"
 
 	model "kasir-api/models"
 )
@@ -17,110 +19,632 @@ func NewProductRepository(db *DB) *ProductRepository {
 	return &ProductRepository{db: db}
 }
 
-// GetAll returns all products with category info from JOIN.
-// If name is provided, filters products by name (case-insensitive partial match).
-func (r *ProductRepository) GetAll(name string) ([]*model.Product, error) {
-	var rows *sql.Rows
-	var err error
+// productStock is the available stock of p: its own stock, or for a bundle the number of
+// complete bundles its components still make.
+const productStock = `COALESCE((
+		SELECT MIN(GREATEST(cp.stock, 0) / bc.quantity)
+		FROM bundle_components bc JOIN products cp ON cp.id = bc.component_id
+		WHERE bc.bundle_id = p.id
+	), p.stock)`
 
-	if name != "" {
-		rows, err = r.db.Query(`
-			SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name, c.description
-			FROM products p
-			LEFT JOIN categories c ON p.category_id = c.id
-			WHERE p.name ILIKE $1
-			ORDER BY p.id
-		`, "%"+name+"%")
-	} else {
-		rows, err = r.db.Query(`
-			SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name, c.description
-			FROM products p
-			LEFT JOIN categories c ON p.category_id = c.id
-			ORDER BY p.id
-		`)
+// productPrice is the current price of p: the latest scheduled change that is due but not applied
+// yet, so reads see it before the price scheduler writes it, otherwise the stored price.
+const productPrice = `COALESCE((
+		SELECT pc.new_price FROM price_changes pc
+		WHERE pc.product_id = p.id AND pc.applied_at IS NULL AND pc.effective_at <= NOW()
+		ORDER BY pc.effective_at DESC, pc.id DESC
+		LIMIT 1
+	), p.price)`
+
+// productComponents aggregates the components of a bundle as JSON, NULL for other products.
+const productComponents = `(
+		SELECT json_agg(json_build_object('product_id', bc.component_id, 'name', cp.name, 'quantity', bc.quantity)
+		                ORDER BY bc.component_id)
+		FROM bundle_components bc JOIN products cp ON cp.id = bc.component_id
+		WHERE bc.bundle_id = p.id
+	)`
+
+// pluIndex is the unique index that keeps scale PLUs apart.
+const pluIndex = "idx_products_plu"
+
+// productSelect is the base query for reading products with their category info and bundle components.
+const productSelect = `
+	SELECT p.id, p.name, ` + productPrice + `, ` + productStock + `, p.unit, p.type, p.allow_negative_stock, COALESCE(p.sku, ''), COALESCE(p.barcode, ''), COALESCE(p.plu, ''),
+	       COALESCE(p.image_key, ''), p.category_id, p.archived_at, p.version, c.name, c.description,
+	       ` + productComponents + `
+	FROM products p
+	LEFT JOIN categories c ON p.category_id = c.id`
+
+// GetAll returns the products matching the filter with category info from JOIN.
+// Name matches substrings (ILIKE) or, for typos, names whose trigrams are similar enough (pg_trgm <%),
+// unless NameSubstring is set.
+// A category filter matches the whole subtree below that category.
+// Archived products are skipped unless IncludeArchived is set.
+func (r *ProductRepository) GetAll(filter model.ProductFilter) ([]*model.Product, error) {
+	var products []*model.Product
+	err := r.ForEach(filter, func(p *model.Product) error {
+		products = append(products, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// productWhere filters products on $1..$7 as bound by productFilterArgs.
+// Name matches substrings or, through pg_trgm and unless $7 asks for substrings only, names similar
+// enough to the search.
+const productWhere = `
+		WHERE ($1 = '' OR p.name ILIKE '%' || $1 || '%' OR (NOT $7 AND $1 <% p.name))
+		  AND ($2 OR p.archived_at IS NULL)
+		  AND ($3 = 0 OR p.category_id IN (
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM categories WHERE id = $3
+				UNION
+				SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+			)
+			SELECT id FROM subtree
+		  ))
+		  AND ($4 = 0 OR ` + productPrice + ` >= $4)
+		  AND ($5 = 0 OR ` + productPrice + ` <= $5)
+		  AND (NOT $6 OR ` + productStock + ` > 0)`
+
+// productFilterArgs returns the arguments for productWhere.
+func productFilterArgs(filter model.ProductFilter) []any {
+	return []any{filter.Name, filter.IncludeArchived, filter.CategoryID,
+		filter.MinPrice, filter.MaxPrice, filter.InStockOnly, filter.NameSubstring}
+}
+
+// ForEach calls fn for every product matching the filter, in the filter's order, reading rows as they arrive.
+// Iteration stops at the first error returned by fn.
+func (r *ProductRepository) ForEach(filter model.ProductFilter, fn func(p *model.Product) error) error {
+	return r.queryProducts(filter, filter.Limit, 0, nil, fn)
+}
+
+// GetPage returns one page of the matching products with the total from a COUNT over the same filter.
+// Offset pages use LIMIT/OFFSET; cursor pages seek past the cursor's sort key and ID, fetching one
+// extra row to learn whether another page follows.
+func (r *ProductRepository) GetPage(filter model.ProductFilter, page model.PageRequest) (*model.Page[*model.Product], error) {
+	result := &model.Page[*model.Product]{Items: make([]*model.Product, 0, page.Limit)}
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM products p`+productWhere, productFilterArgs(filter)...).Scan(&result.Total); err != nil {
+		return nil, err
+	}
+
+	limit, offset := page.Limit, page.Offset()
+	if page.After != nil {
+		limit, offset = page.Limit+1, 0
+	} else if offset >= result.Total {
+		return result, nil
 	}
+	err := r.queryProducts(filter, limit, offset, page.After, func(p *model.Product) error {
+		result.Items = append(result.Items, p)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+
+	more := offset+len(result.Items) < result.Total
+	if page.After != nil {
+		more = len(result.Items) > page.Limit
+		result.Items = result.Items[:min(len(result.Items), page.Limit)]
+	}
+	if more && len(result.Items) > 0 && filter.Sort != model.ProductSortRelevance {
+		result.Next = model.NewProductCursor(result.Items[len(result.Items)-1], filter)
+	}
+	return result, nil
+}
+
+// queryProducts streams the products matching filter to fn, starting after the cursor when one is
+// given, skipping offset rows and returning at most limit (0 means no limit).
+func (r *ProductRepository) queryProducts(filter model.ProductFilter, limit, offset int, after *model.Cursor, fn func(p *model.Product) error) error {
+	args := append(productFilterArgs(filter), limit, offset)
+	keyset, keysetArgs := productKeyset(filter, after)
+	rows, err := r.db.Query(productSelect+productWhere+keyset+`
+		ORDER BY `+productOrderBy(filter)+`
+		LIMIT NULLIF($8, 0) OFFSET $9
+	`, append(args, keysetArgs...)...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	var products []*model.Product
 	for rows.Next() {
-		var p model.Product
-		var categoryID sql.NullInt64
-		var categoryName, categoryDesc sql.NullString
-		if err := rows.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &categoryID, &categoryName, &categoryDesc); err != nil {
-			return nil, err
+		p, err := scanProduct(rows)
+		if err != nil {
+			return err
 		}
-		if categoryID.Valid {
-			id := int(categoryID.Int64)
-			p.CategoryID = &id
-			p.Category = &model.ProductCategory{
-				Name:        categoryName.String,
-				Description: categoryDesc.String,
-			}
+		if err := fn(p); err != nil {
+			return err
 		}
-		products = append(products, &p)
 	}
-	return products, rows.Err()
+	return rows.Err()
+}
+
+// productKeyset returns the condition keeping only rows after the cursor in the order of
+// productOrderBy, bound to $10 (sort key) and $11 (ID), or just $10 (ID) for plain ID order.
+// Like productOrderBy it only returns fixed fragments.
+func productKeyset(filter model.ProductFilter, after *model.Cursor) (string, []any) {
+	if after == nil {
+		return "", nil
+	}
+	op := " > "
+	if filter.Desc {
+		op = " < "
+	}
+	var column string
+	var key any = after.Num
+	switch filter.Sort {
+	case model.ProductSortName:
+		column, key = "LOWER(p.name)", after.Text
+	case model.ProductSortPrice:
+		column = productPrice
+	case model.ProductSortStock:
+		column = productStock
+	default:
+		return `
+		  AND p.id > $10`, []any{after.ID}
+	}
+	return `
+		  AND (` + column + op + `$10 OR (` + column + ` = $10 AND p.id > $11))`, []any{key, after.ID}
+}
+
+// productOrderBy returns the ORDER BY clause for the filter's sort. Only fixed
+// fragments are returned, never input, so it is safe to concatenate into the query.
+// Relevance refers to the name search in $1.
+func productOrderBy(filter model.ProductFilter) string {
+	dir := " ASC"
+	if filter.Desc {
+		dir = " DESC"
+	}
+	switch filter.Sort {
+	case model.ProductSortName:
+		return "LOWER(p.name)" + dir + ", p.id"
+	case model.ProductSortPrice:
+		return productPrice + dir + ", p.id"
+	case model.ProductSortStock:
+		return productStock + dir + ", p.id"
+	case model.ProductSortRelevance:
+		return "CASE WHEN p.name ILIKE '%' || $1 || '%' THEN 1 ELSE word_similarity($1, p.name) END DESC, p.id"
+	default:
+		return "p.id"
+	}
 }
 
-// GetByID returns a product by ID with category info from JOIN.
+// GetByID returns a product by ID with category info from JOIN, including archived products.
 func (r *ProductRepository) GetByID(id int) (*model.Product, error) {
-	var p model.Product
-	var categoryID sql.NullInt64
-	var categoryName, categoryDesc sql.NullString
-	err := r.db.QueryRow(`
-		SELECT p.id, p.name, p.price, p.stock, p.category_id, c.name, c.description
-		FROM products p
-		LEFT JOIN categories c ON p.category_id = c.id
-		WHERE p.id = $1
-	`, id).Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &categoryID, &categoryName, &categoryDesc)
+	p, err := scanProduct(r.db.QueryRow(productSelect+` WHERE p.id = $1`, id))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, model.ErrProductNotFound
 		}
 		return nil, err
 	}
-	if categoryID.Valid {
-		idVal := int(categoryID.Int64)
-		p.CategoryID = &idVal
-		p.Category = &model.ProductCategory{
-			Name:        categoryName.String,
-			Description: categoryDesc.String,
-		}
-	}
-	return &p, nil
+	return p, nil
 }
 
-// Create inserts a new product and returns the generated ID.
+// Create inserts a new product with its bundle components and returns the generated ID.
 // If category_id is set, fetches category info for the response.
 func (r *ProductRepository) Create(product *model.Product) error {
-	err := r.db.QueryRow(`
-		INSERT INTO products (name, price, stock, category_id) VALUES ($1, $2, $3, $4)
-		RETURNING id
-	`, product.Name, product.Price, product.Stock, product.CategoryID).Scan(&product.ID)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	if product.CategoryID != nil {
-		var categoryName, categoryDesc sql.NullString
-		if err := r.db.QueryRow("SELECT name, description FROM categories WHERE id = $1", *product.CategoryID).Scan(&categoryName, &categoryDesc); err == nil {
-			product.Category = &model.ProductCategory{
-				Name:        categoryName.String,
-				Description: categoryDesc.String,
-			}
-		}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	if err := insertProduct(tx, product); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
+	r.loadCategory(product)
+	r.loadComponents(product)
 	return nil
 }
 
 // Update updates an existing product.
+// If the price differs from the stored one, the change is recorded in price_changes within the same transaction.
 // If category_id is set, fetches category info for the response.
 func (r *ProductRepository) Update(product *model.Product) error {
-	result, err := r.db.Exec(`
-		UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4 WHERE id = $5
-	`, product.Name, product.Price, product.Stock, product.CategoryID, product.ID)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	if err := updateProduct(tx, product); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.loadCategory(product)
+	r.loadComponents(product)
+	return nil
+}
+
+// GetBySKU returns the product with the given SKU, archived ones included.
+func (r *ProductRepository) GetBySKU(sku string) (*model.Product, error) {
+	p, err := scanProduct(r.db.QueryRow(productSelect+` WHERE p.sku = $1`, sku))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrProductNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetByPLU returns the product with the given scale PLU, archived ones included.
+func (r *ProductRepository) GetByPLU(plu string) (*model.Product, error) {
+	p, err := scanProduct(r.db.QueryRow(productSelect+` WHERE p.plu = $1`, plu))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrProductNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+// Import writes the batch in a single transaction: new categories first, then product inserts and updates.
+func (r *ProductRepository) Import(batch *model.ProductImportBatch) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	for _, c := range batch.NewCategories {
+		err := tx.QueryRow(`
+			INSERT INTO categories (name, description, parent_id) VALUES ($1, $2, $3)
+			RETURNING id, version
+		`, c.Name, c.Description, c.ParentID).Scan(&c.ID, &c.Version)
+		if err != nil {
+			return err
+		}
+	}
+	for _, p := range batch.Products {
+		if p.ID > 0 {
+			err = updateProduct(tx, p)
+		} else {
+			err = insertProduct(tx, p)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, p := range batch.Products {
+		r.loadCategory(p)
+		r.loadComponents(p)
+	}
+	return nil
+}
+
+// ApplyBulk writes the batch in a single transaction. Products are locked in the order given,
+// so callers sort them by ID to keep concurrent batches from deadlocking.
+func (r *ProductRepository) ApplyBulk(batch *model.ProductBulkBatch) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	for _, p := range batch.Products {
+		if batch.Archive {
+			err = archiveProduct(tx, p.ID, p.Version)
+		} else {
+			err = updateProduct(tx, p)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReplaceImage sets the product's image key and returns the previous one.
+func (r *ProductRepository) ReplaceImage(id int, imageKey string) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	var previous string
+	err = tx.QueryRow(`SELECT COALESCE(image_key, '') FROM products WHERE id = $1 FOR UPDATE`, id).Scan(&previous)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", model.ErrProductNotFound
+		}
+		return "", err
+	}
+	if _, err := tx.Exec(`UPDATE products SET image_key = NULLIF($1, ''), version = version + 1 WHERE id = $2`, imageKey, id); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", err
+	}
+	return previous, nil
+}
+
+// GetOutletStock returns the stock of a product at an outlet; a missing row means none.
+// A bundle's stock is the number of complete bundles its components make at that outlet.
+func (r *ProductRepository) GetOutletStock(productID, outletID int) (int, error) {
+	var productExists, outletExists bool
+	var stock int
+	err := r.db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM products WHERE id = $1),
+		       EXISTS(SELECT 1 FROM outlets WHERE id = $2),
+		       COALESCE((
+		           SELECT MIN(GREATEST(COALESCE(os.stock, 0), 0) / bc.quantity)
+		           FROM bundle_components bc
+		           LEFT JOIN outlet_stock os ON os.product_id = bc.component_id AND os.outlet_id = $2
+		           WHERE bc.bundle_id = $1
+		       ), (SELECT stock FROM outlet_stock WHERE product_id = $1 AND outlet_id = $2), 0)
+	`, productID, outletID).Scan(&productExists, &outletExists, &stock)
+	if err != nil {
+		return 0, err
+	}
+	if !productExists {
+		return 0, model.ErrProductNotFound
+	}
+	if !outletExists {
+		return 0, model.ErrOutletNotFound
+	}
+	return stock, nil
+}
+
+// GetNegativeStock returns the outlet balances below zero, ordered by outlet and product.
+func (r *ProductRepository) GetNegativeStock(outletID int) ([]*model.OutletStock, error) {
+	rows, err := r.db.Query(`
+		SELECT os.outlet_id, os.product_id, p.name, os.stock
+		FROM outlet_stock os
+		JOIN products p ON p.id = os.product_id
+		WHERE os.stock < 0 AND ($1 = 0 OR os.outlet_id = $1)
+		ORDER BY os.outlet_id, os.product_id
+	`, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	negative := make([]*model.OutletStock, 0)
+	for rows.Next() {
+		var s model.OutletStock
+		if err := rows.Scan(&s.OutletID, &s.ProductID, &s.ProductName, &s.Stock); err != nil {
+			return nil, err
+		}
+		negative = append(negative, &s)
+	}
+	return negative, rows.Err()
+}
+
+// IsBundleComponent reports whether any bundle, archived ones included, uses the product as a component.
+func (r *ProductRepository) IsBundleComponent(productID int) (bool, error) {
+	var used bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM bundle_components WHERE component_id = $1)`, productID).Scan(&used)
+	return used, err
+}
+
+// insertProduct inserts a product with its bundle components and sets its generated ID.
+func insertProduct(tx *sql.Tx, product *model.Product) error {
+	err := tx.QueryRow(`
+		INSERT INTO products (name, price, stock, unit, type, allow_negative_stock, sku, barcode, plu, category_id)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), $10)
+		RETURNING id, version
+	`, product.Name, product.Price, product.Stock, productUnit(product), productType(product), product.AllowNegativeStock,
+		product.SKU, product.Barcode, product.PLU, product.CategoryID).Scan(&product.ID, &product.Version)
+	if isUniqueViolationOn(err, pluIndex) {
+		return model.ErrPLUExists
+	}
+	if isUniqueViolation(err) {
+		return model.ErrSKUExists
+	}
+	if err != nil {
+		return err
+	}
+	if err := saveComponents(tx, product); err != nil {
+		return err
+	}
+	return bookOutletStock(tx, product, product.Stock)
+}
+
+// productType returns the type to store for product, stocked when none is set.
+func productType(product *model.Product) string {
+	if product.Type == "" {
+		return model.ProductTypeStocked
+	}
+	return product.Type
+}
+
+// productUnit returns the unit to store for product, pieces when none is set.
+func productUnit(product *model.Product) string {
+	if product.Unit == "" {
+		return model.UnitPiece
+	}
+	return product.Unit
+}
+
+// bookOutletStock adds delta to the stock of product at its outlet (the default outlet when
+// product.OutletID is 0). A bundle or a product without stock holds no outlet stock, so its rows
+// are removed instead. Going below zero fails with ErrOutletStock unless the product allows it.
+func bookOutletStock(tx *sql.Tx, product *model.Product, delta int) error {
+	if product.IsBundle() || !product.TracksStock() {
+		_, err := tx.Exec(`DELETE FROM outlet_stock WHERE product_id = $1`, product.ID)
+		return err
+	}
+	if delta == 0 {
+		return nil
+	}
+	outletID := product.OutletID
+	if outletID <= 0 {
+		outletID = model.DefaultOutletID
+	}
+	var stock int
+	err := tx.QueryRow(`
+		INSERT INTO outlet_stock (outlet_id, product_id, stock) VALUES ($1, $2, $3)
+		ON CONFLICT (outlet_id, product_id) DO UPDATE SET stock = outlet_stock.stock + EXCLUDED.stock
+		RETURNING stock
+	`, outletID, product.ID, delta).Scan(&stock)
+	if isForeignKeyViolation(err) {
+		return model.ErrOutletNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if stock < 0 && !product.AllowNegativeStock {
+		return model.ErrOutletStock
+	}
+	return nil
+}
+
+// saveComponents replaces the stored components of a product with product.Components.
+func saveComponents(tx *sql.Tx, product *model.Product) error {
+	if _, err := tx.Exec(`DELETE FROM bundle_components WHERE bundle_id = $1`, product.ID); err != nil {
+		return err
+	}
+	for _, c := range product.Components {
+		_, err := tx.Exec(`
+			INSERT INTO bundle_components (bundle_id, component_id, quantity) VALUES ($1, $2, $3)
+		`, product.ID, c.ProductID, c.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateProduct locks and updates a product inside tx, recording a price change when the price differs.
+// A non-zero product.Version must match the stored version; the row lock makes the check and the
+// increment atomic. product.Version holds the new version afterwards.
+func updateProduct(tx *sql.Tx, product *model.Product) error {
+	var oldPrice, oldStock, version int
+	var imageKey string
+	var archivedAt sql.NullTime
+	err := tx.QueryRow(`
+		SELECT price, stock, COALESCE(image_key, ''), archived_at, version FROM products WHERE id = $1 FOR UPDATE
+	`, product.ID).Scan(&oldPrice, &oldStock, &imageKey, &archivedAt, &version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrProductNotFound
+		}
+		return err
+	}
+	if product.Version != 0 && product.Version != version {
+		return model.ErrVersionMismatch
+	}
+
+	err = tx.QueryRow(`
+		UPDATE products
+		SET name = $1, price = $2, stock = $3, unit = $4, type = $5, allow_negative_stock = $6,
+		    sku = NULLIF($7, ''), barcode = NULLIF($8, ''), plu = NULLIF($9, ''), category_id = $10,
+		    version = version + 1
+		WHERE id = $11
+		RETURNING version
+	`, product.Name, product.Price, product.Stock, productUnit(product), productType(product), product.AllowNegativeStock,
+		product.SKU, product.Barcode, product.PLU, product.CategoryID, product.ID).Scan(&product.Version)
+	if isCheckViolation(err) {
+		return model.ErrOutletStock
+	}
+	if isUniqueViolationOn(err, pluIndex) {
+		return model.ErrPLUExists
+	}
+	if isUniqueViolation(err) {
+		return model.ErrSKUExists
+	}
+	if err != nil {
+		return err
+	}
+	if err := saveComponents(tx, product); err != nil {
+		return err
+	}
+	if err := bookOutletStock(tx, product, product.Stock-oldStock); err != nil {
+		return err
+	}
+
+	if oldPrice != product.Price {
+		_, err = tx.Exec(`
+			INSERT INTO price_changes (product_id, old_price, new_price, changed_by, effective_at, applied_at)
+			VALUES ($1, $2, $3, $4, NOW(), NOW())
+		`, product.ID, oldPrice, product.Price, product.ChangedBy)
+		if err != nil {
+			return err
+		}
+	}
+	product.ImageKey = imageKey
+	product.ArchivedAt = nullTimePtr(archivedAt)
+	return nil
+}
+
+// loadCategory fills the embedded category info for a product that was just written.
+func (r *ProductRepository) loadCategory(product *model.Product) {
+	product.Category = nil
+	if product.CategoryID == nil {
+		return
+	}
+	var categoryName, categoryDesc sql.NullString
+	err := r.db.QueryRow("SELECT name, description FROM categories WHERE id = $1", *product.CategoryID).
+		Scan(&categoryName, &categoryDesc)
+	if err == nil {
+		product.Category = &model.ProductCategory{
+			Name:        categoryName.String,
+			Description: categoryDesc.String,
+		}
+	}
+}
+
+// loadComponents refreshes the component names and derived stock of a bundle that was just written.
+func (r *ProductRepository) loadComponents(product *model.Product) {
+	if !product.IsBundle() {
+		return
+	}
+	if stored, err := r.GetByID(product.ID); err == nil {
+		product.Components, product.Stock = stored.Components, stored.Stock
+	}
+}
+
+// Archive soft-deletes a product. Archiving twice keeps the original timestamp.
+// A non-zero version must match the stored one; the check is part of the UPDATE.
+func (r *ProductRepository) Archive(id, version int) error {
+	return archiveProduct(r.db, id, version)
+}
+
+// archiveProduct archives a product through q, checking a non-zero version in the same UPDATE.
+func archiveProduct(q queryer, id, version int) error {
+	var newVersion int
+	err := q.QueryRow(`
+		UPDATE products SET archived_at = COALESCE(archived_at, NOW()), version = version + 1
+		WHERE id = $1 AND ($2 = 0 OR version = $2)
+		RETURNING version
+	`, id, version).Scan(&newVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return productMissingOrChanged(q, id)
+	}
+	return err
+}
+
+// productMissingOrChanged explains why a versioned write touched no row.
+func productMissingOrChanged(q queryer, id int) error {
+	var exists bool
+	if err := q.QueryRow(`SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return model.ErrProductNotFound
+	}
+	return model.ErrVersionMismatch
+}
+
+// Restore brings an archived product back.
+func (r *ProductRepository) Restore(id int) error {
+	result, err := r.db.Exec(`UPDATE products SET archived_at = NULL, version = version + 1 WHERE id = $1`, id)
 	if err != nil {
 		return err
 	}
@@ -128,29 +652,205 @@ func (r *ProductRepository) Update(product *model.Product) error {
 	if n == 0 {
 		return model.ErrProductNotFound
 	}
-	if product.CategoryID != nil {
-		var categoryName, categoryDesc sql.NullString
-		if err := r.db.QueryRow("SELECT name, description FROM categories WHERE id = $1", *product.CategoryID).Scan(&categoryName, &categoryDesc); err == nil {
-			product.Category = &model.ProductCategory{
-				Name:        categoryName.String,
-				Description: categoryDesc.String,
-			}
+	return nil
+}
+
+// priceChangeWhere filters the price history of product $1 by the status in $3 at the time in $2.
+const priceChangeWhere = `
+		WHERE product_id = $1
+		  AND ($3 = '' OR ($3 = 'upcoming') = (applied_at IS NULL AND effective_at > $2))`
+
+// GetPriceChanges returns one page of the price history of a product, newest effective time first.
+// Offset pages use LIMIT/OFFSET; cursor pages seek below the cursor's effective time, rebuilt from
+// its microseconds, and ID, fetching one extra row to learn whether another page follows.
+func (r *ProductRepository) GetPriceChanges(productID int, query model.PriceChangeQuery, page model.PageRequest) (*model.Page[*model.PriceChange], error) {
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)`, productID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, model.ErrProductNotFound
+	}
+
+	result := &model.Page[*model.PriceChange]{Items: make([]*model.PriceChange, 0, page.Limit)}
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM price_changes`+priceChangeWhere, productID, query.Now, query.Status).
+		Scan(&result.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, offset, afterMicros, afterID := page.Limit, page.Offset(), 0, 0
+	if page.After != nil {
+		limit, offset, afterMicros, afterID = page.Limit+1, 0, page.After.Num, page.After.ID
+	} else if offset >= result.Total {
+		return result, nil
+	}
+	rows, err := r.db.Query(`
+		SELECT id, product_id, old_price, new_price, changed_by, effective_at, applied_at, created_at
+		FROM price_changes`+priceChangeWhere+`
+		  AND ($7 = 0 OR (effective_at, id) < (TIMESTAMPTZ 'epoch' + $6 * INTERVAL '1 microsecond', $7))
+		ORDER BY effective_at DESC, id DESC
+		LIMIT $4 OFFSET $5
+	`, productID, query.Now, query.Status, limit, offset, afterMicros, afterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c model.PriceChange
+		var oldPrice sql.NullInt64
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&c.ID, &c.ProductID, &oldPrice, &c.NewPrice, &c.ChangedBy, &c.EffectiveAt, &appliedAt, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		if oldPrice.Valid {
+			v := int(oldPrice.Int64)
+			c.OldPrice = &v
 		}
-	} else {
-		product.Category = nil
+		if appliedAt.Valid {
+			c.AppliedAt = &appliedAt.Time
+		}
+		result.Items = append(result.Items, &c)
 	}
-	return nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	more := offset+len(result.Items) < result.Total
+	if page.After != nil {
+		more = len(result.Items) > page.Limit
+		result.Items = result.Items[:min(len(result.Items), page.Limit)]
+	}
+	if more && len(result.Items) > 0 {
+		result.Next = model.NewPriceChangeCursor(result.Items[len(result.Items)-1])
+	}
+	return result, nil
+}
+
+// CreatePriceChange stores a scheduled price change.
+func (r *ProductRepository) CreatePriceChange(change *model.PriceChange) error {
+	err := r.db.QueryRow(`
+		INSERT INTO price_changes (product_id, new_price, changed_by, effective_at)
+		SELECT id, $2, $3, $4 FROM products WHERE id = $1
+		RETURNING id, created_at
+	`, change.ProductID, change.NewPrice, change.ChangedBy, change.EffectiveAt).Scan(&change.ID, &change.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrProductNotFound
+	}
+	return err
 }
 
-// Delete removes a product by ID.
-func (r *ProductRepository) Delete(id int) error {
-	result, err := r.db.Exec(`DELETE FROM products WHERE id = $1`, id)
+// DeletePriceChange cancels a scheduled price change that has not been applied yet.
+func (r *ProductRepository) DeletePriceChange(productID, id int) error {
+	var applied bool
+	err := r.db.QueryRow(`
+		SELECT applied_at IS NOT NULL FROM price_changes WHERE id = $1 AND product_id = $2
+	`, id, productID).Scan(&applied)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.ErrPriceChangeNotFound
+		}
+		return err
+	}
+	if applied {
+		return model.ErrPriceChangeApplied
+	}
+
+	result, err := r.db.Exec(`
+		DELETE FROM price_changes WHERE id = $1 AND product_id = $2 AND applied_at IS NULL
+	`, id, productID)
 	if err != nil {
 		return err
 	}
 	n, _ := result.RowsAffected()
 	if n == 0 {
-		return model.ErrProductNotFound
+		// Applied concurrently between the check and the delete.
+		return model.ErrPriceChangeApplied
 	}
 	return nil
 }
+
+// ApplyDuePriceChanges applies every pending price change whose effective time has passed,
+// in effective order, so the latest due change wins. Runs in a single transaction.
+func (r *ProductRepository) ApplyDuePriceChanges(now time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	rows, err := tx.Query(`
+		SELECT id, product_id, new_price
+		FROM price_changes
+		WHERE applied_at IS NULL AND effective_at <= $1
+		ORDER BY effective_at, id
+		FOR UPDATE SKIP LOCKED
+	`, now)
+	if err != nil {
+		return err
+	}
+
+	var due []model.PriceChange
+	for rows.Next() {
+		var c model.PriceChange
+		if err := rows.Scan(&c.ID, &c.ProductID, &c.NewPrice); err != nil {
+			rows.Close() //nolint:errcheck,gosec // already returning an error
+			return err
+		}
+		due = append(due, c)
+	}
+	rows.Close() //nolint:errcheck,gosec // rows fully consumed
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	for _, c := range due {
+		_, err = tx.Exec(`
+			UPDATE price_changes pc SET old_price = p.price, applied_at = $2
+			FROM products p
+			WHERE pc.id = $1 AND p.id = pc.product_id
+		`, c.ID, now)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE products SET price = $1, version = version + 1 WHERE id = $2`, c.NewPrice, c.ProductID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// scanProduct reads a product row selected with productSelect.
+func scanProduct(row rowScanner) (*model.Product, error) {
+	var p model.Product
+	var categoryID sql.NullInt64
+	var archivedAt sql.NullTime
+	var categoryName, categoryDesc sql.NullString
+	var components []byte
+	err := row.Scan(&p.ID, &p.Name, &p.Price, &p.Stock, &p.Unit, &p.Type, &p.AllowNegativeStock, &p.SKU, &p.Barcode, &p.PLU,
+		&p.ImageKey, &categoryID, &archivedAt, &p.Version, &categoryName, &categoryDesc, &components)
+	if err != nil {
+		return nil, err
+	}
+	if len(components) > 0 {
+		if err := json.Unmarshal(components, &p.Components); err != nil {
+			return nil, err
+		}
+	}
+	if categoryID.Valid {
+		id := int(categoryID.Int64)
+		p.CategoryID = &id
+		p.Category = &model.ProductCategory{
+			Name:        categoryName.String,
+			Description: categoryDesc.String,
+		}
+	}
+	p.ArchivedAt = nullTimePtr(archivedAt)
+	return &p, nil
+}
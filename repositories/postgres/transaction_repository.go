@@ -26,11 +26,84 @@ func (r *TransactionRepository) Create(transaction *model.Transaction) error {
 	}
 	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
 
+	if err := insertTransaction(tx, transaction); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Checkout books the sale in one database transaction. Product rows are locked in ID order while
+// their stock is taken, so concurrent checkouts of the same products queue instead of deadlocking.
+func (r *TransactionRepository) Checkout(batch *model.CheckoutBatch) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck // rollback after commit is a no-op
+
+	transaction := batch.Transaction
+	for _, take := range transaction.StockTakes() {
+		if err := takeStock(tx, take, transaction.OutletID); err != nil {
+			return err
+		}
+	}
+	if err := insertTransaction(tx, transaction); err != nil {
+		return err
+	}
+	for _, m := range batch.Movements {
+		m.TransactionID = &transaction.ID
+	}
+	if err := recordMovements(tx, batch.Movements); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// takeStock locks a product and takes the quantity from its stock and from its stock at the outlet.
+// Bundles and products without stock are left alone.
+func takeStock(tx *sql.Tx, take model.StockTake, outletID int) error {
+	product := model.Product{ID: take.ProductID, OutletID: outletID}
+	var bundle bool
+	err := tx.QueryRow(`
+		SELECT p.type, p.allow_negative_stock, EXISTS (SELECT 1 FROM bundle_components bc WHERE bc.bundle_id = p.id)
+		FROM products p WHERE p.id = $1 FOR UPDATE
+	`, take.ProductID).Scan(&product.Type, &product.AllowNegativeStock, &bundle)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if bundle || !product.TracksStock() {
+		return nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE products SET stock = stock - $1, version = version + 1 WHERE id = $2
+	`, take.Quantity, take.ProductID)
+	if isCheckViolation(err) {
+		return model.ErrInsufficientStock
+	}
+	if err != nil {
+		return err
+	}
+	err = bookOutletStock(tx, &product, -take.Quantity)
+	if errors.Is(err, model.ErrOutletStock) {
+		return model.ErrInsufficientStock
+	}
+	return err
+}
+
+// insertTransaction inserts a transaction with its details inside tx and fills in their IDs.
+func insertTransaction(tx *sql.Tx, transaction *model.Transaction) error {
 	// Insert transaction
-	err = tx.QueryRow(`
-		INSERT INTO transactions (total_amount, created_at) VALUES ($1, $2)
+	err := tx.QueryRow(`
+		INSERT INTO transactions (outlet_id, total_amount, created_at) VALUES ($1, $2, $3)
 		RETURNING id
-	`, transaction.TotalAmount, transaction.CreatedAt).Scan(&transaction.ID)
+	`, transaction.OutletID, transaction.TotalAmount, transaction.CreatedAt).Scan(&transaction.ID)
+	if isForeignKeyViolation(err) {
+		return model.ErrOutletNotFound
+	}
 	if err != nil {
 		return err
 	}
@@ -40,24 +113,25 @@ func (r *TransactionRepository) Create(transaction *model.Transaction) error {
 		detail := &transaction.Details[i]
 		detail.TransactionID = transaction.ID
 		err = tx.QueryRow(`
-			INSERT INTO transaction_details (transaction_id, product_id, product_name, quantity, price, subtotal)
-			VALUES ($1, $2, $3, $4, $5, $6)
+			INSERT INTO transaction_details (transaction_id, product_id, product_name, quantity, unit, price, subtotal,
+			                                 bundle_product_id, category_id)
+			VALUES ($1, NULLIF($2, 0), $3, $4, COALESCE(NULLIF($5, ''), 'pcs'), $6, $7, $8, $9)
 			RETURNING id
-		`, detail.TransactionID, detail.ProductID, detail.ProductName, detail.Quantity, detail.Price, detail.Subtotal).Scan(&detail.ID)
+		`, detail.TransactionID, detail.ProductID, detail.ProductName, detail.Quantity, detail.Unit, detail.Price, detail.Subtotal,
+			detail.BundleProductID, detail.CategoryID).Scan(&detail.ID)
 		if err != nil {
 			return err
 		}
 	}
-
-	return tx.Commit()
+	return nil
 }
 
 // GetByID returns a transaction by ID with its details.
 func (r *TransactionRepository) GetByID(id int) (*model.Transaction, error) {
 	var t model.Transaction
 	err := r.db.QueryRow(`
-		SELECT id, total_amount, created_at FROM transactions WHERE id = $1
-	`, id).Scan(&t.ID, &t.TotalAmount, &t.CreatedAt)
+		SELECT id, outlet_id, total_amount, created_at FROM transactions WHERE id = $1
+	`, id).Scan(&t.ID, &t.OutletID, &t.TotalAmount, &t.CreatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, model.ErrTransactionNotFound
@@ -67,8 +141,10 @@ func (r *TransactionRepository) GetByID(id int) (*model.Transaction, error) {
 
 	// Get transaction details
 	rows, err := r.db.Query(`
-		SELECT id, transaction_id, product_id, product_name, quantity, price, subtotal
+		SELECT id, transaction_id, COALESCE(product_id, 0), product_name, quantity, unit, price, subtotal,
+		       bundle_product_id, category_id
 		FROM transaction_details WHERE transaction_id = $1
+		ORDER BY id
 	`, id)
 	if err != nil {
 		return nil, err
@@ -77,40 +153,49 @@ func (r *TransactionRepository) GetByID(id int) (*model.Transaction, error) {
 
 	for rows.Next() {
 		var d model.TransactionDetail
-		if err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.Quantity, &d.Price, &d.Subtotal); err != nil {
+		var bundleID, categoryID sql.NullInt64
+		if err := rows.Scan(&d.ID, &d.TransactionID, &d.ProductID, &d.ProductName, &d.Quantity, &d.Unit, &d.Price, &d.Subtotal,
+			&bundleID, &categoryID); err != nil {
 			return nil, err
 		}
+		d.BundleProductID = nullIntPtr(bundleID)
+		d.CategoryID = nullIntPtr(categoryID)
 		t.Details = append(t.Details, d)
 	}
 
 	return &t, rows.Err()
 }
 
-// GetReportByDateRange returns report data for a given date range.
-func (r *TransactionRepository) GetReportByDateRange(startDate, endDate time.Time) (*model.ReportResponse, error) {
+// GetReportByDateRange returns report data for a given date range, for one outlet or all when outletID is 0.
+func (r *TransactionRepository) GetReportByDateRange(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
 	report := &model.ReportResponse{}
 
 	// Get total revenue and total transactions
 	err := r.db.QueryRow(`
 		SELECT COALESCE(SUM(total_amount), 0), COUNT(*)
 		FROM transactions
-		WHERE created_at >= $1 AND created_at < $2
-	`, startDate, endDate).Scan(&report.TotalRevenue, &report.TotalTransaksi)
+		WHERE created_at >= $1 AND created_at < $2 AND ($3 = 0 OR outlet_id = $3)
+	`, startDate, endDate, outletID).Scan(&report.TotalRevenue, &report.TotalTransaksi)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get best selling product
+	// Get best selling product, ranked by items so grams are never compared with pieces
 	var produkTerlaris model.ProdukTerlaris
 	err = r.db.QueryRow(`
-		SELECT product_name, COALESCE(SUM(quantity), 0) as total_qty
+		SELECT td.product_id, (ARRAY_AGG(td.product_name ORDER BY td.transaction_id DESC, td.id DESC))[1],
+		       SUM(td.quantity), SUM(CASE WHEN td.unit IN ('kg', 'l') THEN 1 ELSE td.quantity END) AS items
 		FROM transaction_details td
 		JOIN transactions t ON td.transaction_id = t.id
-		WHERE t.created_at >= $1 AND t.created_at < $2
-		GROUP BY product_name
-		ORDER BY total_qty DESC
+		-- bundle components are stock movements, the bundle line is what was sold;
+		-- custom items are reported as their own group
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND td.bundle_product_id IS NULL
+		  AND td.product_id IS NOT NULL AND ($3 = 0 OR t.outlet_id = $3)
+		GROUP BY td.product_id
+		ORDER BY items DESC, td.product_id
 		LIMIT 1
-	`, startDate, endDate).Scan(&produkTerlaris.Nama, &produkTerlaris.QtyTerjual)
+	`, startDate, endDate, outletID).Scan(&produkTerlaris.ProductID, &produkTerlaris.Nama,
+		&produkTerlaris.QtyTerjual, &produkTerlaris.ItemTerjual)
 	if err != nil {
 		if !errors.Is(err, sql.ErrNoRows) {
 			return nil, err
@@ -120,5 +205,180 @@ func (r *TransactionRepository) GetReportByDateRange(startDate, endDate time.Tim
 		report.ProdukTerlaris = &produkTerlaris
 	}
 
+	var custom model.ItemCustom
+	err = r.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(td.quantity), 0), COALESCE(SUM(td.subtotal), 0)
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND td.product_id IS NULL
+		  AND ($3 = 0 OR t.outlet_id = $3)
+	`, startDate, endDate, outletID).Scan(&custom.JumlahBaris, &custom.QtyTerjual, &custom.Revenue)
+	if err != nil {
+		return nil, err
+	}
+	if custom.JumlahBaris > 0 {
+		report.ItemCustom = &custom
+	}
+
 	return report, nil
 }
+
+// GetTimeSeries returns the sales of [startDate, endDate) per bucket, for one outlet or all when outletID is 0.
+// Rows are grouped with date_trunc in startDate's location, which must be a named IANA location such as
+// the store timezone, and placed into the buckets of model.NewTimeSeriesReport, so empty buckets stay
+// zero and the boundaries match the memory repository.
+func (r *TransactionRepository) GetTimeSeries(startDate, endDate time.Time, granularity string, outletID int) (*model.TimeSeriesReport, error) {
+	report, err := model.NewTimeSeriesReport(startDate, endDate, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT date_trunc($4, t.created_at AT TIME ZONE $5) AT TIME ZONE $5 AS bucket, COUNT(*), SUM(t.total_amount), COALESCE(SUM(d.items), 0)
+		FROM transactions t
+		LEFT JOIN (
+			-- bundle components are stock movements, the bundle line is what was sold
+			SELECT transaction_id, SUM(CASE WHEN unit IN ('kg', 'l') THEN 1 ELSE quantity END) AS items
+			FROM transaction_details
+			WHERE bundle_product_id IS NULL
+			GROUP BY transaction_id
+		) d ON d.transaction_id = t.id
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND ($3 = 0 OR t.outlet_id = $3)
+		GROUP BY bucket
+	`, startDate, endDate, outletID, granularity, startDate.Location().String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var start time.Time
+		var revenue, count, items int
+		if err := rows.Scan(&start, &count, &revenue, &items); err != nil {
+			return nil, err
+		}
+		bucket := report.Bucket(start)
+		if bucket == nil {
+			continue
+		}
+		bucket.TotalRevenue += revenue
+		bucket.TotalTransaksi += count
+		bucket.ItemTerjual += items
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.SetAverages()
+	return report, nil
+}
+
+// GetCategorySales returns the sales of [startDate, endDate) per category snapshot, for one outlet or all
+// when outletID is 0. Custom items get a row of their own.
+func (r *TransactionRepository) GetCategorySales(startDate, endDate time.Time, outletID int) ([]*model.CategorySales, error) {
+	rows, err := r.db.Query(`
+		SELECT CASE WHEN td.product_id IS NULL THEN NULL ELSE td.category_id END AS category_id,
+		       td.product_id IS NULL AS custom,
+		       SUM(CASE WHEN td.unit IN ('kg', 'l') THEN 1 ELSE td.quantity END) AS items,
+		       SUM(td.subtotal) AS revenue
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		-- bundle components are stock movements, the bundle line is what was sold
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND td.bundle_product_id IS NULL
+		  AND ($3 = 0 OR t.outlet_id = $3)
+		GROUP BY 1, 2
+		ORDER BY revenue DESC, custom, category_id ASC NULLS LAST
+	`, startDate, endDate, outletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := []*model.CategorySales{}
+	for rows.Next() {
+		var row model.CategorySales
+		var categoryID sql.NullInt64
+		if err := rows.Scan(&categoryID, &row.Custom, &row.ItemTerjual, &row.Revenue); err != nil {
+			return nil, err
+		}
+		row.CategoryID = nullIntPtr(categoryID)
+		sales = append(sales, &row)
+	}
+	return sales, rows.Err()
+}
+
+// GetProductSales ranks the products sold in [startDate, endDate), for one outlet or all when outletID is 0.
+// The share is computed in Go from the revenue of all products, like the memory repository does.
+func (r *TransactionRepository) GetProductSales(startDate, endDate time.Time, outletID int, query model.ProductSalesQuery) ([]*model.ProductSales, error) {
+	rows, err := r.db.Query(`
+		SELECT td.product_id, SUM(td.quantity) AS qty,
+		       SUM(CASE WHEN td.unit IN ('kg', 'l') THEN 1 ELSE td.quantity END) AS items,
+		       SUM(td.subtotal) AS revenue, SUM(SUM(td.subtotal)) OVER () AS total
+		FROM transaction_details td
+		JOIN transactions t ON td.transaction_id = t.id
+		-- bundle components are stock movements and custom items belong to no product
+		WHERE t.created_at >= $1 AND t.created_at < $2 AND td.bundle_product_id IS NULL
+		  AND td.product_id IS NOT NULL AND ($3 = 0 OR t.outlet_id = $3)
+		GROUP BY td.product_id
+		ORDER BY `+productSalesOrderBy(query)+`
+		LIMIT NULLIF($4, 0)
+	`, startDate, endDate, outletID, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sales := []*model.ProductSales{}
+	for rows.Next() {
+		var row model.ProductSales
+		var total int
+		if err := rows.Scan(&row.ProductID, &row.QtyTerjual, &row.ItemTerjual, &row.Revenue, &total); err != nil {
+			return nil, err
+		}
+		row.Persentase = model.Share(row.Revenue, total)
+		sales = append(sales, &row)
+	}
+	return sales, rows.Err()
+}
+
+// productSalesOrderBy returns the ORDER BY clause of a product ranking. Only fixed fragments are
+// returned, never input, so it is safe to concatenate into the query.
+func productSalesOrderBy(query model.ProductSalesQuery) string {
+	column := "items"
+	if query.Sort == model.ProductSalesSortRevenue {
+		column = "revenue"
+	}
+	dir := " ASC"
+	if query.Desc {
+		dir = " DESC"
+	}
+	return column + dir + ", td.product_id"
+}
+
+// GetHeatmap counts the sales of [startDate, endDate) by day and hour in loc, for one outlet or all
+// when outletID is 0. loc must be a named IANA location, such as the store timezone.
+func (r *TransactionRepository) GetHeatmap(startDate, endDate time.Time, loc *time.Location, outletID int) (*model.SalesHeatmap, error) {
+	rows, err := r.db.Query(`
+		SELECT EXTRACT(DOW FROM local_at)::int, EXTRACT(HOUR FROM local_at)::int, COUNT(*), SUM(total_amount)
+		FROM (
+			SELECT created_at AT TIME ZONE $4 AS local_at, total_amount
+			FROM transactions
+			WHERE created_at >= $1 AND created_at < $2 AND ($3 = 0 OR outlet_id = $3)
+		) t
+		GROUP BY 1, 2
+	`, startDate, endDate, outletID, loc.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	heatmap := model.NewSalesHeatmap(loc)
+	for rows.Next() {
+		var weekday, hour, count, revenue int
+		if err := rows.Scan(&weekday, &hour, &count, &revenue); err != nil {
+			return nil, err
+		}
+		heatmap.AddCell(weekday, hour, count, revenue)
+	}
+	return heatmap, rows.Err()
+}
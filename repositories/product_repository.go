@@ -1,13 +1,68 @@
 package repository
 
-import model "kasir-api/models"
+import (
+	"time"
+
+	model "kasir-api/models"
+)
 
 // ProductRepository defines data access for products.
 // Repository layer: data buat logic. Error database → cek sini.
+// Products are never hard-deleted so transaction history keeps resolving them;
+// Archive hides them from GetAll unless filter.IncludeArchived is set, GetByID still returns them.
 type ProductRepository interface {
-	GetAll(name string) ([]*model.Product, error)
+	GetAll(filter model.ProductFilter) ([]*model.Product, error)
+	// GetPage returns one page of the products matching filter, in the filter's order,
+	// together with the total number of matches. filter.Limit is ignored. With page.After set
+	// the page starts right after that cursor (keyset), otherwise at the page offset.
+	// Next is set when more products follow, except for relevance order which has no cursor.
+	GetPage(filter model.ProductFilter, page model.PageRequest) (*model.Page[*model.Product], error)
 	GetByID(id int) (*model.Product, error)
 	Create(product *model.Product) error
+	// Update and Archive check a non-zero version (product.Version for Update) against the stored one
+	// atomically with the write and fail with ErrVersionMismatch when it differs; 0 skips the check.
+	// Every write bumps the stored version, and Update leaves the new one in product.Version.
 	Update(product *model.Product) error
-	Delete(id int) error
+	Archive(id, version int) error
+	Restore(id int) error
+
+	// ForEach streams the products matching filter to fn in the filter's order, stopping at fn's first error.
+	ForEach(filter model.ProductFilter, fn func(p *model.Product) error) error
+
+	// GetBySKU returns the product with the given SKU, archived ones included.
+	GetBySKU(sku string) (*model.Product, error)
+	// GetByPLU returns the product with the given scale PLU (normalized), archived ones included.
+	// PLUs are unique; Create and Update fail with ErrPLUExists on a clash.
+	GetByPLU(plu string) (*model.Product, error)
+	// Import writes a validated batch all-or-nothing. SKUs are unique; a clash fails with ErrSKUExists.
+	Import(batch *model.ProductImportBatch) error
+	// ApplyBulk writes a bulk operation all-or-nothing. Each product's non-zero Version is checked
+	// like Update does, so a product changed since it was read fails the whole batch.
+	ApplyBulk(batch *model.ProductBulkBatch) error
+
+	// GetOutletStock returns the stock of a product at one outlet; for a bundle, the number of complete
+	// bundles its components make at that outlet.
+	// Create and Update book a stock change at product.OutletID, or DefaultOutletID when it is 0,
+	// and fail with ErrOutletStock when that would take the outlet's stock below zero.
+	GetOutletStock(productID, outletID int) (int, error)
+	// GetNegativeStock returns the outlet balances below zero, which only products allowing
+	// negative stock can reach, ordered by outlet and product. An outletID of 0 means all outlets.
+	GetNegativeStock(outletID int) ([]*model.OutletStock, error)
+
+	// IsBundleComponent reports whether any bundle, archived ones included, uses the product as a component.
+	IsBundleComponent(productID int) (bool, error)
+
+	// ReplaceImage sets the product's image key ("" clears it) and returns the previous key.
+	// Update leaves the image key untouched.
+	ReplaceImage(id int, imageKey string) (string, error)
+
+	// Price history. Update records an applied change whenever the price differs.
+	// Every read returns the price of the latest due change that ApplyDuePriceChanges has not written
+	// yet, so a scheduled price shows at its effective time without reads writing anything.
+	// GetPriceChanges returns one page of the changes matching query, newest effective time first
+	// and ties by ID, with the total count. A cursor page starts after page.After.
+	GetPriceChanges(productID int, query model.PriceChangeQuery, page model.PageRequest) (*model.Page[*model.PriceChange], error)
+	CreatePriceChange(change *model.PriceChange) error
+	DeletePriceChange(productID, id int) error
+	ApplyDuePriceChanges(now time.Time) error
 }
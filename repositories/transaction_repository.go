@@ -9,7 +9,27 @@ import (
 // TransactionRepository defines data access for transactions.
 // Repository layer: data buat logic. Error database → cek sini.
 type TransactionRepository interface {
+	// Create inserts a transaction as it is, without touching stock; Checkout is how sales are booked.
 	Create(transaction *model.Transaction) error
+	// Checkout books a sale all-or-nothing: it takes the stock of batch.Transaction.StockTakes() at the
+	// transaction's outlet, inserts the transaction and records batch.Movements. Stock is checked under
+	// the same lock as the write and fails with ErrInsufficientStock unless the product allows negative
+	// stock; bundles and products without stock are skipped. Each product whose stock changes gets a
+	// new version.
+	Checkout(batch *model.CheckoutBatch) error
 	GetByID(id int) (*model.Transaction, error)
-	GetReportByDateRange(startDate, endDate time.Time) (*model.ReportResponse, error)
+	// GetReportByDateRange reports on the transactions of one outlet, or of all outlets when outletID is 0.
+	GetReportByDateRange(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error)
+	// GetTimeSeries splits the sales of [startDate, endDate) into zero-filled buckets of the given granularity,
+	// cut in startDate's location.
+	GetTimeSeries(startDate, endDate time.Time, granularity string, outletID int) (*model.TimeSeriesReport, error)
+	// GetCategorySales sums the sales of [startDate, endDate) per category snapshot, by revenue descending
+	// and then category ID with uncategorized and then the custom items row last. Custom items are
+	// summed apart whatever their category. Names, roll-ups and shares are left to the caller.
+	GetCategorySales(startDate, endDate time.Time, outletID int) ([]*model.CategorySales, error)
+	// GetProductSales ranks the products sold in [startDate, endDate) as query asks, with their shares.
+	// Names are left to the caller.
+	GetProductSales(startDate, endDate time.Time, outletID int, query model.ProductSalesQuery) ([]*model.ProductSales, error)
+	// GetHeatmap counts the sales of [startDate, endDate) by day of week and hour of day in loc.
+	GetHeatmap(startDate, endDate time.Time, loc *time.Location, outletID int) (*model.SalesHeatmap, error)
 }
@@ -19,7 +19,11 @@ type Router struct {
 	productHandler     *handler.ProductHandler
 	categoryHandler    *handler.CategoryHandler
 	transactionHandler *handler.TransactionHandler
+	ingredientHandler  *handler.IngredientHandler
+	outletHandler      *handler.OutletHandler
 	healthChecker      HealthChecker
+	mediaPrefix        string
+	mediaHandler       http.Handler
 }
 
 // NewRouter creates a new Router instance.
@@ -36,6 +40,22 @@ func (rt *Router) SetHealthChecker(hc HealthChecker) {
 	rt.healthChecker = hc
 }
 
+// SetIngredientHandler enables the ingredient, recipe and ingredient usage endpoints.
+func (rt *Router) SetIngredientHandler(h *handler.IngredientHandler) {
+	rt.ingredientHandler = h
+}
+
+// SetOutletHandler enables the outlet, outlet stock and stock transfer endpoints.
+func (rt *Router) SetOutletHandler(h *handler.OutletHandler) {
+	rt.outletHandler = h
+}
+
+// SetMediaHandler serves uploaded files (e.g. product images) for GET requests below prefix.
+func (rt *Router) SetMediaHandler(prefix string, h http.Handler) {
+	rt.mediaPrefix = strings.TrimSuffix(prefix, "/") + "/"
+	rt.mediaHandler = h
+}
+
 // ServeHTTP implements the http.Handler interface.
 func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Path
@@ -57,6 +77,16 @@ func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Uploaded media files
+	if rt.mediaHandler != nil && strings.HasPrefix(path, rt.mediaPrefix) {
+		if method != http.MethodGet && method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.mediaHandler.ServeHTTP(w, r)
+		return
+	}
+
 	// Product endpoints
 	if path == "/api/products" {
 		switch method {
@@ -70,6 +100,62 @@ func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Product import endpoint
+	if path == "/api/products/import" {
+		if method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.productHandler.HandleImport(w, r)
+		return
+	}
+
+	// Product bulk operation endpoint
+	if path == "/api/products/bulk" {
+		if method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.productHandler.HandleBulk(w, r)
+		return
+	}
+
+	// Product typeahead search endpoint
+	if path == "/api/products/search" {
+		if method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.productHandler.HandleSearch(w, r)
+		return
+	}
+
+	// Scale label lookup endpoint
+	if path == "/api/products/scan" {
+		if method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.productHandler.HandleScan(w, r)
+		return
+	}
+
+	// Product export endpoint
+	if path == "/api/products/export" {
+		if method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.productHandler.HandleExport(w, r)
+		return
+	}
+
+	// Product sub-resource endpoints (/api/products/{id}/...)
+	if segments := pathSegments(path, "/api/products/"); len(segments) > 1 {
+		rt.routeProductSubresource(w, r, segments)
+		return
+	}
+
 	// Product by ID endpoints
 	if strings.HasPrefix(path, "/api/products/") && path != "/api/products/" {
 		switch method {
@@ -77,6 +163,8 @@ func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			rt.productHandler.HandleGetByID(w, r)
 		case http.MethodPut:
 			rt.productHandler.HandleUpdate(w, r)
+		case http.MethodPatch:
+			rt.productHandler.HandlePatch(w, r)
 		case http.MethodDelete:
 			rt.productHandler.HandleDelete(w, r)
 		default:
@@ -98,6 +186,12 @@ func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Category sub-resource endpoints (/api/categories/{id}/...)
+	if segments := pathSegments(path, "/api/categories/"); len(segments) > 1 {
+		rt.routeCategorySubresource(w, r, segments)
+		return
+	}
+
 	// Category by ID endpoints
 	if strings.HasPrefix(path, "/api/categories/") && path != "/api/categories/" {
 		switch method {
@@ -105,6 +199,8 @@ func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			rt.categoryHandler.HandleGetByID(w, r)
 		case http.MethodPut:
 			rt.categoryHandler.HandleUpdate(w, r)
+		case http.MethodPatch:
+			rt.categoryHandler.HandlePatch(w, r)
 		case http.MethodDelete:
 			rt.categoryHandler.HandleDelete(w, r)
 		default:
@@ -113,6 +209,18 @@ func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Ingredient endpoints
+	if rt.ingredientHandler != nil && (path == "/api/ingredients" || strings.HasPrefix(path, "/api/ingredients/")) {
+		rt.routeIngredients(w, r)
+		return
+	}
+
+	// Outlet endpoints
+	if rt.outletHandler != nil && (path == "/api/outlets" || strings.HasPrefix(path, "/api/outlets/")) {
+		rt.routeOutlets(w, r)
+		return
+	}
+
 	// Checkout endpoint
 	if path == "/api/checkout" && method == http.MethodPost {
 		rt.transactionHandler.HandleCheckout(w, r)
@@ -136,6 +244,36 @@ func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Sales time-series report endpoint
+	if path == "/api/report/timeseries" && method == http.MethodGet {
+		rt.transactionHandler.HandleGetTimeSeries(w, r)
+		return
+	}
+
+	// Category sales report endpoint
+	if path == "/api/report/categories" && method == http.MethodGet {
+		rt.transactionHandler.HandleGetCategorySales(w, r)
+		return
+	}
+
+	// Product ranking report endpoint
+	if path == "/api/report/products" && method == http.MethodGet {
+		rt.transactionHandler.HandleGetProductSales(w, r)
+		return
+	}
+
+	// Sales heatmap report endpoint
+	if path == "/api/report/heatmap" && method == http.MethodGet {
+		rt.transactionHandler.HandleGetHeatmap(w, r)
+		return
+	}
+
+	// Ingredient usage report endpoint
+	if path == "/api/report/ingredients" && method == http.MethodGet && rt.ingredientHandler != nil {
+		rt.ingredientHandler.HandleGetUsage(w, r)
+		return
+	}
+
 	// Report with date range endpoint
 	if path == "/api/report" && method == http.MethodGet {
 		rt.transactionHandler.HandleGetReport(w, r)
@@ -146,6 +284,151 @@ func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	http.NotFound(w, r)
 }
 
+// routeProductSubresource dispatches /api/products/{id}/<sub>[/...] requests.
+func (rt *Router) routeProductSubresource(w http.ResponseWriter, r *http.Request, segments []string) {
+	switch {
+	case segments[1] == "prices" && len(segments) == 2:
+		switch r.Method {
+		case http.MethodGet:
+			rt.productHandler.HandleGetPriceChanges(w, r)
+		case http.MethodPost:
+			rt.productHandler.HandleSchedulePriceChange(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case segments[1] == "prices" && len(segments) == 3:
+		if r.Method != http.MethodDelete {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.productHandler.HandleCancelPriceChange(w, r)
+	case segments[1] == "restore" && len(segments) == 2:
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.productHandler.HandleRestore(w, r)
+	case segments[1] == "images" && len(segments) == 2:
+		switch r.Method {
+		case http.MethodPost:
+			rt.productHandler.HandleUploadImage(w, r)
+		case http.MethodDelete:
+			rt.productHandler.HandleDeleteImage(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case segments[1] == "recipe" && len(segments) == 2 && rt.ingredientHandler != nil:
+		switch r.Method {
+		case http.MethodGet:
+			rt.ingredientHandler.HandleGetRecipe(w, r)
+		case http.MethodPut:
+			rt.ingredientHandler.HandleSetRecipe(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// routeCategorySubresource dispatches /api/categories/{id}/<sub> requests.
+func (rt *Router) routeCategorySubresource(w http.ResponseWriter, r *http.Request, segments []string) {
+	switch {
+	case segments[1] == "restore" && len(segments) == 2:
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.categoryHandler.HandleRestore(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// routeIngredients dispatches /api/ingredients, /api/ingredients/{id} and /api/ingredients/{id}/movements.
+func (rt *Router) routeIngredients(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/api/ingredients/")
+	switch {
+	case r.URL.Path == "/api/ingredients":
+		switch r.Method {
+		case http.MethodGet:
+			rt.ingredientHandler.HandleGetAll(w, r)
+		case http.MethodPost:
+			rt.ingredientHandler.HandleCreate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(segments) == 1 && segments[0] != "":
+		switch r.Method {
+		case http.MethodGet:
+			rt.ingredientHandler.HandleGetByID(w, r)
+		case http.MethodPut:
+			rt.ingredientHandler.HandleUpdate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(segments) == 2 && segments[1] == "movements":
+		switch r.Method {
+		case http.MethodGet:
+			rt.ingredientHandler.HandleGetMovements(w, r)
+		case http.MethodPost:
+			rt.ingredientHandler.HandleRecordMovement(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// routeOutlets dispatches /api/outlets, /api/outlets/transfers, /api/outlets/{id} and /api/outlets/{id}/stock.
+func (rt *Router) routeOutlets(w http.ResponseWriter, r *http.Request) {
+	segments := pathSegments(r.URL.Path, "/api/outlets/")
+	switch {
+	case r.URL.Path == "/api/outlets":
+		switch r.Method {
+		case http.MethodGet:
+			rt.outletHandler.HandleGetAll(w, r)
+		case http.MethodPost:
+			rt.outletHandler.HandleCreate(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(segments) == 1 && segments[0] == "transfers":
+		switch r.Method {
+		case http.MethodGet:
+			rt.outletHandler.HandleGetTransfers(w, r)
+		case http.MethodPost:
+			rt.outletHandler.HandleTransfer(w, r)
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case len(segments) == 1 && segments[0] != "":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.outletHandler.HandleGetByID(w, r)
+	case len(segments) == 2 && segments[1] == "stock":
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rt.outletHandler.HandleGetStock(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// pathSegments splits the path after prefix into its slash-separated segments.
+// Returns nil when the path does not start with prefix.
+func pathSegments(path, prefix string) []string {
+	if !strings.HasPrefix(path, prefix) {
+		return nil
+	}
+	return strings.Split(strings.TrimPrefix(path, prefix), "/")
+}
+
 // handleHealth handles the health check endpoint with optional DB connectivity check.
 func (rt *Router) handleHealth(w http.ResponseWriter, r *http.Request) {
 	status := map[string]string{
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	handler "kasir-api/handlers"
 	model "kasir-api/models"
@@ -17,12 +18,18 @@ func setupTestRouter() *Router {
 	// Create in-memory repositories
 	categoryRepo := memory.NewCategoryRepository()
 	productRepo := memory.NewProductRepository(categoryRepo)
-	transactionRepo := memory.NewTransactionRepository()
+	ingredientRepo := memory.NewIngredientRepository()
+	transactionRepo := memory.NewTransactionRepository(productRepo, ingredientRepo)
+	outletRepo := memory.NewOutletRepository(productRepo)
 
 	// Create services
 	categoryService := service.NewCategoryService(categoryRepo)
 	productService := service.NewProductService(productRepo, categoryRepo)
 	transactionService := service.NewTransactionService(transactionRepo, productRepo)
+	transactionService.SetIngredientRepository(ingredientRepo)
+	transactionService.SetCategoryRepository(categoryRepo)
+	ingredientService := service.NewIngredientService(ingredientRepo, productRepo)
+	outletService := service.NewOutletService(outletRepo, productRepo)
 
 	// Create handlers
 	categoryHandler := handler.NewCategoryHandler(categoryService)
@@ -30,13 +37,16 @@ func setupTestRouter() *Router {
 	transactionHandler := handler.NewTransactionHandler(transactionService)
 
 	// Create router
-	return NewRouter(productHandler, categoryHandler, transactionHandler)
+	rt := NewRouter(productHandler, categoryHandler, transactionHandler)
+	rt.SetIngredientHandler(handler.NewIngredientHandler(ingredientService))
+	rt.SetOutletHandler(handler.NewOutletHandler(outletService))
+	return rt
 }
 
 func TestNewRouter(t *testing.T) {
 	categoryRepo := memory.NewCategoryRepository()
 	productRepo := memory.NewProductRepository(categoryRepo)
-	transactionRepo := memory.NewTransactionRepository()
+	transactionRepo := memory.NewTransactionRepository(productRepo, nil)
 
 	categoryService := service.NewCategoryService(categoryRepo)
 	productService := service.NewProductService(productRepo, categoryRepo)
@@ -205,6 +215,22 @@ func TestRouter_Products_Update(t *testing.T) {
 	}
 }
 
+func TestRouter_Products_Patch(t *testing.T) {
+	router := setupTestRouter()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/api/products", bytes.NewBufferString(`{"name":"Laptop","price":1000,"stock":10}`))
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/products/1", bytes.NewBufferString(`{"stock":3}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("PATCH /api/products/1 should return 200, got: %d", rr.Code)
+	}
+}
+
 func TestRouter_Products_Delete(t *testing.T) {
 	router := setupTestRouter()
 
@@ -232,6 +258,154 @@ func TestRouter_Products_Delete(t *testing.T) {
 	}
 }
 
+func TestRouter_Products_PriceChanges(t *testing.T) {
+	router := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Rokok", "price": 28000, "stock": 10})
+	createReq := httptest.NewRequest(http.MethodPost, "/api/products", bytes.NewBuffer(body))
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/1/prices", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("GET /api/products/1/prices should return 200, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPut, "/api/products/1/prices", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT /api/products/1/prices should return 405, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/products/1/unknown", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Unknown product sub-resource should return 404, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_Products_Restore(t *testing.T) {
+	router := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Laptop", "price": 1000, "stock": 10})
+	createReq := httptest.NewRequest(http.MethodPost, "/api/products", bytes.NewBuffer(body))
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/products/1", nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/products/1/restore", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("POST /api/products/1/restore should return 200, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/products/1/restore", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/products/1/restore should return 405, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_Products_Import(t *testing.T) {
+	router := setupTestRouter()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/products/import", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("POST /api/products/import without a file should return 400, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/products/import", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/products/import should return 405, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_Products_Export(t *testing.T) {
+	router := setupTestRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/export?format=ndjson", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("GET /api/products/export should return 200, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/products/export", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/products/export should return 405, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_Products_Images(t *testing.T) {
+	router := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Laptop", "price": 1000, "stock": 10})
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/products", bytes.NewBuffer(body)))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/products/1/images", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("DELETE /api/products/1/images without image store should return 503, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/products/1/images", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/products/1/images should return 405, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_Media(t *testing.T) {
+	router := setupTestRouter()
+	router.SetMediaHandler("/media", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/media/products/1/a.jpg", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Body.String() != "/media/products/1/a.jpg" {
+		t.Errorf("GET /media/... should reach the media handler, got: %d %q", rr.Code, rr.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/media/products/1/a.jpg", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /media/... should return 405, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_Products_Search(t *testing.T) {
+	router := setupTestRouter()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/products/search?q=laptop", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("GET /api/products/search should return 200, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/products/search", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/products/search should return 405, got: %d", rr.Code)
+	}
+}
+
 func TestRouter_ProductsByID_MethodNotAllowed(t *testing.T) {
 	router := setupTestRouter()
 
@@ -351,6 +525,22 @@ func TestRouter_Categories_Update(t *testing.T) {
 	}
 }
 
+func TestRouter_Categories_Patch(t *testing.T) {
+	router := setupTestRouter()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/api/categories", bytes.NewBufferString(`{"name":"Minuman"}`))
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/categories/1", bytes.NewBufferString(`{"description":"Dingin"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("PATCH /api/categories/1 should return 200, got: %d", rr.Code)
+	}
+}
+
 func TestRouter_Categories_Delete(t *testing.T) {
 	router := setupTestRouter()
 
@@ -377,6 +567,29 @@ func TestRouter_Categories_Delete(t *testing.T) {
 	}
 }
 
+func TestRouter_Categories_Restore(t *testing.T) {
+	router := setupTestRouter()
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Electronics"})
+	createReq := httptest.NewRequest(http.MethodPost, "/api/categories", bytes.NewBuffer(body))
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/api/categories/1", nil))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/categories/1/restore", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("POST /api/categories/1/restore should return 200, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/categories/1/unknown", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Unknown category sub-resource should return 404, got: %d", rr.Code)
+	}
+}
+
 func TestRouter_CategoriesByID_MethodNotAllowed(t *testing.T) {
 	router := setupTestRouter()
 
@@ -543,6 +756,147 @@ func TestRouter_Report_DateRange_WrongMethod(t *testing.T) {
 	}
 }
 
+func TestRouter_Report_TimeSeries(t *testing.T) {
+	router := setupTestRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	serve(http.MethodPost, "/api/products", `{"name":"Indomie","price":3500,"stock":10}`)
+	serve(http.MethodPost, "/api/checkout", `{"items":[{"product_id":1,"quantity":2}]}`)
+
+	today := time.Now().Format("2006-01-02")
+	rr := serve(http.MethodGet, "/api/report/timeseries?granularity=hour&start_date="+today+"&end_date="+today, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /api/report/timeseries should return 200, got: %d %s", rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Data model.TimeSeriesReport `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	revenue := 0
+	for _, bucket := range response.Data.Buckets {
+		revenue += bucket.TotalRevenue
+	}
+	if len(response.Data.Buckets) != 24 || revenue != 7000 {
+		t.Errorf("Hourly report of today should have 24 buckets totalling 7000, got: %d buckets, %d", len(response.Data.Buckets), revenue)
+	}
+
+	if rr := serve(http.MethodGet, "/api/report/timeseries?granularity=year&start_date="+today+"&end_date="+today, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("An unknown granularity should return 400, got: %d", rr.Code)
+	}
+	if rr := serve(http.MethodGet, "/api/report/timeseries?granularity=hour&start_date=2024-01-01&end_date=2024-12-31", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Too many buckets should return 400, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_Report_Categories(t *testing.T) {
+	router := setupTestRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	serve(http.MethodPost, "/api/categories", `{"name":"Minuman"}`)
+	serve(http.MethodPost, "/api/products", `{"name":"Teh Botol","price":5000,"stock":10,"category_id":1}`)
+	serve(http.MethodPost, "/api/checkout", `{"items":[{"product_id":1,"quantity":2}]}`)
+	// Moving the product to another category must not rewrite the sale.
+	serve(http.MethodPost, "/api/categories", `{"name":"Snack"}`)
+	if rr := serve(http.MethodPatch, "/api/products/1", `{"category_id":2}`); rr.Code != http.StatusOK {
+		t.Fatalf("PATCH /api/products/1 should return 200, got: %d %s", rr.Code, rr.Body.String())
+	}
+
+	today := time.Now().Format("2006-01-02")
+	rr := serve(http.MethodGet, "/api/report/categories?start_date="+today+"&end_date="+today, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /api/report/categories should return 200, got: %d %s", rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Data []model.CategorySales `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].Nama != "Minuman" || response.Data[0].Revenue != 10000 || response.Data[0].Persentase != 100 {
+		t.Errorf("The sale should stay under Minuman, got: %+v", response.Data)
+	}
+}
+
+func TestRouter_Report_Products(t *testing.T) {
+	router := setupTestRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	serve(http.MethodPost, "/api/products", `{"name":"Teh Botol","price":5000,"stock":10}`)
+	serve(http.MethodPost, "/api/products", `{"name":"Surya 12","price":30000,"stock":10}`)
+	serve(http.MethodPost, "/api/checkout", `{"items":[{"product_id":1,"quantity":3},{"product_id":2,"quantity":1}]}`)
+	serve(http.MethodPatch, "/api/products/1", `{"name":"Teh Botol Sosro"}`)
+
+	today := time.Now().Format("2006-01-02")
+	path := "/api/report/products?start_date=" + today + "&end_date=" + today
+	var response struct {
+		Data []model.ProductSales `json:"data"`
+	}
+	rr := serve(http.MethodGet, path+"&sort=revenue&limit=1", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /api/report/products should return 200, got: %d %s", rr.Code, rr.Body.String())
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Data) != 1 || response.Data[0].ProductID != 2 {
+		t.Errorf("Top product by revenue should be Surya 12, got: %+v", response.Data)
+	}
+
+	json.NewDecoder(serve(http.MethodGet, path, "").Body).Decode(&response)
+	if len(response.Data) != 2 || response.Data[0].Nama != "Teh Botol Sosro" || response.Data[0].QtyTerjual != 3 {
+		t.Errorf("Top product by quantity should show its current name, got: %+v", response.Data)
+	}
+
+	for _, query := range []string{"&sort=name", "&order=up", "&limit=0"} {
+		if rr := serve(http.MethodGet, path+query, ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("GET /api/report/products%s should return 400, got: %d", query, rr.Code)
+		}
+	}
+}
+
+func TestRouter_Report_Heatmap(t *testing.T) {
+	router := setupTestRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	serve(http.MethodPost, "/api/products", `{"name":"Teh Botol","price":5000,"stock":10}`)
+	serve(http.MethodPost, "/api/checkout", `{"items":[{"product_id":1,"quantity":1}]}`)
+
+	today := time.Now().Format("2006-01-02")
+	rr := serve(http.MethodGet, "/api/report/heatmap?start_date="+today+"&end_date="+today, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /api/report/heatmap should return 200, got: %d %s", rr.Code, rr.Body.String())
+	}
+	var response struct {
+		Data model.SalesHeatmap `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&response)
+	revenue := 0
+	for _, day := range response.Data.Revenue {
+		for _, cell := range day {
+			revenue += cell
+		}
+	}
+	if revenue != 5000 || len(response.Data.Hari) != 7 {
+		t.Errorf("The heatmap should hold the sale of today, got: %d", revenue)
+	}
+}
+
 func TestRouter_NotFound(t *testing.T) {
 	router := setupTestRouter()
 
@@ -597,3 +951,266 @@ func TestRouter_TransactionsPath_TrailingSlash(t *testing.T) {
 		t.Errorf("GET /api/transactions/ should return 404, got: %d", rr.Code)
 	}
 }
+
+func TestRouter_Products_IfMatch(t *testing.T) {
+	router := setupTestRouter()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/api/products", bytes.NewBufferString(`{"name":"Laptop","price":1000,"stock":10}`))
+	createRr := httptest.NewRecorder()
+	router.ServeHTTP(createRr, createReq)
+	if etag := createRr.Header().Get("ETag"); etag != `"1"` {
+		t.Fatalf("POST /api/products should return ETag \"1\", got: %q", etag)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/products/1", nil)
+	req.Header.Set("If-Match", `"2"`)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("DELETE /api/products/1 with a stale ETag should return 412, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_Products_Bulk(t *testing.T) {
+	router := setupTestRouter()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/api/products", bytes.NewBufferString(`{"name":"Laptop","price":1000,"stock":10}`))
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/products/bulk", bytes.NewBufferString(`{"operation":"set_price","ids":[1],"amount":1500}`))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("POST /api/products/bulk should return 200, got: %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/products/bulk", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/products/bulk should return 405, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_IngredientRecipeUsage(t *testing.T) {
+	router := setupTestRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	serve(http.MethodPost, "/api/products", `{"name":"Es Kopi Susu","price":18000,"stock":100}`)
+	if rr := serve(http.MethodPost, "/api/ingredients", `{"name":"Kopi","unit":"g","stock":1000}`); rr.Code != http.StatusCreated {
+		t.Fatalf("POST /api/ingredients should return 201, got: %d", rr.Code)
+	}
+	serve(http.MethodPost, "/api/ingredients", `{"name":"Susu","unit":"ml","stock":2000}`)
+
+	rr := serve(http.MethodPut, "/api/products/1/recipe", `{"items":[{"ingredient_id":1,"quantity":18.5},{"ingredient_id":2,"quantity":150}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("PUT /api/products/1/recipe should return 200, got: %d %s", rr.Code, rr.Body.String())
+	}
+	if rr := serve(http.MethodPost, "/api/checkout", `{"items":[{"product_id":1,"quantity":2}]}`); rr.Code != http.StatusCreated {
+		t.Fatalf("POST /api/checkout should return 201, got: %d", rr.Code)
+	}
+
+	var ingredient struct {
+		Data model.Ingredient `json:"data"`
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/ingredients/1", "").Body).Decode(&ingredient)
+	if ingredient.Data.Stock != model.NewQuantity(963) {
+		t.Errorf("Checkout should deduct the recipe ingredients, want stock 963, got: %s", ingredient.Data.Stock)
+	}
+
+	// The counted stock is 5g short of what the sales explain.
+	if rr := serve(http.MethodPost, "/api/ingredients/1/movements", `{"type":"count","quantity":958}`); rr.Code != http.StatusCreated {
+		t.Fatalf("POST /api/ingredients/1/movements should return 201, got: %d %s", rr.Code, rr.Body.String())
+	}
+
+	today := time.Now().Format("2006-01-02")
+	rr = serve(http.MethodGet, "/api/report/ingredients?start_date="+today+"&end_date="+today, "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("GET /api/report/ingredients should return 200, got: %d", rr.Code)
+	}
+	var usage struct {
+		Data []model.IngredientUsage `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&usage)
+	if len(usage.Data) != 2 {
+		t.Fatalf("Usage should list every ingredient, got: %d", len(usage.Data))
+	}
+	kopi := usage.Data[0]
+	if kopi.Theoretical != model.NewQuantity(37) || kopi.Actual != model.NewQuantity(42) || kopi.Variance != model.NewQuantity(5) {
+		t.Errorf("Usage should compare recipe and actual usage, got: %+v", kopi)
+	}
+	if rr := serve(http.MethodGet, "/api/report/ingredients?start_date="+today+"&end_date="+today+"&outlet_id=1", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Usage filtered by outlet should return 400 since ingredient stock is shared, got: %d", rr.Code)
+	}
+
+	var list struct {
+		Data model.PaginatedResponse `json:"data"`
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/ingredients?limit=1", "").Body).Decode(&list)
+	if list.Data.TotalItems != 2 || list.Data.TotalPages != 2 || list.Data.NextCursor == "" {
+		t.Errorf("GET /api/ingredients should be paginated, got: %+v", list.Data)
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/ingredients/1/movements?limit=1", "").Body).Decode(&list)
+	if list.Data.TotalItems != 2 || list.Data.NextCursor == "" {
+		t.Errorf("GET /api/ingredients/1/movements should be paginated, got: %+v", list.Data)
+	}
+	rr = serve(http.MethodGet, "/api/ingredients/1/movements?limit=1&after="+list.Data.NextCursor, "")
+	list.Data = model.PaginatedResponse{}
+	json.NewDecoder(rr.Body).Decode(&list)
+	if rr.Code != http.StatusOK || list.Data.NextCursor != "" {
+		t.Errorf("The movements cursor should reach the last page, got: %d %+v", rr.Code, list.Data)
+	}
+	if rr := serve(http.MethodGet, "/api/ingredients?after=abc", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("An invalid cursor should return 400, got: %d", rr.Code)
+	}
+
+	if rr := serve(http.MethodDelete, "/api/ingredients/1", ""); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE /api/ingredients/1 should return 405, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_OutletStockAndReports(t *testing.T) {
+	router := setupTestRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	serve(http.MethodPost, "/api/products", `{"name":"Indomie","price":3500,"stock":10}`)
+	if rr := serve(http.MethodPost, "/api/outlets", `{"name":"Cabang Pasar"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("POST /api/outlets should return 201, got: %d %s", rr.Code, rr.Body.String())
+	}
+
+	rr := serve(http.MethodPost, "/api/outlets/transfers", `{"product_id":1,"from_outlet_id":1,"to_outlet_id":2,"quantity":4}`)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("POST /api/outlets/transfers should return 201, got: %d %s", rr.Code, rr.Body.String())
+	}
+	var transfer struct {
+		Data model.StockTransfer `json:"data"`
+	}
+	json.NewDecoder(rr.Body).Decode(&transfer)
+	if len(transfer.Data.Movements) != 2 || transfer.Data.Movements[0].Quantity != -4 || transfer.Data.Movements[1].Quantity != 4 {
+		t.Errorf("Transfer should be recorded as paired movements, got: %+v", transfer.Data.Movements)
+	}
+
+	// Outlet 2 holds 4, so selling 5 there fails even though 10 are in stock overall.
+	if rr := serve(http.MethodPost, "/api/checkout", `{"outlet_id":2,"items":[{"product_id":1,"quantity":5}]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("Checkout above the outlet stock should return 400, got: %d", rr.Code)
+	}
+	if rr := serve(http.MethodPost, "/api/checkout", `{"outlet_id":2,"items":[{"product_id":1,"quantity":3}]}`); rr.Code != http.StatusCreated {
+		t.Fatalf("POST /api/checkout at outlet 2 should return 201, got: %d %s", rr.Code, rr.Body.String())
+	}
+	serve(http.MethodPost, "/api/checkout", `{"items":[{"product_id":1,"quantity":1}]}`)
+
+	var stock struct {
+		Data struct {
+			Items []model.OutletStock `json:"items"`
+		} `json:"data"`
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/outlets/2/stock", "").Body).Decode(&stock)
+	if len(stock.Data.Items) != 1 || stock.Data.Items[0].Stock != 1 {
+		t.Errorf("Outlet 2 should have 1 left, got: %+v", stock.Data.Items)
+	}
+
+	var report struct {
+		Data model.ReportResponse `json:"data"`
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/report/hari-ini?outlet_id=2", "").Body).Decode(&report)
+	if report.Data.TotalRevenue != 10500 || report.Data.TotalTransaksi != 1 {
+		t.Errorf("Report for outlet 2 should only count its sale, got: %+v", report.Data)
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/report/hari-ini", "").Body).Decode(&report)
+	if report.Data.TotalRevenue != 14000 || report.Data.TotalTransaksi != 2 {
+		t.Errorf("Report without outlet_id should aggregate all outlets, got: %+v", report.Data)
+	}
+	if rr := serve(http.MethodGet, "/api/report/hari-ini?outlet_id=abc", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Invalid outlet_id should return 400, got: %d", rr.Code)
+	}
+	if rr := serve(http.MethodGet, "/api/outlets/9", ""); rr.Code != http.StatusNotFound {
+		t.Errorf("GET /api/outlets/9 should return 404, got: %d", rr.Code)
+	}
+}
+
+func TestRouter_OutletBundleStock(t *testing.T) {
+	router := setupTestRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	serve(http.MethodPost, "/api/products", `{"name":"Kopi","price":8000,"stock":10}`)
+	serve(http.MethodPost, "/api/products", `{"name":"Roti","price":6000,"stock":10}`)
+	serve(http.MethodPost, "/api/products", `{"name":"Paket Sarapan","price":12000,"components":[{"product_id":1,"quantity":1},{"product_id":2,"quantity":2}]}`)
+	serve(http.MethodPost, "/api/outlets", `{"name":"Cabang Pasar"}`)
+	serve(http.MethodPost, "/api/outlets/transfers", `{"product_id":1,"from_outlet_id":1,"to_outlet_id":2,"quantity":3}`)
+	serve(http.MethodPost, "/api/outlets/transfers", `{"product_id":2,"from_outlet_id":1,"to_outlet_id":2,"quantity":2}`)
+
+	// Outlet 2 holds roti for one bundle only, even though outlet 1 holds plenty.
+	if rr := serve(http.MethodPost, "/api/checkout", `{"outlet_id":2,"items":[{"product_id":3,"quantity":2}]}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("A bundle beyond the outlet's component stock should return 400, got: %d %s", rr.Code, rr.Body.String())
+	}
+	// Each line fits on its own, together they need 3 roti at outlet 2.
+	rr := serve(http.MethodPost, "/api/checkout", `{"outlet_id":2,"items":[{"product_id":3,"quantity":1},{"product_id":2,"quantity":1}]}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Lines sharing a component beyond the outlet stock should return 400, got: %d %s", rr.Code, rr.Body.String())
+	}
+
+	var stock struct {
+		Data struct {
+			Items []model.OutletStock `json:"items"`
+		} `json:"data"`
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/outlets/2/stock", "").Body).Decode(&stock)
+	if len(stock.Data.Items) != 2 || stock.Data.Items[0].Stock != 3 || stock.Data.Items[1].Stock != 2 {
+		t.Errorf("Rejected checkouts should leave the outlet stock alone, got: %+v", stock.Data.Items)
+	}
+
+	if rr := serve(http.MethodPost, "/api/checkout", `{"outlet_id":2,"items":[{"product_id":3,"quantity":1}]}`); rr.Code != http.StatusCreated {
+		t.Fatalf("A bundle within the outlet's component stock should return 201, got: %d %s", rr.Code, rr.Body.String())
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/outlets/2/stock", "").Body).Decode(&stock)
+	if stock.Data.Items[0].Stock != 2 || stock.Data.Items[1].Stock != 0 {
+		t.Errorf("The bundle should take its components at outlet 2, got: %+v", stock.Data.Items)
+	}
+}
+
+func TestRouter_OutletTransfersPaginated(t *testing.T) {
+	router := setupTestRouter()
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	serve(http.MethodPost, "/api/products", `{"name":"Kopi","price":8000,"stock":10}`)
+	serve(http.MethodPost, "/api/outlets", `{"name":"Cabang Pasar"}`)
+	serve(http.MethodPost, "/api/outlets/transfers", `{"product_id":1,"from_outlet_id":1,"to_outlet_id":2,"quantity":3}`)
+	serve(http.MethodPost, "/api/outlets/transfers", `{"product_id":1,"from_outlet_id":2,"to_outlet_id":1,"quantity":1}`)
+
+	var list struct {
+		Data model.PaginatedResponse `json:"data"`
+	}
+	json.NewDecoder(serve(http.MethodGet, "/api/outlets/transfers?limit=1", "").Body).Decode(&list)
+	if list.Data.TotalItems != 2 || list.Data.TotalPages != 2 || list.Data.NextCursor == "" {
+		t.Errorf("GET /api/outlets/transfers should be paginated, got: %+v", list.Data)
+	}
+	rr := serve(http.MethodGet, "/api/outlets/transfers?limit=1&after="+list.Data.NextCursor, "")
+	list.Data = model.PaginatedResponse{}
+	json.NewDecoder(rr.Body).Decode(&list)
+	if rr.Code != http.StatusOK || list.Data.NextCursor != "" {
+		t.Errorf("The transfers cursor should reach the last page, got: %d %+v", rr.Code, list.Data)
+	}
+	if rr := serve(http.MethodGet, "/api/outlets/transfers?after=abc", ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("An invalid cursor should return 400, got: %d", rr.Code)
+	}
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"sort"
 	"strings"
 
 	model "kasir-api/models"
@@ -18,9 +21,44 @@ func NewCategoryService(repo repository.CategoryRepository) *CategoryService {
 	return &CategoryService{repo: repo}
 }
 
-// GetAll retrieves all categories.
-func (s *CategoryService) GetAll() ([]*model.Category, error) {
-	return s.repo.GetAll()
+// GetAll retrieves all categories. Archived categories are included only when requested.
+func (s *CategoryService) GetAll(includeArchived bool) ([]*model.Category, error) {
+	return s.repo.GetAll(includeArchived)
+}
+
+// GetPage returns one page of categories ordered by ID and the total number of matches.
+func (s *CategoryService) GetPage(includeArchived bool, page model.PageRequest) (*model.Page[*model.Category], error) {
+	if page.After != nil && (page.After.Sort != "" || page.After.Desc) {
+		return nil, fmt.Errorf("%w: it belongs to a different sort order", model.ErrInvalidCursor)
+	}
+	return s.repo.GetPage(includeArchived, page)
+}
+
+// GetTree returns categories nested under their parents, ordered by ID at every level.
+func (s *CategoryService) GetTree(includeArchived bool) ([]*model.CategoryNode, error) {
+	categories, err := s.repo.GetAll(includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+
+	nodes := make(map[int]*model.CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &model.CategoryNode{Category: c, Children: []*model.CategoryNode{}}
+	}
+
+	roots := make([]*model.CategoryNode, 0)
+	for _, c := range categories {
+		node := nodes[c.ID]
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	return roots, nil
 }
 
 // GetByID retrieves a category by ID.
@@ -36,6 +74,9 @@ func (s *CategoryService) Create(category *model.Category) (*model.Category, err
 	if err := s.validateCategory(category); err != nil {
 		return nil, err
 	}
+	if err := s.validateParent(0, category.ParentID); err != nil {
+		return nil, err
+	}
 	if err := s.repo.Create(category); err != nil {
 		return nil, err
 	}
@@ -50,6 +91,9 @@ func (s *CategoryService) Update(id int, category *model.Category) (*model.Categ
 	if err := s.validateCategory(category); err != nil {
 		return nil, err
 	}
+	if err := s.validateParent(id, category.ParentID); err != nil {
+		return nil, err
+	}
 	category.ID = id
 	if err := s.repo.Update(category); err != nil {
 		return nil, err
@@ -57,12 +101,58 @@ func (s *CategoryService) Update(id int, category *model.Category) (*model.Categ
 	return category, nil
 }
 
-// Delete removes a category by ID.
-func (s *CategoryService) Delete(id int) error {
+// Archive soft-deletes a category.
+// A category with active sub-categories cannot be archived; move or archive them first.
+// Active products are handled by policy: restrict (default) refuses while any exist,
+// reassign moves them to policy.ReassignTo and unset clears their category.
+// A non-zero version must still be current, otherwise it fails with ErrVersionMismatch.
+func (s *CategoryService) Archive(id int, policy model.CategoryProductPolicy, version int) error {
+	if id <= 0 {
+		return model.ErrIDRequired
+	}
+	switch policy.OnProducts {
+	case "":
+		policy.OnProducts = model.OnProductsRestrict
+	case model.OnProductsRestrict, model.OnProductsUnset:
+	case model.OnProductsReassign:
+		if policy.ReassignTo <= 0 || policy.ReassignTo == id {
+			return model.ErrReassignTargetRequired
+		}
+	default:
+		return model.ErrInvalidProductPolicy
+	}
+
+	categories, err := s.repo.GetAll(false)
+	if err != nil {
+		return err
+	}
+	for _, c := range categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			return model.ErrCategoryHasChildren
+		}
+	}
+	return s.repo.Archive(id, policy, version)
+}
+
+// Restore brings an archived category back. Its parent must be active.
+func (s *CategoryService) Restore(id int) error {
 	if id <= 0 {
 		return model.ErrIDRequired
 	}
-	return s.repo.Delete(id)
+	category, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if category.ParentID != nil {
+		parent, err := s.repo.GetByID(*category.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.IsArchived() {
+			return model.ErrParentCategoryArchived
+		}
+	}
+	return s.repo.Restore(id)
 }
 
 func (s *CategoryService) validateCategory(category *model.Category) error {
@@ -71,3 +161,37 @@ func (s *CategoryService) validateCategory(category *model.Category) error {
 	}
 	return nil
 }
+
+// validateParent checks that parentID exists, is active, and is not the category itself or one of its descendants.
+// id is 0 for a category that does not exist yet.
+func (s *CategoryService) validateParent(id int, parentID *int) error {
+	if parentID == nil {
+		return nil
+	}
+	if *parentID == id {
+		return model.ErrCategoryCycle
+	}
+	parent, err := s.repo.GetByID(*parentID)
+	if err != nil {
+		if errors.Is(err, model.ErrCategoryNotFound) {
+			return model.ErrParentCategoryNotFound
+		}
+		return err
+	}
+	if parent.IsArchived() {
+		return model.ErrParentCategoryArchived
+	}
+	if id == 0 {
+		return nil
+	}
+	descendants, err := s.repo.GetDescendantIDs(id)
+	if err != nil {
+		return err
+	}
+	for _, descendantID := range descendants {
+		if descendantID == *parentID {
+			return model.ErrCategoryCycle
+		}
+	}
+	return nil
+}
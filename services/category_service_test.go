@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"kasir-api/mocks"
 	model "kasir-api/models"
@@ -28,7 +29,7 @@ func TestCategoryService_GetAll(t *testing.T) {
 	repo.Categories[1] = &model.Category{ID: 1, Name: "Electronics", Description: "Electronic items"}
 	repo.Categories[2] = &model.Category{ID: 2, Name: "Food", Description: "Food items"}
 
-	categories, err := service.GetAll()
+	categories, err := service.GetAll(false)
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -42,11 +43,11 @@ func TestCategoryService_GetAll_Error(t *testing.T) {
 	service := NewCategoryService(repo)
 
 	expectedErr := errors.New("database error")
-	repo.GetAllFunc = func() ([]*model.Category, error) {
+	repo.GetAllFunc = func(_ bool) ([]*model.Category, error) {
 		return nil, expectedErr
 	}
 
-	_, err := service.GetAll()
+	_, err := service.GetAll(false)
 	if err != expectedErr {
 		t.Errorf("GetAll should return the error from repo, got: %v", err)
 	}
@@ -236,22 +237,40 @@ func TestCategoryService_Update_RepoError(t *testing.T) {
 	}
 }
 
-func TestCategoryService_Delete_Success(t *testing.T) {
+func TestCategoryService_GetAll_HidesArchived(t *testing.T) {
+	repo := mocks.NewMockCategoryRepository()
+	service := NewCategoryService(repo)
+
+	archivedAt := time.Now()
+	repo.Categories[1] = &model.Category{ID: 1, Name: "Electronics"}
+	repo.Categories[2] = &model.Category{ID: 2, Name: "Old", ArchivedAt: &archivedAt}
+
+	categories, _ := service.GetAll(false)
+	if len(categories) != 1 {
+		t.Errorf("GetAll should hide archived categories, got: %d", len(categories))
+	}
+	categories, _ = service.GetAll(true)
+	if len(categories) != 2 {
+		t.Errorf("GetAll with includeArchived should return 2 categories, got: %d", len(categories))
+	}
+}
+
+func TestCategoryService_Archive_Success(t *testing.T) {
 	repo := mocks.NewMockCategoryRepository()
 	service := NewCategoryService(repo)
 
 	repo.Categories[1] = &model.Category{ID: 1, Name: "Electronics", Description: "Electronic items"}
 
-	err := service.Delete(1)
+	err := service.Archive(1, model.CategoryProductPolicy{}, 0)
 	if err != nil {
-		t.Errorf("Delete should not return error, got: %v", err)
+		t.Errorf("Archive should not return error, got: %v", err)
 	}
-	if _, exists := repo.Categories[1]; exists {
-		t.Error("Delete should remove the category from repo")
+	if !repo.Categories[1].IsArchived() {
+		t.Error("Archive should mark the category as archived")
 	}
 }
 
-func TestCategoryService_Delete_InvalidID(t *testing.T) {
+func TestCategoryService_Archive_InvalidID(t *testing.T) {
 	repo := mocks.NewMockCategoryRepository()
 	service := NewCategoryService(repo)
 
@@ -265,20 +284,214 @@ func TestCategoryService_Delete_InvalidID(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := service.Delete(tc.id)
+			err := service.Archive(tc.id, model.CategoryProductPolicy{}, 0)
 			if !errors.Is(err, model.ErrIDRequired) {
-				t.Errorf("Delete with %s id should return ErrIDRequired, got: %v", tc.name, err)
+				t.Errorf("Archive with %s id should return ErrIDRequired, got: %v", tc.name, err)
 			}
 		})
 	}
 }
 
-func TestCategoryService_Delete_NotFound(t *testing.T) {
+func TestCategoryService_Archive_NotFound(t *testing.T) {
+	repo := mocks.NewMockCategoryRepository()
+	service := NewCategoryService(repo)
+
+	err := service.Archive(999, model.CategoryProductPolicy{}, 0)
+	if !errors.Is(err, model.ErrNotFound) {
+		t.Errorf("Archive should return ErrNotFound, got: %v", err)
+	}
+}
+
+func TestCategoryService_Restore_Success(t *testing.T) {
+	repo := mocks.NewMockCategoryRepository()
+	service := NewCategoryService(repo)
+
+	archivedAt := time.Now()
+	repo.Categories[1] = &model.Category{ID: 1, Name: "Electronics", ArchivedAt: &archivedAt}
+
+	err := service.Restore(1)
+	if err != nil {
+		t.Errorf("Restore should not return error, got: %v", err)
+	}
+	if repo.Categories[1].IsArchived() {
+		t.Error("Restore should clear archived_at")
+	}
+}
+
+func TestCategoryService_Restore_NotFound(t *testing.T) {
 	repo := mocks.NewMockCategoryRepository()
 	service := NewCategoryService(repo)
 
-	err := service.Delete(999)
+	err := service.Restore(999)
 	if !errors.Is(err, model.ErrNotFound) {
-		t.Errorf("Delete should return ErrNotFound, got: %v", err)
+		t.Errorf("Restore should return ErrNotFound, got: %v", err)
+	}
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestCategoryService_Create_WithParent(t *testing.T) {
+	repo := mocks.NewMockCategoryRepository()
+	service := NewCategoryService(repo)
+
+	archivedAt := time.Now()
+	repo.Categories[1] = &model.Category{ID: 1, Name: "Minuman"}
+	repo.Categories[2] = &model.Category{ID: 2, Name: "Lama", ArchivedAt: &archivedAt}
+	repo.NextID = 3
+
+	testCases := []struct {
+		name     string
+		parentID *int
+		expected error
+	}{
+		{"no parent", nil, nil},
+		{"active parent", intPtr(1), nil},
+		{"unknown parent", intPtr(999), model.ErrParentCategoryNotFound},
+		{"archived parent", intPtr(2), model.ErrParentCategoryArchived},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.Create(&model.Category{Name: "Soda", ParentID: tc.parentID})
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("Create with %s should return %v, got: %v", tc.name, tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestCategoryService_Update_PreventsCycles(t *testing.T) {
+	repo := mocks.NewMockCategoryRepository()
+	service := NewCategoryService(repo)
+
+	// Minuman > Minuman Dingin > Soda
+	repo.Categories[1] = &model.Category{ID: 1, Name: "Minuman"}
+	repo.Categories[2] = &model.Category{ID: 2, Name: "Minuman Dingin", ParentID: intPtr(1)}
+	repo.Categories[3] = &model.Category{ID: 3, Name: "Soda", ParentID: intPtr(2)}
+
+	testCases := []struct {
+		name     string
+		id       int
+		parentID int
+	}{
+		{"itself", 1, 1},
+		{"direct child", 1, 2},
+		{"grandchild", 1, 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.Update(tc.id, &model.Category{Name: "Moved", ParentID: intPtr(tc.parentID)})
+			if !errors.Is(err, model.ErrCategoryCycle) {
+				t.Errorf("Moving a category under %s should return ErrCategoryCycle, got: %v", tc.name, err)
+			}
+		})
+	}
+
+	if _, err := service.Update(3, &model.Category{Name: "Soda", ParentID: intPtr(1)}); err != nil {
+		t.Errorf("Moving a leaf to another ancestor should succeed, got: %v", err)
+	}
+}
+
+func TestCategoryService_Archive_WithActiveChildren(t *testing.T) {
+	repo := mocks.NewMockCategoryRepository()
+	service := NewCategoryService(repo)
+
+	repo.Categories[1] = &model.Category{ID: 1, Name: "Minuman"}
+	repo.Categories[2] = &model.Category{ID: 2, Name: "Soda", ParentID: intPtr(1)}
+
+	err := service.Archive(1, model.CategoryProductPolicy{}, 0)
+	if !errors.Is(err, model.ErrCategoryHasChildren) {
+		t.Errorf("Archive with active children should return ErrCategoryHasChildren, got: %v", err)
+	}
+
+	if err := service.Archive(2, model.CategoryProductPolicy{}, 0); err != nil {
+		t.Fatalf("Archive of leaf should succeed, got: %v", err)
+	}
+	if err := service.Archive(1, model.CategoryProductPolicy{}, 0); err != nil {
+		t.Errorf("Archive should succeed once children are archived, got: %v", err)
+	}
+}
+
+func TestCategoryService_Restore_ArchivedParent(t *testing.T) {
+	repo := mocks.NewMockCategoryRepository()
+	service := NewCategoryService(repo)
+
+	archivedAt := time.Now()
+	repo.Categories[1] = &model.Category{ID: 1, Name: "Minuman", ArchivedAt: &archivedAt}
+	repo.Categories[2] = &model.Category{ID: 2, Name: "Soda", ParentID: intPtr(1), ArchivedAt: &archivedAt}
+
+	err := service.Restore(2)
+	if !errors.Is(err, model.ErrParentCategoryArchived) {
+		t.Errorf("Restore under archived parent should return ErrParentCategoryArchived, got: %v", err)
+	}
+}
+
+func TestCategoryService_GetTree(t *testing.T) {
+	repo := mocks.NewMockCategoryRepository()
+	service := NewCategoryService(repo)
+
+	repo.Categories[1] = &model.Category{ID: 1, Name: "Minuman"}
+	repo.Categories[2] = &model.Category{ID: 2, Name: "Minuman Dingin", ParentID: intPtr(1)}
+	repo.Categories[3] = &model.Category{ID: 3, Name: "Soda", ParentID: intPtr(2)}
+	repo.Categories[4] = &model.Category{ID: 4, Name: "Rokok"}
+
+	tree, err := service.GetTree(false)
+	if err != nil {
+		t.Fatalf("GetTree should not return error, got: %v", err)
+	}
+	if len(tree) != 2 || tree[0].ID != 1 || tree[1].ID != 4 {
+		t.Fatalf("GetTree should return roots [1 4], got: %d roots", len(tree))
+	}
+	if len(tree[0].Children) != 1 || tree[0].Children[0].ID != 2 {
+		t.Fatalf("Minuman should have Minuman Dingin as child")
+	}
+	if len(tree[0].Children[0].Children) != 1 || tree[0].Children[0].Children[0].Name != "Soda" {
+		t.Error("Minuman Dingin should have Soda as child")
+	}
+	if len(tree[1].Children) != 0 {
+		t.Error("Rokok should have no children")
+	}
+}
+
+func TestCategoryService_Archive_ProductPolicy(t *testing.T) {
+	repo := mocks.NewMockCategoryRepository()
+	service := NewCategoryService(repo)
+
+	repo.Categories[1] = &model.Category{ID: 1, Name: "Rokok"}
+
+	var received model.CategoryProductPolicy
+	repo.ArchiveFunc = func(id int, policy model.CategoryProductPolicy, _ int) error {
+		received = policy
+		return nil
+	}
+
+	testCases := []struct {
+		name     string
+		policy   model.CategoryProductPolicy
+		expected error
+	}{
+		{"default", model.CategoryProductPolicy{}, nil},
+		{"unset", model.CategoryProductPolicy{OnProducts: model.OnProductsUnset}, nil},
+		{"reassign", model.CategoryProductPolicy{OnProducts: model.OnProductsReassign, ReassignTo: 2}, nil},
+		{"reassign without target", model.CategoryProductPolicy{OnProducts: model.OnProductsReassign}, model.ErrReassignTargetRequired},
+		{"reassign to itself", model.CategoryProductPolicy{OnProducts: model.OnProductsReassign, ReassignTo: 1}, model.ErrReassignTargetRequired},
+		{"unknown", model.CategoryProductPolicy{OnProducts: "delete"}, model.ErrInvalidProductPolicy},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := service.Archive(1, tc.policy, 0)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("Archive with %s policy should return %v, got: %v", tc.name, tc.expected, err)
+			}
+		})
+	}
+
+	service.Archive(1, model.CategoryProductPolicy{}, 0)
+	if received.OnProducts != model.OnProductsRestrict {
+		t.Errorf("Default policy should be restrict, got: %q", received.OnProducts)
 	}
 }
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	model "kasir-api/models"
+	repository "kasir-api/repositories"
+)
+
+// IngredientService handles business logic for ingredients, recipes and ingredient stock.
+type IngredientService struct {
+	repo        repository.IngredientRepository
+	productRepo repository.ProductRepository
+	location    *time.Location
+}
+
+// NewIngredientService creates a new IngredientService.
+func NewIngredientService(repo repository.IngredientRepository, productRepo repository.ProductRepository) *IngredientService {
+	return &IngredientService{repo: repo, productRepo: productRepo, location: defaultLocation}
+}
+
+// SetLocation sets the store timezone usage report dates are read in, like the sales reports.
+// It defaults to model.DefaultStoreTimezone.
+func (s *IngredientService) SetLocation(loc *time.Location) {
+	s.location = loc
+}
+
+// Location returns the store timezone usage report dates are read in.
+func (s *IngredientService) Location() *time.Location {
+	return s.location
+}
+
+// GetPage returns one page of ingredients ordered by ID and the total number of ingredients.
+func (s *IngredientService) GetPage(page model.PageRequest) (*model.Page[*model.Ingredient], error) {
+	if page.After != nil && (page.After.Sort != "" || page.After.Desc) {
+		return nil, fmt.Errorf("%w: it belongs to a different sort order", model.ErrInvalidCursor)
+	}
+	return s.repo.GetPage(page)
+}
+
+// GetByID retrieves an ingredient by ID.
+func (s *IngredientService) GetByID(id int) (*model.Ingredient, error) {
+	if id <= 0 {
+		return nil, model.ErrIngredientNotFound
+	}
+	return s.repo.GetByID(id)
+}
+
+// Create creates an ingredient; its stock is the opening balance.
+func (s *IngredientService) Create(ingredient *model.Ingredient) (*model.Ingredient, error) {
+	if strings.TrimSpace(ingredient.Name) == "" {
+		return nil, model.ErrNameRequired
+	}
+	if ingredient.Stock < 0 {
+		return nil, model.ErrStockInvalid
+	}
+	if err := s.repo.Create(ingredient); err != nil {
+		return nil, err
+	}
+	return ingredient, nil
+}
+
+// Update renames an ingredient or changes its unit. The stock in the body is ignored;
+// it only changes through movements.
+func (s *IngredientService) Update(id int, ingredient *model.Ingredient) (*model.Ingredient, error) {
+	if id <= 0 {
+		return nil, model.ErrIngredientNotFound
+	}
+	if strings.TrimSpace(ingredient.Name) == "" {
+		return nil, model.ErrNameRequired
+	}
+	ingredient.ID = id
+	if err := s.repo.Update(ingredient); err != nil {
+		return nil, err
+	}
+	return ingredient, nil
+}
+
+// GetRecipe returns the recipe of a product.
+func (s *IngredientService) GetRecipe(productID int) ([]model.RecipeItem, error) {
+	if productID <= 0 {
+		return nil, model.ErrProductNotFound
+	}
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRecipe(productID)
+}
+
+// SetRecipe replaces the recipe of a product. Each ingredient must exist and be listed once.
+func (s *IngredientService) SetRecipe(productID int, items []model.RecipeItem) ([]model.RecipeItem, error) {
+	if productID <= 0 {
+		return nil, model.ErrProductNotFound
+	}
+	if _, err := s.productRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool, len(items))
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity must be greater than 0", model.ErrRecipeInvalid)
+		}
+		if seen[item.IngredientID] {
+			return nil, fmt.Errorf("%w: ingredient %d is listed twice", model.ErrRecipeInvalid, item.IngredientID)
+		}
+		seen[item.IngredientID] = true
+		if _, err := s.GetByID(item.IngredientID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.SetRecipe(productID, items); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRecipe(productID)
+}
+
+// RecordMovement records a restock, waste or stock count for an ingredient and returns the
+// booked movement, whose quantity is the signed change.
+func (s *IngredientService) RecordMovement(ingredientID int, input *model.IngredientMovementInput) (*model.IngredientMovement, error) {
+	if ingredientID <= 0 {
+		return nil, model.ErrIngredientNotFound
+	}
+	movement := &model.IngredientMovement{IngredientID: ingredientID, Type: input.Type, Quantity: input.Quantity, Note: input.Note}
+	switch input.Type {
+	case model.MovementRestock, model.MovementWaste:
+		if input.Quantity <= 0 {
+			return nil, model.ErrMovementInvalid
+		}
+		if input.Type == model.MovementWaste {
+			movement.Quantity = -input.Quantity
+		}
+	case model.MovementCount:
+		if input.Quantity < 0 {
+			return nil, model.ErrStockInvalid
+		}
+	default:
+		return nil, fmt.Errorf("%w: type must be restock, waste or count", model.ErrMovementInvalid)
+	}
+	if err := s.repo.RecordMovements([]*model.IngredientMovement{movement}); err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// GetMovements returns one page of the movements of an ingredient, newest first.
+func (s *IngredientService) GetMovements(ingredientID int, page model.PageRequest) (*model.Page[*model.IngredientMovement], error) {
+	if ingredientID <= 0 {
+		return nil, model.ErrIngredientNotFound
+	}
+	if page.After != nil && (page.After.Sort != "" || !page.After.Desc) {
+		return nil, fmt.Errorf("%w: it belongs to a different sort order", model.ErrInvalidCursor)
+	}
+	return s.repo.GetMovements(ingredientID, page)
+}
+
+// GetUsage returns theoretical versus actual ingredient usage between two dates, both inclusive.
+// Ingredient stock is shared by all outlets, so the usage always covers every outlet.
+func (s *IngredientService) GetUsage(startDate, endDate time.Time) ([]*model.IngredientUsage, error) {
+	return s.repo.GetUsage(startDate, endDate.AddDate(0, 0, 1))
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"kasir-api/mocks"
+	model "kasir-api/models"
+)
+
+func setupIngredientService() (*IngredientService, *mocks.MockIngredientRepository, *mocks.MockProductRepository) {
+	ingredientRepo := mocks.NewMockIngredientRepository()
+	productRepo := mocks.NewMockProductRepository()
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Es Kopi Susu", Price: 18000, Stock: 10}
+	ingredientRepo.Ingredients[1] = &model.Ingredient{ID: 1, Name: "Kopi", Unit: "g", Stock: model.NewQuantity(500)}
+	return NewIngredientService(ingredientRepo, productRepo), ingredientRepo, productRepo
+}
+
+func TestIngredientService_SetRecipe_Validation(t *testing.T) {
+	service, _, _ := setupIngredientService()
+
+	tests := []struct {
+		name      string
+		productID int
+		items     []model.RecipeItem
+		wantErr   error
+	}{
+		{"unknown product", 99, nil, model.ErrProductNotFound},
+		{"unknown ingredient", 1, []model.RecipeItem{{IngredientID: 9, Quantity: 1000}}, model.ErrIngredientNotFound},
+		{"listed twice", 1, []model.RecipeItem{{IngredientID: 1, Quantity: 1000}, {IngredientID: 1, Quantity: 500}}, model.ErrRecipeInvalid},
+		{"zero quantity", 1, []model.RecipeItem{{IngredientID: 1}}, model.ErrRecipeInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SetRecipe(tt.productID, tt.items)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetRecipe should return %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestIngredientService_RecordMovement(t *testing.T) {
+	service, ingredientRepo, _ := setupIngredientService()
+
+	waste, err := service.RecordMovement(1, &model.IngredientMovementInput{Type: model.MovementWaste, Quantity: 2500})
+	if err != nil {
+		t.Fatalf("RecordMovement should not return error, got: %v", err)
+	}
+	if waste.Quantity != -2500 || ingredientRepo.Ingredients[1].Stock != 497500 {
+		t.Errorf("Waste should be booked as a negative change, got: %s, stock %s", waste.Quantity, ingredientRepo.Ingredients[1].Stock)
+	}
+
+	if _, err := service.RecordMovement(1, &model.IngredientMovementInput{Type: model.MovementRestock}); !errors.Is(err, model.ErrMovementInvalid) {
+		t.Errorf("A restock of 0 should return ErrMovementInvalid, got: %v", err)
+	}
+	if _, err := service.RecordMovement(1, &model.IngredientMovementInput{Type: model.MovementSale, Quantity: 1000}); !errors.Is(err, model.ErrMovementInvalid) {
+		t.Errorf("Sales should only be booked by checkout, got: %v", err)
+	}
+}
+
+func TestTransactionService_Checkout_DeductsIngredients(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	_, ingredientRepo, productRepo := setupIngredientService()
+	service := NewTransactionService(transactionRepo, productRepo)
+	service.SetIngredientRepository(ingredientRepo)
+	transactionRepo.Products = productRepo
+	transactionRepo.Ingredients = ingredientRepo
+	ingredientRepo.Recipes[1] = []model.RecipeItem{{IngredientID: 1, Quantity: 18500}}
+
+	transaction, err := service.Checkout(&model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 3}}})
+	if err != nil {
+		t.Fatalf("Checkout should not return error, got: %v", err)
+	}
+	if len(ingredientRepo.Movements) != 1 {
+		t.Fatalf("Checkout should book one sale movement, got: %d", len(ingredientRepo.Movements))
+	}
+	sale := ingredientRepo.Movements[0]
+	if sale.Type != model.MovementSale || sale.Quantity != -55500 || *sale.TransactionID != transaction.ID {
+		t.Errorf("The sale movement should use the recipe times the quantity sold, got: %+v", sale)
+	}
+	if got := ingredientRepo.Ingredients[1].Stock; got != 444500 {
+		t.Errorf("Ingredient stock should be deducted, want 444.5, got: %s", got)
+	}
+}
+
+func TestTransactionService_Checkout_DeductsIngredientsPerKilogram(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	_, ingredientRepo, productRepo := setupIngredientService()
+	service := NewTransactionService(transactionRepo, productRepo)
+	service.SetIngredientRepository(ingredientRepo)
+	transactionRepo.Products = productRepo
+	transactionRepo.Ingredients = ingredientRepo
+
+	// Kopi bubuk is sold by weight and its recipe is per kilogram: 2 g of kopi per kg sold.
+	productRepo.Products[2] = &model.Product{ID: 2, Name: "Kopi Bubuk", Price: 120000, Stock: 5000, Unit: model.UnitKilogram}
+	ingredientRepo.Recipes[2] = []model.RecipeItem{{IngredientID: 1, Quantity: model.NewQuantity(2)}}
+
+	if _, err := service.Checkout(&model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 1250}}}); err != nil {
+		t.Fatalf("Checkout should not return error, got: %v", err)
+	}
+	if sale := ingredientRepo.Movements[0]; sale.Quantity != -2500 {
+		t.Errorf("1250 g at 2 per kg should use 2.5, got: %s", sale.Quantity)
+	}
+}
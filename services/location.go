@@ -0,0 +1,12 @@
+package service
+
+import (
+	"time"
+	_ "time/tzdata" // the default store timezone must load on hosts without a zoneinfo database
+
+	model "kasir-api/models"
+)
+
+// defaultLocation is the store timezone services start with until SetLocation replaces it. It is the
+// configured default rather than time.Local, whose name PostgreSQL cannot resolve.
+var defaultLocation, _ = time.LoadLocation(model.DefaultStoreTimezone)
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	model "kasir-api/models"
+	repository "kasir-api/repositories"
+)
+
+// OutletService handles business logic for outlets and stock transfers between them.
+type OutletService struct {
+	repo        repository.OutletRepository
+	productRepo repository.ProductRepository
+}
+
+// NewOutletService creates a new OutletService.
+func NewOutletService(repo repository.OutletRepository, productRepo repository.ProductRepository) *OutletService {
+	return &OutletService{repo: repo, productRepo: productRepo}
+}
+
+// GetPage returns one page of outlets ordered by ID and the total number of outlets.
+func (s *OutletService) GetPage(page model.PageRequest) (*model.Page[*model.Outlet], error) {
+	if err := checkIDCursor(page); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPage(page)
+}
+
+// GetByID retrieves an outlet by ID.
+func (s *OutletService) GetByID(id int) (*model.Outlet, error) {
+	if id <= 0 {
+		return nil, model.ErrOutletNotFound
+	}
+	return s.repo.GetByID(id)
+}
+
+// Create creates an outlet. A new outlet starts without stock; it gets stock through transfers
+// or stock updates booked at it.
+func (s *OutletService) Create(outlet *model.Outlet) (*model.Outlet, error) {
+	if strings.TrimSpace(outlet.Name) == "" {
+		return nil, model.ErrNameRequired
+	}
+	if err := s.repo.Create(outlet); err != nil {
+		return nil, err
+	}
+	return outlet, nil
+}
+
+// GetStock returns one page of the stock of every active product at an outlet, ordered by product ID.
+func (s *OutletService) GetStock(outletID int, page model.PageRequest) (*model.Page[*model.OutletStock], error) {
+	if outletID <= 0 {
+		return nil, model.ErrOutletNotFound
+	}
+	if err := checkIDCursor(page); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStock(outletID, page)
+}
+
+// Transfer moves stock of a product between two outlets. Bundles have no stock of their own
+// to move, and archived products are not restocked anywhere.
+func (s *OutletService) Transfer(transfer *model.StockTransfer) (*model.StockTransfer, error) {
+	if transfer.Quantity <= 0 {
+		return nil, model.ErrInvalidQuantity
+	}
+	if transfer.FromOutletID == transfer.ToOutletID {
+		return nil, model.ErrTransferSameOutlet
+	}
+	product, err := s.productRepo.GetByID(transfer.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if product.IsArchived() {
+		return nil, model.ErrProductArchived
+	}
+	if product.IsBundle() {
+		return nil, model.ErrBundleStock
+	}
+	if !product.TracksStock() {
+		return nil, model.ErrNotStocked
+	}
+	if err := s.repo.Transfer(transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// GetTransfers returns one page of stock transfers, newest first.
+func (s *OutletService) GetTransfers(page model.PageRequest) (*model.Page[*model.StockTransfer], error) {
+	if page.After != nil && (page.After.Sort != "" || !page.After.Desc) {
+		return nil, fmt.Errorf("%w: it belongs to a different sort order", model.ErrInvalidCursor)
+	}
+	return s.repo.GetTransfers(page)
+}
+
+// checkIDCursor rejects a cursor that does not come from a listing in ascending ID order.
+func checkIDCursor(page model.PageRequest) error {
+	if page.After != nil && (page.After.Sort != "" || page.After.Desc) {
+		return fmt.Errorf("%w: it belongs to a different sort order", model.ErrInvalidCursor)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"kasir-api/mocks"
+	model "kasir-api/models"
+)
+
+func TestOutletService_Transfer_Validation(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Kopi", Stock: 10}
+	productRepo.Products[2] = &model.Product{ID: 2, Name: "Paket", Components: []model.BundleComponent{{ProductID: 1, Quantity: 1}}}
+	outletRepo := mocks.NewMockOutletRepository()
+	svc := NewOutletService(outletRepo, productRepo)
+
+	tests := []struct {
+		name     string
+		transfer model.StockTransfer
+		want     error
+	}{
+		{"same outlet", model.StockTransfer{ProductID: 1, FromOutletID: 1, ToOutletID: 1, Quantity: 1}, model.ErrTransferSameOutlet},
+		{"zero quantity", model.StockTransfer{ProductID: 1, FromOutletID: 1, ToOutletID: 2, Quantity: 0}, model.ErrInvalidQuantity},
+		{"unknown product", model.StockTransfer{ProductID: 9, FromOutletID: 1, ToOutletID: 2, Quantity: 1}, model.ErrProductNotFound},
+		{"bundle", model.StockTransfer{ProductID: 2, FromOutletID: 1, ToOutletID: 2, Quantity: 1}, model.ErrBundleStock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.Transfer(&tt.transfer); !errors.Is(err, tt.want) {
+				t.Errorf("Transfer should return %v, got: %v", tt.want, err)
+			}
+		})
+	}
+	if len(outletRepo.Transfers) != 0 {
+		t.Errorf("Rejected transfers should not reach the repository, got: %d", len(outletRepo.Transfers))
+	}
+
+	transfer, err := svc.Transfer(&model.StockTransfer{ProductID: 1, FromOutletID: 1, ToOutletID: 2, Quantity: 3})
+	if err != nil {
+		t.Fatalf("Transfer should not return error, got: %v", err)
+	}
+	if len(transfer.Movements) != 2 {
+		t.Errorf("Transfer should return its movements, got: %d", len(transfer.Movements))
+	}
+}
+
+func TestOutletService_Create_RequiresName(t *testing.T) {
+	svc := NewOutletService(mocks.NewMockOutletRepository(), mocks.NewMockProductRepository())
+	if _, err := svc.Create(&model.Outlet{Name: "  "}); !errors.Is(err, model.ErrNameRequired) {
+		t.Errorf("Create should return ErrNameRequired, got: %v", err)
+	}
+}
+
+func TestOutletService_Lists_RejectForeignCursors(t *testing.T) {
+	svc := NewOutletService(mocks.NewMockOutletRepository(), mocks.NewMockProductRepository())
+	byName := model.PageRequest{Limit: 20, After: &model.Cursor{Sort: model.ProductSortName, Text: "kopi", ID: 3}}
+	newestFirst := model.PageRequest{Limit: 20, After: &model.Cursor{Desc: true, ID: 3}}
+
+	if _, err := svc.GetPage(byName); !errors.Is(err, model.ErrInvalidCursor) {
+		t.Errorf("GetPage should reject a cursor from another sort, got: %v", err)
+	}
+	if _, err := svc.GetStock(model.DefaultOutletID, newestFirst); !errors.Is(err, model.ErrInvalidCursor) {
+		t.Errorf("GetStock should reject a newest-first cursor, got: %v", err)
+	}
+	if _, err := svc.GetTransfers(model.PageRequest{Limit: 20, After: &model.Cursor{ID: 3}}); !errors.Is(err, model.ErrInvalidCursor) {
+		t.Errorf("GetTransfers should reject an oldest-first cursor, got: %v", err)
+	}
+	if _, err := svc.GetStock(model.DefaultOutletID, model.PageRequest{Limit: 20, After: &model.Cursor{ID: 3}}); err != nil {
+		t.Errorf("GetStock should accept an ID cursor, got: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	model "kasir-api/models"
+)
+
+// Bulk applies one operation to many products. Every changed product is checked with the same rules
+// as Update. If any product fails nothing is written and ErrBulkInvalid is returned together with the
+// per-product errors. A dry run only reports. Products are written with the version that was read,
+// so one changed by someone else in the meantime fails the whole batch with ErrVersionMismatch.
+// A filter write whose match count differs from ExpectedMatched returns ErrBulkMatched with the
+// preview and writes nothing.
+func (s *ProductService) Bulk(req *model.ProductBulkRequest) (*model.ProductBulkResult, error) {
+	if err := validateBulkRequest(req); err != nil {
+		return nil, err
+	}
+	if req.Operation == model.BulkSetCategory && req.CategoryID != nil {
+		category, err := s.categoryRepo.GetByID(*req.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		if category.IsArchived() {
+			return nil, model.ErrCategoryArchived
+		}
+	}
+	if err := s.repo.ApplyDuePriceChanges(time.Now()); err != nil {
+		return nil, err
+	}
+
+	result := &model.ProductBulkResult{
+		DryRun:    req.DryRun,
+		Operation: req.Operation,
+		Changes:   []model.ProductBulkChange{},
+		Errors:    []model.ProductBulkError{},
+	}
+	targets, err := s.bulkTargets(req, result)
+	if err != nil {
+		return nil, err
+	}
+	result.Matched = len(targets)
+
+	batch := &model.ProductBulkBatch{Archive: req.Operation == model.BulkArchive}
+	for _, p := range targets {
+		if p.IsArchived() && !batch.Archive {
+			result.Errors = append(result.Errors, model.ProductBulkError{ProductID: p.ID, Message: model.ErrProductArchived.Error()})
+			continue
+		}
+		updated := *p
+		updated.ChangedBy = req.ChangedBy
+		change := model.ProductBulkChange{ProductID: p.ID, Name: p.Name}
+		switch req.Operation {
+		case model.BulkSetPrice:
+			updated.Price = *req.Amount
+			change.Old, change.New = p.Price, updated.Price
+		case model.BulkAdjustPrice:
+			if req.Percent != nil {
+				updated.Price = int(math.Round(float64(p.Price) * (100 + *req.Percent) / 100))
+			} else {
+				updated.Price = p.Price + *req.Amount
+			}
+			change.Old, change.New = p.Price, updated.Price
+		case model.BulkSetCategory:
+			updated.CategoryID = req.CategoryID
+			change.Old, change.New = p.CategoryID, updated.CategoryID
+		case model.BulkAdjustStock:
+			if p.IsBundle() {
+				result.Errors = append(result.Errors, model.ProductBulkError{ProductID: p.ID, Message: model.ErrBundleStock.Error()})
+				continue
+			}
+			if !p.TracksStock() {
+				result.Errors = append(result.Errors, model.ProductBulkError{ProductID: p.ID, Message: model.ErrNotStocked.Error()})
+				continue
+			}
+			updated.Stock = p.Stock + *req.Amount
+			change.Old, change.New = p.Stock, updated.Stock
+		case model.BulkArchive:
+			change.Old, change.New = p.IsArchived(), true
+		}
+
+		if change.Old == change.New || (req.Operation == model.BulkSetCategory && sameCategory(p.CategoryID, updated.CategoryID)) {
+			result.Unchanged++
+			continue
+		}
+		if !batch.Archive {
+			if err := s.validateProduct(&updated); err != nil {
+				if !isProductRuleError(err) {
+					return nil, err
+				}
+				result.Errors = append(result.Errors, model.ProductBulkError{ProductID: p.ID, Message: err.Error()})
+				continue
+			}
+		}
+		result.Changes = append(result.Changes, change)
+		batch.Products = append(batch.Products, &updated)
+	}
+	result.Affected = len(batch.Products)
+
+	if req.Filter != nil && !req.DryRun && *req.ExpectedMatched != result.Matched {
+		return result, model.ErrBulkMatched
+	}
+	if len(result.Errors) > 0 {
+		return result, model.ErrBulkInvalid
+	}
+	if req.DryRun || len(batch.Products) == 0 {
+		return result, nil
+	}
+	if err := s.repo.ApplyBulk(batch); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// bulkTargets loads the products a bulk request targets, ordered by ID. Explicit IDs that do not
+// exist are reported on result; a filter only matches active products, by name substring.
+func (s *ProductService) bulkTargets(req *model.ProductBulkRequest, result *model.ProductBulkResult) ([]*model.Product, error) {
+	var targets []*model.Product
+	if req.Filter != nil {
+		products, err := s.repo.GetAll(model.ProductFilter{
+			Name:          strings.TrimSpace(req.Filter.Name),
+			NameSubstring: true,
+			CategoryID:    req.Filter.CategoryID,
+		})
+		if err != nil {
+			return nil, err
+		}
+		targets = products
+	} else {
+		ids := slices.Clone(req.IDs)
+		slices.Sort(ids)
+		for _, id := range slices.Compact(ids) {
+			p, err := s.repo.GetByID(id)
+			if errors.Is(err, model.ErrProductNotFound) {
+				result.Errors = append(result.Errors, model.ProductBulkError{ProductID: id, Message: err.Error()})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, p)
+		}
+	}
+	slices.SortFunc(targets, func(a, b *model.Product) int { return a.ID - b.ID })
+	return targets, nil
+}
+
+// validateBulkRequest checks the operation, its values and its targets before anything is read.
+func validateBulkRequest(req *model.ProductBulkRequest) error {
+	switch {
+	case len(req.IDs) > 0 && req.Filter != nil:
+		return fmt.Errorf("%w, not both", model.ErrBulkTarget)
+	case req.Filter != nil:
+		if strings.TrimSpace(req.Filter.Name) == "" && req.Filter.CategoryID <= 0 {
+			return model.ErrBulkTarget
+		}
+		if !req.DryRun && req.ExpectedMatched == nil {
+			return fmt.Errorf("%w: a filter needs expected_matched, the matched count of a dry run", model.ErrBulkTarget)
+		}
+	case len(req.IDs) == 0:
+		return model.ErrBulkTarget
+	}
+	if req.ExpectedMatched != nil && req.Filter == nil {
+		return fmt.Errorf("%w: expected_matched only applies to a filter", model.ErrBulkTarget)
+	}
+	for _, id := range req.IDs {
+		if id <= 0 {
+			return fmt.Errorf("%w: ids must be greater than 0", model.ErrBulkTarget)
+		}
+	}
+
+	switch req.Operation {
+	case model.BulkSetPrice:
+		if req.Amount == nil || req.Percent != nil {
+			return fmt.Errorf("%w: set_price needs amount", model.ErrBulkValue)
+		}
+		if *req.Amount <= 0 {
+			return model.ErrPriceInvalid
+		}
+	case model.BulkAdjustPrice:
+		if (req.Amount == nil) == (req.Percent == nil) {
+			return fmt.Errorf("%w: adjust_price needs either amount or percent", model.ErrBulkValue)
+		}
+		if req.Percent != nil && (*req.Percent <= -100 || math.IsNaN(*req.Percent) || math.IsInf(*req.Percent, 0)) {
+			return fmt.Errorf("%w: percent must be greater than -100", model.ErrBulkValue)
+		}
+	case model.BulkAdjustStock:
+		if req.Amount == nil || req.Percent != nil {
+			return fmt.Errorf("%w: adjust_stock needs amount", model.ErrBulkValue)
+		}
+	case model.BulkSetCategory, model.BulkArchive:
+		if req.Amount != nil || req.Percent != nil {
+			return fmt.Errorf("%w: %s does not take amount or percent", model.ErrBulkValue, req.Operation)
+		}
+	default:
+		return model.ErrBulkOperation
+	}
+	if req.CategoryID != nil && req.Operation != model.BulkSetCategory {
+		return fmt.Errorf("%w: only set_category takes category_id", model.ErrBulkValue)
+	}
+	return nil
+}
+
+// sameCategory reports whether two optional category IDs point at the same category.
+func sameCategory(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// isProductRuleError reports whether err comes from a broken product rule rather than storage.
+func isProductRuleError(err error) bool {
+	return errors.Is(err, model.ErrNameRequired) ||
+		errors.Is(err, model.ErrPriceInvalid) ||
+		errors.Is(err, model.ErrStockInvalid) ||
+		errors.Is(err, model.ErrCategoryNotFound) ||
+		errors.Is(err, model.ErrCategoryArchived) ||
+		errors.Is(err, model.ErrBundleInvalid)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"kasir-api/mocks"
+	model "kasir-api/models"
+)
+
+func setupBulkService() (*ProductService, *mocks.MockProductRepository, *mocks.MockCategoryRepository) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	categoryRepo.Categories[1] = &model.Category{ID: 1, Name: "Minuman"}
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Teh", Price: 5000, Stock: 10, Version: 1}
+	productRepo.Products[2] = &model.Product{ID: 2, Name: "Kopi", Price: 8000, Stock: 3, Version: 4}
+	productRepo.Products[3] = &model.Product{ID: 3, Name: "Susu", Price: 7000, Stock: 0, Version: 2}
+	return NewProductService(productRepo, categoryRepo), productRepo, categoryRepo
+}
+
+func TestProductService_Bulk_ValidatesRequest(t *testing.T) {
+	service, _, _ := setupBulkService()
+	amount, zero, percent, tooLow := 1000, 0, 10.0, -100.0
+	categoryID := 1
+
+	tests := []struct {
+		name string
+		req  model.ProductBulkRequest
+		want error
+	}{
+		{"unknown operation", model.ProductBulkRequest{Operation: "delete", IDs: []int{1}}, model.ErrBulkOperation},
+		{"no targets", model.ProductBulkRequest{Operation: model.BulkArchive}, model.ErrBulkTarget},
+		{"ids and filter", model.ProductBulkRequest{Operation: model.BulkArchive, IDs: []int{1}, Filter: &model.ProductBulkFilter{Name: "teh"}}, model.ErrBulkTarget},
+		{"empty filter", model.ProductBulkRequest{Operation: model.BulkArchive, Filter: &model.ProductBulkFilter{}}, model.ErrBulkTarget},
+		{"filter write without expected_matched", model.ProductBulkRequest{Operation: model.BulkArchive, Filter: &model.ProductBulkFilter{Name: "teh"}}, model.ErrBulkTarget},
+		{"expected_matched with ids", model.ProductBulkRequest{Operation: model.BulkArchive, IDs: []int{1}, ExpectedMatched: &amount}, model.ErrBulkTarget},
+		{"non-positive id", model.ProductBulkRequest{Operation: model.BulkArchive, IDs: []int{0}}, model.ErrBulkTarget},
+		{"set_price without amount", model.ProductBulkRequest{Operation: model.BulkSetPrice, IDs: []int{1}}, model.ErrBulkValue},
+		{"set_price to zero", model.ProductBulkRequest{Operation: model.BulkSetPrice, IDs: []int{1}, Amount: &zero}, model.ErrPriceInvalid},
+		{"adjust_price with both", model.ProductBulkRequest{Operation: model.BulkAdjustPrice, IDs: []int{1}, Amount: &amount, Percent: &percent}, model.ErrBulkValue},
+		{"adjust_price by -100%", model.ProductBulkRequest{Operation: model.BulkAdjustPrice, IDs: []int{1}, Percent: &tooLow}, model.ErrBulkValue},
+		{"adjust_stock without amount", model.ProductBulkRequest{Operation: model.BulkAdjustStock, IDs: []int{1}}, model.ErrBulkValue},
+		{"archive with amount", model.ProductBulkRequest{Operation: model.BulkArchive, IDs: []int{1}, Amount: &amount}, model.ErrBulkValue},
+		{"category_id on set_price", model.ProductBulkRequest{Operation: model.BulkSetPrice, IDs: []int{1}, Amount: &amount, CategoryID: &categoryID}, model.ErrBulkValue},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := service.Bulk(&tc.req); !errors.Is(err, tc.want) {
+				t.Errorf("Bulk should return %v, got: %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestProductService_Bulk_AdjustPriceByPercent(t *testing.T) {
+	service, productRepo, _ := setupBulkService()
+	percent := 12.5
+
+	var written *model.ProductBulkBatch
+	productRepo.ApplyBulkFunc = func(batch *model.ProductBulkBatch) error {
+		written = batch
+		return nil
+	}
+
+	result, err := service.Bulk(&model.ProductBulkRequest{
+		Operation: model.BulkAdjustPrice,
+		IDs:       []int{2, 1, 2},
+		Percent:   &percent,
+		ChangedBy: "admin",
+	})
+	if err != nil {
+		t.Fatalf("Bulk should not return error, got: %v", err)
+	}
+	if result.Matched != 2 || result.Affected != 2 || len(result.Changes) != 2 {
+		t.Fatalf("Bulk should dedupe ids and change 2 products, got: %+v", result)
+	}
+	// 5000 * 1.125 = 5625, 8000 * 1.125 = 9000; targets are ordered by ID.
+	if result.Changes[0].New != 5625 || result.Changes[1].New != 9000 {
+		t.Errorf("Percent should be applied and rounded to whole rupiah, got: %+v", result.Changes)
+	}
+	if written == nil || written.Archive || len(written.Products) != 2 {
+		t.Fatalf("Bulk should write one batch with both products, got: %+v", written)
+	}
+	if p := written.Products[1]; p.ID != 2 || p.Version != 4 || p.ChangedBy != "admin" {
+		t.Errorf("Written products should keep the version read and record the actor, got: %+v", p)
+	}
+	if productRepo.Products[1].Price != 5000 {
+		t.Error("Bulk should not modify the products it read")
+	}
+}
+
+func TestProductService_Bulk_InvalidProductsRejectEverything(t *testing.T) {
+	service, productRepo, _ := setupBulkService()
+	productRepo.ApplyBulkFunc = func(_ *model.ProductBulkBatch) error {
+		t.Error("ApplyBulk should not be called when a product is invalid")
+		return nil
+	}
+	amount := -5
+
+	result, err := service.Bulk(&model.ProductBulkRequest{Operation: model.BulkAdjustStock, IDs: []int{1, 2, 3, 99}, Amount: &amount})
+	if !errors.Is(err, model.ErrBulkInvalid) {
+		t.Fatalf("Bulk should return ErrBulkInvalid, got: %v", err)
+	}
+	// Kopi (3) and Susu (0) would go negative, 99 does not exist.
+	if len(result.Errors) != 3 || result.Affected != 1 {
+		t.Errorf("Bulk should report 3 errors and 1 valid change, got: %+v", result)
+	}
+}
+
+func TestProductService_Bulk_DryRunAndUnchanged(t *testing.T) {
+	service, productRepo, _ := setupBulkService()
+	productRepo.ApplyBulkFunc = func(_ *model.ProductBulkBatch) error {
+		t.Error("ApplyBulk should not be called on a dry run")
+		return nil
+	}
+	price := 5000
+
+	var filter model.ProductFilter
+	productRepo.GetAllFunc = func(f model.ProductFilter) ([]*model.Product, error) {
+		filter = f
+		return []*model.Product{productRepo.Products[2], productRepo.Products[1]}, nil
+	}
+
+	result, err := service.Bulk(&model.ProductBulkRequest{
+		Operation: model.BulkSetPrice,
+		Filter:    &model.ProductBulkFilter{CategoryID: 1, Name: " te "},
+		Amount:    &price,
+		DryRun:    true,
+	})
+	if err != nil {
+		t.Fatalf("Bulk dry run should not return error, got: %v", err)
+	}
+	if filter.CategoryID != 1 || filter.Name != "te" || !filter.NameSubstring || filter.IncludeArchived {
+		t.Errorf("Bulk should pass the filter to the repository, got: %+v", filter)
+	}
+	if !result.DryRun || result.Matched != 2 || result.Affected != 1 || result.Unchanged != 1 {
+		t.Errorf("Dry run should preview 1 change and 1 unchanged product, got: %+v", result)
+	}
+	if productRepo.Products[2].Price != 8000 {
+		t.Error("Dry run should not change prices")
+	}
+}
+
+func TestProductService_Bulk_SetCategory(t *testing.T) {
+	service, productRepo, categoryRepo := setupBulkService()
+
+	missing := 9
+	if _, err := service.Bulk(&model.ProductBulkRequest{Operation: model.BulkSetCategory, IDs: []int{1}, CategoryID: &missing}); !errors.Is(err, model.ErrCategoryNotFound) {
+		t.Errorf("Bulk should reject a missing category, got: %v", err)
+	}
+	categoryRepo.Categories[2] = &model.Category{ID: 2, Name: "Lama"}
+	categoryRepo.Archive(2, model.CategoryProductPolicy{}, 0)
+	archived := 2
+	if _, err := service.Bulk(&model.ProductBulkRequest{Operation: model.BulkSetCategory, IDs: []int{1}, CategoryID: &archived}); !errors.Is(err, model.ErrCategoryArchived) {
+		t.Errorf("Bulk should reject an archived category, got: %v", err)
+	}
+
+	categoryID := 1
+	result, err := service.Bulk(&model.ProductBulkRequest{Operation: model.BulkSetCategory, IDs: []int{1, 2}, CategoryID: &categoryID})
+	if err != nil || result.Affected != 2 {
+		t.Fatalf("Bulk should move 2 products, got: %+v, %v", result, err)
+	}
+	if p := productRepo.Products[1]; p.CategoryID == nil || *p.CategoryID != 1 {
+		t.Errorf("Product should be moved to category 1, got: %v", p.CategoryID)
+	}
+
+	result, err = service.Bulk(&model.ProductBulkRequest{Operation: model.BulkSetCategory, IDs: []int{1, 3}})
+	if err != nil || result.Affected != 1 || result.Unchanged != 1 {
+		t.Fatalf("Clearing the category should change only product 1, got: %+v, %v", result, err)
+	}
+	if productRepo.Products[1].CategoryID != nil {
+		t.Error("set_category without category_id should clear the category")
+	}
+}
+
+func TestProductService_Bulk_Archive(t *testing.T) {
+	service, productRepo, _ := setupBulkService()
+	productRepo.Archive(3, 0)
+
+	result, err := service.Bulk(&model.ProductBulkRequest{Operation: model.BulkArchive, IDs: []int{1, 3}})
+	if err != nil {
+		t.Fatalf("Bulk archive should not return error, got: %v", err)
+	}
+	if result.Affected != 1 || result.Unchanged != 1 || !productRepo.Products[1].IsArchived() {
+		t.Errorf("Bulk should archive product 1 and skip the archived product 3, got: %+v", result)
+	}
+
+	price := 1000
+	if _, err := service.Bulk(&model.ProductBulkRequest{Operation: model.BulkSetPrice, IDs: []int{1}, Amount: &price}); !errors.Is(err, model.ErrBulkInvalid) {
+		t.Errorf("Changing an archived product should be rejected, got: %v", err)
+	}
+}
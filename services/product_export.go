@@ -0,0 +1,88 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	model "kasir-api/models"
+)
+
+// Export writes the products matching filter to w as CSV, XLSX or NDJSON.
+// CSV and XLSX use the import header, so an exported file can be imported again unchanged.
+// Rows are streamed from the repository; XLSX is assembled by excelize's stream writer first,
+// since the workbook zip can only be written once complete.
+func (s *ProductService) Export(w io.Writer, format string, filter model.ProductFilter) error {
+	switch strings.ToLower(format) {
+	case model.ImportFormatCSV:
+		return s.exportCSV(w, filter)
+	case model.ImportFormatXLSX:
+		return s.exportXLSX(w, filter)
+	case model.ExportFormatNDJSON:
+		return s.exportNDJSON(w, filter)
+	default:
+		return model.ErrExportFormat
+	}
+}
+
+func (s *ProductService) exportCSV(w io.Writer, filter model.ProductFilter) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(model.ImportColumns); err != nil {
+		return err
+	}
+	err := s.repo.ForEach(filter, func(p *model.Product) error {
+		r := model.NewProductRecord(p)
+		return cw.Write([]string{r.Name, strconv.Itoa(r.Price), strconv.Itoa(r.Stock), r.Category, r.SKU, r.Barcode})
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *ProductService) exportXLSX(w io.Writer, filter model.ProductFilter) error {
+	f := excelize.NewFile()
+	defer f.Close() //nolint:errcheck // only removes temp files
+
+	sw, err := f.NewStreamWriter(f.GetSheetName(0))
+	if err != nil {
+		return err
+	}
+	header := make([]any, len(model.ImportColumns))
+	for i, c := range model.ImportColumns {
+		header[i] = c
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	row := 1
+	err = s.repo.ForEach(filter, func(p *model.Product) error {
+		row++
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		r := model.NewProductRecord(p)
+		return sw.SetRow(cell, []any{r.Name, r.Price, r.Stock, r.Category, r.SKU, r.Barcode})
+	})
+	if err != nil {
+		return err
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+func (s *ProductService) exportNDJSON(w io.Writer, filter model.ProductFilter) error {
+	enc := json.NewEncoder(w)
+	return s.repo.ForEach(filter, func(p *model.Product) error {
+		return enc.Encode(model.NewProductRecord(p))
+	})
+}
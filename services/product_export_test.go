@@ -0,0 +1,107 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"kasir-api/mocks"
+	model "kasir-api/models"
+)
+
+func TestProductService_Export_CSV(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+
+	productRepo.Products[2] = &model.Product{ID: 2, Name: "Teh, Manis", Price: 4000, Stock: 5}
+	productRepo.Products[1] = &model.Product{
+		ID: 1, Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01", Barcode: "0899",
+		Category: &model.ProductCategory{Name: "Makanan"},
+	}
+
+	var buf bytes.Buffer
+	if err := service.Export(&buf, model.ImportFormatCSV, model.ProductFilter{}); err != nil {
+		t.Fatalf("Export should not return error, got: %v", err)
+	}
+
+	want := "name,price,stock,category,sku,barcode\n" +
+		"Indomie,3500,10,Makanan,IDM-01,0899\n" +
+		"\"Teh, Manis\",4000,5,,,\n"
+	if buf.String() != want {
+		t.Errorf("Export CSV mismatch:\ngot:  %q\nwant: %q", buf.String(), want)
+	}
+}
+
+func TestProductService_Export_NDJSON(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01"}
+	productRepo.Products[2] = &model.Product{ID: 2, Name: "Teh", Price: 4000, Stock: 5}
+
+	var buf bytes.Buffer
+	if err := service.Export(&buf, model.ExportFormatNDJSON, model.ProductFilter{}); err != nil {
+		t.Fatalf("Export should not return error, got: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("NDJSON should have one line per product, got: %d", len(lines))
+	}
+	var first model.ProductRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("NDJSON line should be valid JSON, got: %v", err)
+	}
+	if first.Name != "Indomie" || first.SKU != "IDM-01" {
+		t.Errorf("First line should be product 1, got: %+v", first)
+	}
+}
+
+func TestProductService_Export_RoundTrip(t *testing.T) {
+	for _, format := range []string{model.ImportFormatCSV, model.ImportFormatXLSX} {
+		t.Run(format, func(t *testing.T) {
+			productRepo := mocks.NewMockProductRepository()
+			categoryRepo := mocks.NewMockCategoryRepository()
+			service := NewProductService(productRepo, categoryRepo)
+
+			categoryRepo.Categories[1] = &model.Category{ID: 1, Name: "Makanan"}
+			categoryID := 1
+			productRepo.Products[1] = &model.Product{
+				ID: 1, Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01", Barcode: "0899",
+				CategoryID: &categoryID, Category: &model.ProductCategory{Name: "Makanan"},
+			}
+			productRepo.NextID = 2
+
+			var buf bytes.Buffer
+			if err := service.Export(&buf, format, model.ProductFilter{}); err != nil {
+				t.Fatalf("Export should not return error, got: %v", err)
+			}
+			result, err := service.Import(&buf, format, model.ImportOptions{DryRun: true})
+			if err != nil {
+				t.Fatalf("Exported file should import cleanly, got: %v (%+v)", err, result)
+			}
+			if result.Updated != 1 || result.Created != 0 {
+				t.Errorf("Re-import should match the product by SKU, got: %+v", result)
+			}
+		})
+	}
+}
+
+func TestProductService_Export_Errors(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+
+	if err := service.Export(&bytes.Buffer{}, "pdf", model.ProductFilter{}); !errors.Is(err, model.ErrExportFormat) {
+		t.Errorf("Export with unknown format should return ErrExportFormat, got: %v", err)
+	}
+
+	repoErr := errors.New("connection lost")
+	productRepo.ForEachFunc = func(_ model.ProductFilter, _ func(p *model.Product) error) error {
+		return repoErr
+	}
+	if err := service.Export(&bytes.Buffer{}, model.ExportFormatNDJSON, model.ProductFilter{}); !errors.Is(err, repoErr) {
+		t.Errorf("Export should return the repository error, got: %v", err)
+	}
+}
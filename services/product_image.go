@@ -0,0 +1,167 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+
+	"kasir-api/helpers/logger"
+	model "kasir-api/models"
+)
+
+const (
+	// thumbnailSize is the longest side of generated thumbnails, in pixels.
+	thumbnailSize = 320
+	// maxImagePixels guards against decompression bombs: small files that decode to huge images.
+	maxImagePixels = 40_000_000
+)
+
+// imageExtensions maps accepted image content types, as sniffed from the file, to file extensions.
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+}
+
+// UploadImage stores a JPEG or PNG image for the product together with a resized thumbnail,
+// replacing any previous image. The content type is sniffed from the data, not trusted from the client.
+func (s *ProductService) UploadImage(productID int, r io.Reader) (*model.Product, error) {
+	if s.images == nil {
+		return nil, model.ErrImageStoreDisabled
+	}
+	if productID <= 0 {
+		return nil, model.ErrProductNotFound
+	}
+	if _, err := s.repo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.maxImageBytes {
+		return nil, model.ErrImageTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, model.ErrImageType
+	}
+	thumbnail, err := makeThumbnail(data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("products/%d/%d%s", productID, time.Now().UnixNano(), ext)
+	if err := s.images.Put(key, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+	if err := s.images.Put(thumbnailKey(key), bytes.NewReader(thumbnail), contentType); err != nil {
+		s.deleteImageFiles(key)
+		return nil, err
+	}
+
+	previous, err := s.repo.ReplaceImage(productID, key)
+	if err != nil {
+		s.deleteImageFiles(key)
+		return nil, err
+	}
+	if previous != "" {
+		s.deleteImageFiles(previous)
+	}
+	return s.GetByID(productID)
+}
+
+// DeleteImage removes the product's image and thumbnail.
+func (s *ProductService) DeleteImage(productID int) (*model.Product, error) {
+	if s.images == nil {
+		return nil, model.ErrImageStoreDisabled
+	}
+	if productID <= 0 {
+		return nil, model.ErrProductNotFound
+	}
+	previous, err := s.repo.ReplaceImage(productID, "")
+	if err != nil {
+		return nil, err
+	}
+	if previous == "" {
+		return nil, model.ErrImageNotFound
+	}
+	s.deleteImageFiles(previous)
+	return s.GetByID(productID)
+}
+
+// attachImageURLs fills the public image URLs from the stored image key.
+func (s *ProductService) attachImageURLs(p *model.Product) {
+	if s.images == nil || p.ImageKey == "" {
+		p.ImageURL, p.ThumbnailURL = "", ""
+		return
+	}
+	p.ImageURL = s.images.URL(p.ImageKey)
+	p.ThumbnailURL = s.images.URL(thumbnailKey(p.ImageKey))
+}
+
+// deleteImageFiles removes an image and its thumbnail. Failures only leave orphaned files, so they are logged.
+func (s *ProductService) deleteImageFiles(key string) {
+	for _, k := range []string{key, thumbnailKey(key)} {
+		if err := s.images.Delete(k); err != nil {
+			logger.Error("delete image %s: %v", k, err)
+		}
+	}
+}
+
+// thumbnailKey derives the thumbnail key from an image key: products/1/123.jpg -> products/1/123_thumb.jpg.
+func thumbnailKey(key string) string {
+	ext := path.Ext(key)
+	return strings.TrimSuffix(key, ext) + "_thumb" + ext
+}
+
+// makeThumbnail decodes the image and scales it to fit thumbnailSize, keeping the original format.
+// Images already small enough are re-encoded as is.
+func makeThumbnail(data []byte, contentType string) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, model.ErrImageType
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, model.ErrImageDimensions
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, model.ErrImageType
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > thumbnailSize || height > thumbnailSize {
+		if width >= height {
+			height = max(1, height*thumbnailSize/width)
+			width = thumbnailSize
+		} else {
+			width = max(1, width*thumbnailSize/height)
+			height = thumbnailSize
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+
+	var buf bytes.Buffer
+	if contentType == "image/png" {
+		err = png.Encode(&buf, dst)
+	} else {
+		err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 80})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
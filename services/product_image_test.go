@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"kasir-api/mocks"
+	model "kasir-api/models"
+)
+
+// fakeBlobStore keeps blobs in a map.
+type fakeBlobStore struct {
+	blobs map[string][]byte
+}
+
+func newFakeBlobStore() *fakeBlobStore {
+	return &fakeBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (f *fakeBlobStore) Put(key string, r io.Reader, _ string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f.blobs[key] = data
+	return nil
+}
+
+func (f *fakeBlobStore) Delete(key string) error {
+	delete(f.blobs, key)
+	return nil
+}
+
+func (f *fakeBlobStore) URL(key string) string {
+	return "/media/" + key
+}
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func setupImageService(t *testing.T) (*ProductService, *mocks.MockProductRepository, *fakeBlobStore) {
+	t.Helper()
+	productRepo := mocks.NewMockProductRepository()
+	store := newFakeBlobStore()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+	service.SetImageStore(store, 1<<20)
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Kopi", Price: 5000, Stock: 10}
+	return service, productRepo, store
+}
+
+func TestProductService_UploadImage_Success(t *testing.T) {
+	service, _, store := setupImageService(t)
+
+	product, err := service.UploadImage(1, bytes.NewReader(testPNG(t, 800, 400)))
+	if err != nil {
+		t.Fatalf("UploadImage should not return error, got: %v", err)
+	}
+	if !strings.HasPrefix(product.ImageURL, "/media/products/1/") || !strings.HasSuffix(product.ThumbnailURL, "_thumb.png") {
+		t.Errorf("Product should expose image and thumbnail URLs, got: %q %q", product.ImageURL, product.ThumbnailURL)
+	}
+	if len(store.blobs) != 2 {
+		t.Fatalf("Upload should store image and thumbnail, got: %d blobs", len(store.blobs))
+	}
+
+	thumb, err := png.Decode(bytes.NewReader(store.blobs[thumbnailKey(product.ImageKey)]))
+	if err != nil {
+		t.Fatalf("Thumbnail should be a valid PNG, got: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != thumbnailSize || b.Dy() != thumbnailSize/2 {
+		t.Errorf("Thumbnail should keep aspect ratio within %dpx, got: %dx%d", thumbnailSize, b.Dx(), b.Dy())
+	}
+}
+
+func TestProductService_UploadImage_ReplacesPrevious(t *testing.T) {
+	service, _, store := setupImageService(t)
+
+	first, _ := service.UploadImage(1, bytes.NewReader(testPNG(t, 10, 10)))
+	firstKey := first.ImageKey
+	second, err := service.UploadImage(1, bytes.NewReader(testPNG(t, 20, 20)))
+	if err != nil {
+		t.Fatalf("Second upload should not return error, got: %v", err)
+	}
+	if second.ImageKey == firstKey {
+		t.Fatal("Second upload should store under a new key")
+	}
+	if _, ok := store.blobs[firstKey]; ok {
+		t.Error("Previous image should be deleted")
+	}
+	if len(store.blobs) != 2 {
+		t.Errorf("Only the new image and thumbnail should remain, got: %d blobs", len(store.blobs))
+	}
+}
+
+func TestProductService_UploadImage_Validation(t *testing.T) {
+	service, _, store := setupImageService(t)
+
+	tests := []struct {
+		name string
+		id   int
+		data []byte
+		want error
+	}{
+		{"unknown product", 99, testPNG(t, 10, 10), model.ErrProductNotFound},
+		{"not an image", 1, []byte("GIF89a not really"), model.ErrImageType},
+		{"corrupt png", 1, append([]byte("\x89PNG\r\n\x1a\n"), 0, 0, 0), model.ErrImageType},
+		{"too large", 1, make([]byte, 1<<20+1), model.ErrImageTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.UploadImage(tt.id, bytes.NewReader(tt.data))
+			if !errors.Is(err, tt.want) {
+				t.Errorf("UploadImage should return %v, got: %v", tt.want, err)
+			}
+		})
+	}
+	if len(store.blobs) != 0 {
+		t.Errorf("Rejected uploads should not store anything, got: %d blobs", len(store.blobs))
+	}
+}
+
+func TestProductService_DeleteImage(t *testing.T) {
+	service, _, store := setupImageService(t)
+
+	if _, err := service.DeleteImage(1); !errors.Is(err, model.ErrImageNotFound) {
+		t.Errorf("DeleteImage without image should return ErrImageNotFound, got: %v", err)
+	}
+
+	service.UploadImage(1, bytes.NewReader(testPNG(t, 10, 10)))
+	product, err := service.DeleteImage(1)
+	if err != nil {
+		t.Fatalf("DeleteImage should not return error, got: %v", err)
+	}
+	if product.ImageURL != "" || len(store.blobs) != 0 {
+		t.Errorf("DeleteImage should clear the URL and files, got: %q, %d blobs", product.ImageURL, len(store.blobs))
+	}
+}
+
+func TestProductService_UploadImage_StoreDisabled(t *testing.T) {
+	service := NewProductService(mocks.NewMockProductRepository(), mocks.NewMockCategoryRepository())
+
+	if _, err := service.UploadImage(1, bytes.NewReader(nil)); !errors.Is(err, model.ErrImageStoreDisabled) {
+		t.Errorf("UploadImage without store should return ErrImageStoreDisabled, got: %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	model "kasir-api/models"
+)
+
+// maxCodeLength is the longest SKU or barcode accepted, matching the database columns.
+const maxCodeLength = 64
+
+// Import reads a CSV or XLSX file and upserts its rows, matching existing products by SKU.
+// Rows are checked with the same rules as Create. If any row is invalid nothing is written and
+// ErrImportInvalid is returned together with the per-row errors. A dry run only reports.
+func (s *ProductService) Import(r io.Reader, format string, opts model.ImportOptions) (*model.ImportResult, error) {
+	rows, err := parseImportFile(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ImportResult{
+		DryRun:            opts.DryRun,
+		TotalRows:         len(rows),
+		CategoriesCreated: []string{},
+		Errors:            []model.ImportRowError{},
+	}
+
+	categories, err := s.categoryRepo.GetAll(true)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*model.Category, len(categories))
+	for _, c := range categories {
+		key := strings.ToLower(strings.TrimSpace(c.Name))
+		if existing, ok := byName[key]; ok && !existing.IsArchived() {
+			continue
+		}
+		byName[key] = c
+	}
+
+	batch := &model.ProductImportBatch{}
+	newCategories := make(map[string]*model.Category)
+	seenSKUs := make(map[string]int)
+
+	for _, row := range rows {
+		product, rowErr := s.importProduct(row, opts, byName, newCategories, batch)
+		if rowErr == nil && product.SKU != "" {
+			if first, dup := seenSKUs[product.SKU]; dup {
+				rowErr = &model.ImportRowError{Field: "sku", Message: fmt.Sprintf("duplicate sku, already used on row %d", first)}
+			} else {
+				seenSKUs[product.SKU] = row.Row
+			}
+		}
+		if rowErr == nil && product.SKU != "" {
+			existing, err := s.repo.GetBySKU(product.SKU)
+			switch {
+			case err == nil:
+				// The file has no components, unit, type or PLU column, so a bundle stays a bundle,
+				// a product sold by weight keeps its unit and scale PLU, and a service keeps no stock.
+				product.ID = existing.ID
+				product.Components = existing.Components
+				product.Unit = existing.Unit
+				product.PLU = existing.PLU
+				product.Type = existing.Type
+				product.AllowNegativeStock = existing.AllowNegativeStock
+				if !product.TracksStock() {
+					product.Stock = 0
+				}
+			case !errors.Is(err, model.ErrProductNotFound):
+				return nil, err
+			}
+		}
+		if rowErr != nil {
+			rowErr.Row = row.Row
+			result.Errors = append(result.Errors, *rowErr)
+			continue
+		}
+
+		if product.ID > 0 {
+			result.Updated++
+		} else {
+			result.Created++
+		}
+		batch.Products = append(batch.Products, product)
+	}
+
+	for _, c := range batch.NewCategories {
+		result.CategoriesCreated = append(result.CategoriesCreated, c.Name)
+	}
+	if len(result.Errors) > 0 {
+		return result, model.ErrImportInvalid
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+	if err := s.repo.ApplyDuePriceChanges(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Import(batch); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// importProduct converts one row into a product. Categories unknown so far are queued on batch
+// when opts.CreateCategories is set, so several rows naming the same new category share it.
+func (s *ProductService) importProduct(
+	row model.ImportRow,
+	opts model.ImportOptions,
+	byName map[string]*model.Category,
+	newCategories map[string]*model.Category,
+	batch *model.ProductImportBatch,
+) (*model.Product, *model.ImportRowError) {
+	price, err := strconv.Atoi(strings.TrimSpace(row.Price))
+	if err != nil {
+		return nil, &model.ImportRowError{Field: "price", Message: "price must be a whole number"}
+	}
+	stock, err := strconv.Atoi(strings.TrimSpace(row.Stock))
+	if err != nil {
+		return nil, &model.ImportRowError{Field: "stock", Message: "stock must be a whole number"}
+	}
+
+	product := &model.Product{
+		Name:      strings.TrimSpace(row.Name),
+		Price:     price,
+		Stock:     stock,
+		SKU:       strings.TrimSpace(row.SKU),
+		Barcode:   strings.TrimSpace(row.Barcode),
+		ChangedBy: opts.ChangedBy,
+	}
+	if err := validateProductFields(product); err != nil {
+		field := "name"
+		switch {
+		case errors.Is(err, model.ErrPriceInvalid):
+			field = "price"
+		case errors.Is(err, model.ErrStockInvalid):
+			field = "stock"
+		}
+		return nil, &model.ImportRowError{Field: field, Message: err.Error()}
+	}
+	if len(product.SKU) > maxCodeLength {
+		return nil, &model.ImportRowError{Field: "sku", Message: fmt.Sprintf("sku must be at most %d characters", maxCodeLength)}
+	}
+	if len(product.Barcode) > maxCodeLength {
+		return nil, &model.ImportRowError{Field: "barcode", Message: fmt.Sprintf("barcode must be at most %d characters", maxCodeLength)}
+	}
+
+	name := strings.TrimSpace(row.Category)
+	if name == "" {
+		return product, nil
+	}
+	key := strings.ToLower(name)
+	if c, ok := byName[key]; ok {
+		if c.IsArchived() {
+			return nil, &model.ImportRowError{Field: "category", Message: model.ErrCategoryArchived.Error()}
+		}
+		product.CategoryID = &c.ID
+		return product, nil
+	}
+	if !opts.CreateCategories {
+		return nil, &model.ImportRowError{Field: "category", Message: fmt.Sprintf("category %q not found", name)}
+	}
+	c, ok := newCategories[key]
+	if !ok {
+		c = &model.Category{Name: name}
+		newCategories[key] = c
+		batch.NewCategories = append(batch.NewCategories, c)
+	}
+	product.CategoryID = &c.ID
+	return product, nil
+}
+
+// parseImportFile reads the header and data rows of a CSV or XLSX file.
+// Columns are matched by header name, case-insensitively; blank rows are skipped.
+func parseImportFile(r io.Reader, format string) ([]model.ImportRow, error) {
+	var records [][]string
+	switch strings.ToLower(format) {
+	case model.ImportFormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		var err error
+		records, err = cr.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrImportFormat, err)
+		}
+	case model.ImportFormatXLSX:
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrImportFormat, err)
+		}
+		defer f.Close() //nolint:errcheck // read-only workbook
+		records, err = f.GetRows(f.GetSheetName(0))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrImportFormat, err)
+		}
+	default:
+		return nil, model.ErrImportFormat
+	}
+
+	if len(records) == 0 {
+		return nil, model.ErrImportHeader
+	}
+	columns := make(map[string]int)
+	for i, h := range records[0] {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, dup := columns[h]; !dup {
+			columns[h] = i
+		}
+	}
+	for _, required := range []string{"name", "price", "stock"} {
+		if _, ok := columns[required]; !ok {
+			return nil, model.ErrImportHeader
+		}
+	}
+
+	cell := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return record[i]
+	}
+
+	rows := make([]model.ImportRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		rows = append(rows, model.ImportRow{
+			Row:      i + 2,
+			Name:     cell(record, "name"),
+			Price:    cell(record, "price"),
+			Stock:    cell(record, "stock"),
+			Category: cell(record, "category"),
+			SKU:      cell(record, "sku"),
+			Barcode:  cell(record, "barcode"),
+		})
+	}
+	return rows, nil
+}
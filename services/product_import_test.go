@@ -0,0 +1,146 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"kasir-api/mocks"
+	model "kasir-api/models"
+)
+
+func TestProductService_Import_CreatesAndUpdatesBySKU(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewProductService(productRepo, categoryRepo)
+
+	categoryRepo.Categories[1] = &model.Category{ID: 1, Name: "Makanan"}
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Indomie", Price: 3500, Stock: 10, SKU: "IDM-01"}
+	productRepo.NextID = 2
+
+	file := "Name,Price,Stock,Category,SKU,Barcode\n" +
+		"Indomie Goreng,4000,20,makanan,IDM-01,\n" +
+		"Roti Tawar,15000,5,,RT-01,8991234567890\n"
+
+	result, err := service.Import(strings.NewReader(file), model.ImportFormatCSV, model.ImportOptions{ChangedBy: "admin"})
+	if err != nil {
+		t.Fatalf("Import should not return error, got: %v", err)
+	}
+	if result.TotalRows != 2 || result.Created != 1 || result.Updated != 1 {
+		t.Errorf("Import should create 1 and update 1, got: %+v", result)
+	}
+
+	updated := productRepo.Products[1]
+	if updated.Name != "Indomie Goreng" || updated.Price != 4000 || updated.ChangedBy != "admin" {
+		t.Errorf("Product 1 should be updated, got: %+v", updated)
+	}
+	if updated.CategoryID == nil || *updated.CategoryID != 1 {
+		t.Errorf("Category should be matched by name case-insensitively, got: %v", updated.CategoryID)
+	}
+	if productRepo.Products[2].Barcode != "8991234567890" {
+		t.Errorf("Created product should keep its barcode, got: %+v", productRepo.Products[2])
+	}
+}
+
+func TestProductService_Import_InvalidRows(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewProductService(productRepo, categoryRepo)
+
+	imported := false
+	productRepo.ImportFunc = func(_ *model.ProductImportBatch) error {
+		imported = true
+		return nil
+	}
+
+	file := "name,price,stock,category,sku\n" +
+		"Kopi,5000,10,,K-01\n" +
+		",5000,10,,\n" +
+		"Teh,abc,10,,\n" +
+		"Susu,7000,-1,,\n" +
+		"Gula,12000,3,Dapur,\n" +
+		"Kopi Susu,6000,4,,K-01\n"
+
+	result, err := service.Import(strings.NewReader(file), model.ImportFormatCSV, model.ImportOptions{})
+	if !errors.Is(err, model.ErrImportInvalid) {
+		t.Fatalf("Import with invalid rows should return ErrImportInvalid, got: %v", err)
+	}
+	if imported {
+		t.Error("Import with invalid rows should not write anything")
+	}
+
+	want := map[int]string{3: "name", 4: "price", 5: "stock", 6: "category", 7: "sku"}
+	if len(result.Errors) != len(want) {
+		t.Fatalf("Should report %d row errors, got: %+v", len(want), result.Errors)
+	}
+	for _, e := range result.Errors {
+		if want[e.Row] != e.Field {
+			t.Errorf("Row %d should fail on %q, got: %q (%s)", e.Row, want[e.Row], e.Field, e.Message)
+		}
+	}
+}
+
+func TestProductService_Import_DryRun(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewProductService(productRepo, categoryRepo)
+
+	file := "name,price,stock,category\nKopi,5000,10,Minuman\nTeh,4000,10,minuman\n"
+	opts := model.ImportOptions{DryRun: true, CreateCategories: true}
+
+	result, err := service.Import(strings.NewReader(file), model.ImportFormatCSV, opts)
+	if err != nil {
+		t.Fatalf("Dry run should not return error, got: %v", err)
+	}
+	if result.Created != 2 {
+		t.Errorf("Dry run should report 2 creates, got: %d", result.Created)
+	}
+	if len(result.CategoriesCreated) != 1 || result.CategoriesCreated[0] != "Minuman" {
+		t.Errorf("Rows naming the same new category should share it, got: %v", result.CategoriesCreated)
+	}
+	if len(productRepo.Products) != 0 {
+		t.Errorf("Dry run should not write products, got: %d", len(productRepo.Products))
+	}
+}
+
+func TestProductService_Import_ArchivedCategory(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewProductService(productRepo, categoryRepo)
+
+	categoryRepo.Categories[1] = &model.Category{ID: 1, Name: "Rokok"}
+	categoryRepo.Archive(1, model.CategoryProductPolicy{}, 0)
+
+	file := "name,price,stock,category\nSurya,30000,10,Rokok\n"
+	result, err := service.Import(strings.NewReader(file), model.ImportFormatCSV, model.ImportOptions{CreateCategories: true})
+	if !errors.Is(err, model.ErrImportInvalid) {
+		t.Fatalf("Import into an archived category should fail, got: %v", err)
+	}
+	if result.Errors[0].Field != "category" {
+		t.Errorf("Error should point at the category column, got: %+v", result.Errors[0])
+	}
+}
+
+func TestProductService_Import_BadFile(t *testing.T) {
+	service := NewProductService(mocks.NewMockProductRepository(), mocks.NewMockCategoryRepository())
+
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    error
+	}{
+		{"unknown format", "json", "[]", model.ErrImportFormat},
+		{"empty file", model.ImportFormatCSV, "", model.ErrImportHeader},
+		{"missing stock column", model.ImportFormatCSV, "name,price\nKopi,5000\n", model.ErrImportHeader},
+		{"broken xlsx", model.ImportFormatXLSX, "not a workbook", model.ErrImportFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Import(strings.NewReader(tt.content), tt.format, model.ImportOptions{})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Import should return %v, got: %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -1,28 +1,108 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"strings"
+	"time"
 
 	model "kasir-api/models"
 	repository "kasir-api/repositories"
+	"kasir-api/storage"
 )
 
 // ProductService handles business logic for products.
 // Service layer: logic kode kita. Error logic → cek sini.
 type ProductService struct {
-	repo         repository.ProductRepository
-	categoryRepo repository.CategoryRepository
+	repo          repository.ProductRepository
+	categoryRepo  repository.CategoryRepository
+	images        storage.BlobStore
+	maxImageBytes int64
+	scaleRules    []model.ScaleBarcodeRule
 }
 
 // NewProductService creates a new ProductService.
 func NewProductService(repo repository.ProductRepository, categoryRepo repository.CategoryRepository) *ProductService {
-	return &ProductService{repo: repo, categoryRepo: categoryRepo}
+	return &ProductService{repo: repo, categoryRepo: categoryRepo, scaleRules: defaultScaleRules}
 }
 
-// GetAll retrieves all products.
-// If name is provided, filters products by name.
-func (s *ProductService) GetAll(name string) ([]*model.Product, error) {
-	return s.repo.GetAll(name)
+// SetScaleBarcodeRules sets how scale labels are decoded by ScanBarcode.
+func (s *ProductService) SetScaleBarcodeRules(rules []model.ScaleBarcodeRule) {
+	s.scaleRules = rules
+}
+
+// ScanBarcode resolves a scale label to its product, quantity and subtotal.
+func (s *ProductService) ScanBarcode(code string) (*model.ScaleScan, error) {
+	return resolveScaleLabel(s.repo, s.scaleRules, code)
+}
+
+// SetImageStore enables product image uploads, stored in store and capped at maxBytes per image.
+func (s *ProductService) SetImageStore(store storage.BlobStore, maxBytes int64) {
+	s.images = store
+	s.maxImageBytes = maxBytes
+}
+
+// GetAll retrieves products matching the filter, in the filter's sort order.
+// Filtering by category includes products in its sub-categories. Archived products are included only when requested.
+func (s *ProductService) GetAll(filter model.ProductFilter) ([]*model.Product, error) {
+	products, err := s.repo.GetAll(filter)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range products {
+		s.attachImageURLs(p)
+	}
+	return products, nil
+}
+
+// GetPage returns one page of the products matching filter and the total number of matches.
+// A cursor must come from a listing with the same sort and order; relevance order has no cursors.
+func (s *ProductService) GetPage(filter model.ProductFilter, page model.PageRequest) (*model.Page[*model.Product], error) {
+	if page.After != nil {
+		if filter.Sort == model.ProductSortRelevance {
+			return nil, fmt.Errorf("%w: not available with sort=relevance", model.ErrInvalidCursor)
+		}
+		if page.After.Sort != filter.Sort || page.After.Desc != filter.Desc {
+			return nil, fmt.Errorf("%w: it belongs to a different sort order", model.ErrInvalidCursor)
+		}
+	}
+	result, err := s.repo.GetPage(filter, page)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range result.Items {
+		s.attachImageURLs(p)
+	}
+	return result, nil
+}
+
+// Suggest returns the best name matches for a typeahead search box: active products only,
+// most relevant first, at most limit results.
+func (s *ProductService) Suggest(query string, limit int) ([]*model.ProductSuggestion, error) {
+	suggestions := make([]*model.ProductSuggestion, 0, limit)
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return suggestions, nil
+	}
+
+	products, err := s.GetAll(model.ProductFilter{Name: query, Sort: model.ProductSortRelevance, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range products {
+		suggestions = append(suggestions, &model.ProductSuggestion{
+			ID:           p.ID,
+			Name:         p.Name,
+			Price:        p.Price,
+			Stock:        p.Stock,
+			Unit:         p.Unit,
+			SKU:          p.SKU,
+			ThumbnailURL: p.ThumbnailURL,
+		})
+	}
+	return suggestions, nil
 }
 
 // GetByID retrieves a product by ID.
@@ -30,7 +110,12 @@ func (s *ProductService) GetByID(id int) (*model.Product, error) {
 	if id <= 0 {
 		return nil, model.ErrProductNotFound
 	}
-	return s.repo.GetByID(id)
+	product, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.attachImageURLs(product)
+	return product, nil
 }
 
 // Create creates a new product with validation.
@@ -45,41 +130,219 @@ func (s *ProductService) Create(product *model.Product) (*model.Product, error)
 }
 
 // Update updates an existing product with validation.
+// A non-zero product.Version must still be current, otherwise it fails with ErrVersionMismatch.
+// Due scheduled prices are applied first, so a due change can never overwrite this update later.
 func (s *ProductService) Update(id int, product *model.Product) (*model.Product, error) {
 	if id <= 0 {
 		return nil, model.ErrProductNotFound
 	}
+	product.ID = id
 	if err := s.validateProduct(product); err != nil {
 		return nil, err
 	}
-	product.ID = id
+	if err := s.repo.ApplyDuePriceChanges(time.Now()); err != nil {
+		return nil, err
+	}
 	if err := s.repo.Update(product); err != nil {
 		return nil, err
 	}
+	s.attachImageURLs(product)
 	return product, nil
 }
 
-// Delete removes a product by ID.
-func (s *ProductService) Delete(id int) error {
+// Archive soft-deletes a product. It disappears from listings and checkout
+// but stays resolvable by ID for transaction history. A non-zero version must still be current.
+func (s *ProductService) Archive(id, version int) error {
 	if id <= 0 {
 		return model.ErrProductNotFound
 	}
-	return s.repo.Delete(id)
+	return s.repo.Archive(id, version)
+}
+
+// Restore brings an archived product back into listings and checkout.
+func (s *ProductService) Restore(id int) error {
+	if id <= 0 {
+		return model.ErrProductNotFound
+	}
+	return s.repo.Restore(id)
+}
+
+// GetPriceChanges returns one page of the price history of a product, newest effective time first.
+// Status filters the result: "upcoming" for scheduled changes, "past" for applied and due ones, empty for all.
+func (s *ProductService) GetPriceChanges(productID int, status string, page model.PageRequest) (*model.Page[*model.PriceChange], error) {
+	if productID <= 0 {
+		return nil, model.ErrProductNotFound
+	}
+	if page.After != nil && (page.After.Sort != model.PriceChangeSortEffective || !page.After.Desc) {
+		return nil, fmt.Errorf("%w: it belongs to a different sort order", model.ErrInvalidCursor)
+	}
+	return s.repo.GetPriceChanges(productID, model.PriceChangeQuery{Status: status, Now: time.Now()}, page)
+}
+
+// SchedulePriceChange schedules a new price that takes effect automatically at effective_at.
+// Immediate price changes go through Update; scheduling requires a future time.
+func (s *ProductService) SchedulePriceChange(productID int, change *model.PriceChange) (*model.PriceChange, error) {
+	if productID <= 0 {
+		return nil, model.ErrProductNotFound
+	}
+	if change.NewPrice <= 0 {
+		return nil, model.ErrPriceInvalid
+	}
+	if !change.EffectiveAt.After(time.Now()) {
+		return nil, model.ErrEffectiveAtNotInFuture
+	}
+	change.ProductID = productID
+	if err := s.repo.CreatePriceChange(change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// CancelPriceChange removes a scheduled price change that has not been applied yet.
+func (s *ProductService) CancelPriceChange(productID, id int) error {
+	if productID <= 0 {
+		return model.ErrProductNotFound
+	}
+	if id <= 0 {
+		return model.ErrPriceChangeNotFound
+	}
+	if err := s.repo.ApplyDuePriceChanges(time.Now()); err != nil {
+		return err
+	}
+	return s.repo.DeletePriceChange(productID, id)
+}
+
+// ApplyDuePriceChanges applies the scheduled prices whose effective time has passed. Reads never
+// apply them but already return the due price, so they stay read-only: RunPriceScheduler calls this
+// periodically, and checkout, updates, imports, bulk operations and cancellations call it once per
+// request before they write.
+func (s *ProductService) ApplyDuePriceChanges() error {
+	return s.repo.ApplyDuePriceChanges(time.Now())
+}
+
+// RunPriceScheduler applies due price changes right away and then every interval until ctx is done.
+// A failed run is reported to onError and retried on the next tick.
+func (s *ProductService) RunPriceScheduler(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.ApplyDuePriceChanges(); err != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
 }
 
 func (s *ProductService) validateProduct(product *model.Product) error {
+	if err := validateProductFields(product); err != nil {
+		return err
+	}
+	if product.CategoryID != nil {
+		category, err := s.categoryRepo.GetByID(*product.CategoryID)
+		if err != nil {
+			return err
+		}
+		if category.IsArchived() {
+			return model.ErrCategoryArchived
+		}
+	}
+	if product.IsBundle() {
+		if product.IsMeasured() {
+			return fmt.Errorf("%w: a bundle is sold per piece", model.ErrBundleInvalid)
+		}
+		if !product.TracksStock() {
+			return fmt.Errorf("%w: a bundle keeps stock through its components", model.ErrBundleInvalid)
+		}
+		if err := s.validateComponents(product); err != nil {
+			return err
+		}
+		// A bundle keeps no stock of its own.
+		product.Stock = 0
+	}
+	return nil
+}
+
+// validateComponents checks a bundle's components: each an existing, active, stocked product other
+// than the bundle itself, listed once with a positive quantity and not a bundle in turn. A product that is
+// already a component of some bundle cannot become a bundle itself.
+func (s *ProductService) validateComponents(product *model.Product) error {
+	if product.ID > 0 {
+		used, err := s.repo.IsBundleComponent(product.ID)
+		if err != nil {
+			return err
+		}
+		if used {
+			return fmt.Errorf("%w: product %d is a component of another bundle", model.ErrBundleInvalid, product.ID)
+		}
+	}
+	seen := make(map[int]bool, len(product.Components))
+	for _, c := range product.Components {
+		switch {
+		case c.ProductID <= 0 || c.Quantity <= 0:
+			return fmt.Errorf("%w: product_id and quantity must be greater than 0", model.ErrBundleInvalid)
+		case c.ProductID == product.ID:
+			return fmt.Errorf("%w: a bundle cannot contain itself", model.ErrBundleInvalid)
+		case seen[c.ProductID]:
+			return fmt.Errorf("%w: product %d is listed twice", model.ErrBundleInvalid, c.ProductID)
+		}
+		seen[c.ProductID] = true
+
+		component, err := s.repo.GetByID(c.ProductID)
+		if errors.Is(err, model.ErrProductNotFound) {
+			return fmt.Errorf("%w: product %d not found", model.ErrBundleInvalid, c.ProductID)
+		}
+		if err != nil {
+			return err
+		}
+		if component.IsArchived() {
+			return fmt.Errorf("%w: product %d is archived", model.ErrBundleInvalid, c.ProductID)
+		}
+		if component.IsBundle() {
+			return fmt.Errorf("%w: product %d is itself a bundle", model.ErrBundleInvalid, c.ProductID)
+		}
+		if !component.TracksStock() {
+			return fmt.Errorf("%w: product %d does not keep stock", model.ErrBundleInvalid, c.ProductID)
+		}
+	}
+	return nil
+}
+
+// validateProductFields checks the product's own fields, without touching the repositories.
+func validateProductFields(product *model.Product) error {
 	if strings.TrimSpace(product.Name) == "" {
 		return model.ErrNameRequired
 	}
 	if product.Price <= 0 {
 		return model.ErrPriceInvalid
 	}
-	if product.Stock < 0 {
+	if product.Type == "" {
+		product.Type = model.ProductTypeStocked
+	}
+	if !slices.Contains(model.ProductTypes, product.Type) {
+		return model.ErrTypeInvalid
+	}
+	// Stock and its policy only mean something for a product that keeps stock.
+	if !product.TracksStock() {
+		product.Stock = 0
+		product.AllowNegativeStock = false
+	}
+	if product.Stock < 0 && !product.AllowNegativeStock {
 		return model.ErrStockInvalid
 	}
-	if product.CategoryID != nil {
-		if _, err := s.categoryRepo.GetByID(*product.CategoryID); err != nil {
-			return err
+	if product.Unit == "" {
+		product.Unit = model.UnitPiece
+	}
+	if !slices.Contains(model.ProductUnits, product.Unit) {
+		return model.ErrUnitInvalid
+	}
+	if product.PLU != "" {
+		product.PLU = model.NormalizePLU(product.PLU)
+		if product.PLU == "" {
+			return model.ErrPLUInvalid
 		}
 	}
 	return nil
@@ -1,8 +1,12 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"io"
+	"slices"
 	"testing"
+	"time"
 
 	"kasir-api/mocks"
 	model "kasir-api/models"
@@ -32,7 +36,7 @@ func TestProductService_GetAll_Success(t *testing.T) {
 	productRepo.Products[1] = &model.Product{ID: 1, Name: "Laptop", Price: 1000, Stock: 10}
 	productRepo.Products[2] = &model.Product{ID: 2, Name: "Phone", Price: 500, Stock: 20}
 
-	products, err := service.GetAll("")
+	products, err := service.GetAll(model.ProductFilter{})
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -46,14 +50,14 @@ func TestProductService_GetAll_WithNameFilter(t *testing.T) {
 	categoryRepo := mocks.NewMockCategoryRepository()
 	service := NewProductService(productRepo, categoryRepo)
 
-	productRepo.GetAllFunc = func(name string) ([]*model.Product, error) {
-		if name == "laptop" {
+	productRepo.GetAllFunc = func(filter model.ProductFilter) ([]*model.Product, error) {
+		if filter.Name == "laptop" {
 			return []*model.Product{{ID: 1, Name: "Laptop", Price: 1000, Stock: 10}}, nil
 		}
 		return []*model.Product{}, nil
 	}
 
-	products, err := service.GetAll("laptop")
+	products, err := service.GetAll(model.ProductFilter{Name: "laptop"})
 	if err != nil {
 		t.Errorf("GetAll should not return error, got: %v", err)
 	}
@@ -68,11 +72,11 @@ func TestProductService_GetAll_Error(t *testing.T) {
 	service := NewProductService(productRepo, categoryRepo)
 
 	expectedErr := errors.New("database error")
-	productRepo.GetAllFunc = func(name string) ([]*model.Product, error) {
+	productRepo.GetAllFunc = func(filter model.ProductFilter) ([]*model.Product, error) {
 		return nil, expectedErr
 	}
 
-	_, err := service.GetAll("")
+	_, err := service.GetAll(model.ProductFilter{})
 	if err != expectedErr {
 		t.Errorf("GetAll should return the error from repo, got: %v", err)
 	}
@@ -298,6 +302,29 @@ func TestProductService_Update_Success(t *testing.T) {
 	}
 }
 
+func TestProductService_Update_AppliesDuePriceChangesFirst(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Rokok", Price: 28000, Stock: 10}
+	var calls []string
+	productRepo.ApplyDuePriceChangesFunc = func(time.Time) error {
+		calls = append(calls, "apply")
+		return nil
+	}
+	productRepo.UpdateFunc = func(*model.Product) error {
+		calls = append(calls, "update")
+		return nil
+	}
+
+	if _, err := service.Update(1, &model.Product{Name: "Rokok", Price: 27000, Stock: 10}); err != nil {
+		t.Fatalf("Update should not return error, got: %v", err)
+	}
+	if !slices.Equal(calls, []string{"apply", "update"}) {
+		t.Errorf("Due prices should be applied before the update so they cannot overwrite it, got: %v", calls)
+	}
+}
+
 func TestProductService_Update_InvalidID(t *testing.T) {
 	productRepo := mocks.NewMockProductRepository()
 	categoryRepo := mocks.NewMockCategoryRepository()
@@ -387,23 +414,62 @@ func TestProductService_Update_RepoError(t *testing.T) {
 	}
 }
 
-func TestProductService_Delete_Success(t *testing.T) {
+func TestProductService_Archive_Success(t *testing.T) {
 	productRepo := mocks.NewMockProductRepository()
 	categoryRepo := mocks.NewMockCategoryRepository()
 	service := NewProductService(productRepo, categoryRepo)
 
 	productRepo.Products[1] = &model.Product{ID: 1, Name: "Laptop", Price: 1000, Stock: 10}
 
-	err := service.Delete(1)
+	err := service.Archive(1, 0)
 	if err != nil {
-		t.Errorf("Delete should not return error, got: %v", err)
+		t.Errorf("Archive should not return error, got: %v", err)
+	}
+	if !productRepo.Products[1].IsArchived() {
+		t.Error("Archive should mark the product as archived")
+	}
+	products, _ := service.GetAll(model.ProductFilter{})
+	if len(products) != 0 {
+		t.Errorf("GetAll should hide archived products, got: %d", len(products))
 	}
-	if _, exists := productRepo.Products[1]; exists {
-		t.Error("Delete should remove the product from repo")
+	products, _ = service.GetAll(model.ProductFilter{IncludeArchived: true})
+	if len(products) != 1 {
+		t.Errorf("GetAll with includeArchived should return archived products, got: %d", len(products))
 	}
 }
 
-func TestProductService_Delete_InvalidID(t *testing.T) {
+func TestProductService_Restore_Success(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewProductService(productRepo, categoryRepo)
+
+	archivedAt := time.Now()
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Laptop", Price: 1000, Stock: 10, ArchivedAt: &archivedAt}
+
+	if err := service.Restore(1); err != nil {
+		t.Errorf("Restore should not return error, got: %v", err)
+	}
+	if productRepo.Products[1].IsArchived() {
+		t.Error("Restore should clear archived_at")
+	}
+}
+
+func TestProductService_Create_ArchivedCategory(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewProductService(productRepo, categoryRepo)
+
+	archivedAt := time.Now()
+	categoryRepo.Categories[1] = &model.Category{ID: 1, Name: "Old", ArchivedAt: &archivedAt}
+
+	categoryID := 1
+	_, err := service.Create(&model.Product{Name: "Laptop", Price: 1000, Stock: 10, CategoryID: &categoryID})
+	if !errors.Is(err, model.ErrCategoryArchived) {
+		t.Errorf("Create with archived category should return ErrCategoryArchived, got: %v", err)
+	}
+}
+
+func TestProductService_Archive_InvalidID(t *testing.T) {
 	productRepo := mocks.NewMockProductRepository()
 	categoryRepo := mocks.NewMockCategoryRepository()
 	service := NewProductService(productRepo, categoryRepo)
@@ -418,21 +484,354 @@ func TestProductService_Delete_InvalidID(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			err := service.Delete(tc.id)
+			err := service.Archive(tc.id, 0)
 			if !errors.Is(err, model.ErrProductNotFound) {
-				t.Errorf("Delete with %s id should return ErrProductNotFound, got: %v", tc.name, err)
+				t.Errorf("Archive with %s id should return ErrProductNotFound, got: %v", tc.name, err)
 			}
 		})
 	}
 }
 
-func TestProductService_Delete_NotFound(t *testing.T) {
+func TestProductService_Archive_NotFound(t *testing.T) {
 	productRepo := mocks.NewMockProductRepository()
 	categoryRepo := mocks.NewMockCategoryRepository()
 	service := NewProductService(productRepo, categoryRepo)
 
-	err := service.Delete(999)
+	err := service.Archive(999, 0)
 	if !errors.Is(err, model.ErrProductNotFound) {
-		t.Errorf("Delete should return ErrProductNotFound, got: %v", err)
+		t.Errorf("Archive should return ErrProductNotFound, got: %v", err)
+	}
+}
+
+func TestProductService_SchedulePriceChange_Success(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewProductService(productRepo, categoryRepo)
+
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Rokok", Price: 28000, Stock: 10}
+
+	change, err := service.SchedulePriceChange(1, &model.PriceChange{NewPrice: 30000, EffectiveAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("SchedulePriceChange should not return error, got: %v", err)
+	}
+	if change.ProductID != 1 {
+		t.Errorf("SchedulePriceChange should set product ID, got: %d", change.ProductID)
+	}
+	if len(productRepo.PriceChanges) != 1 {
+		t.Errorf("SchedulePriceChange should store the change, got: %d", len(productRepo.PriceChanges))
+	}
+}
+
+func TestProductService_SchedulePriceChange_Validation(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewProductService(productRepo, categoryRepo)
+
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Rokok", Price: 28000, Stock: 10}
+
+	testCases := []struct {
+		name     string
+		id       int
+		change   *model.PriceChange
+		expected error
+	}{
+		{"invalid id", 0, &model.PriceChange{NewPrice: 1000, EffectiveAt: time.Now().Add(time.Hour)}, model.ErrProductNotFound},
+		{"zero price", 1, &model.PriceChange{NewPrice: 0, EffectiveAt: time.Now().Add(time.Hour)}, model.ErrPriceInvalid},
+		{"past time", 1, &model.PriceChange{NewPrice: 1000, EffectiveAt: time.Now().Add(-time.Hour)}, model.ErrEffectiveAtNotInFuture},
+		{"unknown product", 999, &model.PriceChange{NewPrice: 1000, EffectiveAt: time.Now().Add(time.Hour)}, model.ErrProductNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.SchedulePriceChange(tc.id, tc.change)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("SchedulePriceChange should return %v, got: %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestProductService_GetPriceChanges_FilterByStatus(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewProductService(productRepo, categoryRepo)
+
+	appliedAt := time.Now().Add(-time.Hour)
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Rokok", Price: 28000, Stock: 10}
+	productRepo.PriceChanges = []*model.PriceChange{
+		{ID: 1, ProductID: 1, NewPrice: 28000, EffectiveAt: appliedAt, AppliedAt: &appliedAt},
+		{ID: 2, ProductID: 1, NewPrice: 30000, EffectiveAt: time.Now().Add(time.Hour)},
+	}
+
+	page := model.PageRequest{Page: 1, Limit: 20}
+	upcoming, _ := service.GetPriceChanges(1, model.PriceChangeUpcoming, page)
+	if len(upcoming.Items) != 1 || upcoming.Items[0].ID != 2 {
+		t.Errorf("Upcoming filter should return only the pending change, got: %v", upcoming.Items)
+	}
+	past, _ := service.GetPriceChanges(1, model.PriceChangePast, page)
+	if len(past.Items) != 1 || past.Items[0].ID != 1 {
+		t.Errorf("Past filter should return only the applied change, got: %v", past.Items)
+	}
+	all, _ := service.GetPriceChanges(1, "", page)
+	if all.Total != 2 {
+		t.Errorf("No filter should return all changes, got: %d", all.Total)
+	}
+	byID := model.PageRequest{Limit: 20, After: &model.Cursor{ID: 2}}
+	if _, err := service.GetPriceChanges(1, "", byID); !errors.Is(err, model.ErrInvalidCursor) {
+		t.Errorf("A cursor from another listing should return ErrInvalidCursor, got: %v", err)
+	}
+}
+
+func TestProductService_ReadsDoNotApplyDuePriceChanges(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewProductService(productRepo, categoryRepo)
+
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Rokok", Price: 28000, Stock: 10}
+	productRepo.ApplyDuePriceChangesFunc = func(now time.Time) error {
+		t.Error("Reads should not apply due price changes")
+		return nil
+	}
+
+	service.GetByID(1)
+	service.GetAll(model.ProductFilter{})
+	service.GetPriceChanges(1, "", model.PageRequest{Page: 1, Limit: 20})
+	service.Export(io.Discard, model.ImportFormatCSV, model.ProductFilter{})
+}
+
+func TestProductService_RunPriceScheduler(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+	productRepo.ApplyDuePriceChangesFunc = func(time.Time) error {
+		runs++
+		if runs == 2 {
+			cancel()
+			return errors.New("database is down")
+		}
+		return nil
+	}
+	var reported error
+	service.RunPriceScheduler(ctx, time.Millisecond, func(err error) { reported = err })
+	if runs != 2 || reported == nil {
+		t.Errorf("RunPriceScheduler should run until cancelled and report failures, got %d runs and error %v", runs, reported)
+	}
+}
+
+func TestProductService_Suggest(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+
+	var got model.ProductFilter
+	productRepo.GetAllFunc = func(filter model.ProductFilter) ([]*model.Product, error) {
+		got = filter
+		return []*model.Product{{ID: 7, Name: "Indomie Goreng", Price: 3500, Stock: 10, SKU: "IDM-01"}}, nil
+	}
+
+	suggestions, err := service.Suggest("  indomi ", 5)
+	if err != nil {
+		t.Fatalf("Suggest should not return error, got: %v", err)
+	}
+	if got.Name != "indomi" || got.Sort != model.ProductSortRelevance || got.Limit != 5 || got.IncludeArchived {
+		t.Errorf("Suggest should search active products by relevance, got filter: %+v", got)
+	}
+	if len(suggestions) != 1 || suggestions[0].ID != 7 || suggestions[0].SKU != "IDM-01" {
+		t.Errorf("Suggest should map products to suggestions, got: %+v", suggestions)
+	}
+
+	empty, err := service.Suggest("   ", 5)
+	if err != nil || len(empty) != 0 {
+		t.Errorf("Suggest with blank query should return no results, got: %v, %v", empty, err)
+	}
+}
+
+func TestProductService_GetPage(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+
+	var gotPage model.PageRequest
+	productRepo.GetPageFunc = func(filter model.ProductFilter, page model.PageRequest) (*model.Page[*model.Product], error) {
+		gotPage = page
+		return &model.Page[*model.Product]{Items: []*model.Product{{ID: 21, Name: "Teh Botol", Price: 5000, Stock: 3}}, Total: 41}, nil
+	}
+
+	result, err := service.GetPage(model.ProductFilter{}, model.PageRequest{Page: 2, Limit: 20})
+	if err != nil {
+		t.Fatalf("GetPage should not return error, got: %v", err)
+	}
+	if gotPage.Page != 2 || gotPage.Limit != 20 {
+		t.Errorf("GetPage should pass the page through, got: %+v", gotPage)
+	}
+	if result.Total != 41 || len(result.Items) != 1 {
+		t.Errorf("GetPage should return the repository page and total, got: %d items, total %d", len(result.Items), result.Total)
+	}
+}
+
+func TestProductService_GetPage_CursorMustMatchSort(t *testing.T) {
+	service := NewProductService(mocks.NewMockProductRepository(), mocks.NewMockCategoryRepository())
+
+	tests := []struct {
+		name   string
+		filter model.ProductFilter
+		after  *model.Cursor
+	}{
+		{"relevance has no cursor", model.ProductFilter{Name: "kopi", Sort: model.ProductSortRelevance}, &model.Cursor{ID: 1}},
+		{"different sort", model.ProductFilter{Sort: model.ProductSortPrice}, &model.Cursor{Sort: model.ProductSortName, ID: 1}},
+		{"different order", model.ProductFilter{Sort: model.ProductSortPrice}, &model.Cursor{Sort: model.ProductSortPrice, Desc: true, ID: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.GetPage(tt.filter, model.PageRequest{Page: 1, Limit: 20, After: tt.after})
+			if !errors.Is(err, model.ErrInvalidCursor) {
+				t.Errorf("GetPage should return ErrInvalidCursor, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestProductService_Create_Bundle(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Kopi", Price: 8000, Stock: 10}
+	productRepo.Products[2] = &model.Product{ID: 2, Name: "Paket", Price: 12000,
+		Components: []model.BundleComponent{{ProductID: 1, Quantity: 1}}}
+	archivedAt := time.Now()
+	productRepo.Products[3] = &model.Product{ID: 3, Name: "Roti Lama", Price: 5000, ArchivedAt: &archivedAt}
+	productRepo.NextID = 4
+
+	tests := []struct {
+		name       string
+		components []model.BundleComponent
+	}{
+		{"missing product", []model.BundleComponent{{ProductID: 99, Quantity: 1}}},
+		{"archived product", []model.BundleComponent{{ProductID: 3, Quantity: 1}}},
+		{"nested bundle", []model.BundleComponent{{ProductID: 2, Quantity: 1}}},
+		{"listed twice", []model.BundleComponent{{ProductID: 1, Quantity: 1}, {ProductID: 1, Quantity: 2}}},
+		{"zero quantity", []model.BundleComponent{{ProductID: 1, Quantity: 0}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Create(&model.Product{Name: "Bundle", Price: 10000, Components: tt.components})
+			if !errors.Is(err, model.ErrBundleInvalid) {
+				t.Errorf("Create should return ErrBundleInvalid, got: %v", err)
+			}
+		})
+	}
+
+	created, err := service.Create(&model.Product{Name: "Paket Hemat", Price: 15000, Stock: 50,
+		Components: []model.BundleComponent{{ProductID: 1, Quantity: 2}}})
+	if err != nil {
+		t.Fatalf("Create with valid components should not return error, got: %v", err)
+	}
+	if created.Stock != 0 {
+		t.Errorf("A bundle should not keep stock of its own, got: %d", created.Stock)
+	}
+
+	_, err = service.Update(1, &model.Product{Name: "Kopi", Price: 8000,
+		Components: []model.BundleComponent{{ProductID: 3, Quantity: 1}}})
+	if !errors.Is(err, model.ErrBundleInvalid) {
+		t.Errorf("A bundle component should not become a bundle itself, got: %v", err)
+	}
+}
+
+func TestProductService_Create_Unit(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Beras", Price: 14000, Stock: 5000, Unit: model.UnitKilogram}
+	productRepo.NextID = 2
+
+	created, err := service.Create(&model.Product{Name: "Indomie", Price: 3500, Stock: 10})
+	if err != nil {
+		t.Fatalf("Create should not return error, got: %v", err)
+	}
+	if created.Unit != model.UnitPiece {
+		t.Errorf("A product without unit should be sold per piece, got: %q", created.Unit)
+	}
+
+	if _, err := service.Create(&model.Product{Name: "Gula", Price: 16000, Unit: "ons"}); !errors.Is(err, model.ErrUnitInvalid) {
+		t.Errorf("Create with an unknown unit should return ErrUnitInvalid, got: %v", err)
+	}
+
+	_, err = service.Create(&model.Product{Name: "Paket Beras", Price: 30000, Unit: model.UnitKilogram,
+		Components: []model.BundleComponent{{ProductID: 1, Quantity: 2000}}})
+	if !errors.Is(err, model.ErrBundleInvalid) {
+		t.Errorf("A bundle sold by weight should return ErrBundleInvalid, got: %v", err)
+	}
+}
+
+func TestProductService_ScanBarcode(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+	productRepo.NextID = 1
+
+	created, err := service.Create(&model.Product{Name: "Keju", Price: 14000, Stock: 5000, Unit: model.UnitKilogram, PLU: "00123"})
+	if err != nil {
+		t.Fatalf("Create should not return error, got: %v", err)
+	}
+	if created.PLU != "123" {
+		t.Errorf("PLU should be stored without leading zeros, got: %q", created.PLU)
+	}
+
+	scan, err := service.ScanBarcode("2000123012506")
+	if err != nil {
+		t.Fatalf("ScanBarcode should not return error, got: %v", err)
+	}
+	if scan.Product.ID != created.ID || scan.Quantity != 1250 || scan.Subtotal != 17500 {
+		t.Errorf("ScanBarcode should resolve 1250 g of Keju for 17500, got: %+v", scan)
+	}
+
+	// Price labels on 21 with a 4-digit PLU and a 6-digit price.
+	rules, err := model.ParseScaleBarcodeRules("21:price:2:4")
+	if err != nil {
+		t.Fatalf("ParseScaleBarcodeRules should not return error, got: %v", err)
+	}
+	service.SetScaleBarcodeRules(rules)
+	if _, err := service.ScanBarcode("2000123012506"); !errors.Is(err, model.ErrBarcodeNotScale) {
+		t.Errorf("A prefix outside the configured rules should return ErrBarcodeNotScale, got: %v", err)
+	}
+	scan, err = service.ScanBarcode("2101230070004")
+	if err != nil {
+		t.Fatalf("ScanBarcode should not return error, got: %v", err)
+	}
+	if scan.Label.Kind != model.ScaleValuePrice || scan.Quantity != 500 || scan.Subtotal != 7000 {
+		t.Errorf("ScanBarcode should resolve Rp 7000 of Keju as 500 g, got: %+v", scan)
+	}
+
+	if _, err := service.Create(&model.Product{Name: "Nol", Price: 1000, PLU: "000"}); !errors.Is(err, model.ErrPLUInvalid) {
+		t.Errorf("A PLU of only zeros should return ErrPLUInvalid, got: %v", err)
+	}
+}
+
+func TestProductService_Create_Type(t *testing.T) {
+	productRepo := mocks.NewMockProductRepository()
+	service := NewProductService(productRepo, mocks.NewMockCategoryRepository())
+	productRepo.NextID = 1
+
+	created, err := service.Create(&model.Product{Name: "Indomie", Price: 3500, Stock: 10})
+	if err != nil {
+		t.Fatalf("Create should not return error, got: %v", err)
+	}
+	if created.Type != model.ProductTypeStocked {
+		t.Errorf("A product without type should be stocked, got: %q", created.Type)
+	}
+
+	created, err = service.Create(&model.Product{Name: "Ongkos Kirim", Price: 10000, Stock: 5, Type: model.ProductTypeService, AllowNegativeStock: true})
+	if err != nil {
+		t.Fatalf("Create should not return error, got: %v", err)
+	}
+	if created.Stock != 0 || created.AllowNegativeStock {
+		t.Errorf("A service should keep no stock and no stock policy, got: %+v", created)
+	}
+
+	if _, err := service.Create(&model.Product{Name: "Gula", Price: 16000, Type: "consignment"}); !errors.Is(err, model.ErrTypeInvalid) {
+		t.Errorf("Create with an unknown type should return ErrTypeInvalid, got: %v", err)
+	}
+	if _, err := service.Create(&model.Product{Name: "Gula", Price: 16000, Stock: -1}); !errors.Is(err, model.ErrStockInvalid) {
+		t.Errorf("Negative stock without the policy should return ErrStockInvalid, got: %v", err)
+	}
+	if _, err := service.Create(&model.Product{Name: "Pulsa", Price: 5000, Stock: -1, AllowNegativeStock: true}); err != nil {
+		t.Errorf("Negative stock with the policy should be accepted, got: %v", err)
 	}
 }
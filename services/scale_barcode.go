@@ -0,0 +1,43 @@
+package service
+
+import (
+	model "kasir-api/models"
+	repository "kasir-api/repositories"
+)
+
+// defaultScaleRules are the rules services start with until SetScaleBarcodeRules replaces them.
+var defaultScaleRules, _ = model.ParseScaleBarcodeRules(model.DefaultScaleBarcodeRules)
+
+// resolveScaleLabel decodes a scale label and works out which product it is for, how much of it
+// to sell and for how much. A weight label sells that many grams or milliliters of a measured
+// product. A price label sells what its price buys and charges exactly the label price.
+func resolveScaleLabel(repo repository.ProductRepository, rules []model.ScaleBarcodeRule, code string) (*model.ScaleScan, error) {
+	label, err := model.ParseScaleBarcode(code, rules)
+	if err != nil {
+		return nil, err
+	}
+	product, err := repo.GetByPLU(label.PLU)
+	if err != nil {
+		return nil, err
+	}
+	scan := &model.ScaleScan{Label: *label, Product: product}
+	switch label.Kind {
+	case model.ScaleValueWeight:
+		if !product.IsMeasured() {
+			return nil, model.ErrScaleLabelUnit
+		}
+		scan.Quantity = label.Value
+		scan.Subtotal = product.LineTotal(label.Value)
+	case model.ScaleValuePrice:
+		scan.Quantity = product.QuantityFor(label.Value)
+		// A piece product must come out at a whole number of pieces.
+		if !product.IsMeasured() && product.LineTotal(scan.Quantity) != label.Value {
+			return nil, model.ErrScaleLabelUnit
+		}
+		scan.Subtotal = label.Value
+	}
+	if scan.Quantity <= 0 {
+		return nil, model.ErrInvalidQuantity
+	}
+	return scan, nil
+}
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	model "kasir-api/models"
@@ -10,68 +12,280 @@ import (
 // TransactionService handles business logic for transactions.
 // Service layer: logic kode kita. Error logic → cek sini.
 type TransactionService struct {
-	repo        repository.TransactionRepository
-	productRepo repository.ProductRepository
+	repo           repository.TransactionRepository
+	productRepo    repository.ProductRepository
+	ingredientRepo repository.IngredientRepository
+	categoryRepo   repository.CategoryRepository
+	scaleRules     []model.ScaleBarcodeRule
+	customItems    model.CustomItemPolicy
+	location       *time.Location
 }
 
 // NewTransactionService creates a new TransactionService.
 func NewTransactionService(repo repository.TransactionRepository, productRepo repository.ProductRepository) *TransactionService {
-	return &TransactionService{repo: repo, productRepo: productRepo}
+	return &TransactionService{repo: repo, productRepo: productRepo, scaleRules: defaultScaleRules, location: defaultLocation}
+}
+
+// SetLocation sets the store timezone: it decides where today starts and ends, the days of report
+// date ranges and the hours of the heatmap. It must be a named IANA location, which PostgreSQL can
+// resolve; it defaults to model.DefaultStoreTimezone, never to the server's timezone.
+func (s *TransactionService) SetLocation(loc *time.Location) {
+	s.location = loc
+}
+
+// Location returns the store timezone report dates are read in.
+func (s *TransactionService) Location() *time.Location {
+	return s.location
+}
+
+// SetCategoryRepository lets custom items name a category, which must exist and be active, and names
+// the categories of the category report.
+func (s *TransactionService) SetCategoryRepository(repo repository.CategoryRepository) {
+	s.categoryRepo = repo
+}
+
+// SetCustomItemPolicy decides whether and by whom custom items may be sold. They are off by default.
+func (s *TransactionService) SetCustomItemPolicy(policy model.CustomItemPolicy) {
+	s.customItems = policy
+}
+
+// SetScaleBarcodeRules sets how scale labels on checkout items are decoded.
+func (s *TransactionService) SetScaleBarcodeRules(rules []model.ScaleBarcodeRule) {
+	s.scaleRules = rules
+}
+
+// SetIngredientRepository makes checkout deduct the recipe ingredients of the products sold.
+func (s *TransactionService) SetIngredientRepository(repo repository.IngredientRepository) {
+	s.ingredientRepo = repo
 }
 
 // Checkout processes a checkout request and creates a transaction.
+// Products sold by weight or volume take their quantity in grams or milliliters and are priced
+// per kilogram or liter; their subtotal is rounded to the rupiah. An item may carry a scale label
+// barcode instead, which names the product and the quantity (see resolveScaleLabel).
+// Stock is checked and taken at the request's outlet (the default outlet when none is given).
+// Non-stocked products and services are sold without touching stock, and a product that allows
+// negative stock is sold past zero. Custom items, outside the catalog, need the custom item policy
+// to allow the request's role and are stored without a product.
+// A bundle is sold at its own price and takes its stock from the components: each component
+// gets a detail line with price 0 pointing back at the bundle.
+// Stock is read here to reject a short sale early; the stock taken, the transaction and the
+// ingredient movements are then written in one repository call, which checks stock again.
 func (s *TransactionService) Checkout(request *model.CheckoutRequest) (*model.Transaction, error) {
 	if len(request.Items) == 0 {
 		return nil, model.ErrEmptyCheckout
 	}
+	outletID := request.OutletID
+	if outletID == 0 {
+		outletID = model.DefaultOutletID
+	}
+
+	// Scheduled prices that became due must apply before we read product prices.
+	if err := s.productRepo.ApplyDuePriceChanges(time.Now()); err != nil {
+		return nil, err
+	}
 
 	transaction := &model.Transaction{
+		OutletID:  outletID,
 		CreatedAt: time.Now(),
 	}
 
 	totalAmount := 0
 	for _, item := range request.Items {
-		if item.Quantity <= 0 {
-			return nil, model.ErrInvalidQuantity
+		if item.IsCustom() {
+			detail, err := s.customLine(item, request.Role)
+			if err != nil {
+				return nil, err
+			}
+			totalAmount += detail.Subtotal
+			transaction.Details = append(transaction.Details, *detail)
+			continue
 		}
 
-		product, err := s.productRepo.GetByID(item.ProductID)
+		product, quantity, subtotal, err := s.checkoutLine(item)
 		if err != nil {
 			return nil, err
 		}
+		if product.IsArchived() {
+			return nil, model.ErrProductArchived
+		}
 
-		if product.Stock < item.Quantity {
-			return nil, model.ErrInsufficientStock
+		// A bundle's stock is checked per component in componentLines.
+		if !product.IsBundle() {
+			if err := s.checkStock(product, quantity, outletID); err != nil {
+				return nil, err
+			}
 		}
 
-		subtotal := product.Price * item.Quantity
 		totalAmount += subtotal
 
 		detail := model.TransactionDetail{
 			ProductID:   product.ID,
 			ProductName: product.Name,
-			Quantity:    item.Quantity,
+			Quantity:    quantity,
+			Unit:        product.Unit,
 			Price:       product.Price,
 			Subtotal:    subtotal,
+			// The category is snapshot so moving the product later does not rewrite past reports.
+			CategoryID: product.CategoryID,
 		}
 		transaction.Details = append(transaction.Details, detail)
 
-		// Update product stock
-		product.Stock -= item.Quantity
-		if err := s.productRepo.Update(product); err != nil {
-			return nil, err
+		if product.IsBundle() {
+			components, err := s.componentLines(product, quantity, outletID)
+			if err != nil {
+				return nil, err
+			}
+			transaction.Details = append(transaction.Details, components...)
 		}
 	}
 
 	transaction.TotalAmount = totalAmount
 
-	if err := s.repo.Create(transaction); err != nil {
+	movements, err := s.saleMovements(transaction)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Checkout(&model.CheckoutBatch{Transaction: transaction, Movements: movements}); err != nil {
 		return nil, err
 	}
 
 	return transaction, nil
 }
 
+// checkStock fails with ErrInsufficientStock when the outlet has less than quantity of the product.
+// Products without stock and products allowed to go negative always pass.
+func (s *TransactionService) checkStock(product *model.Product, quantity, outletID int) error {
+	if !product.TracksStock() {
+		return nil
+	}
+	stock, err := s.productRepo.GetOutletStock(product.ID, outletID)
+	if err != nil {
+		return err
+	}
+	if stock < quantity && !product.AllowNegativeStock {
+		return model.ErrInsufficientStock
+	}
+	return nil
+}
+
+// customLine returns the detail of a custom item after checking the policy allows the role to sell one.
+func (s *TransactionService) customLine(item model.CheckoutItem, role string) (*model.TransactionDetail, error) {
+	if !s.customItems.Enabled {
+		return nil, model.ErrCustomItemDisabled
+	}
+	if len(s.customItems.Roles) > 0 && !slices.Contains(s.customItems.Roles, role) {
+		return nil, model.ErrCustomItemRole
+	}
+	name := strings.TrimSpace(item.Name)
+	if name == "" || item.Price <= 0 {
+		return nil, model.ErrCustomItemInvalid
+	}
+	if item.Quantity <= 0 {
+		return nil, model.ErrInvalidQuantity
+	}
+	if item.CategoryID != nil {
+		if s.categoryRepo == nil {
+			return nil, model.ErrCategoryNotFound
+		}
+		category, err := s.categoryRepo.GetByID(*item.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		if category.IsArchived() {
+			return nil, model.ErrCategoryArchived
+		}
+	}
+	return &model.TransactionDetail{
+		ProductName: name,
+		Quantity:    item.Quantity,
+		Unit:        model.UnitPiece,
+		Price:       item.Price,
+		Subtotal:    item.Price * item.Quantity,
+		CategoryID:  item.CategoryID,
+	}, nil
+}
+
+// checkoutLine returns the product, quantity and subtotal of a checkout item, read from its scale
+// label when it has one.
+func (s *TransactionService) checkoutLine(item model.CheckoutItem) (*model.Product, int, int, error) {
+	if item.Barcode != "" {
+		scan, err := resolveScaleLabel(s.productRepo, s.scaleRules, item.Barcode)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		return scan.Product, scan.Quantity, scan.Subtotal, nil
+	}
+	if item.Quantity <= 0 {
+		return nil, 0, 0, model.ErrInvalidQuantity
+	}
+	product, err := s.productRepo.GetByID(item.ProductID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	return product, item.Quantity, product.LineTotal(item.Quantity), nil
+}
+
+// saleMovements returns a sale movement per ingredient used by the recipes of the sold lines,
+// ordered by ingredient ID. Ingredient stock may go negative; a sale is never refused because of it.
+func (s *TransactionService) saleMovements(transaction *model.Transaction) ([]*model.IngredientMovement, error) {
+	if s.ingredientRepo == nil {
+		return nil, nil
+	}
+	used := make(map[int]model.Quantity)
+	for _, d := range transaction.Details {
+		if d.IsCustom() {
+			continue
+		}
+		recipe, err := s.ingredientRepo.GetRecipe(d.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range recipe {
+			used[item.IngredientID] += item.Usage(d.Quantity, d.Unit)
+		}
+	}
+
+	movements := make([]*model.IngredientMovement, 0, len(used))
+	for id, quantity := range used {
+		movements = append(movements, &model.IngredientMovement{
+			IngredientID: id,
+			Type:         model.MovementSale,
+			Quantity:     -quantity,
+			OutletID:     transaction.OutletID,
+		})
+	}
+	slices.SortFunc(movements, func(a, b *model.IngredientMovement) int { return a.IngredientID - b.IngredientID })
+	return movements, nil
+}
+
+// componentLines checks the outlet holds the components of quantity bundles and returns their
+// detail lines, which take the components' stock at checkout.
+func (s *TransactionService) componentLines(bundle *model.Product, quantity, outletID int) ([]model.TransactionDetail, error) {
+	details := make([]model.TransactionDetail, 0, len(bundle.Components))
+	for _, c := range bundle.Components {
+		component, err := s.productRepo.GetByID(c.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if component.IsArchived() {
+			return nil, model.ErrProductArchived
+		}
+		need := c.Quantity * quantity
+		if err := s.checkStock(component, need, outletID); err != nil {
+			return nil, err
+		}
+		details = append(details, model.TransactionDetail{
+			ProductID:       component.ID,
+			ProductName:     component.Name,
+			Quantity:        need,
+			Unit:            component.Unit,
+			BundleProductID: &bundle.ID,
+		})
+	}
+	return details, nil
+}
+
 // GetByID retrieves a transaction by ID.
 func (s *TransactionService) GetByID(id int) (*model.Transaction, error) {
 	if id <= 0 {
@@ -80,17 +294,142 @@ func (s *TransactionService) GetByID(id int) (*model.Transaction, error) {
 	return s.repo.GetByID(id)
 }
 
-// GetTodayReport returns the report for today.
-func (s *TransactionService) GetTodayReport() (*model.ReportResponse, error) {
-	now := time.Now()
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+// GetTodayReport returns the report for today, for one outlet or all outlets when outletID is 0.
+func (s *TransactionService) GetTodayReport(outletID int) (*model.ReportResponse, error) {
+	now := time.Now().In(s.location)
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
 	endOfDay := startOfDay.AddDate(0, 0, 1)
-	return s.repo.GetReportByDateRange(startOfDay, endOfDay)
+	return s.report(startOfDay, endOfDay, outletID)
 }
 
-// GetReportByDateRange returns the report for a given date range.
-func (s *TransactionService) GetReportByDateRange(startDate, endDate time.Time) (*model.ReportResponse, error) {
+// GetReportByDateRange returns the report for a given date range, for one outlet or all outlets when outletID is 0.
+func (s *TransactionService) GetReportByDateRange(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
 	// Add one day to endDate to include the entire end day
 	endDate = endDate.AddDate(0, 0, 1)
-	return s.repo.GetReportByDateRange(startDate, endDate)
+	return s.report(startDate, endDate, outletID)
+}
+
+// GetTimeSeries returns the sales between two dates, both days included, split into buckets of the
+// given granularity (day when empty), for one outlet or all outlets when outletID is 0.
+func (s *TransactionService) GetTimeSeries(startDate, endDate time.Time, granularity string, outletID int) (*model.TimeSeriesReport, error) {
+	if granularity == "" {
+		granularity = model.GranularityDay
+	}
+	if !slices.Contains(model.Granularities, granularity) {
+		return nil, model.ErrGranularityInvalid
+	}
+	return s.repo.GetTimeSeries(startDate, endDate.AddDate(0, 0, 1), granularity, outletID)
+}
+
+// GetCategorySales returns the sales per category between two dates, both days included, for one outlet
+// or all outlets when outletID is 0. Categories are named by their current name and their sales are
+// rolled up into every ancestor along the current parent_id path.
+func (s *TransactionService) GetCategorySales(startDate, endDate time.Time, outletID int) ([]*model.CategorySales, error) {
+	rows, err := s.repo.GetCategorySales(startDate, endDate.AddDate(0, 0, 1), outletID)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err = s.rollUpCategories(rows); err != nil {
+		return nil, err
+	}
+	model.SetCategoryShares(rows)
+	slices.SortStableFunc(rows, model.CompareCategorySales)
+	return rows, nil
+}
+
+// rollUpCategories names the rows and adds the sales of every category to the totals of its ancestors,
+// adding a row for an ancestor without sales of its own. Categories are archived, never deleted, so
+// archived ones keep their name and place in the tree here.
+func (s *TransactionService) rollUpCategories(rows []*model.CategorySales) ([]*model.CategorySales, error) {
+	byID := make(map[int]*model.CategorySales, len(rows))
+	for _, row := range rows {
+		row.TotalItemTerjual, row.TotalRevenue = row.ItemTerjual, row.Revenue
+		if row.CategoryID != nil {
+			byID[*row.CategoryID] = row
+		}
+	}
+
+	named := make(map[int]bool, len(rows))
+	lookup := func(id int) (*model.CategorySales, error) {
+		row, ok := byID[id]
+		if named[id] {
+			return row, nil
+		}
+		category, err := s.categoryRepo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			row = &model.CategorySales{CategoryID: &category.ID}
+			byID[id] = row
+			rows = append(rows, row)
+		}
+		row.Nama, row.ParentID = category.Name, category.ParentID
+		named[id] = true
+		return row, nil
+	}
+
+	// The range is evaluated once, so ancestor rows appended by lookup are not walked again.
+	for _, row := range rows {
+		switch {
+		case row.Custom:
+			row.Nama = model.CustomItemsName
+			continue
+		case row.CategoryID == nil:
+			row.Nama = model.UncategorizedName
+			continue
+		case s.categoryRepo == nil:
+			continue
+		}
+		current, err := lookup(*row.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		for current.ParentID != nil {
+			if current, err = lookup(*current.ParentID); err != nil {
+				return nil, err
+			}
+			current.TotalItemTerjual += row.ItemTerjual
+			current.TotalRevenue += row.Revenue
+		}
+	}
+	return rows, nil
+}
+
+// GetProductSales ranks the products sold between two dates, both days included, for one outlet or
+// all outlets when outletID is 0. Products are named by their current name, archived ones included.
+func (s *TransactionService) GetProductSales(startDate, endDate time.Time, outletID int, query model.ProductSalesQuery) ([]*model.ProductSales, error) {
+	rows, err := s.repo.GetProductSales(startDate, endDate.AddDate(0, 0, 1), outletID, query)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		product, err := s.productRepo.GetByID(row.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		row.Nama = product.Name
+		row.Unit = product.Unit
+	}
+	return rows, nil
+}
+
+// GetHeatmap returns the sales between two dates, both days included, by day of week and hour of day
+// in the store timezone, for one outlet or all outlets when outletID is 0.
+func (s *TransactionService) GetHeatmap(startDate, endDate time.Time, outletID int) (*model.SalesHeatmap, error) {
+	return s.repo.GetHeatmap(startDate, endDate.AddDate(0, 0, 1), s.location, outletID)
+}
+
+// report returns the sales report for [startDate, endDate) and flags the products whose current
+// stock is below zero, at the outlet or at any outlet when outletID is 0.
+func (s *TransactionService) report(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
+	report, err := s.repo.GetReportByDateRange(startDate, endDate, outletID)
+	if err != nil {
+		return nil, err
+	}
+	report.StokNegatif, err = s.productRepo.GetNegativeStock(outletID)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
 }
@@ -29,6 +29,7 @@ func TestTransactionService_Checkout_Success(t *testing.T) {
 	transactionRepo := mocks.NewMockTransactionRepository()
 	productRepo := mocks.NewMockProductRepository()
 	service := NewTransactionService(transactionRepo, productRepo)
+	transactionRepo.Products = productRepo
 
 	productRepo.Products[1] = &model.Product{ID: 1, Name: "Laptop", Price: 1000, Stock: 10}
 	productRepo.Products[2] = &model.Product{ID: 2, Name: "Phone", Price: 500, Stock: 20}
@@ -144,16 +145,40 @@ func TestTransactionService_Checkout_InsufficientStock(t *testing.T) {
 	}
 }
 
-func TestTransactionService_Checkout_ProductUpdateError(t *testing.T) {
+func TestTransactionService_Checkout_ArchivedProduct(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	productRepo := mocks.NewMockProductRepository()
+	service := NewTransactionService(transactionRepo, productRepo)
+
+	archivedAt := time.Now()
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Laptop", Price: 1000, Stock: 5, ArchivedAt: &archivedAt}
+
+	request := &model.CheckoutRequest{
+		Items: []model.CheckoutItem{
+			{ProductID: 1, Quantity: 1},
+		},
+	}
+
+	_, err := service.Checkout(request)
+	if !errors.Is(err, model.ErrProductArchived) {
+		t.Errorf("Checkout with archived product should return ErrProductArchived, got: %v", err)
+	}
+}
+
+func TestTransactionService_Checkout_CheckoutError(t *testing.T) {
 	transactionRepo := mocks.NewMockTransactionRepository()
 	productRepo := mocks.NewMockProductRepository()
 	service := NewTransactionService(transactionRepo, productRepo)
 
 	productRepo.Products[1] = &model.Product{ID: 1, Name: "Laptop", Price: 1000, Stock: 10}
 
-	expectedErr := errors.New("database error")
+	// Stock is only taken by the repository checkout, so its failure must leave the product alone.
 	productRepo.UpdateFunc = func(product *model.Product) error {
-		return expectedErr
+		t.Error("Checkout should not update products itself")
+		return nil
+	}
+	transactionRepo.CheckoutFunc = func(batch *model.CheckoutBatch) error {
+		return model.ErrInsufficientStock
 	}
 
 	request := &model.CheckoutRequest{
@@ -163,8 +188,11 @@ func TestTransactionService_Checkout_ProductUpdateError(t *testing.T) {
 	}
 
 	_, err := service.Checkout(request)
-	if err != expectedErr {
-		t.Errorf("Checkout should return the error from product update, got: %v", err)
+	if !errors.Is(err, model.ErrInsufficientStock) {
+		t.Errorf("Checkout should return the error from the repository checkout, got: %v", err)
+	}
+	if productRepo.Products[1].Stock != 10 {
+		t.Errorf("A failed checkout should not change stock, got: %d", productRepo.Products[1].Stock)
 	}
 }
 
@@ -266,9 +294,9 @@ func TestTransactionService_GetTodayReport_Success(t *testing.T) {
 		},
 	}
 
-	transactionRepo.GetReportByDateRangeFunc = func(startDate, endDate time.Time) (*model.ReportResponse, error) {
-		// Verify that the date range is for today
-		now := time.Now()
+	transactionRepo.GetReportByDateRangeFunc = func(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
+		// Verify that the date range is today in the default store timezone, not the server's
+		now := time.Now().In(service.Location())
 		expectedStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 		expectedEnd := expectedStart.AddDate(0, 0, 1)
 
@@ -281,7 +309,7 @@ func TestTransactionService_GetTodayReport_Success(t *testing.T) {
 		return expectedReport, nil
 	}
 
-	report, err := service.GetTodayReport()
+	report, err := service.GetTodayReport(0)
 	if err != nil {
 		t.Errorf("GetTodayReport should not return error, got: %v", err)
 	}
@@ -293,17 +321,50 @@ func TestTransactionService_GetTodayReport_Success(t *testing.T) {
 	}
 }
 
+func TestTransactionService_DefaultLocation(t *testing.T) {
+	service := NewTransactionService(mocks.NewMockTransactionRepository(), mocks.NewMockProductRepository())
+	if got := service.Location().String(); got != model.DefaultStoreTimezone {
+		t.Errorf("The store timezone should default to %s rather than the server's, got: %s", model.DefaultStoreTimezone, got)
+	}
+}
+
+func TestTransactionService_GetTodayReport_StoreTimezone(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	service := NewTransactionService(transactionRepo, mocks.NewMockProductRepository())
+	// A zone far from UTC, so the store's day differs from the UTC day for most of it.
+	store := time.FixedZone("UTC+14", 14*60*60)
+	service.SetLocation(store)
+
+	var gotStart, gotEnd time.Time
+	transactionRepo.GetReportByDateRangeFunc = func(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
+		gotStart, gotEnd = startDate, endDate
+		return &model.ReportResponse{}, nil
+	}
+
+	if _, err := service.GetTodayReport(0); err != nil {
+		t.Fatalf("GetTodayReport should not return error, got: %v", err)
+	}
+	now := time.Now()
+	local := gotStart.In(store)
+	if local.Hour() != 0 || local.Minute() != 0 || local.Day() != now.In(store).Day() {
+		t.Errorf("Today should start at midnight in the store timezone, got: %v", local)
+	}
+	if gotEnd.Sub(gotStart) != 24*time.Hour || now.Before(gotStart) || !now.Before(gotEnd) {
+		t.Errorf("Today should be the 24 hours around now, got: %v to %v", gotStart, gotEnd)
+	}
+}
+
 func TestTransactionService_GetTodayReport_Error(t *testing.T) {
 	transactionRepo := mocks.NewMockTransactionRepository()
 	productRepo := mocks.NewMockProductRepository()
 	service := NewTransactionService(transactionRepo, productRepo)
 
 	expectedErr := errors.New("database error")
-	transactionRepo.GetReportByDateRangeFunc = func(startDate, endDate time.Time) (*model.ReportResponse, error) {
+	transactionRepo.GetReportByDateRangeFunc = func(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
 		return nil, expectedErr
 	}
 
-	_, err := service.GetTodayReport()
+	_, err := service.GetTodayReport(0)
 	if err != expectedErr {
 		t.Errorf("GetTodayReport should return the error from repo, got: %v", err)
 	}
@@ -319,14 +380,14 @@ func TestTransactionService_GetReportByDateRange_Success(t *testing.T) {
 		TotalTransaksi: 5,
 	}
 
-	transactionRepo.GetReportByDateRangeFunc = func(startDate, endDate time.Time) (*model.ReportResponse, error) {
+	transactionRepo.GetReportByDateRangeFunc = func(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
 		return expectedReport, nil
 	}
 
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
 
-	report, err := service.GetReportByDateRange(startDate, endDate)
+	report, err := service.GetReportByDateRange(startDate, endDate, 0)
 	if err != nil {
 		t.Errorf("GetReportByDateRange should not return error, got: %v", err)
 	}
@@ -343,7 +404,7 @@ func TestTransactionService_GetReportByDateRange_AddsOneDay(t *testing.T) {
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
 
-	transactionRepo.GetReportByDateRangeFunc = func(start, end time.Time) (*model.ReportResponse, error) {
+	transactionRepo.GetReportByDateRangeFunc = func(start, end time.Time, outletID int) (*model.ReportResponse, error) {
 		// Verify that one day is added to endDate
 		expectedEnd := endDate.AddDate(0, 0, 1)
 		if !end.Equal(expectedEnd) {
@@ -352,7 +413,7 @@ func TestTransactionService_GetReportByDateRange_AddsOneDay(t *testing.T) {
 		return &model.ReportResponse{}, nil
 	}
 
-	_, err := service.GetReportByDateRange(startDate, endDate)
+	_, err := service.GetReportByDateRange(startDate, endDate, 0)
 	if err != nil {
 		t.Errorf("GetReportByDateRange should not return error, got: %v", err)
 	}
@@ -364,15 +425,341 @@ func TestTransactionService_GetReportByDateRange_Error(t *testing.T) {
 	service := NewTransactionService(transactionRepo, productRepo)
 
 	expectedErr := errors.New("database error")
-	transactionRepo.GetReportByDateRangeFunc = func(startDate, endDate time.Time) (*model.ReportResponse, error) {
+	transactionRepo.GetReportByDateRangeFunc = func(startDate, endDate time.Time, outletID int) (*model.ReportResponse, error) {
 		return nil, expectedErr
 	}
 
 	startDate := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	endDate := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
 
-	_, err := service.GetReportByDateRange(startDate, endDate)
+	_, err := service.GetReportByDateRange(startDate, endDate, 0)
 	if err != expectedErr {
 		t.Errorf("GetReportByDateRange should return the error from repo, got: %v", err)
 	}
 }
+
+func TestTransactionService_Checkout_MeasuredProduct(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	productRepo := mocks.NewMockProductRepository()
+	service := NewTransactionService(transactionRepo, productRepo)
+	transactionRepo.Products = productRepo
+
+	// Beras at Rp 14.750/kg with 5 kg in stock, telur at Rp 28.333/kg.
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Beras", Price: 14750, Stock: 5000, Unit: model.UnitKilogram}
+	productRepo.Products[2] = &model.Product{ID: 2, Name: "Telur", Price: 28333, Stock: 2000, Unit: model.UnitKilogram}
+
+	transaction, err := service.Checkout(&model.CheckoutRequest{Items: []model.CheckoutItem{
+		{ProductID: 1, Quantity: 1250},
+		{ProductID: 2, Quantity: 333},
+	}})
+	if err != nil {
+		t.Fatalf("Checkout should not return error, got: %v", err)
+	}
+	// 1.25 kg × 14.750 = 18.437,5 → 18.438; 0.333 kg × 28.333 = 9.434,889 → 9.435.
+	if transaction.Details[0].Subtotal != 18438 || transaction.Details[1].Subtotal != 9435 {
+		t.Errorf("Subtotals should be rounded to the rupiah, got: %d and %d", transaction.Details[0].Subtotal, transaction.Details[1].Subtotal)
+	}
+	if transaction.TotalAmount != 27873 {
+		t.Errorf("Total should be the sum of the rounded subtotals, got: %d", transaction.TotalAmount)
+	}
+	if transaction.Details[0].Unit != model.UnitKilogram || transaction.Details[0].Price != 14750 {
+		t.Errorf("Detail should keep the unit and price per kg, got: %+v", transaction.Details[0])
+	}
+	if productRepo.Products[1].Stock != 3750 {
+		t.Errorf("Stock should be reduced in grams, got: %d", productRepo.Products[1].Stock)
+	}
+
+	_, err = service.Checkout(&model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 2, Quantity: 1668}}})
+	if !errors.Is(err, model.ErrInsufficientStock) {
+		t.Errorf("Checkout above the stock in grams should return ErrInsufficientStock, got: %v", err)
+	}
+}
+
+func TestTransactionService_Checkout_ScaleLabel(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	productRepo := mocks.NewMockProductRepository()
+	service := NewTransactionService(transactionRepo, productRepo)
+	transactionRepo.Products = productRepo
+
+	// Keju at Rp 14.000/kg with PLU 123, roti at Rp 3.500 per piece with PLU 45.
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Keju", Price: 14000, Stock: 5000, Unit: model.UnitKilogram, PLU: "123"}
+	productRepo.Products[2] = &model.Product{ID: 2, Name: "Roti", Price: 3500, Stock: 10, Unit: model.UnitPiece, PLU: "45"}
+
+	transaction, err := service.Checkout(&model.CheckoutRequest{Items: []model.CheckoutItem{
+		{Barcode: "2000123012506"}, // 1.250 g
+		{Barcode: "2500123185007"}, // Rp 18.500
+		{Barcode: "2500045070009"}, // Rp 7.000
+	}})
+	if err != nil {
+		t.Fatalf("Checkout should not return error, got: %v", err)
+	}
+	d := transaction.Details
+	if d[0].ProductID != 1 || d[0].Quantity != 1250 || d[0].Subtotal != 17500 {
+		t.Errorf("Weight label should sell 1250 g for 17500, got: %+v", d[0])
+	}
+	// Rp 18.500 at Rp 14.000/kg is 1.321,43 g → 1.321 g, charged at the label price.
+	if d[1].Quantity != 1321 || d[1].Subtotal != 18500 {
+		t.Errorf("Price label should sell 1321 g for the label price, got: %+v", d[1])
+	}
+	if d[2].ProductID != 2 || d[2].Quantity != 2 || d[2].Subtotal != 7000 {
+		t.Errorf("Price label on a piece product should sell 2 pieces, got: %+v", d[2])
+	}
+	if transaction.TotalAmount != 43000 {
+		t.Errorf("Total should be 43000, got: %d", transaction.TotalAmount)
+	}
+	if productRepo.Products[1].Stock != 5000-1250-1321 {
+		t.Errorf("Stock should be reduced by both labels, got: %d", productRepo.Products[1].Stock)
+	}
+
+	tests := []struct {
+		name    string
+		barcode string
+		wantErr error
+	}{
+		{"wrong check digit", "2000123012507", model.ErrBarcodeCheckDigit},
+		{"unknown PLU", "2000999012501", model.ErrProductNotFound},
+		{"weight label on a piece product", "2000045012509", model.ErrScaleLabelUnit},
+		{"price not a whole number of pieces", "2500045070016", model.ErrScaleLabelUnit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.Checkout(&model.CheckoutRequest{Items: []model.CheckoutItem{{Barcode: tt.barcode}}})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Checkout should return %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Checkout_ProductTypes(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	productRepo := mocks.NewMockProductRepository()
+	service := NewTransactionService(transactionRepo, productRepo)
+	transactionRepo.Products = productRepo
+
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Fotocopy", Price: 500, Type: model.ProductTypeNonStocked}
+	productRepo.Products[2] = &model.Product{ID: 2, Name: "Ongkos Kirim", Price: 10000, Type: model.ProductTypeService}
+	productRepo.Products[3] = &model.Product{ID: 3, Name: "Pulsa", Price: 5000, Stock: 1, Type: model.ProductTypeStocked, AllowNegativeStock: true}
+	productRepo.Products[4] = &model.Product{ID: 4, Name: "Gula", Price: 16000, Stock: 1, Type: model.ProductTypeStocked}
+
+	transaction, err := service.Checkout(&model.CheckoutRequest{Items: []model.CheckoutItem{
+		{ProductID: 1, Quantity: 40},
+		{ProductID: 2, Quantity: 1},
+		{ProductID: 3, Quantity: 3},
+	}})
+	if err != nil {
+		t.Fatalf("Checkout should not return error, got: %v", err)
+	}
+	if transaction.TotalAmount != 20000+10000+15000 {
+		t.Errorf("Total should be 45000, got: %d", transaction.TotalAmount)
+	}
+	if productRepo.Products[1].Stock != 0 || productRepo.Products[2].Stock != 0 {
+		t.Errorf("Products without stock should keep none, got: %d and %d", productRepo.Products[1].Stock, productRepo.Products[2].Stock)
+	}
+	if productRepo.Products[3].Stock != -2 {
+		t.Errorf("Pulsa should be sold past zero, got stock: %d", productRepo.Products[3].Stock)
+	}
+
+	_, err = service.Checkout(&model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 4, Quantity: 2}}})
+	if !errors.Is(err, model.ErrInsufficientStock) {
+		t.Errorf("A stocked product without the policy should return ErrInsufficientStock, got: %v", err)
+	}
+
+	report, err := service.GetReportByDateRange(time.Now(), time.Now(), 0)
+	if err != nil {
+		t.Fatalf("GetReportByDateRange should not return error, got: %v", err)
+	}
+	if len(report.StokNegatif) != 1 || report.StokNegatif[0].ProductID != 3 || report.StokNegatif[0].Stock != -2 {
+		t.Errorf("Report should flag the negative stock of Pulsa, got: %+v", report.StokNegatif)
+	}
+}
+
+func TestTransactionService_Checkout_CustomItem(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewTransactionService(transactionRepo, productRepo)
+	service.SetCategoryRepository(categoryRepo)
+
+	categoryRepo.Categories[1] = &model.Category{ID: 1, Name: "Jasa"}
+	categoryID := 1
+	item := model.CheckoutItem{Name: "Servis Kipas", Price: 15000, Quantity: 2, CategoryID: &categoryID}
+
+	_, err := service.Checkout(&model.CheckoutRequest{Items: []model.CheckoutItem{item}})
+	if !errors.Is(err, model.ErrCustomItemDisabled) {
+		t.Errorf("Custom items should be rejected when disabled, got: %v", err)
+	}
+
+	service.SetCustomItemPolicy(model.CustomItemPolicy{Enabled: true, Roles: []string{"supervisor"}})
+	_, err = service.Checkout(&model.CheckoutRequest{Role: "kasir", Items: []model.CheckoutItem{item}})
+	if !errors.Is(err, model.ErrCustomItemRole) {
+		t.Errorf("A role outside the allowed list should get ErrCustomItemRole, got: %v", err)
+	}
+
+	_, err = service.Checkout(&model.CheckoutRequest{Role: "supervisor", Items: []model.CheckoutItem{{Name: " ", Price: 1000, Quantity: 1}}})
+	if !errors.Is(err, model.ErrCustomItemInvalid) {
+		t.Errorf("A custom item without a name should get ErrCustomItemInvalid, got: %v", err)
+	}
+
+	transaction, err := service.Checkout(&model.CheckoutRequest{Role: "supervisor", Items: []model.CheckoutItem{item}})
+	if err != nil {
+		t.Fatalf("Checkout should not return error, got: %v", err)
+	}
+	if transaction.TotalAmount != 30000 {
+		t.Errorf("Total should be 30000, got: %d", transaction.TotalAmount)
+	}
+	detail := transaction.Details[0]
+	if !detail.IsCustom() || detail.ProductName != "Servis Kipas" || detail.CategoryID == nil || *detail.CategoryID != 1 {
+		t.Errorf("Detail should be a custom line in category 1, got: %+v", detail)
+	}
+}
+
+func TestTransactionService_GetTimeSeries(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	service := NewTransactionService(transactionRepo, mocks.NewMockProductRepository())
+
+	var gotGranularity string
+	var gotEnd time.Time
+	transactionRepo.GetTimeSeriesFunc = func(startDate, endDate time.Time, granularity string, outletID int) (*model.TimeSeriesReport, error) {
+		gotGranularity, gotEnd = granularity, endDate
+		return &model.TimeSeriesReport{Granularity: granularity}, nil
+	}
+
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	if _, err := service.GetTimeSeries(day, day, "", 0); err != nil {
+		t.Fatalf("GetTimeSeries should not return error, got: %v", err)
+	}
+	if gotGranularity != model.GranularityDay || !gotEnd.Equal(day.AddDate(0, 0, 1)) {
+		t.Errorf("GetTimeSeries should default to day and include the end date, got: %s until %v", gotGranularity, gotEnd)
+	}
+
+	if _, err := service.GetTimeSeries(day, day, "year", 0); !errors.Is(err, model.ErrGranularityInvalid) {
+		t.Errorf("An unknown granularity should return ErrGranularityInvalid, got: %v", err)
+	}
+}
+
+func TestTransactionService_GetCategorySales(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	productRepo := mocks.NewMockProductRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewTransactionService(transactionRepo, productRepo)
+	service.SetCategoryRepository(categoryRepo)
+
+	rokok := 3
+	categoryRepo.Categories[rokok] = &model.Category{ID: rokok, Name: "Rokok"}
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Surya 12", Price: 30000, Stock: 10, CategoryID: &rokok}
+
+	transaction, err := service.Checkout(&model.CheckoutRequest{Items: []model.CheckoutItem{{ProductID: 1, Quantity: 1}}})
+	if err != nil {
+		t.Fatalf("Checkout should not return error, got: %v", err)
+	}
+	if id := transaction.Details[0].CategoryID; id == nil || *id != rokok {
+		t.Errorf("Checkout should snapshot the product category, got: %v", id)
+	}
+
+	transactionRepo.GetCategorySalesFunc = func(startDate, endDate time.Time, outletID int) ([]*model.CategorySales, error) {
+		return []*model.CategorySales{{CategoryID: &rokok, Revenue: 30000}, {Revenue: 10000}}, nil
+	}
+	rows, err := service.GetCategorySales(time.Now(), time.Now(), 0)
+	if err != nil {
+		t.Fatalf("GetCategorySales should not return error, got: %v", err)
+	}
+	if rows[0].Nama != "Rokok" || rows[0].Persentase != 75 {
+		t.Errorf("Rokok should be named and have a 75%% share, got: %+v", rows[0])
+	}
+	if rows[1].Nama != model.UncategorizedName || rows[1].Persentase != 25 {
+		t.Errorf("Uncategorized lines should be named %q with a 25%% share, got: %+v", model.UncategorizedName, rows[1])
+	}
+}
+
+func TestTransactionService_GetCategorySales_RollsUpAncestors(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	categoryRepo := mocks.NewMockCategoryRepository()
+	service := NewTransactionService(transactionRepo, mocks.NewMockProductRepository())
+	service.SetCategoryRepository(categoryRepo)
+
+	minuman, dingin, soda, makanan := 1, 2, 3, 4
+	categoryRepo.Categories[minuman] = &model.Category{ID: minuman, Name: "Minuman"}
+	categoryRepo.Categories[dingin] = &model.Category{ID: dingin, Name: "Minuman Dingin", ParentID: &minuman}
+	categoryRepo.Categories[soda] = &model.Category{ID: soda, Name: "Soda", ParentID: &dingin}
+	categoryRepo.Categories[makanan] = &model.Category{ID: makanan, Name: "Makanan"}
+
+	transactionRepo.GetCategorySalesFunc = func(startDate, endDate time.Time, outletID int) ([]*model.CategorySales, error) {
+		return []*model.CategorySales{
+			{CategoryID: &makanan, ItemTerjual: 2, Revenue: 40000},
+			{CategoryID: &soda, ItemTerjual: 3, Revenue: 30000},
+			{CategoryID: &minuman, ItemTerjual: 1, Revenue: 10000},
+			{Custom: true, ItemTerjual: 1, Revenue: 20000},
+		}, nil
+	}
+	rows, err := service.GetCategorySales(time.Now(), time.Now(), 0)
+	if err != nil {
+		t.Fatalf("GetCategorySales should not return error, got: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("GetCategorySales should add a row for the ancestor without sales, got: %d", len(rows))
+	}
+	if rows[0].Nama != "Minuman" || rows[0].Revenue != 10000 || rows[0].TotalRevenue != 40000 ||
+		rows[0].TotalItemTerjual != 4 || rows[0].TotalPersentase != 40 {
+		t.Errorf("Minuman should roll up its whole subtree and come first, got: %+v", rows[0])
+	}
+	if rows[1].Nama != "Makanan" || rows[1].TotalRevenue != 40000 {
+		t.Errorf("Makanan should tie with Minuman and follow on category ID, got: %+v", rows[1])
+	}
+	if rows[2].Nama != "Minuman Dingin" || rows[2].Revenue != 0 || rows[2].TotalRevenue != 30000 ||
+		rows[2].ParentID == nil || *rows[2].ParentID != minuman {
+		t.Errorf("Minuman Dingin should get a row with the soda sales and its parent, got: %+v", rows[2])
+	}
+	if rows[3].Nama != "Soda" || rows[3].TotalRevenue != 30000 || rows[3].Persentase != 30 {
+		t.Errorf("Soda should keep its own sales, got: %+v", rows[3])
+	}
+	if rows[4].Nama != model.CustomItemsName || !rows[4].Custom || rows[4].Persentase != 20 {
+		t.Errorf("Custom items should be their own row, got: %+v", rows[4])
+	}
+}
+
+func TestTransactionService_GetProductSales(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	productRepo := mocks.NewMockProductRepository()
+	service := NewTransactionService(transactionRepo, productRepo)
+
+	productRepo.Products[1] = &model.Product{ID: 1, Name: "Kopi Susu Gula Aren", Unit: model.UnitPiece}
+	var gotQuery model.ProductSalesQuery
+	transactionRepo.GetProductSalesFunc = func(startDate, endDate time.Time, outletID int, query model.ProductSalesQuery) ([]*model.ProductSales, error) {
+		gotQuery = query
+		return []*model.ProductSales{{ProductID: 1, QtyTerjual: 4}}, nil
+	}
+
+	query := model.ProductSalesQuery{Sort: model.ProductSalesSortRevenue, Desc: true, Limit: 5}
+	rows, err := service.GetProductSales(time.Now(), time.Now(), 0, query)
+	if err != nil {
+		t.Fatalf("GetProductSales should not return error, got: %v", err)
+	}
+	if gotQuery != query {
+		t.Errorf("GetProductSales should pass the query through, got: %+v", gotQuery)
+	}
+	if rows[0].Nama != "Kopi Susu Gula Aren" || rows[0].Unit != model.UnitPiece {
+		t.Errorf("Rows should carry the current product name and unit, got: %+v", rows[0])
+	}
+}
+
+func TestTransactionService_GetHeatmap_UsesStoreLocation(t *testing.T) {
+	transactionRepo := mocks.NewMockTransactionRepository()
+	service := NewTransactionService(transactionRepo, mocks.NewMockProductRepository())
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	service.SetLocation(jakarta)
+
+	var gotLoc *time.Location
+	transactionRepo.GetHeatmapFunc = func(startDate, endDate time.Time, loc *time.Location, outletID int) (*model.SalesHeatmap, error) {
+		gotLoc = loc
+		return model.NewSalesHeatmap(loc), nil
+	}
+
+	heatmap, err := service.GetHeatmap(time.Now(), time.Now(), 0)
+	if err != nil {
+		t.Fatalf("GetHeatmap should not return error, got: %v", err)
+	}
+	if gotLoc != jakarta || heatmap.Timezone != "WIB" {
+		t.Errorf("GetHeatmap should use the store timezone, got: %v", gotLoc)
+	}
+}
@@ -0,0 +1,21 @@
+// Package storage holds blob stores for uploaded files such as product images.
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrInvalidKey is returned for keys that are empty or try to escape the store.
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// BlobStore stores opaque files under slash-separated keys, e.g. "products/1/123.jpg".
+// Implementations must be safe for concurrent use.
+type BlobStore interface {
+	// Put writes r under key, replacing any existing blob.
+	Put(key string, r io.Reader, contentType string) error
+	// Delete removes the blob. Deleting a missing key is not an error.
+	Delete(key string) error
+	// URL returns the public URL a client can fetch the blob from.
+	URL(key string) string
+}
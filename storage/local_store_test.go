@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStore_PutServeDelete(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir, "/media/")
+	if err != nil {
+		t.Fatalf("NewLocalStore should not return error, got: %v", err)
+	}
+
+	if err := store.Put("products/1/a.png", strings.NewReader("png-bytes"), "image/png"); err != nil {
+		t.Fatalf("Put should not return error, got: %v", err)
+	}
+	if url := store.URL("products/1/a.png"); url != "/media/products/1/a.png" {
+		t.Errorf("URL should join base URL and key, got: %s", url)
+	}
+
+	rr := httptest.NewRecorder()
+	store.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/media/products/1/a.png", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "png-bytes" {
+		t.Errorf("ServeHTTP should return the stored file, got: %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	store.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/media/products/1/", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Directory listing should return 404, got: %d", rr.Code)
+	}
+
+	if err := store.Delete("products/1/a.png"); err != nil {
+		t.Fatalf("Delete should not return error, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "products", "1", "a.png")); !os.IsNotExist(err) {
+		t.Errorf("Delete should remove the file, got: %v", err)
+	}
+	if err := store.Delete("products/1/a.png"); err != nil {
+		t.Errorf("Deleting a missing key should not fail, got: %v", err)
+	}
+}
+
+func TestLocalStore_InvalidKey(t *testing.T) {
+	store, _ := NewLocalStore(t.TempDir(), "/media")
+
+	for _, key := range []string{"", "/etc/passwd", "../secret", "a/../../b", "a//b"} {
+		if err := store.Put(key, strings.NewReader("x"), ""); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Put(%q) should return ErrInvalidKey, got: %v", key, err)
+		}
+	}
+}
@@ -182,12 +182,20 @@ run_test "Create Category with Invalid JSON" "POST" "/api/categories" '{"name":1
 run_test "Update Non-existent Product (999)" "PUT" "/api/products/999" '{"name":"Test","price":1000,"stock":10}' "4xx"
 run_test "Update Non-existent Category (999)" "PUT" "/api/categories/999" '{"name":"Test","description":"Test"}' "4xx"
 
+# Export Tests
+print_header "9. EXPORT TESTS"
+run_test "Export Products as CSV" "GET" "/api/products/export?format=csv" "" "200"
+run_test "Export Products with Unknown Format" "GET" "/api/products/export?format=pdf" "" "400"
+
 # Delete Tests
-print_header "9. DELETE TESTS"
-run_test "Delete Product (2) - Mouse" "DELETE" "/api/products/2"
-run_test "Verify Product Deleted (2)" "GET" "/api/products/2" "" "404"
-run_test "Delete Category (2)" "DELETE" "/api/categories/2"
-run_test "Verify Category Deleted (2)" "GET" "/api/categories/2" "" "404"
+print_header "10. DELETE (ARCHIVE) TESTS"
+run_test "Archive Product (2) - Mouse" "DELETE" "/api/products/2"
+run_test "Archived Product Still Resolvable (2)" "GET" "/api/products/2" "" "200"
+run_test "List Products Including Archived" "GET" "/api/products?include_archived=true"
+run_test "Restore Product (2)" "POST" "/api/products/2/restore"
+run_test "Archive Category With Products (2)" "DELETE" "/api/categories/2" "" "409"
+run_test "Archive Category Unsetting Products (2)" "DELETE" "/api/categories/2?on_products=unset"
+run_test "Restore Category (2)" "POST" "/api/categories/2/restore"
 run_test "Delete Non-existent Product (999)" "DELETE" "/api/products/999" "" "4xx"
 run_test "Delete Non-existent Category (999)" "DELETE" "/api/categories/999" "" "4xx"
 